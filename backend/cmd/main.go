@@ -13,15 +13,29 @@ import (
 	"github.com/streadway/amqp"
 
 	"backend/internal/address"
+	"backend/internal/analytics"
+	"backend/internal/apikeys"
+	"backend/internal/audit"
 	"backend/internal/backup"
+	"backend/internal/billing"
+	"backend/internal/checklists"
+	"backend/internal/classifications"
 	"backend/internal/clients"
 	"backend/internal/db"
 	"backend/internal/equipment"
 	"backend/internal/files"
+	"backend/internal/integrity"
 	"backend/internal/inventory"
+	"backend/internal/organizations"
+	"backend/internal/payroll"
+	"backend/internal/pdftext"
+	"backend/internal/photos"
 	"backend/internal/report"
 	"backend/internal/requests"
+	"backend/internal/search"
+	"backend/internal/signatures"
 	"backend/internal/storage"
+	"backend/internal/templates"
 	"backend/internal/tickets"
 	"backend/internal/travelsheet"
 	"backend/internal/users"
@@ -30,6 +44,10 @@ import (
 func init() {
 	_ = godotenv.Load()
 
+	if _, exists := os.LookupEnv("JWTKEY"); !exists {
+		panic("JWTKEY not set")
+	}
+
 	ginMode, exists := os.LookupEnv("GIN_MODE")
 	if !exists {
 		panic("GIN_MODE not set")
@@ -90,6 +108,12 @@ func main() {
 
 	_ = storage.InitS3FromEnv()
 
+	report.StartReportJobWorkers()
+	report.StartReportArchiveWorkers()
+	photos.StartCleanup()
+	integrity.StartVerifier()
+	pdftext.StartExtractor()
+
 	if serverMode == "RELEASE" {
 		backup.StartScheduledBackups()
 	} else {
@@ -138,12 +162,17 @@ func main() {
 			"Accept",
 			"Authorization",
 			"X-Requested-With",
+			"X-API-Key",
+			"Idempotency-Key",
 		},
 		ExposeHeaders:    []string{"Content-Length", "Set-Cookie"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Журнал изменений для всех изменяющих запросов
+	r.Use(audit.Middleware())
+
 	// Сертификаты
 	r.GET("/api/files", users.AuthMiddleware(), files.GetFiles)
 	r.POST("/api/files", users.AuthMiddleware(), files.UploadFiles)
@@ -152,13 +181,18 @@ func main() {
 	r.PUT("/api/files/rename", users.AuthMiddleware(), files.RenameFiles)
 	r.DELETE("/api/files/delete/:filename", users.AuthMiddleware(), files.DeleteFiles)
 	r.GET("/api/files/search", users.AuthMiddleware(), files.SearchFiles)
+	r.GET("/api/files/tags", users.AuthMiddleware(), files.GetFileTags)
+	r.PUT("/api/files/tags", users.AuthMiddleware(), files.UpdateFileTags)
+
+	// Полнотекстовый поиск по отчётам, заявкам и сертификатам
+	r.GET("/api/search", users.AuthMiddleware(), search.Search)
 
 	// Пользователь
 	r.POST("/api/register", users.Register)
 	r.POST("/api/login", users.Login)
 	r.POST("/api/logout", users.Logout)
 	r.GET("/api/check-auth", users.CheckAuth)
-	r.GET("/api/users", users.AuthMiddleware(), users.GetUsers)
+	r.GET("/api/users", apikeys.AuthOrKeyMiddleware(apikeys.ScopeUsersRead), users.GetUsers)
 	r.PUT("/api/profile", users.AuthMiddleware(), users.UpdateProfile)
 
 	// Администрирование пользователей
@@ -182,13 +216,22 @@ func main() {
 	)
 	r.PUT("/api/users/:id", users.AuthMiddleware(), users.AdminMiddleware(), users.UpdateUser)
 	r.DELETE("/api/users/:id", users.AuthMiddleware(), users.AdminMiddleware(), users.DeleteUser)
+	r.POST("/api/users/:id/activate", users.AuthMiddleware(), users.AdminMiddleware(), users.ActivateUser)
+	r.POST("/api/users/:id/anonymize", users.AuthMiddleware(), users.AdminMiddleware(), users.AnonymizeUser)
 
 	// Отчеты
 	r.GET("/uploads/reports/:filename", report.ServeReportFile)
 	r.POST("/api/report", users.AuthMiddleware(), report.CreateReport)
+	r.GET("/api/report-jobs/:id", users.AuthMiddleware(), report.GetReportJob)
+	r.GET("/api/report-jobs/:id/events", users.AuthMiddleware(), report.StreamReportJob)
+	r.GET("/api/report-archives", users.AuthMiddleware(), report.GetReportArchives)
+	r.GET("/api/report-archives/:id", users.AuthMiddleware(), report.GetReportArchive)
+	r.GET("/api/report-archives/:id/events", users.AuthMiddleware(), report.StreamReportArchive)
+	r.GET("/api/report-archives/:id/download", users.AuthMiddleware(), report.DownloadReportArchive)
 	r.GET("/api/reports", users.AuthMiddleware(), report.GetReportsHandler)
+	r.GET("/api/reports/export", users.AuthMiddleware(), report.ExportReports)
 	r.GET("/api/reports/monthly-zip", users.AuthMiddleware(), report.DownloadMonthlyReports)
-	r.GET("/api/reports/period-zip", users.AuthMiddleware(), report.DownloadReportsByPeriod)
+	r.GET("/api/reports/period-zip", apikeys.AuthOrKeyMiddleware(apikeys.ScopeReportsRead), report.DownloadReportsByPeriod)
 	r.POST("/api/reports/download-selected", users.AuthMiddleware(), report.DownloadSelectedReports)
 	r.DELETE("/api/reports/:reportname", users.AuthMiddleware(), report.DeleteReport)
 	r.POST(
@@ -200,13 +243,47 @@ func main() {
 	r.POST("/api/reports/upload-multiple", users.AuthMiddleware(), users.AdminMiddleware(), report.UploadMultipleReports)
 	r.GET("/api/reportscount", users.AuthMiddleware(), report.GetReportsCount)
 	r.GET("/api/reports/trends", users.AuthMiddleware(), report.GetReportsTrends)
-	r.GET("/api/reports/preview/:filename", users.AuthMiddleware(), report.PreviewReport)
+	r.GET("/api/analytics", users.AuthMiddleware(), analytics.GetAnalytics)
+	r.GET("/api/reports/data/search", users.AuthMiddleware(), report.SearchReportData)
+	r.GET("/api/reports/:id/data", apikeys.AuthOrKeyMiddleware(apikeys.ScopeReportsRead), report.GetReportData)
+	r.GET("/api/reports/:id/versions", users.AuthMiddleware(), report.GetReportVersions)
+	r.PUT("/api/reports/:id", users.AuthMiddleware(), report.UpdateReport)
+	r.GET("/api/reports/review-queue", users.AuthMiddleware(), users.ReviewerMiddleware(), report.GetReviewQueue)
+	r.GET("/api/reports/:id/reviews", users.AuthMiddleware(), report.GetReportReviews)
+	r.GET("/api/reports/:id/text", users.AuthMiddleware(), pdftext.GetReportText)
+	r.GET("/api/reports/:id/signatures", users.AuthMiddleware(), signatures.GetReportSignatures)
+	r.GET("/api/reports/:id/signatures/:signatureId/image", users.AuthMiddleware(), signatures.GetSignatureImage)
+	r.GET("/api/reports/:id/photos", users.AuthMiddleware(), photos.GetReportPhotos)
+	r.POST("/api/photos", users.AuthMiddleware(), photos.UploadPhoto)
+	r.POST("/api/photos/uploads", users.AuthMiddleware(), photos.CreateUpload)
+	r.GET("/api/photos/uploads/:id", users.AuthMiddleware(), photos.GetUpload)
+	r.PUT("/api/photos/uploads/:id", users.AuthMiddleware(), photos.PutUploadChunk)
+	r.DELETE("/api/photos/uploads/:id", users.AuthMiddleware(), photos.DeleteUpload)
+	r.GET("/api/photos/:id", users.AuthMiddleware(), photos.GetPhoto)
+	r.GET("/api/photos/:id/image", users.AuthMiddleware(), photos.GetPhotoImage)
+	r.DELETE("/api/photos/:id", users.AuthMiddleware(), photos.DeletePhoto)
+	r.POST("/api/reports/:id/submit", users.AuthMiddleware(), report.SubmitReport)
+	r.POST("/api/reports/:id/approve", users.AuthMiddleware(), users.ReviewerMiddleware(), report.ApproveReport)
+	r.POST("/api/reports/:id/reject", users.AuthMiddleware(), users.ReviewerMiddleware(), report.RejectReport)
+	r.GET("/api/reports/preview/:filename", apikeys.AuthOrKeyMiddleware(apikeys.ScopeReportsRead), report.PreviewReport)
 	r.GET("/api/reports/preview-image/:filename", users.AuthMiddleware(), report.PreviewReportImage)
 	r.GET("/api/reports/preview-pages/:filename", users.AuthMiddleware(), report.GetPreviewPages)
 	r.POST("/api/reports/regenerate-preview/:filename", users.AuthMiddleware(), report.RegeneratePreview)
 
+	// Проверка подлинности актов (публично, по токену из QR-кода)
+	r.GET("/api/verify/public-key", integrity.GetPublicKey)
+	r.GET("/api/verify/:token", integrity.PublicVerify)
+	r.POST("/api/verify/:token", integrity.PublicVerifyFile)
+	r.GET("/api/report-integrity", users.AuthMiddleware(), users.AdminMiddleware(), integrity.GetIntegrityStatus)
+	r.POST("/api/report-integrity/verify", users.AuthMiddleware(), users.AdminMiddleware(), integrity.StartIntegrityCheck)
+
+	// Извлечение текста из загруженных PDF
+	r.GET("/api/report-texts", users.AuthMiddleware(), users.AdminMiddleware(), pdftext.GetExtractionStatus)
+	r.POST("/api/report-texts/reindex", users.AuthMiddleware(), users.AdminMiddleware(), pdftext.Reindex)
+
 	// График
 	r.GET("/api/requests", users.AuthMiddleware(), requests.GetRequests)
+	r.GET("/api/requests/export", users.AuthMiddleware(), requests.ExportRequests)
 	r.GET("/api/requests/:id", users.AuthMiddleware(), requests.GetRequestById)
 	r.POST("/api/requests", users.AuthMiddleware(), requests.CreateRequest)
 	r.PUT("/api/requests/:id", users.AuthMiddleware(), requests.UpdateRequest)
@@ -214,14 +291,15 @@ func main() {
 
 	// Заявки клиентов
 	r.POST("/api/client-tickets", optionalClientAuth(), tickets.CreateTicket)
-	r.GET("/api/client-tickets", users.AuthMiddleware(), tickets.GetClientTickets)
-	r.PUT("/api/client-tickets/:id", users.AuthMiddleware(), tickets.UpdateClientTicket)
+	r.GET("/api/client-tickets", apikeys.AuthOrKeyMiddleware(apikeys.ScopeTicketsRead), tickets.GetClientTickets)
+	r.GET("/api/client-tickets/export", apikeys.AuthOrKeyMiddleware(apikeys.ScopeTicketsRead), tickets.ExportClientTickets)
+	r.PUT("/api/client-tickets/:id", apikeys.AuthOrKeyMiddleware(apikeys.ScopeTicketsWrite), tickets.UpdateClientTicket)
 	r.DELETE("/api/client-tickets/:id", users.AuthMiddleware(), tickets.DeleteClientTicket)
 	r.GET("/api/tickets/files/:filename", tickets.ServeTicketFile)
 	r.POST("/api/tickets/link-report", users.AuthMiddleware(), tickets.LinkReportToTicket)
 	r.DELETE("/api/tickets/:ticketId/reports/:reportId", users.AuthMiddleware(), tickets.UnlinkReportFromTicket)
-	r.GET("/api/tickets/:id/reports", users.AuthMiddleware(), tickets.GetTicketReports)
-	r.GET("/api/tickets/by-address", users.AuthMiddleware(), tickets.GetTicketsByAddress)
+	r.GET("/api/tickets/:id/reports", apikeys.AuthOrKeyMiddleware(apikeys.ScopeTicketsRead), tickets.GetTicketReports)
+	r.GET("/api/tickets/by-address", apikeys.AuthOrKeyMiddleware(apikeys.ScopeTicketsRead), tickets.GetTicketsByAddress)
 
 	// Клиентский портал
 	r.POST("/api/client/register", clients.ClientRegister)
@@ -234,23 +312,102 @@ func main() {
 	r.GET("/api/client/reports/preview/:filename", clients.ClientAuthMiddleware(), clients.ClientPreviewReport)
 	r.GET("/api/client/reports/preview-pages/:filename", clients.ClientAuthMiddleware(), clients.ClientGetPreviewPages)
 	r.POST("/api/client/reports/regenerate-preview/:filename", clients.ClientAuthMiddleware(), clients.ClientRegeneratePreview)
+	r.GET("/api/client/reports/download/:filename", clients.ClientAuthMiddleware(), clients.ClientDownloadReport)
+	r.GET("/api/client/reports/period-zip", clients.ClientAuthMiddleware(), clients.ClientDownloadReportsByPeriod)
+	r.GET("/api/client/organization", clients.ClientAuthMiddleware(), clients.GetMyOrganization)
+	r.POST("/api/client/organization/members", clients.ClientAuthMiddleware(), clients.AddMyOrgMember)
+	r.PUT("/api/client/organization/members/:clientId", clients.ClientAuthMiddleware(), clients.UpdateMyOrgMemberRole)
+	r.DELETE("/api/client/organization/members/:clientId", clients.ClientAuthMiddleware(), clients.RemoveMyOrgMember)
+
+	// Организации клиентов (только админ)
+	r.GET("/api/client-organizations", users.AuthMiddleware(), users.AdminMiddleware(), organizations.GetOrganizations)
+	r.GET("/api/client-organizations/:id", users.AuthMiddleware(), users.AdminMiddleware(), organizations.GetOrganization)
+	r.POST("/api/client-organizations", users.AuthMiddleware(), users.AdminMiddleware(), organizations.CreateOrganization)
+	r.PUT("/api/client-organizations/:id", users.AuthMiddleware(), users.AdminMiddleware(), organizations.UpdateOrganization)
+	r.DELETE("/api/client-organizations/:id", users.AuthMiddleware(), users.AdminMiddleware(), organizations.DeleteOrganization)
+	r.POST("/api/client-organizations/:id/addresses", users.AuthMiddleware(), users.AdminMiddleware(), organizations.AssignAddresses)
+	r.DELETE("/api/client-organizations/:id/addresses/:addressId", users.AuthMiddleware(), users.AdminMiddleware(), organizations.UnassignAddress)
+	r.POST("/api/client-organizations/:id/members", users.AuthMiddleware(), users.AdminMiddleware(), organizations.AddMember)
+	r.PUT("/api/client-organizations/:id/members/:clientId", users.AuthMiddleware(), users.AdminMiddleware(), organizations.UpdateMemberRole)
+	r.DELETE("/api/client-organizations/:id/members/:clientId", users.AuthMiddleware(), users.AdminMiddleware(), organizations.RemoveMember)
+
+	// Классификации (изменение - только админ)
+	r.GET("/api/classifications", users.AuthMiddleware(), classifications.GetClassifications)
+	r.POST("/api/classifications", users.AuthMiddleware(), users.AdminMiddleware(), classifications.CreateClassification)
+	r.PUT("/api/classifications/:id", users.AuthMiddleware(), users.AdminMiddleware(), classifications.UpdateClassification)
+	r.DELETE("/api/classifications/:id", users.AuthMiddleware(), users.AdminMiddleware(), classifications.DeleteClassification)
+
+	// Зарплатные ведомости (ставки, корректировки и утверждение - согласующие)
+	r.GET("/api/payroll/rates", users.AuthMiddleware(), users.ReviewerMiddleware(), payroll.GetRates)
+	r.POST("/api/payroll/rates", users.AuthMiddleware(), users.ReviewerMiddleware(), payroll.CreateRate)
+	r.PUT("/api/payroll/rates/:id", users.AuthMiddleware(), users.ReviewerMiddleware(), payroll.UpdateRate)
+	r.DELETE("/api/payroll/rates/:id", users.AuthMiddleware(), users.ReviewerMiddleware(), payroll.DeleteRate)
+	r.GET("/api/payroll/adjustments", users.AuthMiddleware(), users.ReviewerMiddleware(), payroll.GetAdjustments)
+	r.POST("/api/payroll/adjustments", users.AuthMiddleware(), users.ReviewerMiddleware(), payroll.CreateAdjustment)
+	r.DELETE("/api/payroll/adjustments/:id", users.AuthMiddleware(), users.ReviewerMiddleware(), payroll.DeleteAdjustment)
+	r.POST("/api/payroll/statements/calculate", users.AuthMiddleware(), users.ReviewerMiddleware(), payroll.CalculateStatements)
+	r.GET("/api/payroll/statements", users.AuthMiddleware(), payroll.GetStatements)
+	r.GET("/api/payroll/statements/:id", users.AuthMiddleware(), payroll.GetStatement)
+	r.POST("/api/payroll/statements/:id/approve", users.AuthMiddleware(), users.ReviewerMiddleware(), payroll.ApproveStatement)
+	r.GET("/api/payroll/statements/:id/export", users.AuthMiddleware(), payroll.ExportStatement)
+
+	// Счета клиентам и прайс-лист (только админ)
+	r.GET("/api/billing/prices", users.AuthMiddleware(), users.AdminMiddleware(), billing.GetPrices)
+	r.POST("/api/billing/prices", users.AuthMiddleware(), users.AdminMiddleware(), billing.CreatePrice)
+	r.PUT("/api/billing/prices/:id", users.AuthMiddleware(), users.AdminMiddleware(), billing.UpdatePrice)
+	r.DELETE("/api/billing/prices/:id", users.AuthMiddleware(), users.AdminMiddleware(), billing.DeletePrice)
+	r.POST("/api/billing/invoices/generate", users.AuthMiddleware(), users.AdminMiddleware(), billing.GenerateInvoices)
+	r.GET("/api/billing/invoices", users.AuthMiddleware(), users.AdminMiddleware(), billing.GetInvoices)
+	r.GET("/api/billing/invoices/:id", users.AuthMiddleware(), users.AdminMiddleware(), billing.GetInvoice)
+	r.POST("/api/billing/invoices/:id/status", users.AuthMiddleware(), users.AdminMiddleware(), billing.SetInvoiceStatus)
+	r.DELETE("/api/billing/invoices/:id", users.AuthMiddleware(), users.AdminMiddleware(), billing.DeleteInvoice)
+	r.GET("/api/billing/invoices/:id/pdf", users.AuthMiddleware(), users.AdminMiddleware(), billing.DownloadInvoicePDF)
+	r.GET("/api/billing/invoices/:id/zip", users.AuthMiddleware(), users.AdminMiddleware(), billing.DownloadInvoiceZip)
+
+	// Шаблоны чек-листов
+	r.GET("/api/checklists/expected", users.AuthMiddleware(), checklists.GetExpectedChecklist)
+	r.GET("/api/checklist-tasks", users.AuthMiddleware(), checklists.GetChecklistTasks)
+	r.POST("/api/checklist-tasks", users.AuthMiddleware(), users.AdminMiddleware(), checklists.CreateChecklistTask)
+	r.PUT("/api/checklist-tasks/:id", users.AuthMiddleware(), users.AdminMiddleware(), checklists.UpdateChecklistTask)
+	r.DELETE("/api/checklist-tasks/:id", users.AuthMiddleware(), users.AdminMiddleware(), checklists.DeleteChecklistTask)
+
+	// Шаблоны актов (только админ)
+	r.GET("/api/report-templates", users.AuthMiddleware(), users.AdminMiddleware(), templates.GetTemplates)
+	r.GET("/api/report-templates/placeholders", users.AuthMiddleware(), users.AdminMiddleware(), templates.GetPlaceholders)
+	r.POST("/api/report-templates", users.AuthMiddleware(), users.AdminMiddleware(), templates.UploadTemplate)
+	r.GET("/api/report-templates/:id/download", users.AuthMiddleware(), users.AdminMiddleware(), templates.DownloadTemplate)
+	r.PUT("/api/report-templates/:id/active", users.AuthMiddleware(), users.AdminMiddleware(), templates.SetTemplateActive)
+	r.DELETE("/api/report-templates/:id", users.AuthMiddleware(), users.AdminMiddleware(), templates.DeleteTemplate)
+
+	// Сервисные учетные записи и API-ключи (только админ)
+	r.GET("/api/service-accounts", users.AuthMiddleware(), users.AdminMiddleware(), apikeys.GetServiceAccounts)
+	r.POST("/api/service-accounts", users.AuthMiddleware(), users.AdminMiddleware(), apikeys.CreateServiceAccount)
+	r.DELETE("/api/service-accounts/:id", users.AuthMiddleware(), users.AdminMiddleware(), apikeys.DisableServiceAccount)
+	r.POST("/api/service-accounts/:id/keys", users.AuthMiddleware(), users.AdminMiddleware(), apikeys.CreateAPIKey)
+	r.POST("/api/api-keys/:id/rotate", users.AuthMiddleware(), users.AdminMiddleware(), apikeys.RotateAPIKey)
+	r.DELETE("/api/api-keys/:id", users.AuthMiddleware(), users.AdminMiddleware(), apikeys.RevokeAPIKey)
+
+	// Журнал изменений (только админ)
+	r.GET("/api/audit", users.AuthMiddleware(), users.AdminMiddleware(), audit.SearchAuditLogs)
+	r.GET("/api/audit/export", users.AuthMiddleware(), users.AdminMiddleware(), audit.ExportAuditLogsCSV)
 
 	// Debug TG отправка (только админ)
 	r.POST("/api/debug/send-unassigned-alert", users.AuthMiddleware(), users.AdminMiddleware(), tickets.DebugSendUnassigned)
 
 	// Адреса объектов
 	r.GET("/api/addresses", address.GetAddresses)
-	r.POST("/api/addresses", users.AuthMiddleware(), address.AddAddress)
-	r.DELETE("/api/addresses/:id", users.AuthMiddleware(), address.DeleteAddress)
+	r.POST("/api/addresses", apikeys.AuthOrKeyMiddleware(apikeys.ScopeAddressesWrite), address.AddAddress)
+	r.DELETE("/api/addresses/:id", apikeys.AuthOrKeyMiddleware(apikeys.ScopeAddressesWrite), address.DeleteAddress)
+	r.PUT("/api/addresses/:id/location", apikeys.AuthOrKeyMiddleware(apikeys.ScopeAddressesWrite), address.UpdateAddressLocation)
 
 	// Список оборудования
 	r.GET("/api/equipment", equipment.GetEquipment)
-	r.POST("/api/equipment", users.AuthMiddleware(), equipment.AddEquipment)
-	r.DELETE("/api/equipment/:id", users.AuthMiddleware(), equipment.DeleteEquipment)
+	r.POST("/api/equipment", apikeys.AuthOrKeyMiddleware(apikeys.ScopeEquipmentWrite), equipment.AddEquipment)
+	r.DELETE("/api/equipment/:id", apikeys.AuthOrKeyMiddleware(apikeys.ScopeEquipmentWrite), equipment.DeleteEquipment)
 
 	// Запоминание оборудования по объектам
-	r.GET("/api/equipment/memory", users.AuthMiddleware(), equipment.GetEquipmentMemory)
-	r.POST("/api/equipment/memory", users.AuthMiddleware(), equipment.SaveEquipmentMemory)
+	r.GET("/api/equipment/memory", apikeys.AuthOrKeyMiddleware(apikeys.ScopeEquipmentRead), equipment.GetEquipmentMemory)
+	r.POST("/api/equipment/memory", apikeys.AuthOrKeyMiddleware(apikeys.ScopeEquipmentWrite), equipment.SaveEquipmentMemory)
 
 	// Список покупок (инвентарь)
 	r.GET("/api/inventory", users.AuthMiddleware(), inventory.GetInventory)
@@ -261,6 +418,7 @@ func main() {
 
 	// Путевой лист
 	r.GET("/api/travel-sheet", users.AuthMiddleware(), travelsheet.GetTravelRecords)
+	r.GET("/api/travel-sheet/export", users.AuthMiddleware(), travelsheet.ExportTravelRecords)
 	r.POST("/api/travel-sheet", users.AuthMiddleware(), travelsheet.CreateTravelRecord)
 	r.DELETE("/api/travel-sheet/:id", users.AuthMiddleware(), travelsheet.DeleteTravelRecord)
 	r.GET("/api/travel-sheet/stats/daily", users.AuthMiddleware(), travelsheet.GetDailyStats)
@@ -1,6 +1,7 @@
 package address
 
 import (
+	"backend/internal/audit"
 	"backend/internal/db"
 	"net/http"
 	"strings"
@@ -28,7 +29,9 @@ func GetAddresses(c *gin.Context) {
 
 func AddAddress(c *gin.Context) {
 	var input struct {
-		Address string `json:"address" binding:"required"`
+		Address   string   `json:"address" binding:"required"`
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -42,8 +45,15 @@ func AddAddress(c *gin.Context) {
 		return
 	}
 
+	if !validLocation(input.Latitude, input.Longitude) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Координаты указываются парой: широта от -90 до 90, долгота от -180 до 180"})
+		return
+	}
+
 	address := db.Address{
-		Address: strings.TrimSpace(input.Address),
+		Address:   strings.TrimSpace(input.Address),
+		Latitude:  input.Latitude,
+		Longitude: input.Longitude,
 	}
 
 	if err := db.DB.Create(&address).Error; err != nil {
@@ -51,12 +61,20 @@ func AddAddress(c *gin.Context) {
 		return
 	}
 
+	audit.SetEntity(c, "addresses", address.ID)
+	audit.SetAfter(c, address)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Адрес успешно добавлен", "address": address})
 }
 
 func DeleteAddress(c *gin.Context) {
 	id := c.Param("id")
 
+	var existing db.Address
+	if err := db.DB.First(&existing, id).Error; err == nil {
+		audit.SetBefore(c, existing)
+	}
+
 	if err := db.DB.Delete(&db.Address{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении адреса"})
 		return
@@ -64,3 +82,48 @@ func DeleteAddress(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Адрес успешно удален"})
 }
+
+// validLocation - координаты не заданы вовсе или заданы обе и в допустимых пределах
+func validLocation(lat, lon *float64) bool {
+	if lat == nil && lon == nil {
+		return true
+	}
+	return lat != nil && lon != nil && *lat >= -90 && *lat <= 90 && *lon >= -180 && *lon <= 180
+}
+
+// UpdateAddressLocation задаёт координаты адреса (пустые - сброс), по ним
+// проверяются геометки фотографий отчётов
+func UpdateAddressLocation(c *gin.Context) {
+	var input struct {
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	if !validLocation(input.Latitude, input.Longitude) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Координаты указываются парой: широта от -90 до 90, долгота от -180 до 180"})
+		return
+	}
+
+	var address db.Address
+	if err := db.DB.First(&address, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Адрес не найден"})
+		return
+	}
+	audit.SetEntity(c, "addresses", address.ID)
+	audit.SetBefore(c, address)
+
+	address.Latitude, address.Longitude = input.Latitude, input.Longitude
+	if err := db.DB.Model(&address).Updates(map[string]interface{}{
+		"latitude":  input.Latitude,
+		"longitude": input.Longitude,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при сохранении координат"})
+		return
+	}
+	audit.SetAfter(c, address)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Координаты адреса сохранены", "address": address})
+}
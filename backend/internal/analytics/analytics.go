@@ -0,0 +1,429 @@
+package analytics
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"backend/internal/db"
+)
+
+// Измерения
+const (
+	DimEngineer       = "engineer"
+	DimClassification = "classification"
+	DimAddress        = "address"
+	DimClient         = "client"
+	DimMonth          = "month"
+	DimWeek           = "week"
+)
+
+// Показатели
+const (
+	MeasureReports     = "reports"
+	MeasureTickets     = "tickets"
+	MeasureKm          = "km"
+	MeasureSLAHits     = "sla_hits"     // заявки, выполненные в пределах SLA
+	MeasureSLABreaches = "sla_breaches" // выполненные позже срока или просроченные невыполненные
+)
+
+var (
+	Dimensions = []string{DimEngineer, DimClassification, DimAddress, DimClient, DimMonth, DimWeek}
+	Measures   = []string{MeasureReports, MeasureTickets, MeasureKm, MeasureSLAHits, MeasureSLABreaches}
+)
+
+const defaultSLAHours = 48
+
+// Query - запрос агрегатов. Пустые фильтры не применяются
+type Query struct {
+	Dimensions     []string
+	Measures       []string
+	StartDate      string
+	EndDate        string
+	EngineerID     *uint
+	Classification string
+	ClientID       *uint
+	Address        string
+}
+
+// Result - строки для сводной таблицы: значения измерений и показателей,
+// для engineer и client дополнительно engineerName и clientName
+type Result struct {
+	Dimensions []string                 `json:"dimensions"`
+	Measures   []string                 `json:"measures"`
+	Rows       []map[string]interface{} `json:"rows"`
+	Totals     map[string]float64       `json:"totals"`
+}
+
+// source - таблица фактов: выражения измерений (пустая строка - измерение неприменимо)
+// и показателей, которые из неё считаются
+type source struct {
+	from     string
+	where    string
+	date     string
+	dims     map[string]string
+	measures map[string]string
+}
+
+// weekExpr - ISO-неделя даты в виде 2025-W07
+func weekExpr(col string) string {
+	return `CASE WHEN ` + col + ` ~ '^\d{4}-\d{2}-\d{2}' THEN to_char(substr(` + col + `, 1, 10)::date, 'IYYY-"W"IW') END`
+}
+
+func slaHours() int {
+	if v, err := strconv.Atoi(os.Getenv("ANALYTICS_SLA_HOURS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultSLAHours
+}
+
+// Заявка хранит дату создания без времени и время выполнения строками
+const (
+	ticketDateValid      = `t.date ~ '^\d{4}-\d{2}-\d{2}'`
+	ticketCompletedValid = `t.completed_at ~ '^\d{4}-\d{2}-\d{2} \d{2}:\d{2}'`
+)
+
+func slaDeadline() string {
+	return fmt.Sprintf("substr(t.date, 1, 10)::date + make_interval(hours => %d)", slaHours())
+}
+
+// sources - откуда берётся каждый показатель. Отчёты учитываются только согласованные,
+// SLA заявки отсчитывается от начала дня её создания до отметки о выполнении
+func sources() []source {
+	return []source{
+		{
+			from:  "reports r LEFT JOIN addresses a ON a.address = r.address",
+			where: "r.status = '" + db.ReportApproved + "'",
+			date:  "r.date",
+			dims: map[string]string{
+				DimEngineer:       "r.user_id",
+				DimClassification: "r.classification",
+				DimAddress:        "r.address",
+				DimClient:         "a.organization_id",
+				DimMonth:          "substr(r.date, 1, 7)",
+				DimWeek:           weekExpr("r.date"),
+			},
+			measures: map[string]string{MeasureReports: "COUNT(*)"},
+		},
+		{
+			from: "client_tickets t LEFT JOIN addresses a ON a.address = t.address",
+			date: "t.date",
+			dims: map[string]string{
+				DimEngineer: "t.engineer_id",
+				DimAddress:  "t.address",
+				DimClient:   "a.organization_id",
+				DimMonth:    "substr(t.date, 1, 7)",
+				DimWeek:     weekExpr("t.date"),
+			},
+			measures: map[string]string{
+				MeasureTickets: "COUNT(*)",
+				MeasureSLAHits: `COUNT(*) FILTER (WHERE CASE
+					WHEN ` + ticketDateValid + ` AND ` + ticketCompletedValid + `
+					THEN t.completed_at::timestamp <= ` + slaDeadline() + `
+					ELSE false END)`,
+				MeasureSLABreaches: `COUNT(*) FILTER (WHERE CASE
+					WHEN ` + ticketDateValid + ` AND ` + ticketCompletedValid + `
+					THEN t.completed_at::timestamp > ` + slaDeadline() + `
+					WHEN ` + ticketDateValid + ` THEN now() > ` + slaDeadline() + `
+					ELSE false END)`,
+			},
+		},
+		{
+			from: "travel_records tr",
+			date: "tr.date",
+			dims: map[string]string{
+				DimEngineer: "tr.user_id",
+				DimMonth:    "substr(tr.date, 1, 7)",
+				DimWeek:     weekExpr("tr.date"),
+			},
+			measures: map[string]string{MeasureKm: "COALESCE(SUM(tr.distance), 0)"},
+		},
+	}
+}
+
+// Validate проверяет измерения и показатели запроса и убирает их повторы.
+// Показатель, который нельзя разбить по измерению или отфильтровать (пробег по
+// классификации), отклоняется, а не возвращается нулём
+func (q *Query) Validate() error {
+	q.Dimensions = unique(q.Dimensions)
+	q.Measures = unique(q.Measures)
+	for _, d := range q.Dimensions {
+		if !contains(Dimensions, d) {
+			return fmt.Errorf("неизвестное измерение: %s", d)
+		}
+	}
+	if len(q.Measures) == 0 {
+		return fmt.Errorf("не указаны показатели")
+	}
+	for _, m := range q.Measures {
+		if !contains(Measures, m) {
+			return fmt.Errorf("неизвестный показатель: %s", m)
+		}
+	}
+
+	filters := q.filterDimensions()
+	for _, src := range sources() {
+		for _, m := range q.Measures {
+			if _, ok := src.measures[m]; !ok {
+				continue
+			}
+			for _, d := range q.Dimensions {
+				if src.dims[d] == "" {
+					return fmt.Errorf("показатель %s нельзя разбить по измерению %s", m, d)
+				}
+			}
+			for _, d := range filters {
+				if src.dims[d] == "" {
+					return fmt.Errorf("показатель %s нельзя отфильтровать по измерению %s", m, d)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// filterDimensions - измерения, по которым задан фильтр
+func (q Query) filterDimensions() []string {
+	var dims []string
+	if q.EngineerID != nil {
+		dims = append(dims, DimEngineer)
+	}
+	if q.Classification != "" {
+		dims = append(dims, DimClassification)
+	}
+	if q.ClientID != nil {
+		dims = append(dims, DimClient)
+	}
+	if q.Address != "" {
+		dims = append(dims, DimAddress)
+	}
+	return dims
+}
+
+// Run считает показатели одним GROUP BY на каждую таблицу фактов и сводит строки
+// по значениям измерений. Новые значения (классификации, адреса) появляются сами
+func Run(q Query) (*Result, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	rows := make(map[string]map[string]interface{})
+	for _, src := range sources() {
+		measures := make([]string, 0, len(q.Measures))
+		for _, m := range q.Measures {
+			if _, ok := src.measures[m]; ok {
+				measures = append(measures, m)
+			}
+		}
+		if len(measures) == 0 {
+			continue
+		}
+		found, err := runSource(src, q, measures)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range found {
+			key := rowKey(q.Dimensions, row)
+			merged, ok := rows[key]
+			if !ok {
+				merged = make(map[string]interface{}, len(q.Dimensions)+len(q.Measures))
+				for _, d := range q.Dimensions {
+					merged[d] = row[d]
+				}
+				for _, m := range q.Measures {
+					merged[m] = float64(0)
+				}
+				rows[key] = merged
+			}
+			for _, m := range measures {
+				merged[m] = merged[m].(float64) + toFloat(row[m])
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := &Result{
+		Dimensions: q.Dimensions,
+		Measures:   q.Measures,
+		Rows:       make([]map[string]interface{}, 0, len(keys)),
+		Totals:     make(map[string]float64, len(q.Measures)),
+	}
+	for _, key := range keys {
+		row := rows[key]
+		for _, m := range q.Measures {
+			result.Totals[m] += row[m].(float64)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	addLabels(result)
+	return result, nil
+}
+
+// runSource выполняет один GROUP BY по таблице фактов. Неприменимые к таблице
+// измерения и фильтры отклоняет Validate, здесь такая таблица просто пропускается
+func runSource(src source, q Query, measures []string) ([]map[string]interface{}, error) {
+	var selects, groups, where []string
+	var args []interface{}
+	if src.where != "" {
+		where = append(where, src.where)
+	}
+
+	for i, d := range q.Dimensions {
+		expr := src.dims[d]
+		if expr == "" {
+			return nil, nil
+		}
+		selects = append(selects, expr+" AS "+d)
+		groups = append(groups, strconv.Itoa(i+1))
+	}
+	for _, m := range measures {
+		selects = append(selects, src.measures[m]+" AS "+m)
+	}
+
+	filter := func(dim, op string, value interface{}) bool {
+		expr := src.dims[dim]
+		if expr == "" {
+			return false
+		}
+		where = append(where, expr+" "+op+" ?")
+		args = append(args, value)
+		return true
+	}
+	if q.StartDate != "" {
+		where = append(where, src.date+" >= ?")
+		args = append(args, q.StartDate)
+	}
+	if q.EndDate != "" {
+		// Даты хранятся строками, у части записей со временем
+		where = append(where, "substr("+src.date+", 1, 10) <= ?")
+		args = append(args, q.EndDate)
+	}
+	if q.EngineerID != nil && !filter(DimEngineer, "=", *q.EngineerID) {
+		return nil, nil
+	}
+	if q.Classification != "" && !filter(DimClassification, "=", q.Classification) {
+		return nil, nil
+	}
+	if q.ClientID != nil && !filter(DimClient, "=", *q.ClientID) {
+		return nil, nil
+	}
+	if q.Address != "" && !filter(DimAddress, "=", q.Address) {
+		return nil, nil
+	}
+
+	sql := "SELECT " + strings.Join(selects, ", ") + " FROM " + src.from
+	if len(where) > 0 {
+		sql += " WHERE " + strings.Join(where, " AND ")
+	}
+	if len(groups) > 0 {
+		sql += " GROUP BY " + strings.Join(groups, ", ")
+	}
+
+	var rows []map[string]interface{}
+	if err := db.DB.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	// Столбцы без объявленного типа (агрегаты в SQLite) приходят указателем
+	for _, row := range rows {
+		for k, v := range row {
+			if p, ok := v.(*interface{}); ok {
+				row[k] = *p
+			}
+		}
+	}
+	return rows, nil
+}
+
+func rowKey(dims []string, row map[string]interface{}) string {
+	parts := make([]string, len(dims))
+	for i, d := range dims {
+		if v := row[d]; v != nil {
+			parts[i] = fmt.Sprint(v)
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case []byte:
+		f, _ := strconv.ParseFloat(string(n), 64)
+		return f
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+// addLabels добавляет имена инженеров и клиентов к их идентификаторам
+func addLabels(result *Result) {
+	if contains(result.Dimensions, DimEngineer) {
+		var users []db.User
+		db.DB.Select("id, first_name, last_name").Where("id IN ?", collectIDs(result.Rows, DimEngineer)).Find(&users)
+		names := make(map[string]string, len(users))
+		for _, u := range users {
+			names[strconv.FormatUint(uint64(u.ID), 10)] = strings.TrimSpace(u.LastName + " " + u.FirstName)
+		}
+		for _, row := range result.Rows {
+			row["engineerName"] = names[fmt.Sprint(row[DimEngineer])]
+		}
+	}
+	if contains(result.Dimensions, DimClient) {
+		var orgs []db.ClientOrganization
+		db.DB.Select("id, name").Where("id IN ?", collectIDs(result.Rows, DimClient)).Find(&orgs)
+		names := make(map[string]string, len(orgs))
+		for _, o := range orgs {
+			names[strconv.FormatUint(uint64(o.ID), 10)] = o.Name
+		}
+		for _, row := range result.Rows {
+			row["clientName"] = names[fmt.Sprint(row[DimClient])]
+		}
+	}
+}
+
+func collectIDs(rows []map[string]interface{}, dim string) []string {
+	ids := []string{"0"}
+	for _, row := range rows {
+		if v := row[dim]; v != nil {
+			ids = append(ids, fmt.Sprint(v))
+		}
+	}
+	return ids
+}
+
+func unique(list []string) []string {
+	var out []string
+	for _, v := range list {
+		if !contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+
+	"backend/internal/db"
+	"backend/internal/dbtest"
+)
+
+func TestValidateRejectsUnsupportedPairs(t *testing.T) {
+	engineer := uint(1)
+	tests := []struct {
+		name    string
+		q       Query
+		wantErr string
+	}{
+		{"отчёты по классификации", Query{Dimensions: []string{DimClassification}, Measures: []string{MeasureReports}}, ""},
+		{"заявки по классификации", Query{Dimensions: []string{DimClassification}, Measures: []string{MeasureTickets}}, "нельзя разбить"},
+		{"пробег по клиенту", Query{Dimensions: []string{DimClient}, Measures: []string{MeasureKm}}, "нельзя разбить"},
+		{"пробег с фильтром классификации", Query{Measures: []string{MeasureKm}, Classification: "ТО"}, "нельзя отфильтровать"},
+		{"пробег инженера по месяцам", Query{Dimensions: []string{DimMonth}, Measures: []string{MeasureKm}, EngineerID: &engineer}, ""},
+		{"неизвестное измерение", Query{Dimensions: []string{"color"}, Measures: []string{MeasureReports}}, "неизвестное измерение"},
+		{"без показателей", Query{Dimensions: []string{DimMonth}}, "не указаны показатели"},
+	}
+	for _, tt := range tests {
+		err := tt.q.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: неожиданная ошибка %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: ошибка %v, ожидалась %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateDeduplicates(t *testing.T) {
+	q := Query{
+		Dimensions: []string{DimEngineer, DimMonth, DimEngineer},
+		Measures:   []string{MeasureReports, MeasureReports},
+	}
+	if err := q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(q.Dimensions, ",") != "engineer,month" || strings.Join(q.Measures, ",") != "reports" {
+		t.Errorf("повторы не убраны: %v %v", q.Dimensions, q.Measures)
+	}
+}
+
+// Показатели разных таблиц сводятся в одну строку по значению измерения
+func TestRunMergesSources(t *testing.T) {
+	dbtest.Open(t)
+	ivan := db.User{FirstName: "Иван", LastName: "Петров", Phone: "79000000001"}
+	olga := db.User{FirstName: "Ольга", LastName: "Смирнова", Phone: "79000000002"}
+	for _, u := range []*db.User{&ivan, &olga} {
+		if err := db.DB.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range []db.Report{
+		{Date: "2025-03-01", Address: "ул. Ленина, 1", UserID: ivan.ID, Classification: "ТО", Status: db.ReportApproved},
+		{Date: "2025-03-02", Address: "ул. Ленина, 1", UserID: ivan.ID, Classification: "АВ", Status: db.ReportApproved},
+		{Date: "2025-03-03", Address: "ул. Ленина, 1", UserID: ivan.ID, Classification: "ТО", Status: db.ReportDraft},
+		{Date: "2025-03-04", Address: "ул. Мира, 5", UserID: olga.ID, Classification: "ТО", Status: db.ReportApproved},
+		{Date: "2025-04-01", Address: "ул. Мира, 5", UserID: olga.ID, Classification: "ТО", Status: db.ReportApproved},
+	} {
+		if err := db.DB.Create(&r).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.DB.Create(&db.ClientTicket{Date: "2025-03-05", Address: "ул. Мира, 5", EngineerID: &olga.ID, Status: "Выполнено"})
+	db.DB.Create(&db.TravelRecord{Date: "2025-03-05", StartPoint: "офис", EndPoint: "ул. Мира, 5", Distance: 12.5, UserID: olga.ID})
+	db.DB.Create(&db.TravelRecord{Date: "2025-03-06", StartPoint: "офис", EndPoint: "ул. Ленина, 1", Distance: 7, UserID: ivan.ID})
+
+	result, err := Run(Query{
+		Dimensions: []string{DimEngineer, DimEngineer},
+		Measures:   []string{MeasureReports, MeasureTickets, MeasureKm},
+		StartDate:  "2025-03-01",
+		EndDate:    "2025-03-31",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Dimensions) != 1 || len(result.Rows) != 2 {
+		t.Fatalf("измерения %v, строк %d", result.Dimensions, len(result.Rows))
+	}
+	want := map[string][3]float64{
+		"Петров Иван":    {2, 0, 7},
+		"Смирнова Ольга": {1, 1, 12.5},
+	}
+	for _, row := range result.Rows {
+		name, _ := row["engineerName"].(string)
+		w, ok := want[name]
+		if !ok {
+			t.Errorf("лишняя строка %v", row)
+			continue
+		}
+		got := [3]float64{row[MeasureReports].(float64), row[MeasureTickets].(float64), row[MeasureKm].(float64)}
+		if got != w {
+			t.Errorf("%s: %v, ожидалось %v", name, got, w)
+		}
+	}
+	if result.Totals[MeasureReports] != 3 || result.Totals[MeasureTickets] != 1 || result.Totals[MeasureKm] != 19.5 {
+		t.Errorf("итоги %v", result.Totals)
+	}
+}
@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/users"
+)
+
+// GetAnalytics - агрегированная статистика с произвольной группировкой.
+// Параметры: dimensions и measures (через запятую), startDate, endDate и фильтры
+// engineer, classification, client, address. Пользователи без права согласования
+// видят только свои показатели
+func GetAnalytics(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return
+	}
+	var user db.User
+	if err := db.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не найден"})
+		return
+	}
+
+	q := Query{
+		Dimensions:     splitList(c.Query("dimensions")),
+		Measures:       splitList(c.DefaultQuery("measures", MeasureReports)),
+		StartDate:      c.Query("startDate"),
+		EndDate:        c.Query("endDate"),
+		Classification: classifications.Normalize(c.Query("classification")),
+		Address:        c.Query("address"),
+	}
+	if raw := c.Query("engineer"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный идентификатор инженера"})
+			return
+		}
+		engineerID := uint(id)
+		q.EngineerID = &engineerID
+	}
+	if raw := c.Query("client"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный идентификатор клиента"})
+			return
+		}
+		clientID := uint(id)
+		q.ClientID = &clientID
+	}
+	if !users.IsReviewer(&user) {
+		q.EngineerID = &user.ID
+	}
+	if err := q.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := Run(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при расчете статистики"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func splitList(raw string) []string {
+	var list []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,46 @@
+package analytics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/dbtest"
+)
+
+// Фильтр по классификации принимает название и старые написания, как формы отчётов
+func TestGetAnalyticsNormalizesClassification(t *testing.T) {
+	dbtest.Open(t)
+	gin.SetMode(gin.TestMode)
+	db.DB.Create(&db.Classification{Code: "ТО", Name: "Техническое обслуживание", Aliases: "ТО Китчен", CreatedAt: "2025-01-01 00:00:00"})
+	classifications.Invalidate()
+	t.Cleanup(classifications.Invalidate)
+
+	admin := db.User{FirstName: "Анна", LastName: "Иванова", Department: "Админ", Phone: "79000000001"}
+	db.DB.Create(&admin)
+	db.DB.Create(&db.Report{Date: "2025-03-01", Address: "ул. Ленина, 1", UserID: admin.ID, Classification: "ТО", Status: db.ReportApproved})
+
+	for _, value := range []string{"ТО", "Техническое обслуживание", "то китчен"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/analytics?measures=reports&classification="+url.QueryEscape(value), nil)
+		c.Set("userID", admin.ID)
+		GetAnalytics(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: статус %d, ответ %s", value, w.Code, w.Body.String())
+		}
+		var result Result
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Totals[MeasureReports] != 1 {
+			t.Errorf("%q: отчётов %v, ожидался 1", value, result.Totals[MeasureReports])
+		}
+	}
+}
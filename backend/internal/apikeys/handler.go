@@ -0,0 +1,291 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/audit"
+	"backend/internal/db"
+)
+
+const defaultRateLimit = 60
+
+// generateKey создает новый ключ. Открытое значение возвращается один раз,
+// в БД сохраняются только префикс и хеш
+func generateKey() (plain string, prefix string, hash string, err error) {
+	prefixBytes := make([]byte, 4)
+	secretBytes := make([]byte, 24)
+	if _, err = rand.Read(prefixBytes); err != nil {
+		return
+	}
+	if _, err = rand.Read(secretBytes); err != nil {
+		return
+	}
+	prefix = hex.EncodeToString(prefixBytes)
+	plain = keyPrefix + prefix + "_" + hex.EncodeToString(secretBytes)
+	hash = hashKey(plain)
+	return
+}
+
+func normalizeScopes(scopes []string) (string, bool) {
+	known := map[string]bool{}
+	for _, s := range KnownScopes {
+		known[s] = true
+	}
+	var result []string
+	seen := map[string]bool{}
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		if !known[s] {
+			return "", false
+		}
+		seen[s] = true
+		result = append(result, s)
+	}
+	if len(result) == 0 {
+		return "", false
+	}
+	return strings.Join(result, ","), true
+}
+
+// GetServiceAccounts - список сервисных учетных записей с их ключами
+func GetServiceAccounts(c *gin.Context) {
+	var accounts []db.ServiceAccount
+	if err := db.DB.Preload("Keys", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("id DESC")
+	}).Order("id").Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении сервисных учетных записей"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts, "scopes": KnownScopes})
+}
+
+// CreateServiceAccount - создание сервисной учетной записи
+func CreateServiceAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return
+	}
+
+	var input struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+
+	name := strings.TrimSpace(input.Name)
+	var existing db.ServiceAccount
+	if err := db.DB.Where("name = ?", name).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Учетная запись с таким именем уже существует"})
+		return
+	}
+
+	account := db.ServiceAccount{
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+		IsActive:    true,
+		CreatedAt:   time.Now().Format("2006-01-02 15:04:05"),
+		CreatedBy:   userID.(uint),
+	}
+	if err := db.DB.Create(&account).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании учетной записи"})
+		return
+	}
+	audit.SetEntity(c, "service-accounts", account.ID)
+	audit.SetAfter(c, account)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Учетная запись создана", "account": account})
+}
+
+// DisableServiceAccount - отключение учетной записи и отзыв всех её ключей
+func DisableServiceAccount(c *gin.Context) {
+	id := c.Param("id")
+
+	var account db.ServiceAccount
+	if err := db.DB.First(&account, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Учетная запись не найдена"})
+		return
+	}
+	audit.SetBefore(c, account)
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&account).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&db.APIKey{}).
+			Where("service_account_id = ? AND revoked_at IS NULL", account.ID).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при отключении учетной записи"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Учетная запись отключена, ключи отозваны"})
+}
+
+type keyInput struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	RateLimit int      `json:"rateLimit"`
+	ExpiresAt string   `json:"expiresAt"`
+}
+
+// issueKey создает ключ для учетной записи и возвращает открытое значение
+func issueKey(tx *gorm.DB, accountID uint, name, scopes string, rateLimit int, expiresAt string) (*db.APIKey, string, error) {
+	plain, prefix, hash, err := generateKey()
+	if err != nil {
+		return nil, "", err
+	}
+	key := db.APIKey{
+		ServiceAccountID: accountID,
+		Name:             name,
+		Prefix:           prefix,
+		KeyHash:          hash,
+		Scopes:           scopes,
+		RateLimit:        rateLimit,
+		CreatedAt:        time.Now().Format("2006-01-02 15:04:05"),
+		ExpiresAt:        expiresAt,
+	}
+	if err := tx.Create(&key).Error; err != nil {
+		return nil, "", err
+	}
+	return &key, plain, nil
+}
+
+// CreateAPIKey - выпуск нового ключа для сервисной учетной записи
+func CreateAPIKey(c *gin.Context) {
+	id := c.Param("id")
+
+	var account db.ServiceAccount
+	if err := db.DB.First(&account, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Учетная запись не найдена"})
+		return
+	}
+	if !account.IsActive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Учетная запись отключена"})
+		return
+	}
+
+	var input keyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+
+	scopes, ok := normalizeScopes(input.Scopes)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указаны или неизвестны области доступа", "scopes": KnownScopes})
+		return
+	}
+
+	rateLimit := input.RateLimit
+	if rateLimit <= 0 {
+		rateLimit = defaultRateLimit
+	}
+
+	expiresAt := strings.TrimSpace(input.ExpiresAt)
+	if expiresAt != "" {
+		if _, err := time.Parse("2006-01-02", expiresAt); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата окончания должна быть в формате YYYY-MM-DD"})
+			return
+		}
+		expiresAt += " 23:59:59"
+	}
+
+	key, plain, err := issueKey(db.DB, account.ID, strings.TrimSpace(input.Name), scopes, rateLimit, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании ключа"})
+		return
+	}
+	audit.SetEntity(c, "api-keys", key.ID)
+	audit.SetAfter(c, key)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ключ создан. Сохраните его: повторно он показан не будет",
+		"key":     plain,
+		"apiKey":  key,
+	})
+}
+
+// RotateAPIKey - выпуск нового ключа с теми же настройками и отзыв старого
+func RotateAPIKey(c *gin.Context) {
+	id := c.Param("id")
+
+	var old db.APIKey
+	if err := db.DB.First(&old, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ключ не найден"})
+		return
+	}
+	if old.RevokedAt != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ключ уже отозван"})
+		return
+	}
+
+	var account db.ServiceAccount
+	if err := db.DB.First(&account, old.ServiceAccountID).Error; err != nil || !account.IsActive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Учетная запись отключена"})
+		return
+	}
+
+	var newKey *db.APIKey
+	var plain string
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&old).Update("revoked_at", time.Now().Format("2006-01-02 15:04:05")).Error; err != nil {
+			return err
+		}
+		var err error
+		newKey, plain, err = issueKey(tx, old.ServiceAccountID, old.Name, old.Scopes, old.RateLimit, old.ExpiresAt)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при ротации ключа"})
+		return
+	}
+	audit.SetBefore(c, old)
+	audit.SetAfter(c, newKey)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ключ перевыпущен. Сохраните его: повторно он показан не будет",
+		"key":     plain,
+		"apiKey":  newKey,
+	})
+}
+
+// RevokeAPIKey - отзыв ключа
+func RevokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+
+	var key db.APIKey
+	if err := db.DB.First(&key, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ключ не найден"})
+		return
+	}
+	if key.RevokedAt != "" {
+		c.JSON(http.StatusOK, gin.H{"message": "Ключ уже отозван"})
+		return
+	}
+	audit.SetBefore(c, key)
+
+	if err := db.DB.Model(&key).Update("revoked_at", time.Now().Format("2006-01-02 15:04:05")).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при отзыве ключа"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ключ отозван"})
+}
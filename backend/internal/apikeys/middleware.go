@@ -0,0 +1,203 @@
+package apikeys
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/db"
+	"backend/internal/users"
+)
+
+// Области доступа ключей
+const (
+	ScopeAll            = "*"
+	ScopeAddressesWrite = "addresses:write"
+	ScopeEquipmentRead  = "equipment:read"
+	ScopeEquipmentWrite = "equipment:write"
+	ScopeReportsRead    = "reports:read"
+	ScopeTicketsRead    = "tickets:read"
+	ScopeTicketsWrite   = "tickets:write"
+	ScopeUsersRead      = "users:read"
+)
+
+// KnownScopes - допустимые значения при выпуске ключа
+var KnownScopes = []string{
+	ScopeAll,
+	ScopeAddressesWrite,
+	ScopeEquipmentRead,
+	ScopeEquipmentWrite,
+	ScopeReportsRead,
+	ScopeTicketsRead,
+	ScopeTicketsWrite,
+	ScopeUsersRead,
+}
+
+const keyPrefix = "crm_"
+
+// rateWindow - счётчик запросов ключа в текущем минутном окне
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+var (
+	rateMu      sync.Mutex
+	rateWindows = map[uint]*rateWindow{}
+)
+
+// allowRequest проверяет лимит запросов в минуту для ключа
+func allowRequest(keyID uint, limit int) (bool, int) {
+	if limit <= 0 {
+		return true, 0
+	}
+	rateMu.Lock()
+	defer rateMu.Unlock()
+
+	now := time.Now()
+	w, ok := rateWindows[keyID]
+	if !ok || now.Sub(w.start) >= time.Minute {
+		rateWindows[keyID] = &rateWindow{start: now, count: 1}
+		return true, 0
+	}
+	if w.count >= limit {
+		retryAfter := int(time.Minute.Seconds() - now.Sub(w.start).Seconds())
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		return false, retryAfter
+	}
+	w.count++
+	return true, 0
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseKey достаёт префикс из ключа формата crm_<prefix>_<secret>
+func parseKey(key string) (string, bool) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(key, keyPrefix), "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func getAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+	auth := c.GetHeader("Authorization")
+	if strings.HasPrefix(auth, "ApiKey ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "ApiKey "))
+	}
+	return ""
+}
+
+func hasScope(scopes string, scope string) bool {
+	for _, s := range strings.Split(scopes, ",") {
+		s = strings.TrimSpace(s)
+		if s == ScopeAll || s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticateKey проверяет ключ и возвращает его запись или текст ошибки
+func authenticateKey(rawKey string) (*db.APIKey, int, string) {
+	prefix, ok := parseKey(rawKey)
+	if !ok {
+		return nil, http.StatusUnauthorized, "неверный формат API-ключа"
+	}
+
+	var key db.APIKey
+	if err := db.DB.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, http.StatusUnauthorized, "недействительный API-ключ"
+	}
+	if subtle.ConstantTimeCompare([]byte(hashKey(rawKey)), []byte(key.KeyHash)) != 1 {
+		return nil, http.StatusUnauthorized, "недействительный API-ключ"
+	}
+	if key.RevokedAt != "" {
+		return nil, http.StatusUnauthorized, "API-ключ отозван"
+	}
+	if key.ExpiresAt != "" && key.ExpiresAt < time.Now().Format("2006-01-02 15:04:05") {
+		return nil, http.StatusUnauthorized, "срок действия API-ключа истек"
+	}
+
+	var account db.ServiceAccount
+	if err := db.DB.First(&account, key.ServiceAccountID).Error; err != nil || !account.IsActive {
+		return nil, http.StatusUnauthorized, "сервисная учетная запись отключена"
+	}
+
+	return &key, 0, ""
+}
+
+// KeyMiddleware - авторизация только по API-ключу с нужной областью доступа
+func KeyMiddleware(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := getAPIKey(c)
+		if rawKey == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API-ключ отсутствует"})
+			c.Abort()
+			return
+		}
+
+		key, status, msg := authenticateKey(rawKey)
+		if key == nil {
+			c.JSON(status, gin.H{"error": msg})
+			c.Abort()
+			return
+		}
+
+		if !hasScope(key.Scopes, scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "у ключа нет доступа к " + scope})
+			c.Abort()
+			return
+		}
+
+		if ok, retryAfter := allowRequest(key.ID, key.RateLimit); !ok {
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "превышен лимит запросов для API-ключа"})
+			c.Abort()
+			return
+		}
+
+		db.DB.Model(&db.APIKey{}).Where("id = ?", key.ID).UpdateColumns(map[string]interface{}{
+			"last_used_at": time.Now().Format("2006-01-02 15:04:05"),
+			"last_used_ip": c.ClientIP(),
+			"usage_count":  gorm.Expr("usage_count + 1"),
+		})
+
+		c.Set("serviceAccountID", key.ServiceAccountID)
+		c.Set("apiKeyID", key.ID)
+		c.Next()
+	}
+}
+
+// AuthOrKeyMiddleware принимает либо JWT сотрудника (как users.AuthMiddleware),
+// либо API-ключ сервисной учетной записи с нужной областью доступа
+func AuthOrKeyMiddleware(scope string) gin.HandlerFunc {
+	userAuth := users.AuthMiddleware()
+	keyAuth := KeyMiddleware(scope)
+	return func(c *gin.Context) {
+		if getAPIKey(c) != "" {
+			keyAuth(c)
+			return
+		}
+		userAuth(c)
+	}
+}
@@ -0,0 +1,220 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+)
+
+const (
+	beforeKey     = "auditBefore"
+	afterKey      = "auditAfter"
+	entityTypeKey = "auditEntityType"
+	entityIDKey   = "auditEntityID"
+)
+
+// Маршруты, которые не пишутся в журнал (вход/выход, в теле пароли)
+var skippedRoutes = map[string]bool{
+	"/api/login":           true,
+	"/api/logout":          true,
+	"/api/register":        true,
+	"/api/client/login":    true,
+	"/api/client/logout":   true,
+	"/api/client/register": true,
+}
+
+// Поля, значения которых никогда не попадают в журнал
+var redactedFields = []string{"password", "Password", "keyHash", "KeyHash"}
+
+// Параметры маршрута, из которых берётся идентификатор объекта (по приоритету)
+var entityParams = []string{"id", "ticketId", "reportname", "filename", "phone"}
+
+// SetBefore сохраняет состояние объекта до изменения
+func SetBefore(c *gin.Context, v interface{}) {
+	c.Set(beforeKey, v)
+}
+
+// SetAfter сохраняет состояние объекта после изменения
+func SetAfter(c *gin.Context, v interface{}) {
+	c.Set(afterKey, v)
+}
+
+// SetEntity явно задаёт тип и ID изменяемого объекта (если их нельзя вывести из маршрута)
+func SetEntity(c *gin.Context, entityType string, entityID interface{}) {
+	c.Set(entityTypeKey, entityType)
+	c.Set(entityIDKey, fmt.Sprintf("%v", entityID))
+}
+
+// Middleware записывает в журнал все изменяющие запросы (POST, PUT, PATCH, DELETE).
+// Подключается глобально: пользователь/клиент определяется после отработки
+// маршрутных middleware авторизации
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if !isMutating(c.Request.Method) || route == "" || skippedRoutes[route] {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		entry := buildEntry(c, route)
+		if err := db.DB.Create(&entry).Error; err != nil {
+			log.Printf("audit: ошибка записи в журнал: %v", err)
+		}
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func buildEntry(c *gin.Context, route string) db.AuditLog {
+	entry := db.AuditLog{
+		CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
+		Method:     c.Request.Method,
+		Route:      route,
+		Path:       c.Request.URL.Path,
+		StatusCode: c.Writer.Status(),
+		IP:         c.ClientIP(),
+	}
+
+	entry.ActorType, entry.ActorID, entry.ActorName = resolveActor(c)
+	entry.EntityType, entry.EntityID = resolveEntity(c, route)
+
+	before, hasBefore := c.Get(beforeKey)
+	after, hasAfter := c.Get(afterKey)
+	var beforeMap, afterMap map[string]interface{}
+	if hasBefore {
+		beforeMap = toMap(before)
+		entry.Before = marshal(beforeMap)
+	}
+	if hasAfter {
+		afterMap = toMap(after)
+		entry.After = marshal(afterMap)
+	}
+	if hasBefore || hasAfter {
+		if changes := diff(beforeMap, afterMap); len(changes) > 0 {
+			entry.Changes = marshal(changes)
+		}
+	}
+
+	return entry
+}
+
+func resolveActor(c *gin.Context) (string, *uint, string) {
+	if v, ok := c.Get("userID"); ok {
+		if id, ok := v.(uint); ok {
+			var user db.User
+			name := ""
+			if err := db.DB.Select("id", "first_name", "last_name").First(&user, id).Error; err == nil {
+				name = strings.TrimSpace(user.LastName + " " + user.FirstName)
+			}
+			return "user", &id, name
+		}
+	}
+	if v, ok := c.Get("serviceAccountID"); ok {
+		if id, ok := v.(uint); ok {
+			var account db.ServiceAccount
+			name := ""
+			if err := db.DB.Select("id", "name").First(&account, id).Error; err == nil {
+				name = account.Name
+			}
+			return "service", &id, name
+		}
+	}
+	if v, ok := c.Get("clientID"); ok {
+		if id, ok := v.(uint); ok {
+			var client db.Client
+			name := ""
+			if err := db.DB.Select("id", "full_name").First(&client, id).Error; err == nil {
+				name = client.FullName
+			}
+			return "client", &id, name
+		}
+	}
+	return "anonymous", nil, ""
+}
+
+func resolveEntity(c *gin.Context, route string) (string, string) {
+	entityType := ""
+	if v, ok := c.Get(entityTypeKey); ok {
+		entityType, _ = v.(string)
+	}
+	if entityType == "" {
+		parts := strings.Split(strings.TrimPrefix(route, "/api/"), "/")
+		entityType = parts[0]
+		if entityType == "client" && len(parts) > 1 {
+			entityType = "client/" + parts[1]
+		}
+	}
+
+	if v, ok := c.Get(entityIDKey); ok {
+		if id, _ := v.(string); id != "" {
+			return entityType, id
+		}
+	}
+	for _, p := range entityParams {
+		if id := c.Param(p); id != "" {
+			return entityType, id
+		}
+	}
+	return entityType, ""
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return map[string]interface{}{"value": v}
+	}
+	for _, f := range redactedFields {
+		delete(m, f)
+	}
+	return m
+}
+
+func diff(before, after map[string]interface{}) map[string]interface{} {
+	changes := map[string]interface{}{}
+	for k, oldVal := range before {
+		newVal, ok := after[k]
+		if !ok && after != nil {
+			continue
+		}
+		if !reflect.DeepEqual(oldVal, newVal) {
+			changes[k] = gin.H{"old": oldVal, "new": newVal}
+		}
+	}
+	for k, newVal := range after {
+		if _, ok := before[k]; !ok {
+			changes[k] = gin.H{"old": nil, "new": newVal}
+		}
+	}
+	return changes
+}
+
+func marshal(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/db"
+)
+
+// filteredQuery строит запрос к журналу по параметрам фильтрации
+func filteredQuery(c *gin.Context) *gorm.DB {
+	query := db.DB.Model(&db.AuditLog{})
+
+	if v := c.Query("actorType"); v != "" {
+		query = query.Where("actor_type = ?", v)
+	}
+	if v := c.Query("actorId"); v != "" {
+		query = query.Where("actor_id = ?", v)
+	}
+	if v := c.Query("entityType"); v != "" {
+		query = query.Where("entity_type = ?", v)
+	}
+	if v := c.Query("entityId"); v != "" {
+		query = query.Where("entity_id = ?", v)
+	}
+	if v := c.Query("method"); v != "" {
+		query = query.Where("method = ?", v)
+	}
+	if v := c.Query("route"); v != "" {
+		query = query.Where("route ILIKE ?", "%"+v+"%")
+	}
+	if v := c.Query("ip"); v != "" {
+		query = query.Where("ip = ?", v)
+	}
+	if v := c.Query("startDate"); v != "" {
+		query = query.Where("created_at >= ?", v)
+	}
+	if v := c.Query("endDate"); v != "" {
+		query = query.Where("created_at <= ?", v+" 23:59:59")
+	}
+	if v := c.Query("search"); v != "" {
+		like := "%" + v + "%"
+		query = query.Where("(actor_name ILIKE ? OR path ILIKE ? OR before ILIKE ? OR after ILIKE ?)", like, like, like, like)
+	}
+
+	return query
+}
+
+// SearchAuditLogs - поиск по журналу изменений (только для админа)
+func SearchAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 500 {
+		pageSize = 50
+	}
+
+	query := filteredQuery(c)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при подсчете записей журнала"})
+		return
+	}
+
+	var logs []db.AuditLog
+	if err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении журнала"})
+		return
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	c.JSON(http.StatusOK, gin.H{
+		"logs":       logs,
+		"total":      total,
+		"totalPages": totalPages,
+		"page":       page,
+	})
+}
+
+// ExportAuditLogsCSV - выгрузка журнала изменений в CSV с теми же фильтрами
+func ExportAuditLogsCSV(c *gin.Context) {
+	rows, err := filteredQuery(c).Order("id DESC").Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении журнала"})
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("audit_%s.csv", time.Now().Format("2006-01-02"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Status(http.StatusOK)
+
+	// BOM, чтобы Excel корректно открыл кириллицу
+	_, _ = c.Writer.Write([]byte("\xEF\xBB\xBF"))
+
+	w := csv.NewWriter(c.Writer)
+	w.Comma = ';'
+	_ = w.Write([]string{
+		"ID", "Дата", "Тип субъекта", "ID субъекта", "Имя", "Метод", "Маршрут", "Путь",
+		"Тип объекта", "ID объекта", "Статус", "IP", "До", "После", "Изменения",
+	})
+
+	for rows.Next() {
+		var entry db.AuditLog
+		if err := db.DB.ScanRows(rows, &entry); err != nil {
+			continue
+		}
+		actorID := ""
+		if entry.ActorID != nil {
+			actorID = strconv.FormatUint(uint64(*entry.ActorID), 10)
+		}
+		_ = w.Write([]string{
+			strconv.FormatUint(uint64(entry.ID), 10),
+			entry.CreatedAt,
+			entry.ActorType,
+			actorID,
+			entry.ActorName,
+			entry.Method,
+			entry.Route,
+			entry.Path,
+			entry.EntityType,
+			entry.EntityID,
+			strconv.Itoa(entry.StatusCode),
+			entry.IP,
+			entry.Before,
+			entry.After,
+			entry.Changes,
+		})
+	}
+	w.Flush()
+}
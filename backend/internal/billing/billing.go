@@ -0,0 +1,358 @@
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend/internal/classifications"
+	"backend/internal/db"
+)
+
+// Виды позиций прайс-листа и строк счёта
+const (
+	KindWork     = "work"
+	KindMaterial = "material"
+)
+
+// Статусы счёта
+const (
+	StatusDraft  = "draft"
+	StatusIssued = "issued"
+	StatusPaid   = "paid"
+)
+
+// installedStatus - статус ЗИП, после которого он выставляется клиенту
+const installedStatus = "установлен"
+
+// ErrLocked - счёт уже выставлен
+var ErrLocked = errors.New("счёт выставлен и не может быть пересчитан")
+
+// PeriodRange - первый и последний день месяца 2006-01
+func PeriodRange(period string) (string, string, error) {
+	start, err := time.Parse("2006-01", period)
+	if err != nil {
+		return "", "", fmt.Errorf("неверный период %q, ожидается ГГГГ-ММ", period)
+	}
+	return start.Format("2006-01-02"), start.AddDate(0, 1, -1).Format("2006-01-02"), nil
+}
+
+// prices - прайс-лист, применимый к клиенту
+type prices []db.PriceListItem
+
+// work - цена работы: для клиента и классификации, затем для классификации,
+// затем для клиента по любой классификации, затем общая
+func (list prices) work(classification string) (db.PriceListItem, bool) {
+	var best db.PriceListItem
+	bestScore := -1
+	for _, p := range list {
+		if p.Kind != KindWork || (p.Classification != "" && p.Classification != classification) {
+			continue
+		}
+		score := 0
+		if p.Classification != "" {
+			score += 2
+		}
+		if p.OrganizationID != nil {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// material - цена материала по наименованию без учёта регистра, цена клиента важнее общей
+func (list prices) material(name string) (db.PriceListItem, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	var best db.PriceListItem
+	found := false
+	for _, p := range list {
+		if p.Kind != KindMaterial || strings.ToLower(strings.TrimSpace(p.Name)) != name {
+			continue
+		}
+		if !found || p.OrganizationID != nil {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+var (
+	decimalComma      = regexp.MustCompile(`(\d),(\d)`)
+	materialSeparator = regexp.MustCompile(`[,;\n]+`)
+	// materialQuantity - «Фильтр x2», «Фильтр ×2», «Фильтр - 2 шт», «Масло 1.5 л»
+	materialQuantity = regexp.MustCompile(`(?i)^(.+?)\s*(?:[xх×*]\s*(\d+(?:\.\d+)?)|[-–—]?\s*(\d+(?:\.\d+)?)\s*(?:шт|ед|м|л|кг)\.?)$`)
+)
+
+// Material - материал, указанный в акте
+type Material struct {
+	Name     string
+	Quantity float64
+}
+
+// ParseMaterials разбирает поле «Материалы» акта: позиции через запятую, точку с запятой
+// или с новой строки, количество - в конце позиции (по умолчанию 1)
+func ParseMaterials(text string) []Material {
+	var result []Material
+	// Запятая между цифрами - десятичная («1,5 л»), а не разделитель позиций
+	text = decimalComma.ReplaceAllString(text, "$1.$2")
+	for _, part := range materialSeparator.Split(text, -1) {
+		part = strings.TrimSpace(part)
+		switch strings.ToLower(part) {
+		case "", "-", "—", "нет", "не применялись", "не требуется":
+			continue
+		}
+		item := Material{Name: part, Quantity: 1}
+		if m := materialQuantity.FindStringSubmatch(part); m != nil {
+			qty := m[2]
+			if qty == "" {
+				qty = m[3]
+			}
+			if v, err := strconv.ParseFloat(qty, 64); err == nil && v > 0 {
+				item.Name, item.Quantity = strings.TrimSpace(m[1]), v
+			}
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Organizations - клиенты, на адресах которых в месяце есть согласованные акты
+func Organizations(period string) ([]uint, error) {
+	start, end, err := PeriodRange(period)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	err = db.DB.Table("reports r").
+		Joins("JOIN addresses a ON a.address = r.address").
+		Where("r.status = ? AND r.date BETWEEN ? AND ? AND a.organization_id IS NOT NULL", "approved", start, end).
+		Distinct().Order("a.organization_id").Pluck("a.organization_id", &ids).Error
+	return ids, err
+}
+
+// Reports - согласованные акты клиента за месяц
+func Reports(organizationID uint, period string) ([]db.Report, error) {
+	start, end, err := PeriodRange(period)
+	if err != nil {
+		return nil, err
+	}
+	var reports []db.Report
+	err = db.DB.Where("status = ? AND date BETWEEN ? AND ?", "approved", start, end).
+		Where("address IN (?)", db.DB.Model(&db.Address{}).Select("address").Where("organization_id = ?", organizationID)).
+		Order("date, id").Find(&reports).Error
+	return reports, err
+}
+
+// Calculate пересчитывает черновик счёта клиента за месяц: работа по каждому акту
+// по прайс-листу, материалы из актов и установленный на адресах ЗИП, ещё не включённый
+// в другие счета. Возвращает также материалы, которых нет в прайс-листе.
+// Выставленный или оплаченный счёт не меняется (ErrLocked)
+func Calculate(organizationID uint, period string) (*db.Invoice, []string, error) {
+	reports, err := Reports(organizationID, period)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var list prices
+	if err := db.DB.Where("organization_id IS NULL OR organization_id = ?", organizationID).Find(&list).Error; err != nil {
+		return nil, nil, err
+	}
+
+	reportIDs := make([]uint, 0, len(reports))
+	for _, r := range reports {
+		reportIDs = append(reportIDs, r.ID)
+	}
+	var contents []struct {
+		ReportID uint
+		Material string
+	}
+	if len(reportIDs) > 0 {
+		// Материалы берутся из последней версии данных акта
+		err := db.DB.Raw(`SELECT c.report_id, coalesce(c.data->>'material', '') AS material
+			FROM report_contents c WHERE c.report_id IN ?
+				AND c.version = (SELECT MAX(m.version) FROM report_contents m WHERE m.report_id = c.report_id)`, reportIDs).Scan(&contents).Error
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	materials := make(map[uint]string, len(contents))
+	for _, c := range contents {
+		materials[c.ReportID] = c.Material
+	}
+
+	invoice := db.Invoice{
+		OrganizationID: organizationID,
+		Period:         period,
+		Status:         StatusDraft,
+		Reports:        int64(len(reports)),
+		CalculatedAt:   time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	var (
+		lines    []db.InvoiceLine
+		unpriced []string
+	)
+	add := func(line db.InvoiceLine) {
+		line.Position = len(lines) + 1
+		line.Amount = round2(line.Quantity * line.Price)
+		lines = append(lines, line)
+	}
+	addMaterial := func(reportID uint, name string, quantity float64, source string) {
+		p, ok := list.material(name)
+		if !ok {
+			unpriced = append(unpriced, fmt.Sprintf("%s: %s", source, name))
+			return
+		}
+		add(db.InvoiceLine{ReportID: &reportID, Kind: KindMaterial, Description: "Материал: " + p.Name, Unit: p.Unit, Quantity: quantity, Price: p.Price})
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var current db.Invoice
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("organization_id = ? AND period = ?", organizationID, period).First(&current).Error
+		switch {
+		case err == nil:
+			if current.Status != StatusDraft {
+				return ErrLocked
+			}
+			invoice.ID = current.ID
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		if invoice.ID != 0 {
+			if err := tx.Where("invoice_id = ?", invoice.ID).Delete(&db.InvoiceLine{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&db.Inventory{}).Where("invoice_id = ?", invoice.ID).Update("invoice_id", nil).Error; err != nil {
+				return err
+			}
+			if err := tx.Omit("Lines").Save(&invoice).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Omit("Lines").Create(&invoice).Error; err != nil {
+			return err
+		}
+
+		billedAddresses := map[string]bool{}
+		for _, r := range reports {
+			act := r.ActNumber
+			if act == "" {
+				act = fmt.Sprintf("№%d", r.ID)
+			}
+			description := fmt.Sprintf("%s по акту %s от %s, %s", classifications.Name(r.Classification), act, r.Date, r.Address)
+			p, ok := list.work(r.Classification)
+			if c, found := classifications.Find(r.Classification); found && !c.Billable {
+				p.Price, ok = 0, true
+				description += " (не оплачивается)"
+			}
+			if !ok {
+				unpriced = append(unpriced, fmt.Sprintf("Акт %s: работа «%s»", act, classifications.Name(r.Classification)))
+			}
+			add(db.InvoiceLine{ReportID: &r.ID, Kind: KindWork, Description: description, Unit: "акт", Quantity: 1, Price: p.Price})
+
+			for _, m := range ParseMaterials(materials[r.ID]) {
+				addMaterial(r.ID, m.Name, m.Quantity, "Акт "+act)
+			}
+
+			// Установленный на адресе ЗИП выставляется с первым актом по адресу
+			if billedAddresses[r.Address] {
+				continue
+			}
+			billedAddresses[r.Address] = true
+			var items []db.Inventory
+			if err := tx.Where("object_number = ? AND status = ? AND invoice_id IS NULL", r.Address, installedStatus).
+				Order("id").Find(&items).Error; err != nil {
+				return err
+			}
+			for _, item := range items {
+				before := len(lines)
+				addMaterial(r.ID, item.ZipName, float64(item.Quantity), "ЗИП на "+r.Address)
+				if len(lines) == before {
+					continue
+				}
+				if err := tx.Model(&item).Update("invoice_id", invoice.ID).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for i := range lines {
+			lines[i].InvoiceID = invoice.ID
+			invoice.Total += lines[i].Amount
+		}
+		invoice.Total = round2(invoice.Total)
+		if err := tx.Model(&invoice).Update("total", invoice.Total).Error; err != nil {
+			return err
+		}
+		if len(lines) > 0 {
+			return tx.Create(&lines).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	invoice.Lines = lines
+	return &invoice, unpriced, nil
+}
+
+// nextInvoiceNumber выдаёт номер счёта внутри транзакции tx из того же счётчика,
+// что и номера актов (область "invoice"), поэтому нумерация за год без пропусков
+func nextInvoiceNumber(tx *gorm.DB, year int) (string, error) {
+	counter := db.ActNumberCounter{Year: year, Scope: "invoice"}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&counter).Error; err != nil {
+		return "", err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("year = ? AND scope = ?", year, counter.Scope).First(&counter).Error; err != nil {
+		return "", err
+	}
+	counter.LastNumber++
+	if err := tx.Model(&counter).Update("last_number", counter.LastNumber).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("С%d-%05d", year, counter.LastNumber), nil
+}
+
+// SetStatus переводит счёт draft → issued (с выдачей номера) или issued → paid
+func SetStatus(invoice *db.Invoice, status string) error {
+	now := time.Now()
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var current db.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, invoice.ID).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{"status": status}
+		switch {
+		case current.Status == StatusDraft && status == StatusIssued:
+			number, err := nextInvoiceNumber(tx, now.Year())
+			if err != nil {
+				return err
+			}
+			updates["number"] = number
+			updates["issued_at"] = now.Format("2006-01-02 15:04:05")
+		case current.Status == StatusIssued && status == StatusPaid:
+			updates["paid_at"] = now.Format("2006-01-02 15:04:05")
+		default:
+			return fmt.Errorf("переход из статуса %q в %q невозможен", current.Status, status)
+		}
+		if err := tx.Model(&current).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.First(invoice, invoice.ID).Error
+	})
+}
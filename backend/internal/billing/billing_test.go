@@ -0,0 +1,109 @@
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/dbtest"
+)
+
+func TestParseMaterials(t *testing.T) {
+	got := ParseMaterials("Фильтр x2; Масло 1,5 л\nПрокладка, нет")
+	want := []Material{{"Фильтр", 2}, {"Масло", 1.5}, {"Прокладка", 1}}
+	if len(got) != len(want) {
+		t.Fatalf("разобрано %v, ожидалось %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("позиция %d: %v, ожидалось %v", i, got[i], want[i])
+		}
+	}
+}
+
+// Счёт за месяц: работа по актам, материалы из последней версии акта и ЗИП адреса
+func TestCalculateTotals(t *testing.T) {
+	dbtest.Open(t)
+	classifications.Invalidate()
+	t.Cleanup(classifications.Invalidate)
+
+	org := db.ClientOrganization{Name: "Кафе"}
+	db.DB.Create(&org)
+	other := db.ClientOrganization{Name: "Пекарня"}
+	db.DB.Create(&other)
+	db.DB.Create(&db.Address{Address: "ул. Ленина, 1", OrganizationID: &org.ID})
+	db.DB.Create(&db.Address{Address: "ул. Мира, 5", OrganizationID: &other.ID})
+
+	reports := []db.Report{
+		{Date: "2025-03-03", Address: "ул. Ленина, 1", UserID: 1, Classification: "ТО", Status: db.ReportApproved},
+		{Date: "2025-03-10", Address: "ул. Ленина, 1", UserID: 1, Classification: "АВ", Status: db.ReportApproved},
+		// Не входят в счёт: черновик, другой месяц, другой клиент
+		{Date: "2025-03-11", Address: "ул. Ленина, 1", UserID: 1, Classification: "ТО", Status: db.ReportDraft},
+		{Date: "2025-04-01", Address: "ул. Ленина, 1", UserID: 1, Classification: "ТО", Status: db.ReportApproved},
+		{Date: "2025-03-12", Address: "ул. Мира, 5", UserID: 1, Classification: "ТО", Status: db.ReportApproved},
+	}
+	for i := range reports {
+		if err := db.DB.Create(&reports[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []db.ReportContent{
+		{ReportID: reports[0].ID, Version: 1, Data: `{"material": "Фильтр x3"}`},
+		{ReportID: reports[0].ID, Version: 2, Data: `{"material": "Фильтр x2, Уплотнитель"}`},
+		{ReportID: reports[1].ID, Version: 1, Data: `{"material": "нет"}`},
+	} {
+		c.CreatedAt = "2025-03-03 10:00:00"
+		if err := db.DB.Create(&c).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []db.PriceListItem{
+		{Kind: KindWork, Price: 3000},
+		{Kind: KindWork, Classification: "АВ", Price: 5000},
+		{Kind: KindWork, Classification: "АВ", OrganizationID: &org.ID, Price: 4500},
+		{Kind: KindMaterial, Name: "Фильтр", Unit: "шт", Price: 250.5},
+		{Kind: KindMaterial, Name: "Датчик", Unit: "шт", Price: 1200},
+	} {
+		p.CreatedAt = "2025-01-01 00:00:00"
+		if err := db.DB.Create(&p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	zip := db.Inventory{ObjectNumber: "ул. Ленина, 1", ZipName: "датчик", Status: installedStatus, Quantity: 2, EngineerID: 1}
+	db.DB.Create(&zip)
+
+	invoice, unpriced, err := Calculate(org.ID, "2025-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3000 (ТО) + 2*250.5 (фильтр) + 2*1200 (ЗИП) + 4500 (АВ по цене клиента)
+	if want := 10401.0; invoice.Total != want {
+		t.Errorf("итог %v, ожидался %v", invoice.Total, want)
+	}
+	if invoice.Reports != 2 || len(invoice.Lines) != 4 {
+		t.Errorf("актов %d, строк %d: %+v", invoice.Reports, len(invoice.Lines), invoice.Lines)
+	}
+	if len(unpriced) != 1 || unpriced[0] != fmt.Sprintf("Акт №%d: Уплотнитель", reports[0].ID) {
+		t.Errorf("без цены: %v", unpriced)
+	}
+	db.DB.First(&zip, zip.ID)
+	if zip.InvoiceID == nil || *zip.InvoiceID != invoice.ID {
+		t.Errorf("ЗИП не привязан к счёту: %v", zip.InvoiceID)
+	}
+
+	// Повторный расчёт черновика даёт тот же счёт, ЗИП не теряется
+	again, _, err := Calculate(org.ID, "2025-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != invoice.ID || again.Total != invoice.Total {
+		t.Errorf("пересчёт: счёт %d итог %v, было %d и %v", again.ID, again.Total, invoice.ID, invoice.Total)
+	}
+
+	db.DB.Model(&db.Invoice{}).Where("id = ?", invoice.ID).Update("status", StatusIssued)
+	if _, _, err := Calculate(org.ID, "2025-03"); !errors.Is(err, ErrLocked) {
+		t.Errorf("выставленный счёт пересчитан: %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/audit"
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/report"
+)
+
+// GetPrices - прайс-лист. organizationId - цены клиента вместе с общими
+func GetPrices(c *gin.Context) {
+	query := db.DB.Order("kind, classification, name, organization_id NULLS FIRST, id")
+	if organizationID := c.Query("organizationId"); organizationID != "" {
+		query = query.Where("organization_id IS NULL OR organization_id = ?", organizationID)
+	}
+	var list []db.PriceListItem
+	if err := query.Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении прайс-листа"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+type priceInput struct {
+	OrganizationID *uint   `json:"organizationId"`
+	Kind           string  `json:"kind" binding:"required"`
+	Classification string  `json:"classification"`
+	Name           string  `json:"name"`
+	Unit           string  `json:"unit"`
+	Price          float64 `json:"price"`
+}
+
+func (input priceInput) valid() bool {
+	switch input.Kind {
+	case KindWork:
+		return input.Price >= 0
+	case KindMaterial:
+		return input.Price >= 0 && strings.TrimSpace(input.Name) != ""
+	}
+	return false
+}
+
+func (input priceInput) apply(item *db.PriceListItem) {
+	item.OrganizationID = input.OrganizationID
+	item.Kind = input.Kind
+	item.Classification, item.Name = "", ""
+	item.Unit = strings.TrimSpace(input.Unit)
+	if input.Kind == KindWork {
+		item.Classification = classifications.Normalize(input.Classification)
+		item.Unit = "акт"
+	} else {
+		item.Name = strings.TrimSpace(input.Name)
+	}
+	if item.Unit == "" {
+		item.Unit = "шт"
+	}
+	item.Price = input.Price
+}
+
+// CreatePrice - добавление позиции прайс-листа
+func CreatePrice(c *gin.Context) {
+	var input priceInput
+	if err := c.ShouldBindJSON(&input); err != nil || !input.valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	item := db.PriceListItem{CreatedAt: time.Now().Format("2006-01-02 15:04:05")}
+	input.apply(&item)
+	if err := db.DB.Create(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при добавлении цены"})
+		return
+	}
+	audit.SetEntity(c, "price-list", item.ID)
+	audit.SetAfter(c, item)
+	c.JSON(http.StatusOK, gin.H{"message": "Цена добавлена", "price": item})
+}
+
+// UpdatePrice - изменение цены. Выставленные счета не пересчитываются
+func UpdatePrice(c *gin.Context) {
+	var item db.PriceListItem
+	if err := db.DB.First(&item, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Цена не найдена"})
+		return
+	}
+	var input priceInput
+	if err := c.ShouldBindJSON(&input); err != nil || !input.valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	audit.SetBefore(c, item)
+	input.apply(&item)
+	if err := db.DB.Save(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении цены"})
+		return
+	}
+	audit.SetEntity(c, "price-list", item.ID)
+	audit.SetAfter(c, item)
+	c.JSON(http.StatusOK, gin.H{"message": "Цена обновлена", "price": item})
+}
+
+// DeletePrice - удаление позиции прайс-листа
+func DeletePrice(c *gin.Context) {
+	var item db.PriceListItem
+	if err := db.DB.First(&item, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Цена не найдена"})
+		return
+	}
+	audit.SetEntity(c, "price-list", item.ID)
+	audit.SetBefore(c, item)
+	if err := db.DB.Delete(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении цены"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Цена удалена"})
+}
+
+// GenerateInvoices - черновики счетов за месяц клиенту (organizationId) или всем
+// клиентам с согласованными актами. Выставленные и оплаченные счета пропускаются
+func GenerateInvoices(c *gin.Context) {
+	var input struct {
+		Period         string `json:"period" binding:"required"`
+		OrganizationID *uint  `json:"organizationId"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	if _, _, err := PeriodRange(input.Period); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := []uint{}
+	if input.OrganizationID != nil {
+		ids = append(ids, *input.OrganizationID)
+	} else {
+		var err error
+		if ids, err = Organizations(input.Period); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при поиске клиентов за период"})
+			return
+		}
+	}
+
+	invoices := make([]db.Invoice, 0, len(ids))
+	locked := make([]uint, 0)
+	unpriced := make([]string, 0)
+	for _, id := range ids {
+		invoice, missing, err := Calculate(id, input.Period)
+		if errors.Is(err, ErrLocked) {
+			locked = append(locked, id)
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при расчёте счёта: " + err.Error()})
+			return
+		}
+		invoice.Lines = nil
+		invoices = append(invoices, *invoice)
+		unpriced = append(unpriced, missing...)
+	}
+	c.JSON(http.StatusOK, gin.H{"invoices": invoices, "locked": locked, "unpriced": unpriced})
+}
+
+type invoiceView struct {
+	db.Invoice
+	OrganizationName string `json:"organizationName"`
+}
+
+func organizationName(id uint) string {
+	var org db.ClientOrganization
+	if err := db.DB.Select("id, name").First(&org, id).Error; err != nil {
+		return fmt.Sprintf("#%d", id)
+	}
+	return org.Name
+}
+
+// GetInvoices - счета. Фильтры: period, organizationId, status
+func GetInvoices(c *gin.Context) {
+	query := db.DB.Order("period DESC, organization_id")
+	if period := c.Query("period"); period != "" {
+		query = query.Where("period = ?", period)
+	}
+	if organizationID := c.Query("organizationId"); organizationID != "" {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var list []db.Invoice
+	if err := query.Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении счетов"})
+		return
+	}
+	result := make([]invoiceView, 0, len(list))
+	for _, invoice := range list {
+		result = append(result, invoiceView{invoice, organizationName(invoice.OrganizationID)})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func loadInvoice(c *gin.Context) (*db.Invoice, bool) {
+	var invoice db.Invoice
+	err := db.DB.Preload("Lines", func(tx *gorm.DB) *gorm.DB { return tx.Order("position") }).
+		First(&invoice, c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Счёт не найден"})
+		return nil, false
+	}
+	return &invoice, true
+}
+
+// GetInvoice - счёт со строками
+func GetInvoice(c *gin.Context) {
+	invoice, ok := loadInvoice(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, invoiceView{*invoice, organizationName(invoice.OrganizationID)})
+}
+
+// SetInvoiceStatus - выставление (issued) или оплата (paid) счёта
+func SetInvoiceStatus(c *gin.Context) {
+	var input struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	var invoice db.Invoice
+	if err := db.DB.First(&invoice, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Счёт не найден"})
+		return
+	}
+	audit.SetBefore(c, invoice)
+	if err := SetStatus(&invoice, input.Status); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	audit.SetEntity(c, "invoices", invoice.ID)
+	audit.SetAfter(c, invoice)
+	c.JSON(http.StatusOK, gin.H{"message": "Статус счёта обновлён", "invoice": invoice})
+}
+
+// DeleteInvoice - удаление черновика счёта; включённый в него ЗИП снова доступен для выставления
+func DeleteInvoice(c *gin.Context) {
+	var invoice db.Invoice
+	if err := db.DB.First(&invoice, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Счёт не найден"})
+		return
+	}
+	if invoice.Status != StatusDraft {
+		c.JSON(http.StatusConflict, gin.H{"error": "Удалить можно только черновик счёта"})
+		return
+	}
+	audit.SetEntity(c, "invoices", invoice.ID)
+	audit.SetBefore(c, invoice)
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&db.Inventory{}).Where("invoice_id = ?", invoice.ID).Update("invoice_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&invoice).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении счёта"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Счёт удалён"})
+}
+
+// invoiceFile - PDF счёта и имя файла для него
+func invoiceFile(c *gin.Context, invoice *db.Invoice) ([]byte, string, bool) {
+	var organization db.ClientOrganization
+	if err := db.DB.First(&organization, invoice.OrganizationID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Клиент счёта не найден"})
+		return nil, "", false
+	}
+	data, err := InvoicePDF(invoice, &organization)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при формировании счёта: " + err.Error()})
+		return nil, "", false
+	}
+	name := invoice.Number
+	if name == "" {
+		name = "draft"
+	}
+	return data, fmt.Sprintf("invoice_%s_%s", invoice.Period, name), true
+}
+
+// DownloadInvoicePDF - счёт в PDF
+func DownloadInvoicePDF(c *gin.Context) {
+	invoice, ok := loadInvoice(c)
+	if !ok {
+		return
+	}
+	data, name, ok := invoiceFile(c, invoice)
+	if !ok {
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(name+".pdf")))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// DownloadInvoiceZip - ZIP со счётом и всеми актами, вошедшими в него
+func DownloadInvoiceZip(c *gin.Context) {
+	invoice, ok := loadInvoice(c)
+	if !ok {
+		return
+	}
+	data, name, ok := invoiceFile(c, invoice)
+	if !ok {
+		return
+	}
+
+	reportIDs := make([]uint, 0)
+	for _, line := range invoice.Lines {
+		if line.Kind == KindWork && line.ReportID != nil {
+			reportIDs = append(reportIDs, *line.ReportID)
+		}
+	}
+	var reports []db.Report
+	if len(reportIDs) > 0 {
+		if err := db.DB.Where("id IN ?", reportIDs).Order("date, id").Find(&reports).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении отчетов"})
+			return
+		}
+	}
+	report.SendReportsZip(c, reports, name+".zip", report.ZipEntry{Name: name + ".pdf", Data: data})
+}
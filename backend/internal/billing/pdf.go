@@ -0,0 +1,82 @@
+package billing
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+
+	"backend/internal/db"
+	"backend/internal/docgen"
+)
+
+func formatAmount(v float64) string {
+	return strings.Replace(fmt.Sprintf("%.2f", v), ".", ",", 1)
+}
+
+// Title - заголовок счёта: номер после выставления, до него - черновик
+func Title(invoice *db.Invoice) string {
+	if invoice.Number == "" {
+		return fmt.Sprintf("Черновик счёта за %s", invoice.Period)
+	}
+	return fmt.Sprintf("Счёт № %s от %s за %s", invoice.Number, strings.SplitN(invoice.IssuedAt, " ", 2)[0], invoice.Period)
+}
+
+// InvoicePDF - счёт в PDF со строками по актам (шрифты DejaVu, как у нативного генератора актов)
+func InvoicePDF(invoice *db.Invoice, organization *db.ClientOrganization) ([]byte, error) {
+	regular, bold, err := docgen.Fonts()
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes("dejavu", "", regular)
+	pdf.AddUTF8FontFromBytes("dejavu", "B", bold)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	pdf.SetFont("dejavu", "B", 13)
+	pdf.MultiCell(0, 7, Title(invoice), "", "L", false)
+	pdf.SetFont("dejavu", "", 10)
+	payer := "Плательщик: " + organization.Name
+	if organization.INN != "" {
+		payer += ", ИНН " + organization.INN
+	}
+	pdf.MultiCell(0, 6, payer, "", "L", false)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Актов выполненных работ: %d", invoice.Reports), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	widths := []float64{10, 95, 15, 15, 22, 23}
+	pdf.SetFont("dejavu", "B", 9)
+	for i, title := range []string{"№", "Наименование", "Ед.", "Кол-во", "Цена", "Сумма"} {
+		pdf.CellFormat(widths[i], 7, title, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("dejavu", "", 8)
+	for _, line := range invoice.Lines {
+		description := line.Description
+		if len([]rune(description)) > 62 {
+			description = string([]rune(description)[:61]) + "…"
+		}
+		pdf.CellFormat(widths[0], 6, fmt.Sprint(line.Position), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[1], 6, description, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, line.Unit, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[3], 6, strings.TrimSuffix(formatAmount(line.Quantity), ",00"), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, formatAmount(line.Price), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[5], 6, formatAmount(line.Amount), "1", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("dejavu", "B", 10)
+	pdf.CellFormat(widths[0]+widths[1]+widths[2]+widths[3]+widths[4], 7, "Итого", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[5], 7, formatAmount(invoice.Total), "1", 1, "R", false, 0, "")
+	pdf.Ln(3)
+	pdf.SetFont("dejavu", "", 9)
+	pdf.MultiCell(0, 5, "Акты выполненных работ по строкам счёта прилагаются.", "", "L", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
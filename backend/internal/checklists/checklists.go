@@ -0,0 +1,91 @@
+package checklists
+
+import (
+	"strings"
+
+	"backend/internal/classifications"
+	"backend/internal/db"
+)
+
+// Item - пункт ожидаемого чек-листа
+type Item struct {
+	Task     string `json:"task"`
+	Required bool   `json:"required"`
+	Done     bool   `json:"done"`
+}
+
+// MemoryEquipment - оборудование, запомненное для адреса и классификации (EquipmentMemory)
+func MemoryEquipment(address, classification string) []string {
+	var names []string
+	db.DB.Model(&db.EquipmentMemory{}).
+		Where("address = ? AND classification = ?", address, classifications.Normalize(classification)).
+		Order("id").
+		Pluck("machine_name", &names)
+	return names
+}
+
+// matchesEquipment - тип оборудования пункта совпадает с названием оборудования
+// целиком или является его началом («Пароконвектомат» для «Пароконвектомат Rational»)
+func matchesEquipment(equipmentType string, equipment []string) bool {
+	if equipmentType == "" {
+		return true
+	}
+	t := strings.ToLower(strings.TrimSpace(equipmentType))
+	for _, name := range equipment {
+		n := strings.ToLower(strings.TrimSpace(name))
+		if n == t || strings.HasPrefix(n, t+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// Expected возвращает чек-лист для классификации и оборудования: общие пункты
+// и пункты каждого типа оборудования, без повторов. Пустой результат - шаблонов нет
+func Expected(classification string, equipment []string) ([]Item, error) {
+	var tasks []db.ChecklistTask
+	err := db.DB.Where("is_active = ?", true).
+		Where("(classification = ? OR classification = '')", classifications.Normalize(classification)).
+		Order("position, id").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	items := []Item{}
+	index := map[string]int{}
+	for _, task := range tasks {
+		if !matchesEquipment(task.EquipmentType, equipment) {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(task.Task))
+		if i, ok := index[key]; ok {
+			items[i].Required = items[i].Required || task.Required
+			continue
+		}
+		index[key] = len(items)
+		items = append(items, Item{Task: task.Task, Required: task.Required})
+	}
+	return items, nil
+}
+
+// MissingRequired возвращает обязательные пункты, не отмеченные в чек-листе отчёта
+func MissingRequired(classification string, equipment []string, done []string) ([]string, error) {
+	expected, err := Expected(classification, equipment)
+	if err != nil {
+		return nil, err
+	}
+
+	doneSet := map[string]bool{}
+	for _, task := range done {
+		doneSet[strings.ToLower(strings.TrimSpace(task))] = true
+	}
+
+	var missing []string
+	for _, item := range expected {
+		if item.Required && !doneSet[strings.ToLower(strings.TrimSpace(item.Task))] {
+			missing = append(missing, item.Task)
+		}
+	}
+	return missing, nil
+}
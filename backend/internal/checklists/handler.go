@@ -0,0 +1,154 @@
+package checklists
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/audit"
+	"backend/internal/classifications"
+	"backend/internal/db"
+)
+
+// GetExpectedChecklist - чек-лист для адреса и классификации по запомненному оборудованию.
+// Дополнительное оборудование можно передать параметрами equipment
+func GetExpectedChecklist(c *gin.Context) {
+	address := c.Query("address")
+	classification := c.Query("classification")
+	if classification == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Необходимо указать классификацию"})
+		return
+	}
+
+	var equipment []string
+	if address != "" {
+		equipment = MemoryEquipment(address, classification)
+	}
+	for _, name := range c.QueryArray("equipment") {
+		if name = strings.TrimSpace(name); name != "" {
+			equipment = append(equipment, name)
+		}
+	}
+
+	items, err := Expected(classification, equipment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении чек-листа"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"classification": classifications.Normalize(classification),
+		"equipment":      equipment,
+		"items":          items,
+	})
+}
+
+// GetChecklistTasks - пункты шаблонов чек-листов. Фильтры: classification, equipmentType
+func GetChecklistTasks(c *gin.Context) {
+	query := db.DB.Order("classification, equipment_type, position, id")
+	if classification, ok := c.GetQuery("classification"); ok {
+		query = query.Where("classification = ?", classifications.Normalize(classification))
+	}
+	if equipmentType, ok := c.GetQuery("equipmentType"); ok {
+		query = query.Where("equipment_type = ?", equipmentType)
+	}
+
+	var tasks []db.ChecklistTask
+	if err := query.Find(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении пунктов чек-листа"})
+		return
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+type taskInput struct {
+	EquipmentType  string `json:"equipmentType"`
+	Classification string `json:"classification"`
+	Task           string `json:"task" binding:"required"`
+	Required       bool   `json:"required"`
+	Position       int    `json:"position"`
+	IsActive       *bool  `json:"isActive"`
+}
+
+// CreateChecklistTask - добавление пункта шаблона чек-листа
+func CreateChecklistTask(c *gin.Context) {
+	var input taskInput
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Task) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+
+	task := db.ChecklistTask{
+		EquipmentType:  strings.TrimSpace(input.EquipmentType),
+		Classification: classifications.Normalize(input.Classification),
+		Task:           strings.TrimSpace(input.Task),
+		Required:       input.Required,
+		Position:       input.Position,
+		IsActive:       true,
+		CreatedAt:      time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := db.DB.Create(&task).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при добавлении пункта чек-листа"})
+		return
+	}
+	if input.IsActive != nil && !*input.IsActive {
+		db.DB.Model(&task).Update("is_active", false)
+		task.IsActive = false
+	}
+	audit.SetEntity(c, "checklist-tasks", task.ID)
+	audit.SetAfter(c, task)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Пункт чек-листа добавлен", "task": task})
+}
+
+// UpdateChecklistTask - изменение пункта шаблона чек-листа
+func UpdateChecklistTask(c *gin.Context) {
+	var task db.ChecklistTask
+	if err := db.DB.First(&task, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пункт чек-листа не найден"})
+		return
+	}
+
+	var input taskInput
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Task) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	audit.SetBefore(c, task)
+
+	task.EquipmentType = strings.TrimSpace(input.EquipmentType)
+	task.Classification = classifications.Normalize(input.Classification)
+	task.Task = strings.TrimSpace(input.Task)
+	task.Required = input.Required
+	task.Position = input.Position
+	if input.IsActive != nil {
+		task.IsActive = *input.IsActive
+	}
+	if err := db.DB.Save(&task).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении пункта чек-листа"})
+		return
+	}
+	audit.SetEntity(c, "checklist-tasks", task.ID)
+	audit.SetAfter(c, task)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Пункт чек-листа обновлён", "task": task})
+}
+
+// DeleteChecklistTask - удаление пункта шаблона чек-листа
+func DeleteChecklistTask(c *gin.Context) {
+	var task db.ChecklistTask
+	if err := db.DB.First(&task, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пункт чек-листа не найден"})
+		return
+	}
+	audit.SetEntity(c, "checklist-tasks", task.ID)
+	audit.SetBefore(c, task)
+
+	if err := db.DB.Delete(&task).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении пункта чек-листа"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Пункт чек-листа удалён"})
+}
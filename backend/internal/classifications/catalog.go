@@ -0,0 +1,118 @@
+package classifications
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/db"
+)
+
+// Справочник читается часто (каждый отчёт), а меняется редко - держим копию в памяти.
+// После изменения через API копия сбрасывается, другие экземпляры обновят её по cacheTTL
+const cacheTTL = time.Minute
+
+var (
+	mu       sync.RWMutex
+	cached   []db.Classification
+	loadedAt time.Time
+)
+
+// All - классификации справочника (включая отключённые) в порядке Position
+func All() []db.Classification {
+	mu.RLock()
+	if cached != nil && time.Since(loadedAt) < cacheTTL {
+		list := cached
+		mu.RUnlock()
+		return list
+	}
+	mu.RUnlock()
+
+	var list []db.Classification
+	if err := db.DB.Order("position, id").Find(&list).Error; err != nil {
+		mu.RLock()
+		defer mu.RUnlock()
+		return cached
+	}
+	mu.Lock()
+	cached, loadedAt = list, time.Now()
+	mu.Unlock()
+	return list
+}
+
+// Invalidate сбрасывает копию справочника
+func Invalidate() {
+	mu.Lock()
+	cached = nil
+	mu.Unlock()
+}
+
+// Find ищет классификацию по коду, названию или псевдониму (без учёта регистра)
+func Find(value string) (db.Classification, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return db.Classification{}, false
+	}
+	list := All()
+	for _, c := range list {
+		if c.Code == value {
+			return c, true
+		}
+	}
+	for _, c := range list {
+		for _, name := range names(c) {
+			if strings.EqualFold(name, value) {
+				return c, true
+			}
+		}
+	}
+	return db.Classification{}, false
+}
+
+// Normalize приводит классификацию к коду, под которым она хранится в БД.
+// Значения не из справочника возвращаются как есть
+func Normalize(value string) string {
+	if c, ok := Find(value); ok {
+		return c.Code
+	}
+	return strings.TrimSpace(value)
+}
+
+// Name - отображаемое название классификации для кода
+func Name(code string) string {
+	if c, ok := Find(code); ok {
+		return c.Name
+	}
+	return code
+}
+
+// MatchPrefix - классификация, с написания которой начинается текст. Длинные
+// написания проверяются раньше коротких, чтобы «ТО Китчен/Пекарня» не стал «ТО»
+func MatchPrefix(text string) (db.Classification, bool) {
+	type candidate struct {
+		spelling string
+		item     db.Classification
+	}
+	var candidates []candidate
+	for _, c := range All() {
+		for _, name := range names(c) {
+			candidates = append(candidates, candidate{name, c})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].spelling) > len(candidates[j].spelling)
+	})
+	lower := strings.ToLower(text)
+	for _, cand := range candidates {
+		if strings.HasPrefix(lower, strings.ToLower(cand.spelling)) {
+			return cand.item, true
+		}
+	}
+	return db.Classification{}, false
+}
+
+// names - все написания классификации: код, название и псевдонимы
+func names(c db.Classification) []string {
+	return append([]string{c.Code, c.Name}, db.SplitAliases(c.Aliases)...)
+}
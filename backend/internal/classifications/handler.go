@@ -0,0 +1,176 @@
+package classifications
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/audit"
+	"backend/internal/db"
+)
+
+// GetClassifications - справочник классификаций. active=true - только действующие
+func GetClassifications(c *gin.Context) {
+	list := make([]db.Classification, 0)
+	for _, item := range All() {
+		if c.Query("active") == "true" && !item.IsActive {
+			continue
+		}
+		list = append(list, item)
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+type classificationInput struct {
+	Code      string `json:"code" binding:"required"`
+	Name      string `json:"name"`
+	Aliases   string `json:"aliases"`
+	ActPrefix string `json:"actPrefix"`
+	Color     string `json:"color"`
+	Planned   bool   `json:"planned"`
+	Emergency bool   `json:"emergency"`
+	Billable  *bool  `json:"billable"`
+	Position  int    `json:"position"`
+	IsActive  *bool  `json:"isActive"`
+}
+
+// apply переносит поля запроса в классификацию. Пустое название - по коду
+func (input classificationInput) apply(item *db.Classification) {
+	item.Code = strings.TrimSpace(input.Code)
+	item.Name = strings.TrimSpace(input.Name)
+	if item.Name == "" {
+		item.Name = item.Code
+	}
+	item.Aliases = strings.Join(db.SplitAliases(input.Aliases), ", ")
+	item.ActPrefix = strings.ToUpper(strings.TrimSpace(input.ActPrefix))
+	item.Color = strings.TrimSpace(input.Color)
+	item.Planned = input.Planned
+	item.Emergency = input.Emergency
+	if input.Billable != nil {
+		item.Billable = *input.Billable
+	}
+	item.Position = input.Position
+	if input.IsActive != nil {
+		item.IsActive = *input.IsActive
+	}
+}
+
+// conflict - написание классификации, уже занятое другой классификацией
+func conflict(item db.Classification) string {
+	for _, name := range names(item) {
+		if other, ok := Find(name); ok && other.ID != item.ID {
+			return name
+		}
+	}
+	return ""
+}
+
+// CreateClassification - добавление классификации в справочник
+func CreateClassification(c *gin.Context) {
+	var input classificationInput
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Code) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+
+	item := db.Classification{Billable: true, IsActive: true, CreatedAt: time.Now().Format("2006-01-02 15:04:05")}
+	input.apply(&item)
+	if name := conflict(item); name != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Написание «" + name + "» уже используется другой классификацией"})
+		return
+	}
+	if err := db.DB.Create(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при добавлении классификации"})
+		return
+	}
+	Invalidate()
+	audit.SetEntity(c, "classifications", item.ID)
+	audit.SetAfter(c, item)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Классификация добавлена", "classification": item})
+}
+
+// UpdateClassification - изменение классификации. При смене кода он меняется во всех
+// отчётах, памяти оборудования, чек-листах и шаблонах, а старый код остаётся псевдонимом
+func UpdateClassification(c *gin.Context) {
+	var item db.Classification
+	if err := db.DB.First(&item, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Классификация не найдена"})
+		return
+	}
+
+	var input classificationInput
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Code) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	audit.SetBefore(c, item)
+
+	oldCode := item.Code
+	input.apply(&item)
+	if item.Code != oldCode {
+		item.Aliases = strings.Join(append(db.SplitAliases(item.Aliases), oldCode), ", ")
+	}
+	if name := conflict(item); name != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Написание «" + name + "» уже используется другой классификацией"})
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&item).Error; err != nil {
+			return err
+		}
+		if item.Code == oldCode {
+			return nil
+		}
+		for _, table := range db.ClassificationTables {
+			if err := tx.Table(table).Where("classification = ?", oldCode).Update("classification", item.Code).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении классификации"})
+		return
+	}
+	Invalidate()
+	audit.SetEntity(c, "classifications", item.ID)
+	audit.SetAfter(c, item)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Классификация обновлена", "classification": item})
+}
+
+// DeleteClassification - удаление классификации. Используемую в данных классификацию
+// удалить нельзя - её можно только отключить
+func DeleteClassification(c *gin.Context) {
+	var item db.Classification
+	if err := db.DB.First(&item, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Классификация не найдена"})
+		return
+	}
+
+	for _, table := range db.ClassificationTables {
+		var count int64
+		if err := db.DB.Table(table).Where("classification = ?", item.Code).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при проверке использования классификации"})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Классификация используется, её можно только отключить"})
+			return
+		}
+	}
+	audit.SetEntity(c, "classifications", item.ID)
+	audit.SetBefore(c, item)
+
+	if err := db.DB.Delete(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении классификации"})
+		return
+	}
+	Invalidate()
+	c.JSON(http.StatusOK, gin.H{"message": "Классификация удалена"})
+}
@@ -3,6 +3,7 @@ package clients
 import (
 	"backend/internal/db"
 	"backend/internal/docgen"
+	"backend/internal/organizations"
 	"backend/internal/storage"
 	"bytes"
 	"context"
@@ -138,13 +139,7 @@ func ClientRegister(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Регистрация успешна",
 		"token":   token,
-		"client": gin.H{
-			"id":       client.ID,
-			"email":    client.Email,
-			"fullName": client.FullName,
-			"phone":    client.Phone,
-			"position": client.Position,
-		},
+		"client":  clientInfo(&client),
 	})
 }
 
@@ -196,14 +191,8 @@ func ClientLogin(c *gin.Context) {
 	setClientCookies(c, token)
 
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"client": gin.H{
-			"id":       client.ID,
-			"email":    client.Email,
-			"fullName": client.FullName,
-			"phone":    client.Phone,
-			"position": client.Position,
-		},
+		"token":  token,
+		"client": clientInfo(&client),
 	})
 }
 
@@ -260,13 +249,7 @@ func ClientCheckAuth(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"client": gin.H{
-			"id":       client.ID,
-			"email":    client.Email,
-			"fullName": client.FullName,
-			"phone":    client.Phone,
-			"position": client.Position,
-		},
+		"client": clientInfo(&client),
 	})
 }
 
@@ -364,8 +347,21 @@ func GetClientTickets(c *gin.Context) {
 		return
 	}
 
+	client, err := organizations.LoadClient(clientID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "клиент не найден"})
+		return
+	}
+
+	// По умолчанию участник организации видит все заявки организации,
+	// scope=mine - только свои
 	var tickets []db.ClientTicket
-	query := db.DB.Where("client_id = ?", clientID).Order("date desc, id desc")
+	query := db.DB.Model(&db.ClientTicket{}).Order("date desc, id desc")
+	if c.Query("scope") == "mine" {
+		query = query.Where("client_id = ?", client.ID)
+	} else {
+		query = organizations.ScopeTickets(query, client)
+	}
 
 	// Фильтр по статусу
 	status := c.Query("status")
@@ -403,7 +399,7 @@ func GetClientTickets(c *gin.Context) {
 		if err := db.DB.Where("ticket_id = ?", ticket.ID).Find(&ticketReports).Error; err == nil {
 			for _, tr := range ticketReports {
 				var report db.Report
-				if err := db.DB.First(&report, tr.ReportID).Error; err == nil {
+				if err := db.DB.Where("status = ?", db.ReportApproved).First(&report, tr.ReportID).Error; err == nil {
 					ticketWithReports.Reports = append(ticketWithReports.Reports, ReportInfo{
 						ID:             report.ID,
 						Filename:       report.Filename,
@@ -431,9 +427,15 @@ func GetClientTicketByID(c *gin.Context) {
 		return
 	}
 
+	client, err := organizations.LoadClient(clientID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "клиент не найден"})
+		return
+	}
+
 	ticketID := c.Param("id")
 	var ticket db.ClientTicket
-	if err := db.DB.Where("id = ? AND client_id = ?", ticketID, clientID).First(&ticket).Error; err != nil {
+	if err := organizations.ScopeTickets(db.DB.Where("client_tickets.id = ?", ticketID), client).First(&ticket).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Заявка не найдена"})
 		return
 	}
@@ -451,7 +453,7 @@ func GetClientTicketByID(c *gin.Context) {
 	if err := db.DB.Where("ticket_id = ?", ticket.ID).Find(&ticketReports).Error; err == nil {
 		for _, tr := range ticketReports {
 			var report db.Report
-			if err := db.DB.First(&report, tr.ReportID).Error; err == nil {
+			if err := db.DB.Where("status = ?", db.ReportApproved).First(&report, tr.ReportID).Error; err == nil {
 				reports = append(reports, ReportInfo{
 					ID:             report.ID,
 					Filename:       report.Filename,
@@ -520,6 +522,36 @@ func ClientAuthMiddleware() gin.HandlerFunc {
 }
 
 // Helper functions
+
+// hasReportAccess - единая проверка доступа клиента к отчёту (с учётом организации)
+func hasReportAccess(clientID interface{}, report *db.Report) bool {
+	client, err := organizations.LoadClient(clientID)
+	if err != nil {
+		return false
+	}
+	return organizations.CanAccessReport(client, report)
+}
+
+// clientInfo - данные клиента для ответов авторизации
+func clientInfo(client *db.Client) gin.H {
+	info := gin.H{
+		"id":             client.ID,
+		"email":          client.Email,
+		"fullName":       client.FullName,
+		"phone":          client.Phone,
+		"position":       client.Position,
+		"organizationId": client.OrganizationID,
+		"orgRole":        client.OrgRole,
+	}
+	if client.OrganizationID != nil {
+		var org db.ClientOrganization
+		if err := db.DB.First(&org, *client.OrganizationID).Error; err == nil {
+			info["organizationName"] = org.Name
+		}
+	}
+	return info
+}
+
 func getClientToken(c *gin.Context) string {
 	tokenString, err := c.Cookie("client_token")
 	if err != nil {
@@ -576,24 +608,7 @@ func ClientPreviewReport(c *gin.Context) {
 		}
 	}
 
-	// Проверяем доступ одним оптимизированным запросом
-	var count int64
-	db.DB.Model(&db.TicketReport{}).
-		Joins("JOIN client_tickets ON client_tickets.id = ticket_reports.ticket_id").
-		Where("ticket_reports.report_id = ? AND client_tickets.client_id = ?", report.ID, clientID).
-		Count(&count)
-
-	hasAccess := count > 0
-
-	// Если не нашли через привязки, проверяем по адресу
-	if !hasAccess {
-		db.DB.Model(&db.ClientTicket{}).
-			Where("client_id = ? AND address = ?", clientID, report.Address).
-			Count(&count)
-		hasAccess = count > 0
-	}
-
-	if !hasAccess {
+	if !hasReportAccess(clientID, &report) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "нет доступа к этому отчёту"})
 		return
 	}
@@ -650,22 +665,7 @@ func ClientGetPreviewPages(c *gin.Context) {
 	}
 
 	// Проверяем доступ
-	var count int64
-	db.DB.Model(&db.TicketReport{}).
-		Joins("JOIN client_tickets ON client_tickets.id = ticket_reports.ticket_id").
-		Where("ticket_reports.report_id = ? AND client_tickets.client_id = ?", report.ID, clientID).
-		Count(&count)
-
-	hasAccess := count > 0
-
-	if !hasAccess {
-		db.DB.Model(&db.ClientTicket{}).
-			Where("client_id = ? AND address = ?", clientID, report.Address).
-			Count(&count)
-		hasAccess = count > 0
-	}
-
-	if !hasAccess {
+	if !hasReportAccess(clientID, &report) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "нет доступа к этому отчёту"})
 		return
 	}
@@ -745,22 +745,7 @@ func ClientRegeneratePreview(c *gin.Context) {
 	}
 
 	// Проверяем доступ
-	var count int64
-	db.DB.Model(&db.TicketReport{}).
-		Joins("JOIN client_tickets ON client_tickets.id = ticket_reports.ticket_id").
-		Where("ticket_reports.report_id = ? AND client_tickets.client_id = ?", report.ID, clientID).
-		Count(&count)
-
-	hasAccess := count > 0
-
-	if !hasAccess {
-		db.DB.Model(&db.ClientTicket{}).
-			Where("client_id = ? AND address = ?", clientID, report.Address).
-			Count(&count)
-		hasAccess = count > 0
-	}
-
-	if !hasAccess {
+	if !hasReportAccess(clientID, &report) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "нет доступа к этому отчёту"})
 		return
 	}
@@ -0,0 +1,177 @@
+package clients
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/audit"
+	"backend/internal/db"
+	"backend/internal/organizations"
+)
+
+// GetMyOrganization - организация клиента: реквизиты, адреса и (для администратора) участники
+func GetMyOrganization(c *gin.Context) {
+	clientID, _ := c.Get("clientID")
+	client, err := organizations.LoadClient(clientID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "клиент не найден"})
+		return
+	}
+	if client.OrganizationID == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Вы не состоите в организации"})
+		return
+	}
+
+	var org db.ClientOrganization
+	if err := db.DB.First(&org, *client.OrganizationID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Организация не найдена"})
+		return
+	}
+
+	resp := gin.H{
+		"organization": org,
+		"role":         client.OrgRole,
+		"addresses":    organizations.OrganizationAddresses(client),
+	}
+	if organizations.IsOrgAdmin(client) {
+		resp["members"] = organizations.Members(org.ID)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// loadOrgAdmin возвращает текущего клиента, если он администратор организации
+func loadOrgAdmin(c *gin.Context) (*db.Client, bool) {
+	clientID, _ := c.Get("clientID")
+	client, err := organizations.LoadClient(clientID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "клиент не найден"})
+		return nil, false
+	}
+	if !organizations.IsOrgAdmin(client) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Доступно только администратору организации"})
+		return nil, false
+	}
+	return client, true
+}
+
+// AddMyOrgMember - приглашение зарегистрированного клиента в организацию (по email или телефону)
+func AddMyOrgMember(c *gin.Context) {
+	admin, ok := loadOrgAdmin(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Login string `json:"login" binding:"required"`
+		Role  string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	if input.Role == "" {
+		input.Role = organizations.RoleRequester
+	}
+	if !organizations.IsValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестная роль"})
+		return
+	}
+
+	login := strings.TrimSpace(strings.ToLower(input.Login))
+	var member db.Client
+	var err error
+	if strings.Contains(login, "@") {
+		err = db.DB.Where("email = ?", login).First(&member).Error
+	} else {
+		err = db.DB.Where("phone = ?", normalizePhone(login)).First(&member).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Клиент не найден. Он должен сначала зарегистрироваться"})
+		return
+	}
+	if member.OrganizationID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Клиент уже состоит в организации"})
+		return
+	}
+
+	if err := db.DB.Model(&member).Updates(map[string]interface{}{
+		"organization_id": *admin.OrganizationID,
+		"org_role":        input.Role,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при добавлении участника"})
+		return
+	}
+	audit.SetEntity(c, "client/organization", *admin.OrganizationID)
+	audit.SetAfter(c, gin.H{"clientId": member.ID, "role": input.Role})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Участник добавлен", "members": organizations.Members(*admin.OrganizationID)})
+}
+
+// UpdateMyOrgMemberRole - смена роли участника администратором организации
+func UpdateMyOrgMemberRole(c *gin.Context) {
+	admin, ok := loadOrgAdmin(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || !organizations.IsValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестная роль"})
+		return
+	}
+
+	var member db.Client
+	if err := db.DB.Where("id = ? AND organization_id = ?", c.Param("clientId"), *admin.OrganizationID).
+		First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Участник не найден"})
+		return
+	}
+	if member.ID == admin.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нельзя изменить собственную роль"})
+		return
+	}
+	audit.SetBefore(c, gin.H{"clientId": member.ID, "role": member.OrgRole})
+
+	if err := db.DB.Model(&member).Update("org_role", input.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при смене роли"})
+		return
+	}
+	audit.SetAfter(c, gin.H{"clientId": member.ID, "role": input.Role})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Роль обновлена", "members": organizations.Members(*admin.OrganizationID)})
+}
+
+// RemoveMyOrgMember - исключение участника администратором организации
+func RemoveMyOrgMember(c *gin.Context) {
+	admin, ok := loadOrgAdmin(c)
+	if !ok {
+		return
+	}
+
+	var member db.Client
+	if err := db.DB.Where("id = ? AND organization_id = ?", c.Param("clientId"), *admin.OrganizationID).
+		First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Участник не найден"})
+		return
+	}
+	if member.ID == admin.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нельзя исключить самого себя"})
+		return
+	}
+	audit.SetBefore(c, gin.H{"clientId": member.ID, "role": member.OrgRole})
+
+	if err := db.DB.Model(&member).Updates(map[string]interface{}{
+		"organization_id": nil,
+		"org_role":        nil,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при исключении участника"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Участник исключен", "members": organizations.Members(*admin.OrganizationID)})
+}
@@ -0,0 +1,103 @@
+package clients
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+	"backend/internal/organizations"
+	"backend/internal/report"
+)
+
+// ClientDownloadReport - скачивание PDF акта клиентом (та же проверка доступа, что и для превью)
+func ClientDownloadReport(c *gin.Context) {
+	clientID, exists := c.Get("clientID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "не авторизован"})
+		return
+	}
+
+	client, err := organizations.LoadClient(clientID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "клиент не найден"})
+		return
+	}
+
+	filename := filepath.Base(c.Param("filename"))
+
+	// Ищем только среди доступных клиенту отчётов: сначала по точному имени файла,
+	// затем старые записи, хранящие путь с каталогом, - по окончанию пути
+	var rep db.Report
+	exact := organizations.ScopeReports(db.DB.Model(&db.Report{}).Where("reports.filename = ?", filename), client)
+	if err := exact.First(&rep).Error; err != nil {
+		var legacy []db.Report
+		suffix := organizations.ScopeReports(db.DB.Model(&db.Report{}).
+			Where(`reports.filename LIKE ? ESCAPE '\'`, "%/"+escapeLike(filename)), client)
+		if err := suffix.Limit(2).Find(&legacy).Error; err != nil || len(legacy) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "отчёт не найден"})
+			return
+		}
+		if len(legacy) > 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "найдено несколько отчётов с таким именем файла"})
+			return
+		}
+		rep = legacy[0]
+	}
+
+	reader, err := report.OpenReportFile(rep.Filename)
+	if err != nil || reader == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "файл отчёта не найден"})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(filepath.Base(rep.Filename))))
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, reader)
+}
+
+// escapeLike экранирует % и _ из запроса, чтобы они не работали как шаблон LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// ClientDownloadReportsByPeriod - ZIP со всеми актами за период по доступным клиенту адресам
+func ClientDownloadReportsByPeriod(c *gin.Context) {
+	clientID, exists := c.Get("clientID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "не авторизован"})
+		return
+	}
+	client, err := organizations.LoadClient(clientID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "клиент не найден"})
+		return
+	}
+
+	startDate := c.Query("startDate")
+	endDate := c.Query("endDate")
+	if startDate == "" || endDate == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указан период"})
+		return
+	}
+
+	var reports []db.Report
+	query := db.DB.Model(&db.Report{}).Where("reports.date BETWEEN ? AND ?", startDate, endDate)
+	if err := organizations.ScopeReports(query, client).Order("reports.date").Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении отчетов"})
+		return
+	}
+	if len(reports) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "За указанный период отчётов нет"})
+		return
+	}
+
+	report.SendReportsZip(c, reports, "reports_by_period.zip")
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// defaultClassifications - справочник при первом запуске: классификации,
+// которые раньше были зашиты в код
+var defaultClassifications = []Classification{
+	{Code: "ТО Китчен", Name: "ТО Китчен", ActPrefix: "ТОК", Color: "#4ecdc4", Planned: true, Billable: true},
+	{Code: "ТО Пекарня", Name: "ТО Пекарня", ActPrefix: "ТОП", Color: "#45b7d1", Planned: true, Billable: true},
+	{Code: "ТО Китчен/Пекарня", Name: "ТО Китчен/Пекарня", ActPrefix: "ТОКП", Color: "#96ceb4", Planned: true, Billable: true},
+	{Code: "ТО", Name: "ТО", ActPrefix: "ТО", Color: "#feca57", Planned: true, Billable: true},
+	{Code: "АВ", Name: "Аварийный вызов", Aliases: "Аварийный вызов", ActPrefix: "АВ", Color: "#ff6b6b", Emergency: true, Billable: true},
+	{Code: "ПНР", Name: "ПНР", ActPrefix: "ПНР", Color: "#a29bfe", Billable: true},
+}
+
+// ClassificationTables - таблицы, в которых хранится код классификации
+var ClassificationTables = []string{"reports", "equipment_memories", "checklist_tasks", "report_templates", "report_texts"}
+
+// SplitAliases разбирает список псевдонимов через запятую
+func SplitAliases(aliases string) []string {
+	var list []string
+	for _, alias := range strings.Split(aliases, ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			list = append(list, alias)
+		}
+	}
+	return list
+}
+
+// migrateClassifications заполняет справочник, переводит псевдонимы в отчётах и памяти
+// оборудования на коды и добавляет в справочник встреченные в данных неизвестные значения
+func migrateClassifications() {
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	var count int64
+	DB.Model(&Classification{}).Count(&count)
+	if count == 0 {
+		for i, c := range defaultClassifications {
+			c.Position = i + 1
+			c.CreatedAt = now
+			if err := DB.Create(&c).Error; err != nil {
+				log.Printf("Ошибка при заполнении справочника классификаций: %v", err)
+			}
+		}
+	}
+
+	// Префиксы номеров актов для справочника, заполненного до их появления
+	for _, c := range defaultClassifications {
+		DB.Model(&Classification{}).Where("code = ? AND act_prefix = ''", c.Code).Update("act_prefix", c.ActPrefix)
+	}
+
+	var catalog []Classification
+	DB.Find(&catalog)
+	for _, c := range catalog {
+		if aliases := SplitAliases(c.Aliases); len(aliases) > 0 {
+			for _, table := range []string{"reports", "equipment_memories"} {
+				DB.Table(table).Where("classification IN ?", aliases).Update("classification", c.Code)
+			}
+		}
+	}
+
+	var unknown []string
+	DB.Raw(`SELECT DISTINCT classification FROM (
+			SELECT classification FROM reports UNION SELECT classification FROM equipment_memories
+		) v WHERE classification <> '' AND classification <> 'Не указано'
+			AND classification NOT IN (SELECT code FROM classifications)`).Scan(&unknown)
+	for _, code := range unknown {
+		c := Classification{Code: code, Name: code, Billable: true, Position: len(catalog) + 1, CreatedAt: now}
+		if err := DB.Create(&c).Error; err != nil {
+			log.Printf("Ошибка при добавлении классификации %q в справочник: %v", code, err)
+			continue
+		}
+		catalog = append(catalog, c)
+		log.Printf("В справочник добавлена классификация из данных: %s", code)
+	}
+}
@@ -13,6 +13,7 @@ var DB *gorm.DB
 type File struct {
 	ID       uint   `gorm:"primaryKey"  json:"id"`
 	Filename string `gorm:"uniqueIndex" json:"filename"`
+	Tags     string `gorm:"type:text;default:null" json:"tags"` // теги сертификата через запятую
 }
 
 type User struct {
@@ -22,11 +23,22 @@ type User struct {
 	Department       string `gorm:"not null"             json:"department"`
 	HomeAddress      string `gorm:"default:null"         json:"homeAddress"`
 	Phone            string `gorm:"uniqueIndex;not null" json:"phone"`
-	Password         string `gorm:"not null"             json:"password"`
+	Password         string `gorm:"not null"             json:"-"` // хеш bcrypt, наружу не отдаётся
 	TelegramChatID   *int64 `gorm:"default:null"         json:"telegramChatId"`
 	TelegramNotifyOn bool   `gorm:"not null;default:true" json:"telegramNotifyOn"`
+	IsActive         bool   `gorm:"not null;default:true" json:"isActive"`
+	DeactivatedAt    string `gorm:"default:null"         json:"deactivatedAt"`
+	AnonymizedAt     string `gorm:"default:null"         json:"anonymizedAt"`
 }
 
+// Статусы согласования отчёта. Клиентам и в статистике видны только согласованные
+const (
+	ReportDraft     = "draft"
+	ReportSubmitted = "submitted"
+	ReportApproved  = "approved"
+	ReportRejected  = "rejected"
+)
+
 type Report struct {
 	ID             uint   `gorm:"primaryKey" json:"id"`
 	Filename       string `gorm:"not null"   json:"filename"`
@@ -34,11 +46,118 @@ type Report struct {
 	Address        string `gorm:"not null"   json:"address"`
 	UserID         uint   `gorm:"not null"   json:"userId"`
 	Classification string `gorm:"not null;default:'Не указано'" json:"classification"`
+	Version        int    `gorm:"not null;default:1" json:"version"`
+	Status         string `gorm:"not null;default:'approved';index" json:"status"` // draft, submitted, approved, rejected
+	ReviewComment  string `gorm:"default:null" json:"reviewComment"`
+	ReviewedBy     *uint  `gorm:"default:null" json:"reviewedBy"`
+	ReviewedAt     string `gorm:"default:null" json:"reviewedAt"`
+	// Целостность файла: SHA-256 объекта в хранилище, подпись ключом сервера
+	// и токен публичной проверки (QR-код в акте)
+	SHA256             string `gorm:"default:null;index" json:"sha256"`
+	SealSignature      string `gorm:"default:null" json:"sealSignature"`
+	SealedAt           string `gorm:"default:null" json:"sealedAt"`
+	VerifyToken        string `gorm:"default:null;uniqueIndex" json:"verifyToken"`
+	IntegrityStatus    string `gorm:"default:null;index" json:"integrityStatus"` // ok, mismatch, missing
+	IntegrityCheckedAt string `gorm:"default:null" json:"integrityCheckedAt"`
+	// Номер акта из сквозной нумерации (ActNumberCounter); у загруженных до нумерации - пустой
+	ActNumber string `gorm:"default:null;uniqueIndex" json:"actNumber"`
+	ActYear   int    `gorm:"default:null" json:"actYear"`
+	ActSeq    int    `gorm:"default:null" json:"actSeq"`
+}
+
+// ActNumberCounter - последний выданный номер акта в году для области нумерации:
+// "" - общая, "org:<id>" - по клиенту, "cls:<классификация>" - по классификации
+type ActNumberCounter struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	Year       int    `gorm:"not null;uniqueIndex:idx_act_counter_scope" json:"year"`
+	Scope      string `gorm:"not null;default:'';uniqueIndex:idx_act_counter_scope" json:"scope"`
+	LastNumber int    `gorm:"not null;default:0" json:"lastNumber"`
+}
+
+// StoredObject - имя объекта S3, занятое при выгрузке сгенерированного документа.
+// Уникальный ключ не даёт одновременным задачам выбрать одно имя
+type StoredObject struct {
+	ObjectKey string `gorm:"primaryKey" json:"objectKey"`
+	CreatedAt string `gorm:"not null" json:"createdAt"`
+}
+
+// Результаты перепроверки файла отчёта
+const (
+	IntegrityOK       = "ok"
+	IntegrityMismatch = "mismatch"
+	IntegrityMissing  = "missing"
+)
+
+// ReportReview - запись истории согласования отчёта
+type ReportReview struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	ReportID   uint   `gorm:"not null;index" json:"reportId"`
+	FromStatus string `gorm:"not null" json:"fromStatus"`
+	ToStatus   string `gorm:"not null" json:"toStatus"`
+	Comment    string `gorm:"default:null" json:"comment"`
+	UserID     uint   `gorm:"not null" json:"userId"`
+	CreatedAt  string `gorm:"not null" json:"createdAt"`
+}
+
+// ReportSignature - подпись инженера или представителя заказчика, встроенная в акт.
+// Изображение (PNG) хранится в signatures/ под именем SHA256.png
+type ReportSignature struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	ReportID       uint   `gorm:"not null;index" json:"reportId"`
+	Role           string `gorm:"not null" json:"role"` // engineer, client
+	SignerName     string `gorm:"not null" json:"signerName"`
+	SignerPosition string `gorm:"default:null" json:"signerPosition"`
+	SignedAt       string `gorm:"not null" json:"signedAt"`
+	Filename       string `gorm:"not null" json:"-"`
+	SHA256         string `gorm:"not null" json:"sha256"`
+	CreatedBy      uint   `gorm:"not null" json:"createdBy"`
+	CreatedAt      string `gorm:"not null" json:"createdAt"`
+}
+
+// ReportPhoto - фотография к отчёту. Загружается до создания отчёта отдельно от его данных,
+// отчёт ссылается на неё по ID. Хранится уменьшенной (photos/SHA256.jpg) вместе с миниатюрой
+// (photos/SHA256_thumb.jpg); время съёмки и координаты берутся из EXIF оригинала
+type ReportPhoto struct {
+	ID           uint     `gorm:"primaryKey" json:"id"`
+	UserID       uint     `gorm:"not null;index" json:"userId"`
+	ReportID     *uint    `gorm:"default:null;index" json:"reportId"`
+	OriginalName string   `gorm:"not null" json:"originalName"`
+	Filename     string   `gorm:"not null" json:"-"`
+	ThumbName    string   `gorm:"not null" json:"-"`
+	SHA256       string   `gorm:"not null;index" json:"sha256"`
+	Width        int      `gorm:"not null" json:"width"`
+	Height       int      `gorm:"not null" json:"height"`
+	Size         int64    `gorm:"not null" json:"size"`
+	TakenAt      string   `gorm:"default:null" json:"takenAt"`
+	Latitude     *float64 `gorm:"default:null" json:"latitude"`
+	Longitude    *float64 `gorm:"default:null" json:"longitude"`
+	Address      string   `gorm:"default:null" json:"address"`
+	DistanceM    *float64 `gorm:"default:null" json:"distanceM"`
+	GeoStatus    string   `gorm:"not null;default:'unknown'" json:"geoStatus"` // ok, far, no_gps, unknown
+	CreatedAt    string   `gorm:"not null" json:"createdAt"`
+}
+
+// PhotoUpload - незавершённая загрузка фотографии частями. Принятые байты дописываются
+// в uploads/photo_uploads/<ID>.part; Received - смещение, с которого продолжать
+type PhotoUpload struct {
+	ID        string `gorm:"primaryKey;size:32" json:"id"`
+	UserID    uint   `gorm:"not null;index" json:"userId"`
+	Filename  string `gorm:"not null" json:"filename"`
+	Address   string `gorm:"default:null" json:"address"`
+	Size      int64  `gorm:"not null" json:"size"`
+	Received  int64  `gorm:"not null;default:0" json:"received"`
+	CreatedAt string `gorm:"not null" json:"createdAt"`
+	UpdatedAt string `gorm:"not null" json:"updatedAt"`
 }
 
 type Address struct {
-	ID      uint   `gorm:"primaryKey"           json:"id"`
-	Address string `gorm:"uniqueIndex;not null" json:"address"`
+	ID             uint                `gorm:"primaryKey"           json:"id"`
+	Address        string              `gorm:"uniqueIndex;not null" json:"address"`
+	OrganizationID *uint               `gorm:"default:null;index"   json:"organizationId"`
+	Organization   *ClientOrganization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:SET NULL" json:"-"`
+	// Координаты объекта - для проверки геометки фотографий отчёта
+	Latitude  *float64 `gorm:"default:null" json:"latitude"`
+	Longitude *float64 `gorm:"default:null" json:"longitude"`
 }
 
 type AllowedPhone struct {
@@ -59,6 +178,7 @@ type Inventory struct {
 	Quantity     int    `gorm:"not null;default:1" json:"quantity"`
 	EngineerID   uint   `gorm:"not null" json:"engineerId"`
 	Engineer     User   `gorm:"foreignKey:EngineerID;constraint:OnDelete:CASCADE" json:"-"`
+	InvoiceID    *uint  `gorm:"default:null;index" json:"invoiceId"` // счёт, в который включён установленный ЗИП
 }
 
 type TravelRecord struct {
@@ -94,6 +214,37 @@ type EquipmentMemory struct {
 	Quantity       int    `gorm:"not null;default:1" json:"quantity"`
 }
 
+// Classification - классификация работ. В отчётах, памяти оборудования, чек-листах
+// и шаблонах хранится код; Aliases - другие написания через запятую («Аварийный вызов»)
+type Classification struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	Code      string `gorm:"uniqueIndex;not null" json:"code"`
+	Name      string `gorm:"not null" json:"name"`
+	Aliases   string `gorm:"type:text;default:''" json:"aliases"`
+	ActPrefix string `gorm:"default:''" json:"actPrefix"` // префикс номера акта при ACT_NUMBER_SCOPE=classification
+	Color     string `gorm:"default:''" json:"color"`
+	Planned   bool   `gorm:"not null;default:false" json:"planned"`
+	Emergency bool   `gorm:"not null;default:false" json:"emergency"`
+	Billable  bool   `gorm:"not null;default:true" json:"billable"`
+	Position  int    `gorm:"not null;default:0" json:"position"`
+	IsActive  bool   `gorm:"not null;default:true" json:"isActive"`
+	CreatedAt string `gorm:"not null" json:"createdAt"`
+}
+
+// ChecklistTask - пункт шаблона чек-листа для типа оборудования и классификации.
+// Пустые EquipmentType/Classification - пункт для любого оборудования/классификации.
+// Required - без отметки этого пункта акт не будет создан
+type ChecklistTask struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	EquipmentType  string `gorm:"not null;default:'';index:idx_checklist_task_scope" json:"equipmentType"`
+	Classification string `gorm:"not null;default:'';index:idx_checklist_task_scope" json:"classification"`
+	Task           string `gorm:"not null" json:"task"`
+	Required       bool   `gorm:"not null;default:false" json:"required"`
+	Position       int    `gorm:"not null;default:0" json:"position"`
+	IsActive       bool   `gorm:"not null;default:true" json:"isActive"`
+	CreatedAt      string `gorm:"not null" json:"createdAt"`
+}
+
 // Client - модель клиента (заказчика услуг)
 type Client struct {
 	ID       uint   `gorm:"primaryKey" json:"id"`
@@ -105,6 +256,20 @@ type Client struct {
 	Position    string `gorm:"default:null" json:"position"`
 	CreatedAt   string `gorm:"not null" json:"createdAt"`
 	LastLoginAt string `gorm:"default:null" json:"lastLoginAt"`
+
+	OrganizationID *uint               `gorm:"default:null;index" json:"organizationId"`
+	Organization   *ClientOrganization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:SET NULL" json:"-"`
+	OrgRole        string              `gorm:"default:null" json:"orgRole"` // viewer, requester, org_admin
+}
+
+// ClientOrganization - организация клиента (сеть магазинов, региональный офис),
+// которой принадлежат адреса и в которой состоят несколько клиентов
+type ClientOrganization struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Name        string `gorm:"uniqueIndex;not null" json:"name"`
+	INN         string `gorm:"default:null" json:"inn"`
+	Description string `gorm:"default:null" json:"description"`
+	CreatedAt   string `gorm:"not null" json:"createdAt"`
 }
 
 // TicketReport - связь между заявкой и отчётом
@@ -115,6 +280,97 @@ type TicketReport struct {
 	CreatedAt string `gorm:"not null" json:"createdAt"`
 }
 
+// ReportContent - исходные данные отчёта (ReportData) в виде JSON.
+// Version - номер версии отчёта, SchemaVersion - версия формата JSON.
+// PDF прежних версий остаются в хранилище под своими именами (Filename)
+type ReportContent struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	ReportID      uint   `gorm:"not null;uniqueIndex:idx_report_content_version" json:"reportId"`
+	Version       int    `gorm:"not null;default:1;uniqueIndex:idx_report_content_version" json:"version"`
+	SchemaVersion int    `gorm:"not null;default:1" json:"schemaVersion"`
+	Data          string `gorm:"type:jsonb;not null" json:"-"`
+	Filename      string `gorm:"default:null" json:"filename"` // PDF, сформированный по этой версии
+	SHA256        string `gorm:"default:null" json:"sha256"`   // SHA-256 этого PDF
+	CreatedAt     string `gorm:"not null" json:"createdAt"`
+	CreatedBy     uint   `gorm:"default:null" json:"createdBy"`
+}
+
+// ReportText - текст, извлечённый из PDF загруженного отчёта, и распознанные по нему поля.
+// Нужен для поиска по актам, у которых нет исходных данных (ReportContent)
+type ReportText struct {
+	ID              uint   `gorm:"primaryKey" json:"id"`
+	ReportID        uint   `gorm:"not null;uniqueIndex" json:"reportId"`
+	Status          string `gorm:"not null;default:'pending';index" json:"status"` // pending, running, done, empty, failed
+	Attempts        int    `gorm:"not null;default:0" json:"attempts"`
+	Text            string `gorm:"type:text" json:"text"`
+	Pages           int    `gorm:"default:null" json:"pages"`
+	MachineName     string `gorm:"default:null" json:"machineName"`
+	MachineNumber   string `gorm:"default:null" json:"machineNumber"`
+	InventoryNumber string `gorm:"default:null" json:"inventoryNumber"`
+	Classification  string `gorm:"default:null" json:"classification"`
+	Error           string `gorm:"default:null" json:"error"`
+	CreatedAt       string `gorm:"not null" json:"createdAt"`
+	ExtractedAt     string `gorm:"default:null" json:"extractedAt"`
+}
+
+// ReportJob - фоновая генерация отчёта. IdempotencyKey уникален в пределах автора,
+// повторная отправка с тем же ключом возвращает существующую задачу
+type ReportJob struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	UserID         uint   `gorm:"not null;uniqueIndex:idx_report_job_idempotency" json:"userId"`
+	IdempotencyKey string `gorm:"default:null;uniqueIndex:idx_report_job_idempotency" json:"idempotencyKey"`
+	Kind           string `gorm:"not null;default:'create'" json:"kind"`         // create, update (ReportID - редактируемый отчёт)
+	Status         string `gorm:"not null;default:'queued';index" json:"status"` // queued, running, succeeded, failed
+	Attempts       int    `gorm:"not null;default:0" json:"attempts"`
+	Payload        string `gorm:"type:jsonb;not null" json:"-"`
+	ReportID       *uint  `gorm:"default:null" json:"reportId"`
+	Filename       string `gorm:"default:null" json:"filename"`
+	PreviewName    string `gorm:"default:null" json:"previewName"`
+	Error          string `gorm:"default:null" json:"error"`
+	CreatedAt      string `gorm:"not null" json:"createdAt"`
+	StartedAt      string `gorm:"default:null" json:"startedAt"`
+	FinishedAt     string `gorm:"default:null" json:"finishedAt"`
+	// Номер акта, выданный задаче: повторные попытки используют его же
+	ActNumber string `gorm:"default:null" json:"actNumber"`
+	ActScope  string `gorm:"not null;default:''" json:"-"`
+	ActYear   int    `gorm:"not null;default:0" json:"-"`
+	ActSeq    int    `gorm:"not null;default:0" json:"-"`
+}
+
+// ReportArchive - архив отчётов, подготовленный в фоне для больших выборок.
+// Готовый архив лежит в S3 (archives/) или в uploads/archives и скачивается по ссылке
+type ReportArchive struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	UserID     uint   `gorm:"not null;index" json:"userId"`
+	Status     string `gorm:"not null;default:'queued';index" json:"status"` // queued, running, succeeded, failed
+	Name       string `gorm:"not null" json:"name"`
+	ReportIDs  string `gorm:"type:jsonb;not null" json:"-"`
+	Count      int    `gorm:"not null;default:0" json:"count"`
+	Size       int64  `gorm:"not null;default:0" json:"size"`
+	Error      string `gorm:"default:null" json:"error"`
+	CreatedAt  string `gorm:"not null" json:"createdAt"`
+	StartedAt  string `gorm:"default:null" json:"startedAt"`
+	FinishedAt string `gorm:"default:null" json:"finishedAt"`
+}
+
+// ReportTemplate - DOCX-шаблон акта для классификации и/или организации клиента.
+// Каждая загрузка в ту же область (классификация + организация) - новая версия;
+// при генерации берётся последняя активная версия самой точной области
+type ReportTemplate struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	Name           string `gorm:"not null" json:"name"`
+	Classification string `gorm:"not null;default:'';index:idx_report_template_scope" json:"classification"` // пусто - любая
+	OrganizationID *uint  `gorm:"default:null;index:idx_report_template_scope" json:"organizationId"`        // nil - все клиенты
+	Version        int    `gorm:"not null;default:1" json:"version"`
+	Filename       string `gorm:"not null" json:"filename"`
+	Placeholders   string `gorm:"not null" json:"placeholders"` // через запятую
+	SHA256         string `gorm:"not null" json:"sha256"`
+	Size           int64  `gorm:"not null" json:"size"`
+	IsActive       bool   `gorm:"not null;default:true" json:"isActive"`
+	CreatedAt      string `gorm:"not null" json:"createdAt"`
+	CreatedBy      uint   `gorm:"not null" json:"createdBy"`
+}
+
 type ClientTicket struct {
 	ID           uint    `gorm:"primaryKey" json:"id"`
 	Date         string  `gorm:"not null" json:"date"`
@@ -133,6 +389,174 @@ type ClientTicket struct {
 	CompletedAt  string  `gorm:"default:null" json:"completedAt"`
 }
 
+// AuditLog - запись журнала изменений (кто, что и когда изменил)
+type AuditLog struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	CreatedAt  string `gorm:"not null;index" json:"createdAt"`
+	ActorType  string `gorm:"not null;index:idx_audit_actor" json:"actorType"` // user, client, service, anonymous
+	ActorID    *uint  `gorm:"default:null;index:idx_audit_actor" json:"actorId"`
+	ActorName  string `gorm:"default:null" json:"actorName"`
+	Method     string `gorm:"not null" json:"method"`
+	Route      string `gorm:"not null;index" json:"route"`
+	Path       string `gorm:"not null" json:"path"`
+	EntityType string `gorm:"default:null;index:idx_audit_entity" json:"entityType"`
+	EntityID   string `gorm:"default:null;index:idx_audit_entity" json:"entityId"`
+	StatusCode int    `gorm:"not null" json:"statusCode"`
+	IP         string `gorm:"default:null" json:"ip"`
+	Before     string `gorm:"type:text" json:"before"`
+	After      string `gorm:"type:text" json:"after"`
+	Changes    string `gorm:"type:text" json:"changes"`
+}
+
+// ServiceAccount - учетная запись интеграции (скрипты, docgen, 1С)
+type ServiceAccount struct {
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	Name        string   `gorm:"uniqueIndex;not null" json:"name"`
+	Description string   `gorm:"default:null" json:"description"`
+	IsActive    bool     `gorm:"not null;default:true" json:"isActive"`
+	CreatedAt   string   `gorm:"not null" json:"createdAt"`
+	CreatedBy   uint     `gorm:"not null" json:"createdBy"`
+	Keys        []APIKey `gorm:"foreignKey:ServiceAccountID;constraint:OnDelete:CASCADE" json:"keys,omitempty"`
+}
+
+// APIKey - ключ доступа сервисной учетной записи. Хранится только хеш ключа
+type APIKey struct {
+	ID               uint   `gorm:"primaryKey" json:"id"`
+	ServiceAccountID uint   `gorm:"not null;index" json:"serviceAccountId"`
+	Name             string `gorm:"default:null" json:"name"`
+	Prefix           string `gorm:"uniqueIndex;not null" json:"prefix"`
+	KeyHash          string `gorm:"not null" json:"-"`
+	Scopes           string `gorm:"not null" json:"scopes"`               // через запятую, "*" - все
+	RateLimit        int    `gorm:"not null;default:60" json:"rateLimit"` // запросов в минуту
+	CreatedAt        string `gorm:"not null" json:"createdAt"`
+	ExpiresAt        string `gorm:"default:null" json:"expiresAt"`
+	RevokedAt        string `gorm:"default:null" json:"revokedAt"`
+	LastUsedAt       string `gorm:"default:null" json:"lastUsedAt"`
+	LastUsedIP       string `gorm:"default:null" json:"lastUsedIp"`
+	UsageCount       int64  `gorm:"not null;default:0" json:"usageCount"`
+}
+
+// PayRate - ставка для расчёта зарплаты. Kind: act - за согласованный акт, km - за километр
+// пробега, sla_breach - удержание за заявку с нарушением SLA. Пустая Classification -
+// любая классификация, UserID = nil - все инженеры; применяется самая точная ставка
+type PayRate struct {
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	Kind           string  `gorm:"not null;index" json:"kind"`
+	Classification string  `gorm:"not null;default:''" json:"classification"`
+	UserID         *uint   `gorm:"default:null;index" json:"userId"`
+	Amount         float64 `gorm:"not null" json:"amount"`
+	CreatedAt      string  `gorm:"not null" json:"createdAt"`
+}
+
+// PayAdjustment - премия (bonus) или удержание (penalty) инженера за месяц
+type PayAdjustment struct {
+	ID        uint    `gorm:"primaryKey" json:"id"`
+	UserID    uint    `gorm:"not null;index:idx_pay_adjustment_period" json:"userId"`
+	Period    string  `gorm:"not null;index:idx_pay_adjustment_period" json:"period"` // 2006-01
+	Kind      string  `gorm:"not null" json:"kind"`
+	Amount    float64 `gorm:"not null" json:"amount"`
+	Reason    string  `gorm:"not null" json:"reason"`
+	CreatedBy uint    `gorm:"not null" json:"createdBy"`
+	CreatedAt string  `gorm:"not null" json:"createdAt"`
+}
+
+// PayrollStatement - расчётная ведомость инженера за месяц с показателями KPI.
+// После утверждения (approved) ведомость не пересчитывается
+type PayrollStatement struct {
+	ID           uint          `gorm:"primaryKey" json:"id"`
+	UserID       uint          `gorm:"not null;uniqueIndex:idx_payroll_statement_period" json:"userId"`
+	Period       string        `gorm:"not null;uniqueIndex:idx_payroll_statement_period" json:"period"`
+	Status       string        `gorm:"not null;default:'draft';index" json:"status"`
+	Reports      int64         `gorm:"not null;default:0" json:"reports"`
+	Tickets      int64         `gorm:"not null;default:0" json:"tickets"`
+	SLAHits      int64         `gorm:"not null;default:0" json:"slaHits"`
+	SLABreaches  int64         `gorm:"not null;default:0" json:"slaBreaches"`
+	Km           float64       `gorm:"not null;default:0" json:"km"`
+	Total        float64       `gorm:"not null;default:0" json:"total"`
+	CalculatedAt string        `gorm:"not null" json:"calculatedAt"`
+	ApprovedBy   *uint         `gorm:"default:null" json:"approvedBy"`
+	ApprovedAt   string        `gorm:"default:null" json:"approvedAt"`
+	Lines        []PayrollLine `gorm:"foreignKey:StatementID;constraint:OnDelete:CASCADE" json:"lines,omitempty"`
+}
+
+// PayrollLine - строка ведомости: количество × ставка = сумма (удержания - с минусом)
+type PayrollLine struct {
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	StatementID    uint    `gorm:"not null;index" json:"statementId"`
+	Position       int     `gorm:"not null;default:0" json:"position"`
+	Kind           string  `gorm:"not null" json:"kind"`
+	Classification string  `gorm:"default:''" json:"classification"`
+	Description    string  `gorm:"not null" json:"description"`
+	Quantity       float64 `gorm:"not null" json:"quantity"`
+	Rate           float64 `gorm:"not null" json:"rate"`
+	Amount         float64 `gorm:"not null" json:"amount"`
+}
+
+// PriceListItem - позиция прайс-листа: работа (work) по классификации или материал
+// (material) по наименованию. OrganizationID - цена для клиента, NULL - общая
+type PriceListItem struct {
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	OrganizationID *uint   `gorm:"default:null;index" json:"organizationId"`
+	Kind           string  `gorm:"not null;index" json:"kind"`
+	Classification string  `gorm:"not null;default:''" json:"classification"`
+	Name           string  `gorm:"not null;default:''" json:"name"`
+	Unit           string  `gorm:"not null;default:'шт'" json:"unit"`
+	Price          float64 `gorm:"not null" json:"price"`
+	CreatedAt      string  `gorm:"not null" json:"createdAt"`
+}
+
+// Invoice - счёт клиенту за месяц по актам на его адресах.
+// Номер выдаётся при выставлении (issued), после чего счёт не пересчитывается
+type Invoice struct {
+	ID             uint          `gorm:"primaryKey" json:"id"`
+	OrganizationID uint          `gorm:"not null;uniqueIndex:idx_invoice_period" json:"organizationId"`
+	Period         string        `gorm:"not null;uniqueIndex:idx_invoice_period" json:"period"` // 2006-01
+	Number         string        `gorm:"default:null;uniqueIndex" json:"number"`
+	Status         string        `gorm:"not null;default:'draft';index" json:"status"` // draft, issued, paid
+	Reports        int64         `gorm:"not null;default:0" json:"reports"`
+	Total          float64       `gorm:"not null;default:0" json:"total"`
+	CalculatedAt   string        `gorm:"not null" json:"calculatedAt"`
+	IssuedAt       string        `gorm:"default:null" json:"issuedAt"`
+	PaidAt         string        `gorm:"default:null" json:"paidAt"`
+	Lines          []InvoiceLine `gorm:"foreignKey:InvoiceID;constraint:OnDelete:CASCADE" json:"lines,omitempty"`
+}
+
+// InvoiceLine - строка счёта: работа по акту или материал
+type InvoiceLine struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	InvoiceID   uint    `gorm:"not null;index" json:"invoiceId"`
+	Position    int     `gorm:"not null;default:0" json:"position"`
+	ReportID    *uint   `gorm:"default:null;index" json:"reportId"`
+	Kind        string  `gorm:"not null" json:"kind"`
+	Description string  `gorm:"not null" json:"description"`
+	Unit        string  `gorm:"not null;default:''" json:"unit"`
+	Quantity    float64 `gorm:"not null" json:"quantity"`
+	Price       float64 `gorm:"not null" json:"price"`
+	Amount      float64 `gorm:"not null" json:"amount"`
+}
+
+// migrateTemplateVersions - номер версии уникален в области шаблона (классификация + организация).
+// Отсутствие организации приравнивается к 0: иначе PostgreSQL считал бы общие шаблоны разными
+func migrateTemplateVersions() {
+	if err := DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_report_template_version
+		ON report_templates (classification, COALESCE(organization_id, 0), version)`).Error; err != nil {
+		log.Printf("Индекс версий шаблонов: %v", err)
+	}
+}
+
+// Models - таблицы схемы в порядке миграции
+func Models() []interface{} {
+	return []interface{}{
+		&File{}, &ClientOrganization{}, &User{}, &Report{}, &Request{}, &Address{}, &AllowedPhone{},
+		&Equipment{}, &Inventory{}, &TravelRecord{}, &EquipmentMemory{}, &Classification{},
+		&ChecklistTask{}, &ClientTicket{}, &Client{}, &TicketReport{}, &ReportContent{}, &ReportText{},
+		&ReportReview{}, &ActNumberCounter{}, &ReportSignature{}, &ReportPhoto{}, &PhotoUpload{},
+		&ReportJob{}, &ReportArchive{}, &ReportTemplate{}, &AuditLog{}, &ServiceAccount{}, &APIKey{},
+		&PayRate{}, &PayAdjustment{}, &PayrollStatement{}, &PayrollLine{}, &PriceListItem{}, &Invoice{},
+		&InvoiceLine{}, &StoredObject{},
+	}
+}
+
 func InitDB() {
 	var err error
 	dsn := os.Getenv("POSTGRES_DSN")
@@ -144,9 +568,12 @@ func InitDB() {
 		log.Fatal("Ошибка при подключении к PostgreSQL:", err)
 	}
 
-	if err := DB.AutoMigrate(&File{}, &User{}, &Report{}, &Request{}, &Address{}, &AllowedPhone{}, &Equipment{}, &Inventory{}, &TravelRecord{}, &EquipmentMemory{}, &ClientTicket{}, &Client{}, &TicketReport{}); err != nil {
+	if err := DB.AutoMigrate(Models()...); err != nil {
 		log.Fatal("Ошибка миграции схемы:", err)
 	}
+	migrateSearch()
+	migrateClassifications()
+	migrateTemplateVersions()
 
 	var count int64
 	DB.Model(&AllowedPhone{}).Count(&count)
@@ -0,0 +1,38 @@
+package db
+
+import "log"
+
+// Полнотекстовый поиск: вычисляемые колонки search_vector (словарь russian)
+// и GIN-индексы по ним. Колонки не описаны в моделях - их заполняет PostgreSQL (12+)
+var searchColumns = []struct {
+	Table      string
+	Expression string
+}{
+	{"reports", `setweight(to_tsvector('russian', coalesce(address, '') || ' ' || coalesce(act_number, '') || ' ' || coalesce(filename, '')), 'A') ||
+		setweight(to_tsvector('russian', coalesce(classification, '')), 'C')`},
+	{"report_contents", `setweight(to_tsvector('russian', coalesce(data->>'defects', '') || ' ' || coalesce(data->>'recommendations', '')), 'A') ||
+		setweight(to_tsvector('russian', coalesce(data->>'machine_name', '') || ' ' || coalesce(data->>'machine_number', '') || ' ' ||
+			coalesce(data->>'inventory_number', '') || ' ' || coalesce(jsonb_path_query_array(data, '$.equipmentItems[*].name')::text, '') || ' ' ||
+			coalesce(jsonb_path_query_array(data, '$.equipmentItems[*].number')::text, '')), 'A') ||
+		setweight(to_tsvector('russian', coalesce(data->>'material', '') || ' ' || coalesce(data->>'additionalWorks', '') || ' ' || coalesce(data->>'comments', '')), 'B')`},
+	{"report_texts", `setweight(to_tsvector('russian', coalesce(machine_name, '') || ' ' || coalesce(machine_number, '') || ' ' || coalesce(inventory_number, '')), 'A') ||
+		setweight(to_tsvector('russian', left(coalesce("text", ''), 200000)), 'B')`},
+	{"client_tickets", `setweight(to_tsvector('russian', coalesce(description, '')), 'A') ||
+		setweight(to_tsvector('russian', coalesce(address, '') || ' ' || coalesce(full_name, '') || ' ' || coalesce("position", '') || ' ' || coalesce(engineer_name, '')), 'B')`},
+	{"files", `setweight(to_tsvector('russian', regexp_replace(coalesce(filename, ''), '[_.-]+', ' ', 'g')), 'A') ||
+		setweight(to_tsvector('russian', coalesce(tags, '')), 'A')`},
+}
+
+// migrateSearch добавляет колонки и индексы полнотекстового поиска. Ошибки не фатальны:
+// без них не работает только /api/search
+func migrateSearch() {
+	for _, col := range searchColumns {
+		if err := DB.Exec("ALTER TABLE " + col.Table + " ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (" + col.Expression + ") STORED").Error; err != nil {
+			log.Printf("Полнотекстовый поиск: колонка %s.search_vector: %v", col.Table, err)
+			continue
+		}
+		if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_" + col.Table + "_search ON " + col.Table + " USING GIN (search_vector)").Error; err != nil {
+			log.Printf("Полнотекстовый поиск: индекс %s: %v", col.Table, err)
+		}
+	}
+}
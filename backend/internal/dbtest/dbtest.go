@@ -0,0 +1,40 @@
+// Package dbtest - база для тестов: временный файл SQLite с полной схемой вместо PostgreSQL
+package dbtest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend/internal/db"
+)
+
+// Open подменяет db.DB чистой базой на время теста. Возможности PostgreSQL
+// (ILIKE, jsonb, блокировки строк) здесь недоступны или игнорируются
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	// Файл в WAL: чтение не ждёт чужих транзакций, записи ждут друг друга до busy_timeout
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_journal_mode=WAL&_busy_timeout=5000"
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("открытие тестовой базы: %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatalf("открытие тестовой базы: %v", err)
+	}
+	if err := conn.AutoMigrate(db.Models()...); err != nil {
+		t.Fatalf("миграция тестовой базы: %v", err)
+	}
+
+	prev := db.DB
+	db.DB = conn
+	t.Cleanup(func() {
+		db.DB = prev
+		sqlDB.Close()
+	})
+	return conn
+}
@@ -0,0 +1,310 @@
+package docgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"backend/internal/storage"
+)
+
+// Максимальное время формирования одного документа
+const generateTimeout = 120 * time.Second
+
+// HTTPGenerator - внешний Python-сервис (PY_SERVICE_URL), сам выгружающий файлы в S3
+type HTTPGenerator struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPGenerator создаёт генератор, работающий через HTTP-сервис
+func NewHTTPGenerator(url string) *HTTPGenerator {
+	return &HTTPGenerator{url: url, client: &http.Client{Timeout: generateTimeout}}
+}
+
+func (g *HTTPGenerator) Name() string { return "http" }
+
+// Healthy - сервис кладёт файлы прямо в S3, без него результат недоступен
+func (g *HTTPGenerator) Healthy(ctx context.Context) error {
+	if !storage.IsS3Enabled() {
+		return errors.New("S3 не настроен")
+	}
+	return nil
+}
+
+func (g *HTTPGenerator) Generate(ctx context.Context, doc *Document) (*Result, error) {
+	payload, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return nil, unavailable(fmt.Errorf("сервис вернул статус %d", resp.StatusCode))
+	default:
+		return nil, fmt.Errorf("сервис вернул статус %d", resp.StatusCode)
+	}
+
+	var out struct {
+		PDF     string `json:"pdf"`
+		Preview string `json:"preview"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(out.PDF, ".pdf") {
+		return nil, fmt.Errorf("неожиданное имя PDF: %q", out.PDF)
+	}
+
+	res := &Result{PDFName: filepath.Base(out.PDF), Stored: true}
+	if out.Preview != "" {
+		res.PreviewName = filepath.Base(out.Preview)
+	}
+	return res, nil
+}
+
+// GRPCGenerator - gRPC-сервис генерации документов (DOCGEN_GRPC_ADDRESS)
+type GRPCGenerator struct {
+	address string
+}
+
+// NewGRPCGenerator создаёт генератор, работающий через gRPC
+func NewGRPCGenerator(address string) *GRPCGenerator {
+	return &GRPCGenerator{address: address}
+}
+
+func (g *GRPCGenerator) Name() string { return "grpc" }
+
+func (g *GRPCGenerator) Healthy(ctx context.Context) error {
+	client, err := NewClient(g.address)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	healthy, err := client.HealthCheck(ctx)
+	if err != nil {
+		return err
+	}
+	if !healthy {
+		return errors.New("сервис сообщил о неисправности")
+	}
+	return nil
+}
+
+func (g *GRPCGenerator) Generate(ctx context.Context, doc *Document) (*Result, error) {
+	client, err := NewClient(g.address)
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	defer client.Close()
+
+	var checklistItems []*ChecklistItem
+	for _, item := range doc.ChecklistItems {
+		checklistItems = append(checklistItems, &ChecklistItem{Task: item.Task, Done: item.Done})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, generateTimeout)
+	defer cancel()
+
+	resp, err := client.GenerateDocument(ctx, &GenerateDocumentRequest{
+		Date:               doc.Date,
+		Address:            doc.Address,
+		MachineName:        doc.MachineName,
+		MachineNumber:      doc.MachineNumber,
+		InventoryNumber:    doc.InventoryNumber,
+		Classification:     doc.Classification,
+		CustomClass:        doc.CustomClass,
+		Material:           doc.Material,
+		Recommendations:    doc.Recommendations,
+		Defects:            doc.Defects,
+		AdditionalWorks:    doc.AdditionalWorks,
+		Comments:           doc.Comments,
+		ChecklistItems:     checklistItems,
+		Photos:             doc.Photos,
+		FirstName:          doc.FirstName,
+		LastName:           doc.LastName,
+		Template:           templateRef(doc.Template),
+		Signatures:         signatureRefs(doc.Signatures),
+		VerifyUrl:          doc.VerifyURL,
+		VerifyQr:           doc.VerifyQR,
+		ActNumber:          doc.ActNumber,
+		ClassificationName: doc.ClassificationName,
+	})
+	if status.Code(err) == codes.Unavailable {
+		return nil, unavailable(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.ErrorMessage)
+	}
+
+	res := &Result{PDFName: resp.PdfFilename, PreviewName: resp.PreviewFilename}
+	// Без содержимого в ответе файлы ожидаются в общих локальных uploads/
+	if len(resp.PdfContent) > 0 {
+		res.PDF = resp.PdfContent
+	}
+	if len(resp.PreviewContent) > 0 {
+		res.Preview = resp.PreviewContent
+	}
+	return res, nil
+}
+
+func templateRef(tpl *DocumentTemplate) *TemplateRef {
+	if tpl == nil {
+		return nil
+	}
+	return &TemplateRef{
+		Id:       uint32(tpl.ID),
+		Version:  int32(tpl.Version),
+		Filename: tpl.Filename,
+		Content:  tpl.Content,
+	}
+}
+
+func signatureRefs(signatures []DocumentSignature) []*Signature {
+	var refs []*Signature
+	for _, sig := range signatures {
+		refs = append(refs, &Signature{
+			Role:           sig.Role,
+			SignerName:     sig.SignerName,
+			SignerPosition: sig.SignerPosition,
+			SignedAt:       sig.SignedAt,
+			Image:          sig.Image,
+		})
+	}
+	return refs
+}
+
+// ExecGenerator - локальный запуск scripts/document_generator_core.py.
+// Скрипт пишет PDF и превью в uploads/reports и uploads/previews и печатает их имена
+type ExecGenerator struct {
+	scriptsDir string
+}
+
+// NewExecGenerator создаёт генератор, запускающий Python-скрипт из scriptsDir
+func NewExecGenerator(scriptsDir string) *ExecGenerator {
+	return &ExecGenerator{scriptsDir: scriptsDir}
+}
+
+func (g *ExecGenerator) Name() string { return "exec" }
+
+func (g *ExecGenerator) scriptPath() string {
+	return filepath.Join(g.scriptsDir, "document_generator_core.py")
+}
+
+func (g *ExecGenerator) pythonPath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(g.scriptsDir, "venv", "Scripts", "python.exe")
+	}
+	for _, path := range []string{
+		filepath.Join(g.scriptsDir, "venv", "bin", "python3"),
+		filepath.Join(g.scriptsDir, "venv", "bin", "python"),
+		"python3",
+		"python",
+	} {
+		if _, err := exec.LookPath(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func (g *ExecGenerator) Healthy(ctx context.Context) error {
+	if _, err := os.Stat(g.scriptPath()); err != nil {
+		return fmt.Errorf("скрипт Python не найден: %v", err)
+	}
+	if g.pythonPath() == "" {
+		return errors.New("Python интерпретатор не найден")
+	}
+	return nil
+}
+
+func (g *ExecGenerator) Generate(ctx context.Context, doc *Document) (*Result, error) {
+	// Скрипт читает шаблон с диска, поэтому содержимое кладётся во временный файл
+	if doc.Template != nil && doc.Template.Path == "" && len(doc.Template.Content) > 0 {
+		templateFile, err := os.CreateTemp("", "report_template_*.docx")
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при создании временного файла: %v", err)
+		}
+		defer os.Remove(templateFile.Name())
+		_, err = templateFile.Write(doc.Template.Content)
+		templateFile.Close()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при записи шаблона: %v", err)
+		}
+
+		withPath := *doc
+		tpl := *doc.Template
+		tpl.Path = templateFile.Name()
+		withPath.Template = &tpl
+		doc = &withPath
+	}
+
+	jsonData, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при обработке данных: %v", err)
+	}
+
+	tempFile, err := os.CreateTemp("", "report_data_*.json")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании временного файла: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(jsonData); err != nil {
+		tempFile.Close()
+		return nil, fmt.Errorf("ошибка при записи данных: %v", err)
+	}
+	tempFile.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, generateTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, g.pythonPath(), g.scriptPath(), tempFile.Name()).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при генерации документа: %v\nOutput: %s", err, string(output))
+	}
+
+	res := &Result{}
+	for _, lineRaw := range strings.Split(string(output), "\n") {
+		line := strings.TrimSpace(strings.ReplaceAll(strings.TrimPrefix(lineRaw, "\ufeff"), "\r", ""))
+		if line == "" || strings.Contains(line, "%") {
+			continue
+		}
+		if strings.HasSuffix(line, ".pdf") {
+			res.PDFName = line
+		}
+		if strings.HasSuffix(line, ".png") {
+			res.PreviewName = line
+		}
+	}
+	if res.PDFName == "" {
+		return nil, errors.New("не удалось получить имя сгенерированного файла")
+	}
+	return res, nil
+}
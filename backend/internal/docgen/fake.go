@@ -0,0 +1,120 @@
+package docgen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// FakeGenerator формирует простой PDF без Python и внешних сервисов.
+// Включается через DOCGEN_BACKENDS=fake для проверки всего процесса создания отчёта
+type FakeGenerator struct{}
+
+// NewFakeGenerator создаёт тестовый генератор
+func NewFakeGenerator() *FakeGenerator {
+	return &FakeGenerator{}
+}
+
+func (g *FakeGenerator) Name() string { return "fake" }
+
+func (g *FakeGenerator) Healthy(ctx context.Context) error { return nil }
+
+func (g *FakeGenerator) Generate(ctx context.Context, doc *Document) (*Result, error) {
+	base, err := documentBaseName(doc)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при выборе имени файла: %v", err)
+	}
+
+	lines := []string{
+		"FAKE DOCUMENT (DOCGEN_BACKENDS=fake)",
+		"Date: " + doc.Date,
+		fmt.Sprintf("Equipment items: %d", len(doc.EquipmentItems)),
+		fmt.Sprintf("Checklist items: %d", len(doc.ChecklistItems)),
+		fmt.Sprintf("Photos: %d", len(doc.Photos)),
+	}
+
+	preview, err := fakePreview()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		PDFName:     base + ".pdf",
+		PreviewName: base + ".png",
+		PDF:         SimplePDF(lines),
+		Preview:     preview,
+		Pages:       [][]byte{preview},
+	}, nil
+}
+
+func fakePreview() ([]byte, error) {
+	const w, h = 420, 594
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{255, 255, 255, 255}
+			if x < 4 || y < 4 || x >= w-4 || y >= h-4 {
+				c = color.RGBA{180, 180, 180, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SimplePDF собирает одностраничный PDF A4 со строками текста (только ASCII, шрифт Helvetica)
+func SimplePDF(lines []string) []byte {
+	var content bytes.Buffer
+	content.WriteString("BT /F1 12 Tf 50 790 Td 16 TL\n")
+	for _, line := range lines {
+		content.WriteString("(" + pdfEscape(line) + ") Tj T*\n")
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 128:
+			b.WriteRune(r)
+		default:
+			b.WriteRune('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,251 @@
+package docgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChecklistEntry - пункт чек-листа
+type ChecklistEntry struct {
+	Task string `json:"task"`
+	Done bool   `json:"done"`
+}
+
+// EquipmentEntry - позиция оборудования в акте
+type EquipmentEntry struct {
+	Name     string `json:"name"`
+	Number   string `json:"number"`
+	Quantity int    `json:"quantity"`
+}
+
+// DocumentTemplate - DOCX-шаблон акта, выбранный для документа.
+// Без шаблона генераторы используют стандартный scripts/template.docx
+type DocumentTemplate struct {
+	ID       uint   `json:"id"`
+	Version  int    `json:"version"`
+	Filename string `json:"filename"`       // имя в хранилище (templates/)
+	Path     string `json:"path,omitempty"` // локальная копия для document_generator_core.py
+	Content  []byte `json:"-"`
+}
+
+// DocumentSignature - подпись, встраиваемая в акт. Image - PNG,
+// в JSON передаётся в base64
+type DocumentSignature struct {
+	Role           string `json:"role"` // engineer или client
+	SignerName     string `json:"signerName"`
+	SignerPosition string `json:"signerPosition"`
+	SignedAt       string `json:"signedAt"`
+	Image          []byte `json:"image"`
+}
+
+// Document - данные для формирования акта. JSON-ключи совпадают с форматом,
+// который ожидают Python-сервис (PY_SERVICE_URL) и document_generator_core.py
+type Document struct {
+	Date            string              `json:"date"`
+	Address         string              `json:"address"`
+	MachineName     string              `json:"machine_name"`
+	MachineNumber   string              `json:"machine_number"`
+	InventoryNumber string              `json:"inventory_number"`
+	EquipmentItems  []EquipmentEntry    `json:"equipmentItems"`
+	Classification  string              `json:"classification"`
+	CustomClass     string              `json:"customClass"`
+	Material        string              `json:"material"`
+	Recommendations string              `json:"recommendations"`
+	Defects         string              `json:"defects"`
+	AdditionalWorks string              `json:"additionalWorks"`
+	Comments        string              `json:"comments"`
+	ChecklistItems  []ChecklistEntry    `json:"checklistItems"`
+	Photos          []string            `json:"photos"`
+	FirstName       string              `json:"firstName"`
+	LastName        string              `json:"lastName"`
+	Template        *DocumentTemplate   `json:"template,omitempty"`
+	Signatures      []DocumentSignature `json:"signatures,omitempty"`
+	VerifyURL       string              `json:"verifyUrl,omitempty"` // ссылка на публичную проверку акта
+	VerifyQR        []byte              `json:"verifyQr,omitempty"`  // QR-код ссылки (PNG)
+	ActNumber       string              `json:"actNumber,omitempty"` // номер акта
+	// Название классификации из справочника («Аварийный вызов» для кода «АВ»)
+	ClassificationName string `json:"classificationName,omitempty"`
+}
+
+// Result - результат генерации. Содержимое может прийти в памяти (PDF, Preview, Pages),
+// лежать в локальных uploads/reports и uploads/previews (PDF == nil)
+// или быть уже выгруженным в хранилище самим генератором (Stored)
+type Result struct {
+	PDFName     string
+	PreviewName string
+	PDF         []byte
+	Preview     []byte
+	Pages       [][]byte // превью страниц: <имя>_page_1.png, <имя>_page_2.png, ...
+	Stored      bool
+}
+
+// Generator - способ формирования PDF акта
+type Generator interface {
+	// Name - короткое имя для логов и настройки порядка (DOCGEN_BACKENDS)
+	Name() string
+	// Healthy возвращает ошибку, если генератор сейчас недоступен
+	Healthy(ctx context.Context) error
+	Generate(ctx context.Context, doc *Document) (*Result, error)
+}
+
+// ErrNoGenerator - ни один генератор цепочки не смог сформировать документ
+var ErrNoGenerator = errors.New("нет доступных генераторов документов")
+
+// unavailableError - генератор недоступен (сеть, сервис не отвечает), в отличие от ошибки
+// конкретного документа, после которой генератор остаётся в цепочке
+type unavailableError struct {
+	err error
+}
+
+func (e *unavailableError) Error() string { return e.err.Error() }
+
+func (e *unavailableError) Unwrap() error { return e.err }
+
+// unavailable помечает ошибку как недоступность генератора
+func unavailable(err error) error {
+	return &unavailableError{err: err}
+}
+
+func isUnavailable(err error) bool {
+	var ue *unavailableError
+	return errors.As(err, &ue)
+}
+
+// Время, на которое запоминается результат проверки доступности генератора
+const healthTTL = 30 * time.Second
+
+type healthState struct {
+	err       error
+	checkedAt time.Time
+}
+
+// Chain перебирает генераторы по порядку, пропуская недоступные
+type Chain struct {
+	generators []Generator
+
+	mu     sync.Mutex
+	health map[string]healthState
+}
+
+// NewChain создаёт цепочку генераторов в порядке приоритета
+func NewChain(generators ...Generator) *Chain {
+	return &Chain{generators: generators, health: map[string]healthState{}}
+}
+
+// Generators возвращает генераторы цепочки
+func (ch *Chain) Generators() []Generator {
+	return ch.generators
+}
+
+func (ch *Chain) healthy(ctx context.Context, g Generator) error {
+	ch.mu.Lock()
+	state, ok := ch.health[g.Name()]
+	ch.mu.Unlock()
+	if ok && time.Since(state.checkedAt) < healthTTL {
+		return state.err
+	}
+
+	hctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	err := g.Healthy(hctx)
+	ch.markHealth(g, err)
+	return err
+}
+
+func (ch *Chain) markHealth(g Generator, err error) {
+	ch.mu.Lock()
+	ch.health[g.Name()] = healthState{err: err, checkedAt: time.Now()}
+	ch.mu.Unlock()
+}
+
+// Generate формирует документ первым доступным генератором.
+// Возвращает результат и имя сработавшего генератора
+func (ch *Chain) Generate(ctx context.Context, doc *Document) (*Result, string, error) {
+	var errs []string
+	for _, g := range ch.generators {
+		if err := ch.healthy(ctx, g); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: недоступен: %v", g.Name(), err))
+			continue
+		}
+		res, err := g.Generate(ctx, doc)
+		if err != nil {
+			log.Printf("docgen: генератор %s: %v", g.Name(), err)
+			// Ошибка в данных одного документа не выключает генератор для остальных
+			if isUnavailable(err) {
+				ch.markHealth(g, err)
+			}
+			errs = append(errs, fmt.Sprintf("%s: %v", g.Name(), err))
+			continue
+		}
+		return res, g.Name(), nil
+	}
+	if len(errs) == 0 {
+		return nil, "", ErrNoGenerator
+	}
+	return nil, "", fmt.Errorf("%w: %s", ErrNoGenerator, strings.Join(errs, "; "))
+}
+
+// GenerateAndStore формирует документ и выгружает PDF и превью в хранилище.
+// Возвращает итоговые имена файлов PDF и превью
+func (ch *Chain) GenerateAndStore(ctx context.Context, doc *Document) (string, string, error) {
+	res, name, err := ch.Generate(ctx, doc)
+	if err != nil {
+		return "", "", err
+	}
+	log.Printf("docgen: документ %s сформирован генератором %s", res.PDFName, name)
+	return Store(ctx, res)
+}
+
+var (
+	defaultChain     *Chain
+	defaultChainOnce sync.Once
+)
+
+// DefaultChain - цепочка из переменной DOCGEN_BACKENDS (через запятую: http, grpc, exec, native, fake).
+// По умолчанию: http (если задан PY_SERVICE_URL), grpc, exec, native
+func DefaultChain() *Chain {
+	defaultChainOnce.Do(func() {
+		names := strings.Split(os.Getenv("DOCGEN_BACKENDS"), ",")
+		if strings.TrimSpace(os.Getenv("DOCGEN_BACKENDS")) == "" {
+			names = []string{"http", "grpc", "exec", "native"}
+		}
+		var generators []Generator
+		for _, name := range names {
+			if g := generatorByName(strings.TrimSpace(name)); g != nil {
+				generators = append(generators, g)
+			}
+		}
+		defaultChain = NewChain(generators...)
+	})
+	return defaultChain
+}
+
+func generatorByName(name string) Generator {
+	switch name {
+	case "http":
+		if url := os.Getenv("PY_SERVICE_URL"); url != "" {
+			return NewHTTPGenerator(url)
+		}
+	case "grpc":
+		address := os.Getenv("DOCGEN_GRPC_ADDRESS")
+		if address == "" {
+			address = "localhost:50051"
+		}
+		return NewGRPCGenerator(address)
+	case "exec":
+		return NewExecGenerator("scripts")
+	case "native":
+		return NewNativeGenerator("scripts")
+	case "fake":
+		return NewFakeGenerator()
+	default:
+		log.Printf("docgen: неизвестный генератор %q в DOCGEN_BACKENDS", name)
+	}
+	return nil
+}
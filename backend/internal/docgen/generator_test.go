@@ -0,0 +1,110 @@
+package docgen
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/internal/dbtest"
+)
+
+// stubGenerator падает с заданной ошибкой и считает вызовы
+type stubGenerator struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (g *stubGenerator) Name() string { return g.name }
+
+func (g *stubGenerator) Healthy(ctx context.Context) error { return nil }
+
+func (g *stubGenerator) Generate(ctx context.Context, doc *Document) (*Result, error) {
+	g.calls++
+	if g.err != nil {
+		return nil, g.err
+	}
+	return &Result{PDFName: g.name + ".pdf"}, nil
+}
+
+func TestChainKeepsGeneratorAfterDocumentError(t *testing.T) {
+	primary := &stubGenerator{name: "primary", err: errors.New("некорректные данные акта")}
+	fallback := &stubGenerator{name: "fallback"}
+	ch := NewChain(primary, fallback)
+
+	for i := 0; i < 2; i++ {
+		if _, name, err := ch.Generate(context.Background(), &Document{}); err != nil || name != "fallback" {
+			t.Fatalf("попытка %d: генератор %q, ошибка %v", i, name, err)
+		}
+	}
+	if primary.calls != 2 {
+		t.Errorf("после ошибки документа основной генератор вызван %d раз, ожидалось 2", primary.calls)
+	}
+}
+
+func TestChainSkipsUnavailableGenerator(t *testing.T) {
+	primary := &stubGenerator{name: "primary", err: unavailable(errors.New("connection refused"))}
+	fallback := &stubGenerator{name: "fallback"}
+	ch := NewChain(primary, fallback)
+
+	for i := 0; i < 2; i++ {
+		if _, name, err := ch.Generate(context.Background(), &Document{}); err != nil || name != "fallback" {
+			t.Fatalf("попытка %d: генератор %q, ошибка %v", i, name, err)
+		}
+	}
+	if primary.calls != 1 {
+		t.Errorf("недоступный генератор вызван %d раз, ожидался 1 (пропуск на healthTTL)", primary.calls)
+	}
+}
+
+func TestDocumentBaseNameUnique(t *testing.T) {
+	t.Chdir(t.TempDir())
+	doc := &Document{Date: "2025-03-14", Address: "ул. Ленина, 1"}
+
+	names := make(chan string, 8)
+	for i := 0; i < cap(names); i++ {
+		go func() {
+			name, err := documentBaseName(doc)
+			if err != nil {
+				t.Error(err)
+			}
+			names <- name
+		}()
+	}
+	seen := map[string]bool{}
+	for i := 0; i < cap(names); i++ {
+		name := <-names
+		if seen[name] {
+			t.Fatalf("имя %q выдано дважды", name)
+		}
+		seen[name] = true
+	}
+}
+
+func TestReserveObjectNameUnique(t *testing.T) {
+	dbtest.Open(t)
+
+	names := make(chan string, 8)
+	for i := 0; i < cap(names); i++ {
+		go func() {
+			name, err := reserveObjectName(context.Background(), "reports/", "Акт.pdf")
+			if err != nil {
+				t.Error(err)
+			}
+			names <- name
+		}()
+	}
+	seen := map[string]bool{}
+	for i := 0; i < cap(names); i++ {
+		name := <-names
+		if seen[name] {
+			t.Fatalf("имя %q выдано дважды", name)
+		}
+		seen[name] = true
+	}
+
+	releaseObjectName("reports/Акт.pdf")
+	if name, err := reserveObjectName(context.Background(), "reports/", "Акт.pdf"); err != nil || name != "Акт.pdf" {
+		t.Fatalf("освобождённое имя не выдано повторно: %q, %v", name, err)
+	}
+}
@@ -0,0 +1,504 @@
+package docgen
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-pdf/fpdf"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// Масштаб превью как в document_generator_core.py (fitz.Matrix(1.5, 1.5))
+	previewScale = 1.5
+	// Длинная сторона фото в PDF, пикселей
+	photoMaxPixels = 1600
+	logoHeight     = 20.0
+	pdfFontFamily  = "dejavu"
+)
+
+// Каталоги, в которых ищутся шрифты DejaVu, если не заданы DOCGEN_FONT и DOCGEN_FONT_BOLD
+var fontDirs = []string{
+	filepath.Join("scripts", "fonts"),
+	"/usr/share/fonts/truetype/dejavu",
+	"/usr/share/fonts/dejavu",
+	"/usr/local/share/fonts",
+}
+
+// NativeGenerator формирует «Акт выполненных работ» средствами Go, без Python,
+// LibreOffice и внешних сервисов: PDF (go-pdf/fpdf) и PNG-превью страниц.
+// DOCX-шаблоны (Document.Template) не применяются - раскладка всегда стандартная
+type NativeGenerator struct {
+	scriptsDir string
+
+	once   sync.Once
+	assets *nativeAssets
+	err    error
+}
+
+type nativeAssets struct {
+	regularTTF, boldTTF []byte
+	regular, bold       *sfnt.Font
+	stamp, logo         *docImage
+}
+
+// NewNativeGenerator создаёт генератор; печать берётся из scriptsDir/stamp.png,
+// логотип - из шаблона scriptsDir/template.docx (если есть)
+func NewNativeGenerator(scriptsDir string) *NativeGenerator {
+	return &NativeGenerator{scriptsDir: scriptsDir}
+}
+
+func (g *NativeGenerator) Name() string { return "native" }
+
+func (g *NativeGenerator) Healthy(ctx context.Context) error {
+	_, err := g.load()
+	return err
+}
+
+func (g *NativeGenerator) load() (*nativeAssets, error) {
+	g.once.Do(func() {
+		g.assets, g.err = loadNativeAssets(g.scriptsDir)
+	})
+	return g.assets, g.err
+}
+
+func loadNativeAssets(scriptsDir string) (*nativeAssets, error) {
+	regularPath := findFont(os.Getenv("DOCGEN_FONT"), "DejaVuSans.ttf")
+	if regularPath == "" {
+		return nil, errors.New("шрифт не найден: задайте DOCGEN_FONT или положите DejaVuSans.ttf в scripts/fonts")
+	}
+	a := &nativeAssets{}
+	var err error
+	if a.regularTTF, err = os.ReadFile(regularPath); err != nil {
+		return nil, err
+	}
+	if a.regular, err = opentype.Parse(a.regularTTF); err != nil {
+		return nil, fmt.Errorf("шрифт %s: %v", regularPath, err)
+	}
+
+	a.boldTTF, a.bold = a.regularTTF, a.regular
+	if boldPath := findFont(os.Getenv("DOCGEN_FONT_BOLD"), "DejaVuSans-Bold.ttf"); boldPath != "" {
+		if data, err := os.ReadFile(boldPath); err == nil {
+			if f, err := opentype.Parse(data); err == nil {
+				a.boldTTF, a.bold = data, f
+			}
+		}
+	}
+
+	stampData, err := os.ReadFile(filepath.Join(scriptsDir, "stamp.png"))
+	if err != nil {
+		return nil, fmt.Errorf("файл печати не найден: %v", err)
+	}
+	if a.stamp, err = newDocImage("stamp", stampData, false); err != nil {
+		return nil, fmt.Errorf("файл печати: %v", err)
+	}
+
+	if logoData := templateLogo(filepath.Join(scriptsDir, "template.docx")); logoData != nil {
+		a.logo, _ = newDocImage("logo", logoData, false)
+	}
+	return a, nil
+}
+
+// Fonts - TTF обычного и жирного начертания (DOCGEN_FONT, DOCGEN_FONT_BOLD или scripts/fonts)
+// для других PDF-документов с кириллицей. Без жирного шрифта возвращается обычный
+func Fonts() (regular, bold []byte, err error) {
+	regularPath := findFont(os.Getenv("DOCGEN_FONT"), "DejaVuSans.ttf")
+	if regularPath == "" {
+		return nil, nil, errors.New("шрифт не найден: задайте DOCGEN_FONT или положите DejaVuSans.ttf в scripts/fonts")
+	}
+	if regular, err = os.ReadFile(regularPath); err != nil {
+		return nil, nil, err
+	}
+	bold = regular
+	if boldPath := findFont(os.Getenv("DOCGEN_FONT_BOLD"), "DejaVuSans-Bold.ttf"); boldPath != "" {
+		if data, err := os.ReadFile(boldPath); err == nil {
+			bold = data
+		}
+	}
+	return regular, bold, nil
+}
+
+func findFont(explicit, name string) string {
+	if explicit != "" {
+		if _, err := os.Stat(explicit); err == nil {
+			return explicit
+		}
+		return ""
+	}
+	for _, dir := range fontDirs {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// templateLogo достаёт логотип из шапки DOCX-шаблона
+func templateLogo(path string) []byte {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.Name != "word/media/image1.png" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil
+		}
+		defer rc.Close()
+		data, _ := io.ReadAll(rc)
+		return data
+	}
+	return nil
+}
+
+// newDocImage декодирует изображение. Фото уменьшаются и сохраняются в JPEG на белом фоне,
+// остальные картинки (печать, логотип) - в PNG с прозрачностью
+func newDocImage(name string, data []byte, photo bool) (*docImage, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if !photo {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		return &docImage{name: name, kind: "PNG", data: buf.Bytes(), img: img}, nil
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if longest := max(w, h); longest > photoMaxPixels {
+		w = w * photoMaxPixels / longest
+		h = h * photoMaxPixels / longest
+	}
+	rgb := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(rgb, rgb.Bounds(), image.White, image.Point{}, draw.Src)
+	xdraw.ApproxBiLinear.Scale(rgb, rgb.Bounds(), img, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, rgb, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return &docImage{name: name, kind: "JPG", data: buf.Bytes(), img: rgb}, nil
+}
+
+// decodePhoto - фото приходит как data URL (data:image/jpeg;base64,...) или чистый base64
+func decodePhoto(s string) ([]byte, error) {
+	if i := strings.Index(s, ","); i >= 0 && strings.HasPrefix(s, "data:") {
+		s = s[i+1:]
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func (g *NativeGenerator) Generate(ctx context.Context, doc *Document) (*Result, error) {
+	assets, err := g.load()
+	if err != nil {
+		return nil, err
+	}
+
+	pages := layoutAct(doc, assets)
+
+	pdfData, err := renderPDF(pages, assets)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка формирования PDF: %v", err)
+	}
+
+	var previews [][]byte
+	for _, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := renderPNG(page, assets)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка формирования превью: %v", err)
+		}
+		previews = append(previews, data)
+	}
+
+	base, err := documentBaseName(doc)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при выборе имени файла: %v", err)
+	}
+	return &Result{
+		PDFName:     base + ".pdf",
+		PreviewName: base + ".png",
+		PDF:         pdfData,
+		Preview:     previews[0],
+		Pages:       previews,
+	}, nil
+}
+
+// layoutAct раскладывает акт по образцу template.docx
+func layoutAct(doc *Document, assets *nativeAssets) []*docPage {
+	l := newActLayout(newTextMetrics(assets.regular, assets.bold))
+	regular := cellStyle{size: baseFontSize}
+	bold := cellStyle{size: baseFontSize, bold: true}
+
+	// Шапка: логотип и заголовок
+	titleH := l.m.lineHeight(titleSize)
+	headerH := titleH
+	if assets.logo != nil {
+		headerH = math.Max(headerH, logoHeight)
+	}
+	top := l.y
+	l.hline(top)
+	if assets.logo != nil {
+		b := assets.logo.img.Bounds()
+		w := logoHeight * float64(b.Dx()) / float64(b.Dy())
+		l.add(drawOp{kind: opImage, x: pageMargin + cellPadding, y: top + cellPadding, w: w, h: logoHeight, img: assets.logo})
+	}
+	title := "Акт выполненных работ"
+	if doc.ActNumber != "" {
+		title += " № " + doc.ActNumber
+	}
+	l.add(drawOp{
+		kind: opText,
+		x:    pageMargin + (contentWidth-l.m.width(title, titleSize, true))/2,
+		y:    top + cellPadding + (headerH-titleH)/2 + titleSize*mmPerPt,
+		text: title, size: titleSize, bold: true,
+	})
+	l.y = top + headerH + 2*cellPadding
+	l.vlines(top, l.y, []float64{contentWidth})
+	l.hline(l.y)
+
+	l.row(
+		[]string{"Дата ТО: " + doc.Date, "Объект: " + doc.Address},
+		[]float64{contentWidth / 2, contentWidth / 2},
+		[]cellStyle{regular, regular},
+	)
+
+	if len(doc.EquipmentItems) > 0 {
+		widths := []float64{10, contentWidth - 10 - 55 - 20, 55, 20}
+		headerStyles := []cellStyle{bold, bold, bold, bold}
+		l.row([]string{"№", "Название оборудования", "Номер оборудования", "Кол-во"}, widths, headerStyles)
+		for i, item := range doc.EquipmentItems {
+			quantity := item.Quantity
+			if quantity < 1 {
+				quantity = 1
+			}
+			l.row(
+				[]string{fmt.Sprint(i + 1), item.Name, item.Number, fmt.Sprint(quantity)},
+				widths,
+				[]cellStyle{regular, regular, regular, {size: baseFontSize, center: true}},
+			)
+		}
+	} else {
+		l.labelRow("Название оборудования:", doc.MachineName)
+		l.labelRow("Номер оборудования:", doc.MachineNumber)
+	}
+	l.labelRow("Инвентаризационный номер:", doc.InventoryNumber)
+
+	classification := doc.ClassificationName
+	if classification == "" {
+		classification = doc.Classification
+	}
+	l.labelRow("Классификация работ:", classification)
+
+	var works []string
+	for _, item := range doc.ChecklistItems {
+		if item.Done {
+			works = append(works, "• "+item.Task)
+		}
+	}
+	l.labelRow("Описание проведенных работ:", strings.Join(works, "\n"))
+
+	l.fullRow("Фото фиксация:", bold)
+	for i, photo := range doc.Photos {
+		data, err := decodePhoto(photo)
+		if err != nil {
+			continue
+		}
+		img, err := newDocImage(fmt.Sprintf("photo%d", i), data, true)
+		if err != nil {
+			continue
+		}
+		l.imageRow(img, contentWidth-2*cellPadding, photoMaxH)
+	}
+
+	l.labelRow("Материалы применяемые при ТО:", doc.Material)
+	l.labelRow("Дополнительные проведенные работы:", doc.AdditionalWorks)
+	l.labelRow("Рекомендации:", doc.Recommendations)
+	l.labelRow("Комментарии:", doc.Comments)
+	l.labelRow("Выявленные дефекты при ТО:", doc.Defects)
+	l.fullRow("ФИО/должность", bold)
+	if signatureFor(doc, "engineer") == nil {
+		l.labelRow("Работы произвел:", strings.TrimSpace(doc.LastName+" "+doc.FirstName))
+	}
+	for i, role := range []string{"engineer", "client"} {
+		sig := signatureFor(doc, role)
+		if sig == nil {
+			continue
+		}
+		label := "Работы произвел:"
+		if role == "client" {
+			label = "Представитель заказчика:"
+		}
+		var lines []string
+		for _, line := range []string{sig.SignerName, sig.SignerPosition, "Подписано: " + sig.SignedAt} {
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+		}
+		img, err := newDocImage(fmt.Sprintf("signature%d", i), sig.Image, false)
+		if err != nil {
+			img = nil
+		}
+		l.signatureRow(label, strings.Join(lines, "\n"), img)
+	}
+
+	if doc.VerifyURL != "" {
+		qr, err := newDocImage("verify_qr", doc.VerifyQR, false)
+		if err != nil {
+			qr = nil
+		}
+		l.signatureRow("Проверка подлинности:", "Отсканируйте QR-код или откройте ссылку:\n"+doc.VerifyURL, qr)
+	}
+
+	// Печать поверх первой страницы, в той же области, что и add_stamp_to_pdf (100,10)-(300,210) pt
+	b := assets.stamp.img.Bounds()
+	box := 200 * mmPerPt
+	w, h := box, box*float64(b.Dy())/float64(b.Dx())
+	if h > box {
+		w, h = box*float64(b.Dx())/float64(b.Dy()), box
+	}
+	first := l.pages[0]
+	first.ops = append(first.ops, drawOp{kind: opImage, x: 100 * mmPerPt, y: 10 * mmPerPt, w: w, h: h, img: assets.stamp})
+
+	return l.pages
+}
+
+// signatureFor - подпись с указанной ролью или nil
+func signatureFor(doc *Document, role string) *DocumentSignature {
+	for i := range doc.Signatures {
+		if doc.Signatures[i].Role == role {
+			return &doc.Signatures[i]
+		}
+	}
+	return nil
+}
+
+func renderPDF(pages []*docPage, assets *nativeAssets) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+	pdf.AddUTF8FontFromBytes(pdfFontFamily, "", assets.regularTTF)
+	pdf.AddUTF8FontFromBytes(pdfFontFamily, "B", assets.boldTTF)
+	pdf.SetLineWidth(0.2)
+
+	registered := map[string]bool{}
+	for _, page := range pages {
+		pdf.AddPage()
+		for _, op := range page.ops {
+			switch op.kind {
+			case opText:
+				style := ""
+				if op.bold {
+					style = "B"
+				}
+				pdf.SetFont(pdfFontFamily, style, op.size)
+				pdf.Text(op.x, op.y, op.text)
+			case opLine:
+				pdf.Line(op.x, op.y, op.x2, op.y2)
+			case opImage:
+				opts := fpdf.ImageOptions{ImageType: op.img.kind}
+				if !registered[op.img.name] {
+					pdf.RegisterImageOptionsReader(op.img.name, opts, bytes.NewReader(op.img.data))
+					registered[op.img.name] = true
+				}
+				pdf.ImageOptions(op.img.name, op.x, op.y, op.w, op.h, false, opts, 0, "")
+			}
+		}
+	}
+	if err := pdf.Error(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderPNG рисует страницу в PNG тем же набором операций, что и PDF
+func renderPNG(page *docPage, assets *nativeAssets) ([]byte, error) {
+	scale := previewScale * 72 / 25.4 // пикселей на мм
+	px := func(mm float64) int { return int(math.Round(mm * scale)) }
+
+	img := image.NewRGBA(image.Rect(0, 0, px(pageWidth), px(pageHeight)))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	faces := map[string]font.Face{}
+	faceFor := func(size float64, bold bool) font.Face {
+		key := fmt.Sprint(size, bold)
+		if f, ok := faces[key]; ok {
+			return f
+		}
+		fnt := assets.regular
+		if bold {
+			fnt = assets.bold
+		}
+		f, err := opentype.NewFace(fnt, &opentype.FaceOptions{Size: size, DPI: 72 * previewScale, Hinting: font.HintingFull})
+		if err != nil {
+			return nil
+		}
+		faces[key] = f
+		return f
+	}
+
+	lineColor := image.NewUniform(color.Black)
+	for _, op := range page.ops {
+		switch op.kind {
+		case opText:
+			face := faceFor(op.size, op.bold)
+			if face == nil {
+				continue
+			}
+			d := font.Drawer{
+				Dst:  img,
+				Src:  image.Black,
+				Face: face,
+				Dot:  fixed.Point26_6{X: fixed.Int26_6(op.x * scale * 64), Y: fixed.Int26_6(op.y * scale * 64)},
+			}
+			d.DrawString(op.text)
+		case opLine:
+			r := image.Rect(px(op.x), px(op.y), px(op.x2)+1, px(op.y2)+1)
+			draw.Draw(img, r, lineColor, image.Point{}, draw.Src)
+		case opImage:
+			r := image.Rect(px(op.x), px(op.y), px(op.x+op.w), px(op.y+op.h))
+			xdraw.ApproxBiLinear.Scale(img, r, op.img.img, op.img.img.Bounds(), draw.Over, nil)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
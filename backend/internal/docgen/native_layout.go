@@ -0,0 +1,323 @@
+package docgen
+
+import (
+	"image"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// Размеры страницы и таблицы акта в мм (A4, поля как в template.docx)
+const (
+	pageWidth    = 210.0
+	pageHeight   = 297.0
+	pageMargin   = 12.7
+	contentWidth = pageWidth - 2*pageMargin
+	labelWidth   = 62.0
+	cellPadding  = 1.8
+	baseFontSize = 10.0
+	titleSize    = 16.0
+	lineFactor   = 1.3
+	photoMaxH    = 135.0
+	signatureW   = 50.0
+	signatureH   = 20.0
+	mmPerPt      = 25.4 / 72
+)
+
+type opKind int
+
+const (
+	opText opKind = iota
+	opLine
+	opImage
+)
+
+// drawOp - элементарная операция отрисовки; координаты в мм от левого верхнего угла,
+// для текста Y - базовая линия
+type drawOp struct {
+	kind   opKind
+	x, y   float64
+	x2, y2 float64
+	w, h   float64
+	text   string
+	size   float64
+	bold   bool
+	img    *docImage
+}
+
+type docPage struct {
+	ops []drawOp
+}
+
+// docImage - изображение, подготовленное для PDF (name, data) и растра (img)
+type docImage struct {
+	name string
+	kind string // PNG или JPG
+	data []byte
+	img  image.Image
+}
+
+// textMetrics измеряет ширину строк по тем же шрифтам, что используются при отрисовке
+type textMetrics struct {
+	regular, bold *sfnt.Font
+	faces         map[float64]map[bool]font.Face
+}
+
+func newTextMetrics(regular, bold *sfnt.Font) *textMetrics {
+	return &textMetrics{regular: regular, bold: bold, faces: map[float64]map[bool]font.Face{}}
+}
+
+func (m *textMetrics) face(size float64, bold bool) font.Face {
+	if m.faces[size] == nil {
+		m.faces[size] = map[bool]font.Face{}
+	}
+	if f, ok := m.faces[size][bold]; ok {
+		return f
+	}
+	fnt := m.regular
+	if bold {
+		fnt = m.bold
+	}
+	f, err := opentype.NewFace(fnt, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil
+	}
+	m.faces[size][bold] = f
+	return f
+}
+
+// width - ширина строки в мм
+func (m *textMetrics) width(s string, size float64, bold bool) float64 {
+	f := m.face(size, bold)
+	if f == nil {
+		return float64(len([]rune(s))) * size * 0.5 * mmPerPt
+	}
+	return float64(font.MeasureString(f, s)) / 64 * mmPerPt
+}
+
+// wrap разбивает текст на строки не шире width (мм), сохраняя переводы строк
+func (m *textMetrics) wrap(text string, width, size float64, bold bool) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := ""
+		for _, word := range words {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if m.width(candidate, size, bold) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// Слово длиннее строки режется посимвольно
+			line = ""
+			for _, r := range word {
+				if line != "" && m.width(line+string(r), size, bold) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (m *textMetrics) lineHeight(size float64) float64 {
+	return size * lineFactor * mmPerPt
+}
+
+// actLayout раскладывает акт по страницам
+type actLayout struct {
+	m     *textMetrics
+	pages []*docPage
+	cur   *docPage
+	y     float64
+}
+
+func newActLayout(m *textMetrics) *actLayout {
+	l := &actLayout{m: m}
+	l.newPage()
+	return l
+}
+
+func (l *actLayout) newPage() {
+	l.cur = &docPage{}
+	l.pages = append(l.pages, l.cur)
+	l.y = pageMargin
+}
+
+func (l *actLayout) bottom() float64 {
+	return pageHeight - pageMargin
+}
+
+func (l *actLayout) add(op drawOp) {
+	l.cur.ops = append(l.cur.ops, op)
+}
+
+func (l *actLayout) hline(y float64) {
+	l.add(drawOp{kind: opLine, x: pageMargin, y: y, x2: pageMargin + contentWidth, y2: y})
+}
+
+func (l *actLayout) vlines(top, bottom float64, widths []float64) {
+	x := pageMargin
+	l.add(drawOp{kind: opLine, x: x, y: top, x2: x, y2: bottom})
+	for _, w := range widths {
+		x += w
+		l.add(drawOp{kind: opLine, x: x, y: top, x2: x, y2: bottom})
+	}
+}
+
+type cellStyle struct {
+	bold   bool
+	size   float64
+	center bool
+}
+
+// row - строка таблицы из ячеек заданной ширины; при нехватке места
+// продолжается на следующей странице
+func (l *actLayout) row(cells []string, widths []float64, styles []cellStyle) {
+	wrapped := make([][]string, len(cells))
+	maxLines := 0
+	lineH := 0.0
+	for i, text := range cells {
+		st := styles[i]
+		wrapped[i] = l.m.wrap(text, widths[i]-2*cellPadding, st.size, st.bold)
+		if len(wrapped[i]) > maxLines {
+			maxLines = len(wrapped[i])
+		}
+		if h := l.m.lineHeight(st.size); h > lineH {
+			lineH = h
+		}
+	}
+
+	if l.y+lineH+2*cellPadding > l.bottom() {
+		l.newPage()
+	}
+	top := l.y
+	l.hline(top)
+	l.y += cellPadding
+
+	for n := 0; n < maxLines; n++ {
+		if l.y+lineH > l.bottom() {
+			l.vlines(top, l.y, widths)
+			l.hline(l.y)
+			l.newPage()
+			top = l.y
+			l.hline(top)
+			l.y += cellPadding
+		}
+		x := pageMargin
+		for i, lines := range wrapped {
+			if n < len(lines) && lines[n] != "" {
+				st := styles[i]
+				tx := x + cellPadding
+				if st.center {
+					tx = x + (widths[i]-l.m.width(lines[n], st.size, st.bold))/2
+				}
+				l.add(drawOp{kind: opText, x: tx, y: l.y + st.size*mmPerPt, text: lines[n], size: st.size, bold: st.bold})
+			}
+			x += widths[i]
+		}
+		l.y += lineH
+	}
+
+	l.y += cellPadding
+	l.vlines(top, l.y, widths)
+	l.hline(l.y)
+}
+
+// labelRow - строка «подпись | значение», как в шаблоне акта
+func (l *actLayout) labelRow(label, value string) {
+	l.row(
+		[]string{label, value},
+		[]float64{labelWidth, contentWidth - labelWidth},
+		[]cellStyle{{bold: true, size: baseFontSize}, {size: baseFontSize}},
+	)
+}
+
+// fullRow - строка на всю ширину таблицы
+func (l *actLayout) fullRow(text string, st cellStyle) {
+	l.row([]string{text}, []float64{contentWidth}, []cellStyle{st})
+}
+
+// imageRow - изображение по центру строки на всю ширину, с переносом на новую страницу
+func (l *actLayout) imageRow(img *docImage, maxW, maxH float64) {
+	b := img.img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return
+	}
+	w := maxW
+	h := w * float64(b.Dy()) / float64(b.Dx())
+	if h > maxH {
+		h = maxH
+		w = h * float64(b.Dx()) / float64(b.Dy())
+	}
+	if l.y+h+2*cellPadding > l.bottom() {
+		l.newPage()
+		l.hline(l.y)
+	}
+	top := l.y
+	l.y += cellPadding
+	l.add(drawOp{kind: opImage, x: pageMargin + (contentWidth-w)/2, y: l.y, w: w, h: h, img: img})
+	l.y += h + cellPadding
+	l.vlines(top, l.y, []float64{contentWidth})
+	l.hline(l.y)
+}
+
+// signatureRow - строка «подпись | ФИО, должность, время» с изображением подписи
+// справа в ячейке значения. Строка не разрывается между страницами
+func (l *actLayout) signatureRow(label, value string, img *docImage) {
+	valueW := contentWidth - labelWidth
+	textW := valueW - 2*cellPadding
+	if img != nil {
+		textW -= signatureW + cellPadding
+	}
+	lineH := l.m.lineHeight(baseFontSize)
+	labelLines := l.m.wrap(label, labelWidth-2*cellPadding, baseFontSize, true)
+	valueLines := l.m.wrap(value, textW, baseFontSize, false)
+	h := float64(max(len(labelLines), len(valueLines))) * lineH
+	if img != nil {
+		h = math.Max(h, signatureH)
+	}
+
+	if l.y+h+2*cellPadding > l.bottom() {
+		l.newPage()
+	}
+	top := l.y
+	l.hline(top)
+	l.y += cellPadding
+	for i, line := range labelLines {
+		l.add(drawOp{kind: opText, x: pageMargin + cellPadding, y: l.y + float64(i)*lineH + baseFontSize*mmPerPt, text: line, size: baseFontSize, bold: true})
+	}
+	for i, line := range valueLines {
+		l.add(drawOp{kind: opText, x: pageMargin + labelWidth + cellPadding, y: l.y + float64(i)*lineH + baseFontSize*mmPerPt, text: line, size: baseFontSize})
+	}
+	if img != nil {
+		if b := img.img.Bounds(); b.Dx() > 0 && b.Dy() > 0 {
+			w := signatureW
+			ih := w * float64(b.Dy()) / float64(b.Dx())
+			if ih > signatureH {
+				ih = signatureH
+				w = ih * float64(b.Dx()) / float64(b.Dy())
+			}
+			x := pageMargin + contentWidth - cellPadding - signatureW + (signatureW-w)/2
+			l.add(drawOp{kind: opImage, x: x, y: l.y + (h-ih)/2, w: w, h: ih, img: img})
+		}
+	}
+	l.y += h + cellPadding
+	l.vlines(top, l.y, []float64{labelWidth, valueW})
+	l.hline(l.y)
+}
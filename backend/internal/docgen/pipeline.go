@@ -0,0 +1,187 @@
+package docgen
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"backend/internal/db"
+	"backend/internal/storage"
+)
+
+var (
+	reportsDir  = filepath.Join("uploads", "reports")
+	previewsDir = filepath.Join("uploads", "previews")
+)
+
+// documentBaseName повторяет схему имён document_generator_core.py и занимает имя,
+// создавая пустой PDF с O_EXCL: одновременные задачи не получат одно имя, файл затем перезаписывает Store
+func documentBaseName(doc *Document) (string, error) {
+	base := fmt.Sprintf("Акт выполненных работ %s %s", strings.ReplaceAll(doc.Date, ":", "."), doc.Address)
+	base = strings.NewReplacer("/", "_", "\\", "_").Replace(strings.TrimSpace(base))
+	if err := os.MkdirAll(reportsDir, 0755); err != nil {
+		return "", err
+	}
+	name := base
+	for counter := 1; ; counter++ {
+		if _, err := os.Stat(filepath.Join(previewsDir, name+".png")); os.IsNotExist(err) {
+			f, err := os.OpenFile(filepath.Join(reportsDir, name+".pdf"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if err == nil {
+				f.Close()
+				return name, nil
+			}
+			if !os.IsExist(err) {
+				return "", err
+			}
+		}
+		name = fmt.Sprintf("%s (%d)", base, counter)
+	}
+}
+
+// Store - общая обработка результата любого генератора: сохраняет содержимое
+// в uploads/, а при включённом S3 выгружает PDF, превью и страницы превью
+// под уникальными именами и удаляет локальные копии. Возвращает итоговые имена PDF и превью
+func Store(ctx context.Context, res *Result) (string, string, error) {
+	if res.Stored {
+		return res.PDFName, res.PreviewName, nil
+	}
+
+	pdfName := filepath.Base(res.PDFName)
+	previewName := ""
+	if res.PreviewName != "" {
+		previewName = filepath.Base(res.PreviewName)
+	}
+	previewBase := strings.TrimSuffix(previewName, filepath.Ext(previewName))
+
+	if res.PDF != nil {
+		if err := writeLocal(reportsDir, pdfName, res.PDF); err != nil {
+			return "", "", fmt.Errorf("ошибка при сохранении PDF: %v", err)
+		}
+	}
+	if res.Preview != nil && previewName != "" {
+		if err := writeLocal(previewsDir, previewName, res.Preview); err != nil {
+			log.Printf("docgen: ошибка при сохранении превью: %v", err)
+		}
+	}
+	for i, page := range res.Pages {
+		if previewName == "" {
+			break
+		}
+		if err := writeLocal(previewsDir, fmt.Sprintf("%s_page_%d.png", previewBase, i+1), page); err != nil {
+			log.Printf("docgen: ошибка при сохранении страницы превью: %v", err)
+		}
+	}
+
+	pdfPath := filepath.Join(reportsDir, pdfName)
+	if _, err := os.Stat(pdfPath); err != nil {
+		return "", "", fmt.Errorf("сгенерированный файл не найден: %v", err)
+	}
+
+	if !storage.IsS3Enabled() {
+		return pdfName, previewName, nil
+	}
+
+	uniquePDF, err := reserveObjectName(ctx, "reports/", pdfName)
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка при выборе имени PDF в хранилище: %v", err)
+	}
+	if err := uploadLocal(ctx, pdfPath, "reports/"+uniquePDF, "application/pdf"); err != nil {
+		releaseObjectName("reports/" + uniquePDF)
+		return "", "", fmt.Errorf("ошибка при загрузке PDF в хранилище: %v", err)
+	}
+	pdfName = uniquePDF
+
+	if previewName == "" {
+		return pdfName, "", nil
+	}
+	previewPath := filepath.Join(previewsDir, previewName)
+	if _, err := os.Stat(previewPath); err != nil {
+		return pdfName, previewName, nil
+	}
+	uniquePreview, err := reserveObjectName(ctx, "previews/", previewName)
+	if err != nil {
+		log.Printf("docgen: ошибка при выборе имени превью в хранилище: %v", err)
+		return pdfName, "", nil
+	}
+	if err := uploadLocal(ctx, previewPath, "previews/"+uniquePreview, "image/png"); err != nil {
+		releaseObjectName("previews/" + uniquePreview)
+		log.Printf("docgen: ошибка при загрузке превью в хранилище: %v", err)
+		return pdfName, "", nil
+	}
+
+	// Страницы превью (_page_1.png, _page_2.png, ...) следуют за именем первой страницы
+	uniqueBase := strings.TrimSuffix(uniquePreview, filepath.Ext(uniquePreview))
+	for pageNum := 1; ; pageNum++ {
+		pagePath := filepath.Join(previewsDir, fmt.Sprintf("%s_page_%d.png", previewBase, pageNum))
+		if _, err := os.Stat(pagePath); err != nil {
+			break
+		}
+		pageKey := fmt.Sprintf("previews/%s_page_%d.png", uniqueBase, pageNum)
+		if err := uploadLocal(ctx, pagePath, pageKey, "image/png"); err != nil {
+			log.Printf("docgen: ошибка при загрузке страницы превью %d: %v", pageNum, err)
+		}
+	}
+
+	return pdfName, uniquePreview, nil
+}
+
+// reserveObjectName занимает свободное имя объекта prefix+name в S3 (name(1).ext, name(2).ext, ...).
+// Имя записывается в stored_objects до выгрузки: одновременные задачи, в том числе
+// на разных серверах, не выберут одно имя между проверкой и загрузкой
+func reserveObjectName(ctx context.Context, prefix, name string) (string, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for counter := 1; ; counter++ {
+		if !storage.ObjectExists(ctx, prefix, candidate) {
+			res := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&db.StoredObject{
+				ObjectKey: prefix + candidate,
+				CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+			})
+			if res.Error != nil {
+				return "", res.Error
+			}
+			if res.RowsAffected > 0 {
+				return candidate, nil
+			}
+		}
+		candidate = fmt.Sprintf("%s(%d)%s", base, counter, ext)
+	}
+}
+
+// releaseObjectName освобождает имя, объект под которым так и не был выгружен
+func releaseObjectName(key string) {
+	db.DB.Where("object_key = ?", key).Delete(&db.StoredObject{})
+}
+
+func writeLocal(dir, name string, content []byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name), content, 0644)
+}
+
+// uploadLocal выгружает локальный файл в S3 и удаляет его
+func uploadLocal(ctx context.Context, path, key, contentType string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = storage.UploadReportObject(ctx, key, f, info.Size(), contentType)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -1,6 +1,8 @@
 package equipment
 
 import (
+	"backend/internal/audit"
+	"backend/internal/classifications"
 	"backend/internal/db"
 	"net/http"
 	"strings"
@@ -51,12 +53,20 @@ func AddEquipment(c *gin.Context) {
 		return
 	}
 
+	audit.SetEntity(c, "equipment", equipment.ID)
+	audit.SetAfter(c, equipment)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Оборудование успешно добавлено", "equipment": equipment})
 }
 
 func DeleteEquipment(c *gin.Context) {
 	id := c.Param("id")
 
+	var existing db.Equipment
+	if err := db.DB.First(&existing, id).Error; err == nil {
+		audit.SetBefore(c, existing)
+	}
+
 	if err := db.DB.Delete(&db.Equipment{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении оборудования"})
 		return
@@ -74,11 +84,8 @@ func GetEquipmentMemory(c *gin.Context) {
 		return
 	}
 
-	// Преобразуем "Аварийный вызов" в "АВ" для поиска в БД
-	dbClassification := classification
-	if classification == "Аварийный вызов" {
-		dbClassification = "АВ"
-	}
+	// Псевдонимы («Аварийный вызов») хранятся в БД под кодом классификации
+	dbClassification := classifications.Normalize(classification)
 
 	var equipmentMemoryList []db.EquipmentMemory
 	if err := db.DB.Where("address = ? AND classification = ?", address, dbClassification).Find(&equipmentMemoryList).Error; err != nil {
@@ -115,11 +122,7 @@ func SaveEquipmentMemory(c *gin.Context) {
 		return
 	}
 
-	// Преобразуем "Аварийный вызов" в "АВ" для сохранения в БД
-	dbClassification := input.Classification
-	if input.Classification == "Аварийный вызов" {
-		dbClassification = "АВ"
-	}
+	dbClassification := classifications.Normalize(input.Classification)
 
 	// Удаляем все старые записи для этого адреса и классификации
 	db.DB.Where("address = ? AND classification = ?", input.Address, dbClassification).Delete(&db.EquipmentMemory{})
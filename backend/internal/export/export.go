@@ -0,0 +1,264 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"backend/internal/db"
+)
+
+// Kind - тип значения колонки, от него зависит формат ячейки
+type Kind int
+
+const (
+	Text     Kind = iota
+	Number        // число (в CSV - с десятичной запятой)
+	Date          // дата YYYY-MM-DD
+	DateTime      // дата и время YYYY-MM-DD HH:MM:SS
+)
+
+// Column - колонка выгрузки: ключ для параметра columns, русский заголовок и значение строки
+type Column[T any] struct {
+	Key    string
+	Header string
+	Kind   Kind
+	Width  float64
+	Value  func(*T) interface{}
+}
+
+// flushEvery - через сколько строк CSV сбрасывается клиенту
+const flushEvery = 500
+
+// Select - колонки из списка ключей через запятую в указанном порядке; пустой список - все
+func Select[T any](all []Column[T], keys string) ([]Column[T], error) {
+	if strings.TrimSpace(keys) == "" {
+		return all, nil
+	}
+	byKey := make(map[string]Column[T], len(all))
+	for _, col := range all {
+		byKey[col.Key] = col
+	}
+	var selected []Column[T]
+	for _, key := range strings.Split(keys, ",") {
+		col, ok := byKey[strings.TrimSpace(key)]
+		if !ok {
+			return nil, fmt.Errorf("неизвестная колонка %q", strings.TrimSpace(key))
+		}
+		selected = append(selected, col)
+	}
+	return selected, nil
+}
+
+// Stream выгружает результат запроса query в XLSX (format=xlsx, по умолчанию) или CSV
+// (format=csv) с колонками из параметра columns. Строки читаются курсором по одной:
+// CSV пишется прямо в ответ, XLSX - потоковой записью excelize, которая держит
+// в памяти только текущую строку, а остальное сбрасывает во временный файл
+func Stream[T any](c *gin.Context, query *gorm.DB, all []Column[T], name string) {
+	format := c.DefaultQuery("format", "xlsx")
+	if format != "xlsx" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестный формат: допустимы xlsx и csv"})
+		return
+	}
+	columns, err := Select(all, c.Query("columns"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при выгрузке данных"})
+		return
+	}
+	defer rows.Close()
+
+	next := func() (*T, bool) {
+		for rows.Next() {
+			var item T
+			if err := db.DB.ScanRows(rows, &item); err != nil {
+				log.Printf("export %s: %v", name, err)
+				continue
+			}
+			return &item, true
+		}
+		return nil, false
+	}
+
+	filename := fmt.Sprintf("%s_%s.%s", name, time.Now().Format("2006-01-02"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(filename)))
+	if format == "csv" {
+		err = writeCSV(c, columns, next)
+	} else {
+		err = writeXLSX(c, columns, next)
+	}
+	if err != nil {
+		// Заголовки уже отправлены - остаётся только записать ошибку в лог
+		log.Printf("export %s: %v", name, err)
+	}
+}
+
+func writeCSV[T any](c *gin.Context, columns []Column[T], next func() (*T, bool)) error {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	// BOM и точка с запятой - чтобы русский Excel открыл файл без мастера импорта
+	if _, err := c.Writer.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	w := csv.NewWriter(c.Writer)
+	w.Comma = ';'
+
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		record[i] = col.Header
+	}
+	if err := w.Write(record); err != nil {
+		return err
+	}
+	for n := 1; ; n++ {
+		item, ok := next()
+		if !ok {
+			break
+		}
+		for i, col := range columns {
+			record[i] = csvValue(col.Kind, col.Value(item))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		if n%flushEvery == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeXLSX[T any](c *gin.Context, columns []Column[T], next func() (*T, bool)) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Выгрузка"
+	f.SetSheetName("Sheet1", sheet)
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	bold, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	dateFmt, dateTimeFmt := "dd.mm.yyyy", "dd.mm.yyyy hh:mm"
+	dateStyle, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFmt})
+	dateTimeStyle, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &dateTimeFmt})
+
+	header := make([]interface{}, len(columns))
+	for i, col := range columns {
+		width := col.Width
+		if width == 0 {
+			width = 18
+		}
+		if err := sw.SetColWidth(i+1, i+1, width); err != nil {
+			return err
+		}
+		header[i] = excelize.Cell{StyleID: bold, Value: col.Header}
+	}
+	if err := sw.SetRow("A1", header, excelize.RowOpts{Height: 18}); err != nil {
+		return err
+	}
+
+	row := make([]interface{}, len(columns))
+	for n := 2; ; n++ {
+		item, ok := next()
+		if !ok {
+			break
+		}
+		for i, col := range columns {
+			value := col.Value(item)
+			switch col.Kind {
+			case Date, DateTime:
+				if t, ok := parseTime(value); ok {
+					style := dateStyle
+					if col.Kind == DateTime {
+						style = dateTimeStyle
+					}
+					value = excelize.Cell{StyleID: style, Value: t}
+				}
+			}
+			row[i] = value
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, n)
+		if err := sw.SetRow(cell, row); err != nil {
+			return err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Status(http.StatusOK)
+	return f.Write(c.Writer)
+}
+
+// parseTime - дата из строкового поля модели; пустые и неразборчивые значения остаются текстом
+func parseTime(value interface{}) (time.Time, bool) {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func csvValue(kind Kind, value interface{}) string {
+	switch kind {
+	case Date, DateTime:
+		if t, ok := parseTime(value); ok {
+			if kind == Date {
+				return t.Format("02.01.2006")
+			}
+			return t.Format("02.01.2006 15:04")
+		}
+	case Number:
+		switch v := value.(type) {
+		case float64:
+			return strings.Replace(strconv.FormatFloat(v, 'f', -1, 64), ".", ",", 1)
+		}
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// UserNames - имя пользователя по ID с кэшем на время одной выгрузки
+func UserNames() func(id uint) string {
+	cache := map[uint]string{}
+	return func(id uint) string {
+		if id == 0 {
+			return ""
+		}
+		if name, ok := cache[id]; ok {
+			return name
+		}
+		var user db.User
+		name := fmt.Sprintf("#%d", id)
+		if err := db.DB.Select("id, first_name, last_name").First(&user, id).Error; err == nil {
+			name = strings.TrimSpace(user.LastName + " " + user.FirstName)
+		}
+		cache[id] = name
+		return name
+	}
+}
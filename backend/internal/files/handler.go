@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -251,3 +252,52 @@ func SearchFiles(c *gin.Context) {
 
 	c.JSON(http.StatusOK, filenames)
 }
+
+// GetFileTags - теги сертификатов (имя файла -> теги через запятую)
+func GetFileTags(c *gin.Context) {
+	var files []db.File
+	if err := db.DB.Where("tags IS NOT NULL AND tags <> ''").Find(&files).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении тегов"})
+		return
+	}
+
+	tags := make(map[string]string, len(files))
+	for _, cert := range files {
+		tags[cert.Filename] = cert.Tags
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// UpdateFileTags - замена тегов сертификата; теги участвуют в полнотекстовом поиске
+func UpdateFileTags(c *gin.Context) {
+	var request struct {
+		Filename string   `json:"filename"`
+		Tags     []string `json:"tags"`
+	}
+	if err := c.BindJSON(&request); err != nil || request.Filename == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат запроса"})
+		return
+	}
+
+	tags := make([]string, 0, len(request.Tags))
+	seen := make(map[string]bool)
+	for _, tag := range request.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		tags = append(tags, tag)
+	}
+
+	result := db.DB.Model(&db.File{}).Where("filename = ?", request.Filename).Update("tags", strings.Join(tags, ", "))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при сохранении тегов"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Файл не найден"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Теги сохранены", "tags": tags})
+}
@@ -0,0 +1,162 @@
+package integrity
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+)
+
+// Максимальный размер файла, присылаемого на проверку
+const maxVerifyFileSize = 50 << 20
+
+type publicSignature struct {
+	Role           string `json:"role"`
+	SignerName     string `json:"signerName"`
+	SignerPosition string `json:"signerPosition"`
+	SignedAt       string `json:"signedAt"`
+}
+
+// findByToken загружает отчёт по токену проверки
+func findByToken(c *gin.Context) (*db.Report, bool) {
+	token := c.Param("token")
+	var report db.Report
+	if token == "" || db.DB.Where("verify_token = ?", token).First(&report).Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Акт не найден"})
+		return nil, false
+	}
+	return &report, true
+}
+
+// PublicVerify - публичная проверка подлинности акта по токену из QR-кода:
+// метаданные акта, записанный хеш, подпись сервера и результат последней перепроверки
+func PublicVerify(c *gin.Context) {
+	report, ok := findByToken(c)
+	if !ok {
+		return
+	}
+
+	signed := report.SealSignature != ""
+	signatureValid := signed && VerifySignature(report.VerifyToken, report.SHA256, report.SealSignature)
+	approved := report.Status == db.ReportApproved
+	intact := report.SHA256 != "" && report.IntegrityStatus != db.IntegrityMismatch && report.IntegrityStatus != db.IntegrityMissing
+
+	var rows []db.ReportSignature
+	db.DB.Where("report_id = ?", report.ID).Order("id").Find(&rows)
+	signatures := make([]publicSignature, 0, len(rows))
+	for _, row := range rows {
+		signatures = append(signatures, publicSignature{
+			Role:           row.Role,
+			SignerName:     row.SignerName,
+			SignerPosition: row.SignerPosition,
+			SignedAt:       row.SignedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":    approved && intact && (!signed || signatureValid),
+		"approved": approved,
+		"report": gin.H{
+			"filename":       report.Filename,
+			"actNumber":      report.ActNumber,
+			"date":           report.Date,
+			"address":        report.Address,
+			"classification": report.Classification,
+			"version":        report.Version,
+			"status":         report.Status,
+		},
+		"sha256":             report.SHA256,
+		"sealedAt":           report.SealedAt,
+		"signed":             signed,
+		"signatureValid":     signatureValid,
+		"algorithm":          SealAlgorithm,
+		"publicKey":          PublicKey(),
+		"integrityStatus":    report.IntegrityStatus,
+		"integrityCheckedAt": report.IntegrityCheckedAt,
+		"signatures":         signatures,
+	})
+}
+
+// PublicVerifyFile - сверка присланного PDF (multipart: file) с записанным хешем акта.
+// Если файл совпадает с одной из прежних версий, сообщает её номер
+func PublicVerifyFile(c *gin.Context) {
+	report, ok := findByToken(c)
+	if !ok {
+		return
+	}
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не удалось получить файл"})
+		return
+	}
+	defer file.Close()
+
+	hash, err := HashReader(io.LimitReader(file, maxVerifyFileSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка при чтении файла"})
+		return
+	}
+
+	resp := gin.H{"sha256": hash, "matches": hash == report.SHA256, "current": hash == report.SHA256}
+	if hash != report.SHA256 {
+		var content db.ReportContent
+		if err := db.DB.Where("report_id = ? AND sha256 = ?", report.ID, hash).First(&content).Error; err == nil {
+			resp["matches"] = true
+			resp["version"] = content.Version
+		}
+	} else {
+		resp["version"] = report.Version
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetPublicKey - открытый ключ, которым проверяются подписи хешей
+func GetPublicKey(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"algorithm": SealAlgorithm, "publicKey": PublicKey()})
+}
+
+// GetIntegrityStatus - итоги последней перепроверки и отчёты с изменёнными или отсутствующими файлами
+func GetIntegrityStatus(c *gin.Context) {
+	type statusCount struct {
+		IntegrityStatus string `json:"status"`
+		Count           int64  `json:"count"`
+	}
+	var counts []statusCount
+	db.DB.Model(&db.Report{}).
+		Select("COALESCE(integrity_status, '') AS integrity_status, COUNT(*) AS count").
+		Group("COALESCE(integrity_status, '')").
+		Scan(&counts)
+
+	var problems []db.Report
+	if err := db.DB.Where("integrity_status IN ?", []string{db.IntegrityMismatch, db.IntegrityMissing}).
+		Order("id DESC").Limit(200).Find(&problems).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении отчетов"})
+		return
+	}
+
+	summary, running := LastSummary()
+	c.JSON(http.StatusOK, gin.H{
+		"running":   running,
+		"last":      summary,
+		"counts":    counts,
+		"problems":  problems,
+		"signing":   PublicKey() != "",
+		"publicKey": PublicKey(),
+	})
+}
+
+// StartIntegrityCheck - запуск перепроверки всех файлов отчётов в фоне
+func StartIntegrityCheck(c *gin.Context) {
+	if _, running := LastSummary(); running {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrVerificationRunning.Error()})
+		return
+	}
+	go func() {
+		_, _ = RunVerification(context.Background())
+	}()
+	c.JSON(http.StatusAccepted, gin.H{"message": "Перепроверка запущена"})
+}
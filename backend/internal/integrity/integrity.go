@@ -0,0 +1,184 @@
+package integrity
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+
+	"backend/internal/db"
+	"backend/internal/storage"
+)
+
+// Алгоритм подписи, которым запечатываются хеши отчётов
+const SealAlgorithm = "Ed25519"
+
+var reportsDir = filepath.Join("uploads", "reports")
+
+// NewToken - случайный токен публичной проверки акта
+func NewToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// VerifyURL - адрес страницы проверки акта, который попадает в QR-код.
+// Базовый адрес - PUBLIC_BASE_URL
+func VerifyURL(token string) string {
+	base := os.Getenv("PUBLIC_BASE_URL")
+	if base == "" {
+		base = "https://crmlite-vv.ru"
+	}
+	return strings.TrimRight(base, "/") + "/verify/" + token
+}
+
+// QRCode - PNG с QR-кодом ссылки на проверку
+func QRCode(url string) ([]byte, error) {
+	return qrcode.Encode(url, qrcode.Medium, 256)
+}
+
+// HashReader считает SHA-256 потока
+func HashReader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashFile считает SHA-256 файла отчёта в хранилище (S3 reports/ или uploads/reports)
+func HashFile(ctx context.Context, filename string) (string, error) {
+	if storage.IsS3Enabled() {
+		obj, _, err := storage.GetReportObject(ctx, "reports/"+filename)
+		if err == nil {
+			defer obj.Close()
+			return HashReader(obj)
+		}
+	}
+	f, err := os.Open(filepath.Join(reportsDir, filepath.Base(filename)))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return HashReader(f)
+}
+
+var (
+	signingKey     ed25519.PrivateKey
+	signingKeyOnce sync.Once
+)
+
+// key - ключ подписи из REPORT_SIGNING_KEY (base64 seed 32 байта или ключ 64 байта)
+// или файла REPORT_SIGNING_KEY_FILE. Без ключа хеши не подписываются
+func key() ed25519.PrivateKey {
+	signingKeyOnce.Do(func() {
+		raw := strings.TrimSpace(os.Getenv("REPORT_SIGNING_KEY"))
+		if path := os.Getenv("REPORT_SIGNING_KEY_FILE"); raw == "" && path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				log.Printf("integrity: не удалось прочитать ключ подписи: %v", err)
+				return
+			}
+			raw = strings.TrimSpace(string(data))
+		}
+		if raw == "" {
+			return
+		}
+		decoded, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			log.Printf("integrity: ключ подписи должен быть в base64: %v", err)
+			return
+		}
+		switch len(decoded) {
+		case ed25519.SeedSize:
+			signingKey = ed25519.NewKeyFromSeed(decoded)
+		case ed25519.PrivateKeySize:
+			signingKey = ed25519.PrivateKey(decoded)
+		default:
+			log.Printf("integrity: неверная длина ключа подписи: %d байт", len(decoded))
+		}
+	})
+	return signingKey
+}
+
+// PublicKey - открытый ключ подписи в base64, пустая строка - подпись отключена
+func PublicKey() string {
+	k := key()
+	if k == nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(k.Public().(ed25519.PublicKey))
+}
+
+// sealMessage - подписываемое сообщение: токен проверки и хеш файла
+func sealMessage(token, hash string) []byte {
+	return []byte("crm-report-seal:v1\n" + token + "\n" + hash)
+}
+
+// Sign подписывает хеш файла отчёта. Без ключа возвращает пустую строку
+func Sign(token, hash string) string {
+	k := key()
+	if k == nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(k, sealMessage(token, hash)))
+}
+
+// VerifySignature проверяет подпись хеша текущим ключом сервера
+func VerifySignature(token, hash, signature string) bool {
+	k := key()
+	if k == nil || signature == "" {
+		return false
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(k.Public().(ed25519.PublicKey), sealMessage(token, hash), sig)
+}
+
+// Seal считает SHA-256 файла отчёта в хранилище, подписывает его и сохраняет в отчёте
+// и в версии данных с тем же файлом. Токен проверки создаётся, если его ещё нет
+func Seal(ctx context.Context, report *db.Report) error {
+	if report.VerifyToken == "" {
+		report.VerifyToken = NewToken()
+	}
+	hash, err := HashFile(ctx, report.Filename)
+	if err != nil {
+		return err
+	}
+	now := time.Now().Format("2006-01-02 15:04:05")
+	report.SHA256 = hash
+	report.SealSignature = Sign(report.VerifyToken, hash)
+	report.SealedAt = now
+	report.IntegrityStatus = db.IntegrityOK
+	report.IntegrityCheckedAt = now
+
+	err = db.DB.Model(report).Updates(map[string]interface{}{
+		"sha256":               report.SHA256,
+		"seal_signature":       report.SealSignature,
+		"sealed_at":            report.SealedAt,
+		"verify_token":         report.VerifyToken,
+		"integrity_status":     report.IntegrityStatus,
+		"integrity_checked_at": report.IntegrityCheckedAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return db.DB.Model(&db.ReportContent{}).
+		Where("report_id = ? AND filename = ?", report.ID, report.Filename).
+		Update("sha256", hash).Error
+}
@@ -0,0 +1,142 @@
+package integrity
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend/internal/db"
+)
+
+// ErrVerificationRunning - перепроверка уже выполняется
+var ErrVerificationRunning = errors.New("перепроверка уже выполняется")
+
+// Summary - итоги перепроверки файлов отчётов
+type Summary struct {
+	StartedAt  string `json:"startedAt"`
+	FinishedAt string `json:"finishedAt"`
+	Checked    int    `json:"checked"`
+	OK         int    `json:"ok"`
+	Mismatch   int    `json:"mismatch"`
+	Missing    int    `json:"missing"`
+	Sealed     int    `json:"sealed"` // отчёты без хеша, запечатанные при проверке
+}
+
+var (
+	verifyMu    sync.Mutex
+	verifying   bool
+	lastSummary *Summary
+)
+
+// StartVerifier запускает периодическую перепроверку (INTEGRITY_VERIFY_INTERVAL, по умолчанию 24h;
+// значение 0 отключает)
+func StartVerifier() {
+	interval := 24 * time.Hour
+	if raw := os.Getenv("INTEGRITY_VERIFY_INTERVAL"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Printf("integrity: неверный INTEGRITY_VERIFY_INTERVAL %q: %v", raw, err)
+		} else {
+			interval = d
+		}
+	}
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			time.Sleep(interval)
+			if _, err := RunVerification(context.Background()); err != nil {
+				log.Printf("integrity: перепроверка: %v", err)
+			}
+		}
+	}()
+}
+
+// LastSummary - итоги последней завершённой перепроверки и признак выполнения
+func LastSummary() (*Summary, bool) {
+	verifyMu.Lock()
+	defer verifyMu.Unlock()
+	return lastSummary, verifying
+}
+
+// RunVerification пересчитывает хеши всех файлов отчётов в хранилище и сравнивает
+// с записанными. Отчёты без хеша (загруженные до запечатывания) запечатываются
+func RunVerification(ctx context.Context) (*Summary, error) {
+	verifyMu.Lock()
+	if verifying {
+		verifyMu.Unlock()
+		return nil, ErrVerificationRunning
+	}
+	verifying = true
+	verifyMu.Unlock()
+	defer func() {
+		verifyMu.Lock()
+		verifying = false
+		verifyMu.Unlock()
+	}()
+
+	summary := &Summary{StartedAt: time.Now().Format("2006-01-02 15:04:05")}
+	var batch []db.Report
+	err := db.DB.Order("id").FindInBatches(&batch, 100, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			verifyReport(ctx, &batch[i], summary)
+		}
+		return nil
+	}).Error
+	summary.FinishedAt = time.Now().Format("2006-01-02 15:04:05")
+
+	log.Printf("integrity: проверено %d, совпадает %d, изменено %d, нет файла %d, запечатано %d",
+		summary.Checked, summary.OK, summary.Mismatch, summary.Missing, summary.Sealed)
+	verifyMu.Lock()
+	lastSummary = summary
+	verifyMu.Unlock()
+	return summary, err
+}
+
+func verifyReport(ctx context.Context, report *db.Report, summary *Summary) {
+	summary.Checked++
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	if report.SHA256 == "" {
+		if err := Seal(ctx, report); err == nil {
+			summary.Sealed++
+			summary.OK++
+			return
+		}
+		summary.Missing++
+		db.DB.Model(report).Updates(map[string]interface{}{
+			"integrity_status":     db.IntegrityMissing,
+			"integrity_checked_at": now,
+		})
+		return
+	}
+
+	status := db.IntegrityOK
+	hash, err := HashFile(ctx, report.Filename)
+	switch {
+	case err != nil:
+		status = db.IntegrityMissing
+		summary.Missing++
+		log.Printf("integrity: файл отчёта %d (%s) недоступен: %v", report.ID, report.Filename, err)
+	case hash != report.SHA256:
+		status = db.IntegrityMismatch
+		summary.Mismatch++
+		log.Printf("integrity: файл отчёта %d (%s) изменён: ожидался %s, получен %s", report.ID, report.Filename, report.SHA256, hash)
+	default:
+		summary.OK++
+	}
+	db.DB.Model(report).Updates(map[string]interface{}{
+		"integrity_status":     status,
+		"integrity_checked_at": now,
+	})
+}
@@ -0,0 +1,93 @@
+package organizations
+
+import (
+	"gorm.io/gorm"
+
+	"backend/internal/db"
+)
+
+// Роли клиента в организации
+const (
+	RoleViewer    = "viewer"    // только просмотр заявок и актов
+	RoleRequester = "requester" // просмотр и создание заявок
+	RoleOrgAdmin  = "org_admin" // всё выше + управление участниками
+)
+
+// IsValidRole проверяет, что роль входит в список допустимых
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleViewer, RoleRequester, RoleOrgAdmin:
+		return true
+	}
+	return false
+}
+
+// LoadClient загружает клиента по ID из контекста авторизации
+func LoadClient(clientID interface{}) (*db.Client, error) {
+	var client db.Client
+	if err := db.DB.First(&client, clientID).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// CanCreateTickets - может ли клиент оставлять заявки (наблюдатели не могут)
+func CanCreateTickets(client *db.Client) bool {
+	return client.OrganizationID == nil || client.OrgRole != RoleViewer
+}
+
+// IsOrgAdmin - является ли клиент администратором своей организации
+func IsOrgAdmin(client *db.Client) bool {
+	return client.OrganizationID != nil && client.OrgRole == RoleOrgAdmin
+}
+
+// ScopeTickets ограничивает запрос к client_tickets заявками, видимыми клиенту:
+// свои заявки, а для участника организации - заявки всех участников
+// и все заявки по адресам организации
+func ScopeTickets(tx *gorm.DB, client *db.Client) *gorm.DB {
+	if client.OrganizationID == nil {
+		return tx.Where("client_tickets.client_id = ?", client.ID)
+	}
+	orgID := *client.OrganizationID
+	return tx.Where(
+		"(client_tickets.client_id = ? OR client_tickets.client_id IN (?) OR client_tickets.address IN (?))",
+		client.ID,
+		db.DB.Model(&db.Client{}).Select("id").Where("organization_id = ?", orgID),
+		db.DB.Model(&db.Address{}).Select("address").Where("organization_id = ?", orgID),
+	)
+}
+
+// OrganizationAddresses возвращает адреса, принадлежащие организации клиента
+func OrganizationAddresses(client *db.Client) []string {
+	var addresses []string
+	if client.OrganizationID == nil {
+		return addresses
+	}
+	db.DB.Model(&db.Address{}).Where("organization_id = ?", *client.OrganizationID).Pluck("address", &addresses)
+	return addresses
+}
+
+// ScopeReports ограничивает запрос к reports согласованными отчётами, доступными клиенту:
+// привязанными к видимым заявкам, по адресам видимых заявок и по адресам организации
+func ScopeReports(tx *gorm.DB, client *db.Client) *gorm.DB {
+	tx = tx.Where("reports.status = ?", db.ReportApproved)
+
+	linked := ScopeTickets(db.DB.Model(&db.TicketReport{}).
+		Select("ticket_reports.report_id").
+		Joins("JOIN client_tickets ON client_tickets.id = ticket_reports.ticket_id"), client)
+	ticketAddresses := ScopeTickets(db.DB.Model(&db.ClientTicket{}).Select("client_tickets.address"), client)
+
+	if client.OrganizationID == nil {
+		return tx.Where("(reports.id IN (?) OR reports.address IN (?))", linked, ticketAddresses)
+	}
+	orgAddresses := db.DB.Model(&db.Address{}).Select("address").Where("organization_id = ?", *client.OrganizationID)
+	return tx.Where("(reports.id IN (?) OR reports.address IN (?) OR reports.address IN (?))", linked, ticketAddresses, orgAddresses)
+}
+
+// CanAccessReport проверяет доступ клиента к отчёту: отчёт привязан к видимой
+// заявке, по адресу отчёта есть видимая заявка или адрес принадлежит организации
+func CanAccessReport(client *db.Client, report *db.Report) bool {
+	var count int64
+	ScopeReports(db.DB.Model(&db.Report{}).Where("reports.id = ?", report.ID), client).Count(&count)
+	return count > 0
+}
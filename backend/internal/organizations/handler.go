@@ -0,0 +1,318 @@
+package organizations
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/audit"
+	"backend/internal/db"
+)
+
+// MemberInfo - участник организации без служебных полей
+type MemberInfo struct {
+	ID       uint   `json:"id"`
+	FullName string `json:"fullName"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Position string `json:"position"`
+	OrgRole  string `json:"orgRole"`
+}
+
+// Members возвращает участников организации
+func Members(orgID uint) []MemberInfo {
+	var clients []db.Client
+	db.DB.Where("organization_id = ?", orgID).Order("full_name").Find(&clients)
+	members := make([]MemberInfo, 0, len(clients))
+	for _, cl := range clients {
+		members = append(members, MemberInfo{
+			ID:       cl.ID,
+			FullName: cl.FullName,
+			Email:    cl.Email,
+			Phone:    cl.Phone,
+			Position: cl.Position,
+			OrgRole:  cl.OrgRole,
+		})
+	}
+	return members
+}
+
+// GetOrganizations - список организаций клиентов с количеством участников и адресов
+func GetOrganizations(c *gin.Context) {
+	type orgWithCounts struct {
+		db.ClientOrganization
+		MembersCount   int64 `json:"membersCount"`
+		AddressesCount int64 `json:"addressesCount"`
+	}
+
+	var orgs []db.ClientOrganization
+	query := db.DB.Order("name")
+	if search := c.Query("search"); search != "" {
+		query = query.Where("name ILIKE ? OR inn ILIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+	if err := query.Find(&orgs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении организаций"})
+		return
+	}
+
+	result := make([]orgWithCounts, 0, len(orgs))
+	for _, org := range orgs {
+		item := orgWithCounts{ClientOrganization: org}
+		db.DB.Model(&db.Client{}).Where("organization_id = ?", org.ID).Count(&item.MembersCount)
+		db.DB.Model(&db.Address{}).Where("organization_id = ?", org.ID).Count(&item.AddressesCount)
+		result = append(result, item)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetOrganization - карточка организации с участниками и адресами
+func GetOrganization(c *gin.Context) {
+	var org db.ClientOrganization
+	if err := db.DB.First(&org, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Организация не найдена"})
+		return
+	}
+
+	var addresses []db.Address
+	db.DB.Where("organization_id = ?", org.ID).Order("address").Find(&addresses)
+
+	c.JSON(http.StatusOK, gin.H{
+		"organization": org,
+		"members":      Members(org.ID),
+		"addresses":    addresses,
+	})
+}
+
+// CreateOrganization - создание организации клиента
+func CreateOrganization(c *gin.Context) {
+	var input struct {
+		Name        string `json:"name" binding:"required"`
+		INN         string `json:"inn"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+
+	name := strings.TrimSpace(input.Name)
+	var existing db.ClientOrganization
+	if err := db.DB.Where("name = ?", name).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Организация с таким названием уже существует"})
+		return
+	}
+
+	org := db.ClientOrganization{
+		Name:        name,
+		INN:         strings.TrimSpace(input.INN),
+		Description: strings.TrimSpace(input.Description),
+		CreatedAt:   time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := db.DB.Create(&org).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании организации"})
+		return
+	}
+	audit.SetEntity(c, "client-organizations", org.ID)
+	audit.SetAfter(c, org)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Организация создана", "organization": org})
+}
+
+// UpdateOrganization - изменение реквизитов организации
+func UpdateOrganization(c *gin.Context) {
+	var org db.ClientOrganization
+	if err := db.DB.First(&org, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Организация не найдена"})
+		return
+	}
+
+	var input struct {
+		Name        string `json:"name"`
+		INN         string `json:"inn"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	audit.SetBefore(c, org)
+
+	if name := strings.TrimSpace(input.Name); name != "" {
+		org.Name = name
+	}
+	org.INN = strings.TrimSpace(input.INN)
+	org.Description = strings.TrimSpace(input.Description)
+
+	if err := db.DB.Save(&org).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении организации"})
+		return
+	}
+	audit.SetAfter(c, org)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Организация обновлена", "organization": org})
+}
+
+// DeleteOrganization - удаление организации. Адреса и клиенты остаются, но отвязываются
+func DeleteOrganization(c *gin.Context) {
+	var org db.ClientOrganization
+	if err := db.DB.First(&org, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Организация не найдена"})
+		return
+	}
+	audit.SetBefore(c, org)
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&db.Address{}).Where("organization_id = ?", org.ID).
+			Update("organization_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&db.Client{}).Where("organization_id = ?", org.ID).
+			Updates(map[string]interface{}{"organization_id": nil, "org_role": nil}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&org).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении организации"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Организация удалена"})
+}
+
+// AssignAddresses - привязка адресов к организации
+func AssignAddresses(c *gin.Context) {
+	var org db.ClientOrganization
+	if err := db.DB.First(&org, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Организация не найдена"})
+		return
+	}
+
+	var input struct {
+		AddressIDs []uint `json:"addressIds" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || len(input.AddressIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указаны адреса"})
+		return
+	}
+
+	if err := db.DB.Model(&db.Address{}).Where("id IN ?", input.AddressIDs).
+		Update("organization_id", org.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при привязке адресов"})
+		return
+	}
+	audit.SetAfter(c, gin.H{"organizationId": org.ID, "addressIds": input.AddressIDs})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Адреса привязаны к организации"})
+}
+
+// UnassignAddress - отвязка адреса от организации
+func UnassignAddress(c *gin.Context) {
+	result := db.DB.Model(&db.Address{}).
+		Where("id = ? AND organization_id = ?", c.Param("addressId"), c.Param("id")).
+		Update("organization_id", nil)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при отвязке адреса"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Адрес не привязан к этой организации"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Адрес отвязан от организации"})
+}
+
+// AddMember - добавление клиента в организацию с ролью
+func AddMember(c *gin.Context) {
+	var org db.ClientOrganization
+	if err := db.DB.First(&org, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Организация не найдена"})
+		return
+	}
+
+	var input struct {
+		ClientID uint   `json:"clientId" binding:"required"`
+		Role     string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	if input.Role == "" {
+		input.Role = RoleRequester
+	}
+	if !IsValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестная роль"})
+		return
+	}
+
+	var client db.Client
+	if err := db.DB.First(&client, input.ClientID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Клиент не найден"})
+		return
+	}
+	if client.OrganizationID != nil && *client.OrganizationID != org.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Клиент уже состоит в другой организации"})
+		return
+	}
+
+	if err := db.DB.Model(&client).Updates(map[string]interface{}{
+		"organization_id": org.ID,
+		"org_role":        input.Role,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при добавлении участника"})
+		return
+	}
+	audit.SetAfter(c, gin.H{"organizationId": org.ID, "clientId": client.ID, "role": input.Role})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Клиент добавлен в организацию"})
+}
+
+// UpdateMemberRole - смена роли участника организации
+func UpdateMemberRole(c *gin.Context) {
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || !IsValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестная роль"})
+		return
+	}
+
+	var client db.Client
+	if err := db.DB.Where("id = ? AND organization_id = ?", c.Param("clientId"), c.Param("id")).
+		First(&client).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Участник не найден"})
+		return
+	}
+	audit.SetBefore(c, gin.H{"clientId": client.ID, "role": client.OrgRole})
+
+	if err := db.DB.Model(&client).Update("org_role", input.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при смене роли"})
+		return
+	}
+	audit.SetAfter(c, gin.H{"clientId": client.ID, "role": input.Role})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Роль участника обновлена"})
+}
+
+// RemoveMember - исключение клиента из организации
+func RemoveMember(c *gin.Context) {
+	result := db.DB.Model(&db.Client{}).
+		Where("id = ? AND organization_id = ?", c.Param("clientId"), c.Param("id")).
+		Updates(map[string]interface{}{"organization_id": nil, "org_role": nil})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при исключении участника"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Участник не найден"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Клиент исключен из организации"})
+}
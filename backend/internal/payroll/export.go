@@ -0,0 +1,146 @@
+package payroll
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+
+	"backend/internal/db"
+	"backend/internal/docgen"
+)
+
+var statusLabels = map[string]string{
+	StatusDraft:    "Черновик",
+	StatusApproved: "Утверждена",
+}
+
+// kpiRows - показатели KPI ведомости в порядке вывода
+func kpiRows(s *db.PayrollStatement) [][2]string {
+	return [][2]string{
+		{"Согласованных актов", fmt.Sprint(s.Reports)},
+		{"Заявок", fmt.Sprint(s.Tickets)},
+		{"Заявок в SLA", fmt.Sprint(s.SLAHits)},
+		{"Нарушений SLA", fmt.Sprint(s.SLABreaches)},
+		{"Пробег, км", formatAmount(s.Km)},
+	}
+}
+
+func formatAmount(v float64) string {
+	return strings.Replace(fmt.Sprintf("%.2f", v), ".", ",", 1)
+}
+
+func statementTitle(s *db.PayrollStatement, engineer string) string {
+	return fmt.Sprintf("Расчётная ведомость за %s: %s", s.Period, engineer)
+}
+
+// ExportXLSX - ведомость с показателями и строками начислений в XLSX
+func ExportXLSX(s *db.PayrollStatement, engineer string) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Ведомость"
+	f.SetSheetName("Sheet1", sheet)
+
+	bold, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	money, _ := f.NewStyle(&excelize.Style{NumFmt: 4})
+	boldMoney, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}, NumFmt: 4})
+
+	f.SetCellValue(sheet, "A1", statementTitle(s, engineer))
+	f.SetCellStyle(sheet, "A1", "A1", bold)
+	f.SetCellValue(sheet, "A2", "Статус: "+statusLabels[s.Status])
+	if s.ApprovedAt != "" {
+		f.SetCellValue(sheet, "C2", "Утверждена: "+s.ApprovedAt)
+	}
+
+	row := 4
+	for _, kpi := range kpiRows(s) {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), kpi[0])
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), kpi[1])
+		row++
+	}
+
+	row++
+	header := row
+	f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &[]interface{}{"№", "Начисление", "Количество", "Ставка", "Сумма"})
+	f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("E%d", row), bold)
+	for _, line := range s.Lines {
+		row++
+		f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &[]interface{}{line.Position, line.Description, line.Quantity, line.Rate, line.Amount})
+	}
+	row++
+	f.SetCellValue(sheet, fmt.Sprintf("B%d", row), "Итого")
+	f.SetCellValue(sheet, fmt.Sprintf("E%d", row), s.Total)
+	f.SetCellStyle(sheet, fmt.Sprintf("D%d", header+1), fmt.Sprintf("E%d", row-1), money)
+	f.SetCellStyle(sheet, fmt.Sprintf("B%d", row), fmt.Sprintf("B%d", row), bold)
+	f.SetCellStyle(sheet, fmt.Sprintf("E%d", row), fmt.Sprintf("E%d", row), boldMoney)
+
+	f.SetColWidth(sheet, "A", "A", 6)
+	f.SetColWidth(sheet, "B", "B", 48)
+	f.SetColWidth(sheet, "C", "E", 14)
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportPDF - ведомость в PDF (шрифты DejaVu, как у нативного генератора актов)
+func ExportPDF(s *db.PayrollStatement, engineer string) ([]byte, error) {
+	regular, bold, err := docgen.Fonts()
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes("dejavu", "", regular)
+	pdf.AddUTF8FontFromBytes("dejavu", "B", bold)
+	pdf.SetMargins(15, 15, 15)
+	pdf.AddPage()
+
+	pdf.SetFont("dejavu", "B", 13)
+	pdf.MultiCell(0, 7, statementTitle(s, engineer), "", "L", false)
+	pdf.SetFont("dejavu", "", 10)
+	status := "Статус: " + statusLabels[s.Status]
+	if s.ApprovedAt != "" {
+		status += ", утверждена " + s.ApprovedAt
+	}
+	pdf.CellFormat(0, 6, status, "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	for _, kpi := range kpiRows(s) {
+		pdf.CellFormat(60, 6, kpi[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, kpi[1], "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	widths := []float64{10, 90, 25, 25, 30}
+	pdf.SetFont("dejavu", "B", 10)
+	for i, title := range []string{"№", "Начисление", "Кол-во", "Ставка", "Сумма"} {
+		pdf.CellFormat(widths[i], 7, title, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("dejavu", "", 9)
+	for _, line := range s.Lines {
+		description := line.Description
+		if len([]rune(description)) > 55 {
+			description = string([]rune(description)[:54]) + "…"
+		}
+		pdf.CellFormat(widths[0], 6, fmt.Sprint(line.Position), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[1], 6, description, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, formatAmount(line.Quantity), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 6, formatAmount(line.Rate), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, formatAmount(line.Amount), "1", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("dejavu", "B", 10)
+	pdf.CellFormat(widths[0]+widths[1]+widths[2]+widths[3], 7, "Итого", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[4], 7, formatAmount(s.Total), "1", 1, "R", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
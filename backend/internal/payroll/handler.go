@@ -0,0 +1,395 @@
+package payroll
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/audit"
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/users"
+)
+
+func currentUser(c *gin.Context) (*db.User, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return nil, false
+	}
+	var user db.User
+	if err := db.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не найден"})
+		return nil, false
+	}
+	return &user, true
+}
+
+func engineerName(userID uint) string {
+	var user db.User
+	if err := db.DB.Select("id, first_name, last_name").First(&user, userID).Error; err != nil {
+		return fmt.Sprintf("#%d", userID)
+	}
+	return strings.TrimSpace(user.LastName + " " + user.FirstName)
+}
+
+// locked - утверждена ли ведомость инженера за период
+func locked(userID uint, period string) bool {
+	var count int64
+	db.DB.Model(&db.PayrollStatement{}).
+		Where("user_id = ? AND period = ? AND status = ?", userID, period, StatusApproved).Count(&count)
+	return count > 0
+}
+
+// GetRates - ставки расчёта зарплаты
+func GetRates(c *gin.Context) {
+	var list []db.PayRate
+	if err := db.DB.Order("kind, classification, user_id NULLS FIRST, id").Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении ставок"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+type rateInput struct {
+	Kind           string  `json:"kind" binding:"required"`
+	Classification string  `json:"classification"`
+	UserID         *uint   `json:"userId"`
+	Amount         float64 `json:"amount"`
+}
+
+func (input rateInput) valid() bool {
+	switch input.Kind {
+	case RateAct, RateKm, RateSLABreach:
+		return input.Amount >= 0
+	}
+	return false
+}
+
+func (input rateInput) apply(rate *db.PayRate) {
+	rate.Kind = input.Kind
+	rate.Classification = ""
+	if input.Kind == RateAct {
+		rate.Classification = classifications.Normalize(input.Classification)
+	}
+	rate.UserID = input.UserID
+	rate.Amount = input.Amount
+}
+
+// CreateRate - добавление ставки
+func CreateRate(c *gin.Context) {
+	var input rateInput
+	if err := c.ShouldBindJSON(&input); err != nil || !input.valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	rate := db.PayRate{CreatedAt: time.Now().Format("2006-01-02 15:04:05")}
+	input.apply(&rate)
+	if err := db.DB.Create(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при добавлении ставки"})
+		return
+	}
+	audit.SetEntity(c, "pay-rates", rate.ID)
+	audit.SetAfter(c, rate)
+	c.JSON(http.StatusOK, gin.H{"message": "Ставка добавлена", "rate": rate})
+}
+
+// UpdateRate - изменение ставки. Утверждённые ведомости не пересчитываются
+func UpdateRate(c *gin.Context) {
+	var rate db.PayRate
+	if err := db.DB.First(&rate, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ставка не найдена"})
+		return
+	}
+	var input rateInput
+	if err := c.ShouldBindJSON(&input); err != nil || !input.valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	audit.SetBefore(c, rate)
+	input.apply(&rate)
+	if err := db.DB.Save(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении ставки"})
+		return
+	}
+	audit.SetEntity(c, "pay-rates", rate.ID)
+	audit.SetAfter(c, rate)
+	c.JSON(http.StatusOK, gin.H{"message": "Ставка обновлена", "rate": rate})
+}
+
+// DeleteRate - удаление ставки
+func DeleteRate(c *gin.Context) {
+	var rate db.PayRate
+	if err := db.DB.First(&rate, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ставка не найдена"})
+		return
+	}
+	audit.SetEntity(c, "pay-rates", rate.ID)
+	audit.SetBefore(c, rate)
+	if err := db.DB.Delete(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении ставки"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Ставка удалена"})
+}
+
+// GetAdjustments - премии и удержания. Фильтры: period, userId
+func GetAdjustments(c *gin.Context) {
+	query := db.DB.Order("period DESC, id")
+	if period := c.Query("period"); period != "" {
+		query = query.Where("period = ?", period)
+	}
+	if userID := c.Query("userId"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	var list []db.PayAdjustment
+	if err := query.Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении корректировок"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// CreateAdjustment - премия или удержание инженеру за месяц. В ведомость попадает
+// при следующем пересчёте; к утверждённой ведомости добавить нельзя
+func CreateAdjustment(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		UserID uint    `json:"userId" binding:"required"`
+		Period string  `json:"period" binding:"required"`
+		Kind   string  `json:"kind" binding:"required"`
+		Amount float64 `json:"amount"`
+		Reason string  `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Amount <= 0 ||
+		(input.Kind != AdjustmentBonus && input.Kind != AdjustmentPenalty) || strings.TrimSpace(input.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	if _, _, err := PeriodRange(input.Period); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if locked(input.UserID, input.Period) {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrLocked.Error()})
+		return
+	}
+
+	adjustment := db.PayAdjustment{
+		UserID:    input.UserID,
+		Period:    input.Period,
+		Kind:      input.Kind,
+		Amount:    input.Amount,
+		Reason:    strings.TrimSpace(input.Reason),
+		CreatedBy: user.ID,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := db.DB.Create(&adjustment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при добавлении корректировки"})
+		return
+	}
+	audit.SetEntity(c, "pay-adjustments", adjustment.ID)
+	audit.SetAfter(c, adjustment)
+	c.JSON(http.StatusOK, gin.H{"message": "Корректировка добавлена", "adjustment": adjustment})
+}
+
+// DeleteAdjustment - удаление корректировки, пока ведомость не утверждена
+func DeleteAdjustment(c *gin.Context) {
+	var adjustment db.PayAdjustment
+	if err := db.DB.First(&adjustment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Корректировка не найдена"})
+		return
+	}
+	if locked(adjustment.UserID, adjustment.Period) {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrLocked.Error()})
+		return
+	}
+	audit.SetEntity(c, "pay-adjustments", adjustment.ID)
+	audit.SetBefore(c, adjustment)
+	if err := db.DB.Delete(&adjustment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении корректировки"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Корректировка удалена"})
+}
+
+// CalculateStatements - расчёт черновиков ведомостей за месяц для инженера (userId)
+// или для всех, у кого в месяце была работа. Утверждённые ведомости пропускаются
+func CalculateStatements(c *gin.Context) {
+	var input struct {
+		Period string `json:"period" binding:"required"`
+		UserID *uint  `json:"userId"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	if _, _, err := PeriodRange(input.Period); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := []uint{}
+	if input.UserID != nil {
+		ids = append(ids, *input.UserID)
+	} else {
+		var err error
+		if ids, err = ActiveEngineers(input.Period); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при поиске инженеров за период"})
+			return
+		}
+	}
+
+	calculated := make([]db.PayrollStatement, 0, len(ids))
+	skipped := make([]uint, 0)
+	for _, id := range ids {
+		statement, err := Calculate(id, input.Period)
+		if errors.Is(err, ErrLocked) {
+			skipped = append(skipped, id)
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при расчёте ведомости: " + err.Error()})
+			return
+		}
+		statement.Lines = nil
+		calculated = append(calculated, *statement)
+	}
+	c.JSON(http.StatusOK, gin.H{"statements": calculated, "locked": skipped})
+}
+
+type statementView struct {
+	db.PayrollStatement
+	EngineerName string `json:"engineerName"`
+}
+
+// GetStatements - ведомости за период (period). Инженеры без права согласования
+// видят только свои
+func GetStatements(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	query := db.DB.Order("period DESC, user_id")
+	if period := c.Query("period"); period != "" {
+		query = query.Where("period = ?", period)
+	}
+	if !users.IsReviewer(user) {
+		query = query.Where("user_id = ?", user.ID)
+	}
+	var list []db.PayrollStatement
+	if err := query.Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении ведомостей"})
+		return
+	}
+	result := make([]statementView, 0, len(list))
+	for _, s := range list {
+		result = append(result, statementView{s, engineerName(s.UserID)})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// loadStatement - ведомость со строками с проверкой доступа
+func loadStatement(c *gin.Context) (*db.PayrollStatement, bool) {
+	user, ok := currentUser(c)
+	if !ok {
+		return nil, false
+	}
+	var statement db.PayrollStatement
+	err := db.DB.Preload("Lines", func(tx *gorm.DB) *gorm.DB { return tx.Order("position") }).
+		First(&statement, c.Param("id")).Error
+	if err != nil || (!users.IsReviewer(user) && statement.UserID != user.ID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ведомость не найдена"})
+		return nil, false
+	}
+	return &statement, true
+}
+
+// GetStatement - ведомость со строками начислений
+func GetStatement(c *gin.Context) {
+	statement, ok := loadStatement(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, statementView{*statement, engineerName(statement.UserID)})
+}
+
+// ApproveStatement - утверждение ведомости: после него пересчёт и корректировки
+// за этот месяц недоступны
+func ApproveStatement(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var statement db.PayrollStatement
+	if err := db.DB.First(&statement, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ведомость не найдена"})
+		return
+	}
+	if statement.Status == StatusApproved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ведомость уже утверждена"})
+		return
+	}
+	audit.SetBefore(c, statement)
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	result := db.DB.Model(&db.PayrollStatement{}).
+		Where("id = ? AND status = ?", statement.ID, StatusDraft).
+		Updates(map[string]interface{}{"status": StatusApproved, "approved_by": user.ID, "approved_at": now})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при утверждении ведомости"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ведомость уже утверждена"})
+		return
+	}
+	statement.Status, statement.ApprovedBy, statement.ApprovedAt = StatusApproved, &user.ID, now
+	audit.SetEntity(c, "payroll-statements", statement.ID)
+	audit.SetAfter(c, statement)
+	c.JSON(http.StatusOK, gin.H{"message": "Ведомость утверждена", "statement": statement})
+}
+
+// ExportStatement - выгрузка ведомости: format=xlsx (по умолчанию) или pdf
+func ExportStatement(c *gin.Context) {
+	statement, ok := loadStatement(c)
+	if !ok {
+		return
+	}
+	name := engineerName(statement.UserID)
+
+	var (
+		data        []byte
+		err         error
+		contentType string
+	)
+	format := c.DefaultQuery("format", "xlsx")
+	switch format {
+	case "xlsx":
+		data, err = ExportXLSX(statement, name)
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case "pdf":
+		data, err = ExportPDF(statement, name)
+		contentType = "application/pdf"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестный формат: допустимы xlsx и pdf"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при формировании файла: " + err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("Ведомость_%s_%s.%s", statement.Period, strings.ReplaceAll(name, " ", "_"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(filename)))
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,258 @@
+package payroll
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend/internal/analytics"
+	"backend/internal/classifications"
+	"backend/internal/db"
+)
+
+// Виды ставок (PayRate.Kind)
+const (
+	RateAct       = "act"
+	RateKm        = "km"
+	RateSLABreach = "sla_breach"
+)
+
+// Виды корректировок (PayAdjustment.Kind)
+const (
+	AdjustmentBonus   = "bonus"
+	AdjustmentPenalty = "penalty"
+)
+
+// Статусы ведомости
+const (
+	StatusDraft    = "draft"
+	StatusApproved = "approved"
+)
+
+// Виды строк ведомости (PayrollLine.Kind)
+const (
+	LineAct       = "act"
+	LineKm        = "km"
+	LineSLABreach = "sla_breach"
+	LineBonus     = "bonus"
+	LinePenalty   = "penalty"
+)
+
+// ErrLocked - ведомость уже утверждена
+var ErrLocked = errors.New("ведомость утверждена и не может быть изменена")
+
+// PeriodRange - первый и последний день месяца 2006-01
+func PeriodRange(period string) (string, string, error) {
+	start, err := time.Parse("2006-01", period)
+	if err != nil {
+		return "", "", fmt.Errorf("неверный период %q, ожидается ГГГГ-ММ", period)
+	}
+	return start.Format("2006-01-02"), start.AddDate(0, 1, -1).Format("2006-01-02"), nil
+}
+
+// rates - ставки, применимые к инженеру
+type rates []db.PayRate
+
+// find - самая точная ставка: инженер и классификация, затем классификация для всех,
+// затем ставка инженера для любой классификации, затем общая
+func (list rates) find(kind, classification string) float64 {
+	best, bestScore := 0.0, -1
+	for _, r := range list {
+		if r.Kind != kind || (r.Classification != "" && r.Classification != classification) {
+			continue
+		}
+		score := 0
+		if r.Classification != "" {
+			score += 2
+		}
+		if r.UserID != nil {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = r.Amount, score
+		}
+	}
+	return best
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// statementLines - строки ведомости по актам (строки аналитики по классификациям),
+// пробегу и нарушениям SLA из statement, ставкам и корректировкам; итог пишется в statement.Total
+func statementLines(statement *db.PayrollStatement, byClass []map[string]interface{}, list rates, adjustments []db.PayAdjustment) []db.PayrollLine {
+	var lines []db.PayrollLine
+	add := func(line db.PayrollLine) {
+		line.Position = len(lines) + 1
+		line.Amount = round2(line.Quantity * line.Rate)
+		if line.Kind == LineSLABreach || line.Kind == LinePenalty {
+			line.Amount = -line.Amount
+		}
+		lines = append(lines, line)
+	}
+
+	for _, row := range byClass {
+		code, _ := row[analytics.DimClassification].(string)
+		count := row[analytics.MeasureReports].(float64)
+		rate := list.find(RateAct, code)
+		description := "Акты: " + classifications.Name(code)
+		if c, ok := classifications.Find(code); ok && !c.Billable {
+			rate = 0
+			description += " (не оплачивается)"
+		}
+		add(db.PayrollLine{Kind: LineAct, Classification: code, Description: description, Quantity: count, Rate: rate})
+	}
+	if statement.Km > 0 {
+		add(db.PayrollLine{Kind: LineKm, Description: "Компенсация пробега, км", Quantity: statement.Km, Rate: list.find(RateKm, "")})
+	}
+	if statement.SLABreaches > 0 {
+		if rate := list.find(RateSLABreach, ""); rate > 0 {
+			add(db.PayrollLine{Kind: LineSLABreach, Description: "Нарушения SLA по заявкам", Quantity: float64(statement.SLABreaches), Rate: rate})
+		}
+	}
+	for _, a := range adjustments {
+		kind, description := LineBonus, "Премия: "+a.Reason
+		if a.Kind == AdjustmentPenalty {
+			kind, description = LinePenalty, "Удержание: "+a.Reason
+		}
+		add(db.PayrollLine{Kind: kind, Description: description, Quantity: 1, Rate: a.Amount})
+	}
+	for _, line := range lines {
+		statement.Total += line.Amount
+	}
+	statement.Total = round2(statement.Total)
+	return lines
+}
+
+// Calculate пересчитывает черновик ведомости инженера за месяц: акты по ставкам
+// классификаций (неоплачиваемые - с нулевой ставкой), компенсация пробега, удержания
+// за нарушения SLA и ручные корректировки. Утверждённая ведомость не меняется (ErrLocked)
+func Calculate(userID uint, period string) (*db.PayrollStatement, error) {
+	start, end, err := PeriodRange(period)
+	if err != nil {
+		return nil, err
+	}
+
+	byClass, err := analytics.Run(analytics.Query{
+		Dimensions: []string{analytics.DimClassification},
+		Measures:   []string{analytics.MeasureReports},
+		StartDate:  start,
+		EndDate:    end,
+		EngineerID: &userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	kpi, err := analytics.Run(analytics.Query{
+		Measures:   []string{analytics.MeasureTickets, analytics.MeasureSLAHits, analytics.MeasureSLABreaches, analytics.MeasureKm},
+		StartDate:  start,
+		EndDate:    end,
+		EngineerID: &userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var list rates
+	if err := db.DB.Where("user_id IS NULL OR user_id = ?", userID).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	var adjustments []db.PayAdjustment
+	if err := db.DB.Where("user_id = ? AND period = ?", userID, period).Order("id").Find(&adjustments).Error; err != nil {
+		return nil, err
+	}
+
+	statement := db.PayrollStatement{
+		UserID:       userID,
+		Period:       period,
+		Status:       StatusDraft,
+		Reports:      int64(byClass.Totals[analytics.MeasureReports]),
+		Tickets:      int64(kpi.Totals[analytics.MeasureTickets]),
+		SLAHits:      int64(kpi.Totals[analytics.MeasureSLAHits]),
+		SLABreaches:  int64(kpi.Totals[analytics.MeasureSLABreaches]),
+		Km:           round2(kpi.Totals[analytics.MeasureKm]),
+		CalculatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	lines := statementLines(&statement, byClass.Rows, list, adjustments)
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var current db.PayrollStatement
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND period = ?", userID, period).First(&current).Error
+		switch {
+		case err == nil:
+			if current.Status == StatusApproved {
+				return ErrLocked
+			}
+			statement.ID = current.ID
+			if err := tx.Where("statement_id = ?", current.ID).Delete(&db.PayrollLine{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Omit("Lines").Save(&statement).Error; err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Omit("Lines").Create(&statement).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		for i := range lines {
+			lines[i].StatementID = statement.ID
+		}
+		if len(lines) > 0 {
+			if err := tx.Create(&lines).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	statement.Lines = lines
+	return &statement, nil
+}
+
+// ActiveEngineers - инженеры, у которых в месяце были акты, заявки, пробег или корректировки
+func ActiveEngineers(period string) ([]uint, error) {
+	start, end, err := PeriodRange(period)
+	if err != nil {
+		return nil, err
+	}
+	result, err := analytics.Run(analytics.Query{
+		Dimensions: []string{analytics.DimEngineer},
+		Measures:   []string{analytics.MeasureReports, analytics.MeasureTickets, analytics.MeasureKm},
+		StartDate:  start,
+		EndDate:    end,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[uint]bool{}
+	var ids []uint
+	for _, row := range result.Rows {
+		var id uint
+		if _, err := fmt.Sscan(fmt.Sprint(row[analytics.DimEngineer]), &id); err == nil && id != 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	var adjusted []uint
+	db.DB.Model(&db.PayAdjustment{}).Where("period = ?", period).Distinct().Pluck("user_id", &adjusted)
+	for _, id := range adjusted {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,97 @@
+package payroll
+
+import (
+	"testing"
+
+	"backend/internal/analytics"
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/dbtest"
+)
+
+func TestRatesFindMostSpecific(t *testing.T) {
+	engineer := uint(7)
+	list := rates{
+		{Kind: RateAct, Amount: 1000},
+		{Kind: RateAct, UserID: &engineer, Amount: 1200},
+		{Kind: RateAct, Classification: "АВ", Amount: 2000},
+		{Kind: RateAct, Classification: "АВ", UserID: &engineer, Amount: 2500},
+		{Kind: RateKm, Amount: 8},
+	}
+	tests := []struct {
+		kind, classification string
+		want                 float64
+	}{
+		{RateAct, "АВ", 2500},
+		{RateAct, "ТО", 1200},
+		{RateKm, "", 8},
+		{RateSLABreach, "", 0},
+	}
+	for _, tt := range tests {
+		if got := list.find(tt.kind, tt.classification); got != tt.want {
+			t.Errorf("%s/%s: ставка %v, ожидалась %v", tt.kind, tt.classification, got, tt.want)
+		}
+	}
+}
+
+// Итог ведомости: акты по ставкам (неоплачиваемые по нулевой), пробег, удержания за SLA и корректировки
+func TestStatementLinesTotal(t *testing.T) {
+	dbtest.Open(t)
+	for _, c := range []db.Classification{
+		{Code: "ТО", Name: "ТО", Billable: true},
+		{Code: "АВ", Name: "Аварийный вызов", Billable: true},
+		{Code: "Гарантия", Name: "Гарантия"},
+	} {
+		c.CreatedAt = "2025-01-01 00:00:00"
+		if err := db.DB.Create(&c).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Billable по умолчанию true в схеме - неоплачиваемость задаётся явно
+	db.DB.Model(&db.Classification{}).Where("code = ?", "Гарантия").Update("billable", false)
+	classifications.Invalidate()
+	t.Cleanup(classifications.Invalidate)
+
+	list := rates{
+		{Kind: RateAct, Amount: 1000},
+		{Kind: RateAct, Classification: "АВ", Amount: 2500},
+		{Kind: RateKm, Amount: 8.5},
+		{Kind: RateSLABreach, Amount: 300},
+	}
+	byClass := []map[string]interface{}{
+		{analytics.DimClassification: "ТО", analytics.MeasureReports: float64(10)},
+		{analytics.DimClassification: "АВ", analytics.MeasureReports: float64(2)},
+		{analytics.DimClassification: "Гарантия", analytics.MeasureReports: float64(3)},
+	}
+	adjustments := []db.PayAdjustment{
+		{Kind: AdjustmentBonus, Amount: 5000, Reason: "переработки"},
+		{Kind: AdjustmentPenalty, Amount: 1000, Reason: "опоздание"},
+	}
+	statement := db.PayrollStatement{Km: 120.4, SLABreaches: 2}
+
+	lines := statementLines(&statement, byClass, list, adjustments)
+
+	// 10*1000 + 2*2500 + 3*0 + 120.4*8.5 - 2*300 + 5000 - 1000
+	if want := 19423.4; statement.Total != want {
+		t.Errorf("итог %v, ожидался %v", statement.Total, want)
+	}
+	if len(lines) != 7 {
+		t.Fatalf("строк %d, ожидалось 7: %+v", len(lines), lines)
+	}
+	var sum float64
+	for i, line := range lines {
+		if line.Position != i+1 {
+			t.Errorf("строка %d: позиция %d", i+1, line.Position)
+		}
+		sum += line.Amount
+	}
+	if round2(sum) != statement.Total {
+		t.Errorf("сумма строк %v не совпадает с итогом %v", sum, statement.Total)
+	}
+	if lines[2].Rate != 0 || lines[2].Description != "Акты: Гарантия (не оплачивается)" {
+		t.Errorf("неоплачиваемая классификация: %+v", lines[2])
+	}
+	if lines[4].Kind != LineSLABreach || lines[4].Amount != -600 {
+		t.Errorf("удержание за SLA: %+v", lines[4])
+	}
+}
@@ -0,0 +1,59 @@
+package pdftext
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+)
+
+// GetReportText - извлечённый из PDF текст отчёта и распознанные поля
+func GetReportText(c *gin.Context) {
+	var row db.ReportText
+	if err := db.DB.Where("report_id = ?", c.Param("id")).First(&row).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Текст отчёта не извлекался"})
+		return
+	}
+	c.JSON(http.StatusOK, row)
+}
+
+// GetExtractionStatus - число отчётов по статусам извлечения и последние ошибки
+func GetExtractionStatus(c *gin.Context) {
+	type statusCount struct {
+		Status string `json:"status"`
+		Count  int64  `json:"count"`
+	}
+	var counts []statusCount
+	db.DB.Model(&db.ReportText{}).Select("status, COUNT(*) AS count").Group("status").Scan(&counts)
+
+	var failed []db.ReportText
+	if err := db.DB.Select("id, report_id, status, attempts, error, created_at").
+		Where("status = ?", StatusFailed).Order("id DESC").Limit(100).Find(&failed).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статуса"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"counts": counts, "failed": failed})
+}
+
+// Reindex - повторное извлечение текста: scope=failed (по умолчанию) - только неудачные,
+// scope=all - все загруженные отчёты без исходных данных
+func Reindex(c *gin.Context) {
+	var ids []uint
+	query := db.DB.Model(&db.ReportText{})
+	switch c.DefaultQuery("scope", "failed") {
+	case "failed":
+		query = query.Where("status IN ?", []string{StatusFailed, StatusEmpty})
+	case "all":
+		query = query.Where("status <> ?", StatusRunning)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестная область: допустимы failed и all"})
+		return
+	}
+	if err := query.Pluck("report_id", &ids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при постановке в очередь"})
+		return
+	}
+	Enqueue(ids...)
+	c.JSON(http.StatusAccepted, gin.H{"message": "Отчеты поставлены в очередь", "count": len(ids)})
+}
@@ -0,0 +1,151 @@
+package pdftext
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ledongthuc/pdf"
+
+	"backend/internal/classifications"
+)
+
+// Ограничения на размер разбираемого PDF и сохраняемого текста
+const (
+	maxPDFSize  = 50 << 20
+	maxTextSize = 200000
+)
+
+// Fields - поля акта, распознанные по тексту
+type Fields struct {
+	MachineName     string
+	MachineNumber   string
+	InventoryNumber string
+	Classification  string
+}
+
+// Extract извлекает текст PDF построчно. Библиотека разбора может паниковать
+// на повреждённых файлах - паника превращается в ошибку
+func Extract(data []byte) (text string, pages int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("не удалось разобрать PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", 0, err
+	}
+	plain, err := reader.GetPlainText()
+	if err != nil {
+		return "", 0, err
+	}
+	raw, err := io.ReadAll(io.LimitReader(plain, maxTextSize*4))
+	if err != nil {
+		return "", 0, err
+	}
+	return normalize(string(raw)), reader.NumPage(), nil
+}
+
+var spaces = regexp.MustCompile(`[ \t\f\v\x{00a0}]+`)
+
+// normalize убирает пустые строки, лишние пробелы и управляющие символы
+func normalize(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) && r != '\t' {
+				return -1
+			}
+			return r
+		}, line)
+		line = strings.TrimSpace(spaces.ReplaceAllString(line, " "))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	text = strings.Join(lines, "\n")
+	if r := []rune(text); len(r) > maxTextSize {
+		text = string(r[:maxTextSize])
+	}
+	return text
+}
+
+// Readable - похож ли текст на осмысленный: сканы дают пустой текст,
+// а шрифты без таблицы Unicode - набор случайных символов
+func Readable(text string) bool {
+	letters, total := 0, 0
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		total++
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			letters++
+		}
+	}
+	return total >= 20 && letters*10 >= total*6
+}
+
+// Подписи полей в акте (template.docx и нативный генератор)
+var (
+	machineNameLabels     = []string{"Название оборудования", "Наименование оборудования"}
+	machineNumberLabels   = []string{"Номер оборудования", "Заводской номер", "Серийный номер"}
+	inventoryNumberLabels = []string{"Инвентаризационный номер", "Инвентарный номер", "Инв. номер"}
+	classificationLabels  = []string{"Классификация работ", "Классификация", "Вид работ"}
+)
+
+// Infer распознаёт поля акта по подписям в тексте
+func Infer(text string) Fields {
+	lines := strings.Split(text, "\n")
+	fields := Fields{
+		MachineName:     labelValue(lines, machineNameLabels),
+		MachineNumber:   labelValue(lines, machineNumberLabels),
+		InventoryNumber: labelValue(lines, inventoryNumberLabels),
+	}
+	if value := labelValue(lines, classificationLabels); value != "" {
+		fields.Classification = matchClassification(value)
+	}
+	return fields
+}
+
+// labelValue - значение после подписи в той же строке или, если строка
+// заканчивается подписью, в следующей (ячейки таблицы часто разбиваются по строкам)
+func labelValue(lines []string, labels []string) string {
+	for i, line := range lines {
+		lower := strings.ToLower(line)
+		for _, label := range labels {
+			if !strings.HasPrefix(lower, strings.ToLower(label)) {
+				continue
+			}
+			value := strings.TrimSpace(strings.TrimLeft(line[len(label):], ": "))
+			if value == "" && i+1 < len(lines) && !strings.HasSuffix(lines[i+1], ":") {
+				value = lines[i+1]
+			}
+			if value != "" {
+				return truncate(value, 255)
+			}
+		}
+	}
+	return ""
+}
+
+// matchClassification - код классификации из справочника, с написания которой
+// (код, название или псевдоним) начинается значение
+func matchClassification(value string) string {
+	if c, ok := classifications.MatchPrefix(value); ok {
+		return c.Code
+	}
+	return ""
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
@@ -0,0 +1,194 @@
+package pdftext
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend/internal/db"
+	"backend/internal/storage"
+)
+
+// Статусы извлечения текста
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusEmpty   = "empty" // скан или PDF без текстового слоя
+	StatusFailed  = "failed"
+)
+
+const (
+	maxAttempts  = 3
+	batchSize    = 20
+	pollInterval = time.Minute
+)
+
+var (
+	reportsDir = filepath.Join("uploads", "reports")
+	wake       = make(chan struct{}, 1)
+)
+
+// Enqueue ставит отчёты в очередь извлечения текста (повторно - тоже)
+func Enqueue(reportIDs ...uint) {
+	if len(reportIDs) == 0 {
+		return
+	}
+	now := time.Now().Format("2006-01-02 15:04:05")
+	rows := make([]db.ReportText, 0, len(reportIDs))
+	for _, id := range reportIDs {
+		rows = append(rows, db.ReportText{ReportID: id, Status: StatusPending, CreatedAt: now})
+	}
+	err := db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "report_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"status": StatusPending, "attempts": 0, "error": nil}),
+	}).CreateInBatches(rows, 100).Error
+	if err != nil {
+		log.Printf("pdftext: постановка в очередь: %v", err)
+		return
+	}
+	notify()
+}
+
+func notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// StartExtractor запускает фоновое извлечение текста. При старте в очередь попадают
+// загруженные отчёты без исходных данных и без извлечённого текста, а прерванные
+// перезапуском возвращаются в очередь. REPORT_TEXT_EXTRACTION=off отключает обработку
+func StartExtractor() {
+	if os.Getenv("REPORT_TEXT_EXTRACTION") == "off" {
+		return
+	}
+
+	db.DB.Model(&db.ReportText{}).Where("status = ?", StatusRunning).Update("status", StatusPending)
+	backfill := db.DB.Exec(`INSERT INTO report_texts (report_id, status, attempts, created_at)
+		SELECT r.id, ?, 0, ? FROM reports r
+		WHERE NOT EXISTS (SELECT 1 FROM report_contents rc WHERE rc.report_id = r.id)
+			AND NOT EXISTS (SELECT 1 FROM report_texts rt WHERE rt.report_id = r.id)`,
+		StatusPending, time.Now().Format("2006-01-02 15:04:05"))
+	if backfill.Error != nil {
+		log.Printf("pdftext: поиск загруженных отчётов: %v", backfill.Error)
+	} else if backfill.RowsAffected > 0 {
+		log.Printf("pdftext: в очередь поставлено загруженных отчётов: %d", backfill.RowsAffected)
+	}
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			for processBatch() == batchSize {
+			}
+			select {
+			case <-wake:
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// processBatch обрабатывает очередную порцию отчётов и возвращает их число
+func processBatch() int {
+	var ids []uint
+	db.DB.Model(&db.ReportText{}).Where("status = ?", StatusPending).
+		Order("id").Limit(batchSize).Pluck("id", &ids)
+	for _, id := range ids {
+		process(id)
+	}
+	return len(ids)
+}
+
+func process(id uint) {
+	claim := db.DB.Model(&db.ReportText{}).Where("id = ? AND status = ?", id, StatusPending).
+		Updates(map[string]interface{}{"status": StatusRunning, "attempts": gorm.Expr("attempts + 1")})
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return
+	}
+	var row db.ReportText
+	if err := db.DB.First(&row, id).Error; err != nil {
+		return
+	}
+
+	updates, err := extractReport(row.ReportID)
+	if err != nil {
+		status := StatusPending
+		if row.Attempts >= maxAttempts {
+			status = StatusFailed
+		}
+		log.Printf("pdftext: отчёт %d, попытка %d: %v", row.ReportID, row.Attempts, err)
+		db.DB.Model(&row).Updates(map[string]interface{}{"status": status, "error": err.Error()})
+		return
+	}
+	updates["extracted_at"] = time.Now().Format("2006-01-02 15:04:05")
+	updates["error"] = nil
+	db.DB.Model(&row).Updates(updates)
+}
+
+// extractReport читает PDF отчёта, извлекает текст и распознаёт поля
+func extractReport(reportID uint) (map[string]interface{}, error) {
+	var report db.Report
+	if err := db.DB.First(&report, reportID).Error; err != nil {
+		return nil, err
+	}
+	data, err := readReportFile(context.Background(), report.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	text, pages, err := Extract(data)
+	if err != nil {
+		return nil, err
+	}
+	if !Readable(text) {
+		return map[string]interface{}{"status": StatusEmpty, "text": "", "pages": pages}, nil
+	}
+
+	fields := Infer(text)
+	return map[string]interface{}{
+		"status":           StatusDone,
+		"text":             text,
+		"pages":            pages,
+		"machine_name":     fields.MachineName,
+		"machine_number":   fields.MachineNumber,
+		"inventory_number": fields.InventoryNumber,
+		"classification":   fields.Classification,
+	}, nil
+}
+
+// readReportFile читает PDF отчёта из S3 (reports/) или uploads/reports
+func readReportFile(ctx context.Context, filename string) ([]byte, error) {
+	var r io.ReadCloser
+	if storage.IsS3Enabled() {
+		if obj, _, err := storage.GetReportObject(ctx, "reports/"+filename); err == nil {
+			r = obj
+		}
+	}
+	if r == nil {
+		f, err := os.Open(filepath.Join(reportsDir, filepath.Base(filename)))
+		if err != nil {
+			return nil, err
+		}
+		r = f
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxPDFSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPDFSize {
+		return nil, errors.New("файл слишком большой для извлечения текста")
+	}
+	return data, nil
+}
@@ -0,0 +1,202 @@
+package photos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+// exifInfo - данные EXIF, нужные для фотографии отчёта
+type exifInfo struct {
+	Orientation int
+	TakenAt     string
+	Latitude    *float64
+	Longitude   *float64
+}
+
+// Теги EXIF
+const (
+	tagOrientation      = 0x0112
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagGPSLatitudeRef   = 0x0001
+	tagGPSLatitude      = 0x0002
+	tagGPSLongitudeRef  = 0x0003
+	tagGPSLongitude     = 0x0004
+)
+
+// Типы значений TIFF и их размер в байтах
+var tiffTypeSize = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+// parseEXIF читает EXIF из JPEG: ориентацию, время съёмки и координаты GPS.
+// Повреждённые или отсутствующие данные не считаются ошибкой - поля остаются пустыми
+func parseEXIF(data []byte) exifInfo {
+	info := exifInfo{Orientation: 1}
+	tiff := findEXIF(data)
+	if tiff == nil {
+		return info
+	}
+	t := &tiffReader{data: tiff}
+	switch string(tiff[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return info
+	}
+
+	ifd0 := t.ifd(t.u32(4))
+	if v, ok := ifd0[tagOrientation]; ok {
+		if o := int(t.short(v)); o >= 1 && o <= 8 {
+			info.Orientation = o
+		}
+	}
+	taken := ""
+	if v, ok := ifd0[tagExifIFD]; ok {
+		if v, ok := t.ifd(t.long(v))[tagDateTimeOriginal]; ok {
+			taken = t.ascii(v)
+		}
+	}
+	if v, ok := ifd0[tagDateTime]; ok && taken == "" {
+		taken = t.ascii(v)
+	}
+	if ts, err := time.Parse("2006:01:02 15:04:05", taken); err == nil {
+		info.TakenAt = ts.Format("2006-01-02 15:04:05")
+	}
+
+	if v, ok := ifd0[tagGPSIFD]; ok {
+		gps := t.ifd(t.long(v))
+		info.Latitude = t.coordinate(gps, tagGPSLatitude, tagGPSLatitudeRef, "S")
+		info.Longitude = t.coordinate(gps, tagGPSLongitude, tagGPSLongitudeRef, "W")
+		if info.Latitude == nil || info.Longitude == nil {
+			info.Latitude, info.Longitude = nil, nil
+		}
+	}
+	return info
+}
+
+// findEXIF - данные TIFF из сегмента APP1 "Exif" JPEG-файла
+func findEXIF(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return nil // дальше идут сами данные изображения
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil
+		}
+		segment := data[pos+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) && len(segment) > 14 {
+			return segment[6:]
+		}
+		pos = end
+	}
+	return nil
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// tiffEntry - запись каталога: тип, число значений и смещение значения в data
+type tiffEntry struct {
+	typ    uint16
+	count  uint32
+	offset uint32
+}
+
+func (t *tiffReader) u16(off uint32) uint16 {
+	if uint64(off)+2 > uint64(len(t.data)) {
+		return 0
+	}
+	return t.order.Uint16(t.data[off:])
+}
+
+func (t *tiffReader) u32(off uint32) uint32 {
+	if uint64(off)+4 > uint64(len(t.data)) {
+		return 0
+	}
+	return t.order.Uint32(t.data[off:])
+}
+
+// ifd читает каталог по смещению off
+func (t *tiffReader) ifd(off uint32) map[uint16]tiffEntry {
+	entries := map[uint16]tiffEntry{}
+	if off == 0 {
+		return entries
+	}
+	n := uint32(t.u16(off))
+	for i := uint32(0); i < n; i++ {
+		pos := off + 2 + i*12
+		if uint64(pos)+12 > uint64(len(t.data)) {
+			break
+		}
+		e := tiffEntry{typ: t.u16(pos + 2), count: t.u32(pos + 4), offset: pos + 8}
+		size, ok := tiffTypeSize[e.typ]
+		if !ok {
+			continue
+		}
+		// Значения длиннее 4 байт лежат отдельно, в записи - их смещение
+		if uint64(size)*uint64(e.count) > 4 {
+			e.offset = t.u32(pos + 8)
+		}
+		entries[t.u16(pos)] = e
+	}
+	return entries
+}
+
+func (t *tiffReader) short(e tiffEntry) uint16 {
+	if e.typ == 4 {
+		return uint16(t.u32(e.offset))
+	}
+	return t.u16(e.offset)
+}
+
+func (t *tiffReader) long(e tiffEntry) uint32 {
+	if e.typ == 3 {
+		return uint32(t.u16(e.offset))
+	}
+	return t.u32(e.offset)
+}
+
+func (t *tiffReader) ascii(e tiffEntry) string {
+	end := uint64(e.offset) + uint64(e.count)
+	if e.typ != 2 || end > uint64(len(t.data)) {
+		return ""
+	}
+	return strings.TrimRight(string(t.data[e.offset:end]), "\x00 ")
+}
+
+func (t *tiffReader) rational(off uint32) float64 {
+	num, den := t.u32(off), t.u32(off+4)
+	if den == 0 {
+		return 0
+	}
+	return float64(num) / float64(den)
+}
+
+// coordinate - координата в градусах из тройки (градусы, минуты, секунды) и полушария
+func (t *tiffReader) coordinate(gps map[uint16]tiffEntry, tag, refTag uint16, negative string) *float64 {
+	e, ok := gps[tag]
+	if !ok || e.typ != 5 || e.count < 3 {
+		return nil
+	}
+	value := t.rational(e.offset) + t.rational(e.offset+8)/60 + t.rational(e.offset+16)/3600
+	if ref, ok := gps[refTag]; ok && strings.EqualFold(t.ascii(ref), negative) {
+		value = -value
+	}
+	return &value
+}
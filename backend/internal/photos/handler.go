@@ -0,0 +1,196 @@
+package photos
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+)
+
+func currentUserID(c *gin.Context) (uint, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return 0, false
+	}
+	id, _ := userID.(uint)
+	return id, true
+}
+
+// UploadPhoto - загрузка фотографии одним запросом (multipart: photo, address)
+func UploadPhoto(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxSize()+(1<<20))
+	file, header, err := c.Request.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Файл фотографии не получен"})
+		return
+	}
+	defer file.Close()
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка при чтении файла"})
+		return
+	}
+	photo, err := Process(c.Request.Context(), userID, header.Filename, raw, c.PostForm("address"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, photo)
+}
+
+// CreateUpload начинает загрузку частями: {filename, size, address} -> {upload, chunkSize}
+func CreateUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Filename string `json:"filename" binding:"required"`
+		Size     int64  `json:"size"`
+		Address  string `json:"address"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	upload, err := NewUpload(userID, input.Filename, input.Size, input.Address)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"upload": upload, "chunkSize": ChunkSize})
+}
+
+// loadOwnUpload - незавершённая загрузка текущего пользователя
+func loadOwnUpload(c *gin.Context) (*db.PhotoUpload, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	var upload db.PhotoUpload
+	if err := db.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&upload).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Загрузка не найдена"})
+		return nil, false
+	}
+	return &upload, true
+}
+
+// GetUpload - состояние загрузки: received - с какого смещения продолжать
+func GetUpload(c *gin.Context) {
+	upload, ok := loadOwnUpload(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, upload)
+}
+
+// PutUploadChunk принимает очередную часть файла (тело запроса) со смещения offset.
+// При несовпадении смещения - 409 с фактически принятым размером; после последней
+// части возвращает готовую фотографию
+func PutUploadChunk(c *gin.Context) {
+	upload, ok := loadOwnUpload(c)
+	if !ok {
+		return
+	}
+	offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверное смещение"})
+		return
+	}
+	photo, err := WriteChunk(c.Request.Context(), upload, offset, c.Request.Body)
+	if errors.Is(err, ErrOffsetMismatch) {
+		var fresh db.PhotoUpload
+		db.DB.First(&fresh, "id = ?", upload.ID)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "received": fresh.Received})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"upload": upload, "photo": photo})
+}
+
+// DeleteUpload отменяет загрузку
+func DeleteUpload(c *gin.Context) {
+	upload, ok := loadOwnUpload(c)
+	if !ok {
+		return
+	}
+	discardUpload(upload)
+	c.JSON(http.StatusOK, gin.H{"message": "Загрузка отменена"})
+}
+
+func loadPhoto(c *gin.Context) (*db.ReportPhoto, bool) {
+	var photo db.ReportPhoto
+	if err := db.DB.First(&photo, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Фотография не найдена"})
+		return nil, false
+	}
+	return &photo, true
+}
+
+// GetPhoto - метаданные фотографии (время съёмки, координаты, проверка геометки)
+func GetPhoto(c *gin.Context) {
+	photo, ok := loadPhoto(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, photo)
+}
+
+// GetPhotoImage - уменьшенная фотография (JPEG), с thumb=true - миниатюра
+func GetPhotoImage(c *gin.Context) {
+	photo, ok := loadPhoto(c)
+	if !ok {
+		return
+	}
+	name := photo.Filename
+	if c.Query("thumb") == "true" {
+		name = photo.ThumbName
+	}
+	data, err := Load(c.Request.Context(), name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Файл фотографии не найден"})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Data(http.StatusOK, "image/jpeg", data)
+}
+
+// DeletePhoto удаляет свою фотографию, ещё не привязанную к отчёту
+func DeletePhoto(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	photo, ok := loadPhoto(c)
+	if !ok {
+		return
+	}
+	if photo.UserID != userID || photo.ReportID != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Удалить можно только свою фотографию, не вошедшую в отчёт"})
+		return
+	}
+	Delete(c.Request.Context(), photo)
+	c.JSON(http.StatusOK, gin.H{"message": "Фотография удалена"})
+}
+
+// GetReportPhotos - фотографии отчёта с данными EXIF и результатом проверки геометки
+func GetReportPhotos(c *gin.Context) {
+	var rows []db.ReportPhoto
+	if err := db.DB.Where("report_id = ?", c.Param("id")).Order("id").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении фотографий"})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
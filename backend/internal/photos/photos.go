@@ -0,0 +1,382 @@
+package photos
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+
+	"backend/internal/db"
+	"backend/internal/storage"
+)
+
+// Результат проверки геометки фотографии по координатам адреса
+const (
+	GeoOK      = "ok"      // снято рядом с объектом
+	GeoFar     = "far"     // снято дальше PHOTO_GEO_RADIUS_M от объекта
+	GeoNoGPS   = "no_gps"  // в EXIF нет координат
+	GeoUnknown = "unknown" // у адреса не указаны координаты
+)
+
+const (
+	defaultMaxSizeMB   = 25
+	defaultMaxPixels   = 1920
+	defaultGeoRadiusM  = 500
+	defaultOrphanDays  = 7
+	thumbPixels        = 320
+	maxSourcePixels    = 60_000_000
+	photoQuality       = 85
+	thumbQuality       = 75
+	uploadSessionHours = 24
+)
+
+var photosDir = filepath.Join("uploads", "photos")
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// MaxSize - наибольший размер загружаемого файла (PHOTO_MAX_SIZE_MB, по умолчанию 25 МБ)
+func MaxSize() int64 {
+	return int64(envInt("PHOTO_MAX_SIZE_MB", defaultMaxSizeMB)) << 20
+}
+
+// Process проверяет и сохраняет загруженную фотографию: читает EXIF (время съёмки,
+// координаты, ориентацию), уменьшает до PHOTO_MAX_PIXELS по большей стороне
+// (по умолчанию 1920), делает миниатюру и сверяет геометку с адресом
+func Process(ctx context.Context, userID uint, name string, raw []byte, address string) (*db.ReportPhoto, error) {
+	if int64(len(raw)) > MaxSize() {
+		return nil, fmt.Errorf("размер фотографии превышает %d МБ", MaxSize()>>20)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, errors.New("поддерживаются фотографии JPEG и PNG")
+	}
+	if cfg.Width*cfg.Height > maxSourcePixels {
+		return nil, errors.New("слишком большое разрешение фотографии")
+	}
+	info := exifInfo{Orientation: 1}
+	if format == "jpeg" {
+		info = parseEXIF(raw)
+	}
+	src, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, errors.New("не удалось прочитать фотографию")
+	}
+
+	// EXIF в уменьшенную копию не переносится, поэтому поворот применяется к пикселям
+	img := orient(resize(src, envInt("PHOTO_MAX_PIXELS", defaultMaxPixels)), info.Orientation)
+	data, err := encodeJPEG(img, photoQuality)
+	if err != nil {
+		return nil, err
+	}
+	thumb, err := encodeJPEG(resize(img, thumbPixels), thumbQuality)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	photo := db.ReportPhoto{
+		UserID:       userID,
+		OriginalName: filepath.Base(name),
+		Filename:     hash + ".jpg",
+		ThumbName:    hash + "_thumb.jpg",
+		SHA256:       hash,
+		Width:        img.Bounds().Dx(),
+		Height:       img.Bounds().Dy(),
+		Size:         int64(len(data)),
+		TakenAt:      info.TakenAt,
+		Latitude:     info.Latitude,
+		Longitude:    info.Longitude,
+		CreatedAt:    time.Now().Format("2006-01-02 15:04:05"),
+	}
+	checkLocation(&photo, address)
+
+	if err := save(ctx, photo.Filename, data); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить фотографию: %v", err)
+	}
+	if err := save(ctx, photo.ThumbName, thumb); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить миниатюру: %v", err)
+	}
+	if err := db.DB.Create(&photo).Error; err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+// resize уменьшает изображение так, чтобы большая сторона не превышала maxSide
+func resize(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+	if w >= h {
+		w, h = maxSide, h*maxSide/w
+	} else {
+		w, h = w*maxSide/h, maxSide
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, max(w, 1), max(h, 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+// orient поворачивает и отражает изображение согласно тегу EXIF Orientation (1-8)
+func orient(src image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checkLocation сверяет координаты снимка с координатами адреса объекта
+func checkLocation(photo *db.ReportPhoto, address string) {
+	photo.Address = strings.TrimSpace(address)
+	photo.DistanceM = nil
+	switch {
+	case photo.Latitude == nil || photo.Longitude == nil:
+		photo.GeoStatus = GeoNoGPS
+		return
+	case photo.Address == "":
+		photo.GeoStatus = GeoUnknown
+		return
+	}
+	var addr db.Address
+	if err := db.DB.Where("address = ?", photo.Address).First(&addr).Error; err != nil || addr.Latitude == nil || addr.Longitude == nil {
+		photo.GeoStatus = GeoUnknown
+		return
+	}
+	distance := math.Round(haversine(*photo.Latitude, *photo.Longitude, *addr.Latitude, *addr.Longitude))
+	photo.DistanceM = &distance
+	photo.GeoStatus = GeoOK
+	if distance > float64(envInt("PHOTO_GEO_RADIUS_M", defaultGeoRadiusM)) {
+		photo.GeoStatus = GeoFar
+	}
+}
+
+// haversine - расстояние между точками в метрах
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371000
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// Check проверяет, что фотографии существуют, загружены одним из owners и не
+// привязаны к другому отчёту (reportID = 0 - отчёт ещё не создан)
+func Check(ids []uint, reportID uint, owners ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var rows []db.ReportPhoto
+	if err := db.DB.Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]db.ReportPhoto, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+	for _, id := range ids {
+		photo, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("фотография %d не найдена", id)
+		}
+		if photo.ReportID != nil {
+			if *photo.ReportID != reportID {
+				return fmt.Errorf("фотография %d относится к другому отчёту", id)
+			}
+			continue
+		}
+		owned := false
+		for _, owner := range owners {
+			owned = owned || photo.UserID == owner
+		}
+		if !owned {
+			return fmt.Errorf("фотография %d загружена другим пользователем", id)
+		}
+	}
+	return nil
+}
+
+// Attach привязывает фотографии к отчёту и заново сверяет геометку с адресом отчёта.
+// Фотографии, убранные из отчёта при редактировании, остаются привязанными -
+// на них ссылаются прежние версии данных
+func Attach(reportID uint, address string, ids []uint) {
+	if len(ids) == 0 {
+		return
+	}
+	var rows []db.ReportPhoto
+	db.DB.Where("id IN ? AND (report_id IS NULL OR report_id = ?)", ids, reportID).Find(&rows)
+	for i := range rows {
+		rows[i].ReportID = &reportID
+		if rows[i].Address != strings.TrimSpace(address) {
+			checkLocation(&rows[i], address)
+		}
+		if err := db.DB.Save(&rows[i]).Error; err != nil {
+			log.Printf("Ошибка при привязке фотографии %d к отчёту %d: %v", rows[i].ID, reportID, err)
+		}
+	}
+}
+
+// ForDocument - фотографии в порядке ids в виде base64 для встраивания в акт
+func ForDocument(ctx context.Context, ids []uint) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var rows []db.ReportPhoto
+	if err := db.DB.Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*db.ReportPhoto, len(rows))
+	for i := range rows {
+		byID[rows[i].ID] = &rows[i]
+	}
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		photo, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("фотография %d не найдена", id)
+		}
+		data, err := Load(ctx, photo.Filename)
+		if err != nil {
+			return nil, fmt.Errorf("фотография %d недоступна: %v", id, err)
+		}
+		result = append(result, base64.StdEncoding.EncodeToString(data))
+	}
+	return result, nil
+}
+
+// DeleteForReport удаляет фотографии отчёта вместе с файлами
+func DeleteForReport(ctx context.Context, reportID uint) {
+	var rows []db.ReportPhoto
+	db.DB.Where("report_id = ?", reportID).Find(&rows)
+	for i := range rows {
+		Delete(ctx, &rows[i])
+	}
+}
+
+// Delete удаляет запись фотографии и её файлы, если на них не ссылаются другие записи
+func Delete(ctx context.Context, photo *db.ReportPhoto) {
+	db.DB.Delete(photo)
+	var count int64
+	db.DB.Model(&db.ReportPhoto{}).Where("filename = ?", photo.Filename).Count(&count)
+	if count > 0 {
+		return
+	}
+	for _, name := range []string{photo.Filename, photo.ThumbName} {
+		if storage.IsS3Enabled() {
+			_ = storage.DeleteObject(ctx, "photos/", name)
+		}
+		_ = os.Remove(filepath.Join(photosDir, name))
+	}
+}
+
+// Load читает файл фотографии или миниатюры из S3 или локального uploads/photos
+func Load(ctx context.Context, filename string) ([]byte, error) {
+	if storage.IsS3Enabled() {
+		obj, _, err := storage.GetObject(ctx, "photos/", filename)
+		if err == nil {
+			defer obj.Close()
+			return io.ReadAll(obj)
+		}
+	}
+	return os.ReadFile(filepath.Join(photosDir, filename))
+}
+
+func save(ctx context.Context, filename string, content []byte) error {
+	if storage.IsS3Enabled() {
+		return storage.UploadObject(ctx, "photos/", filename, bytes.NewReader(content), int64(len(content)), "image/jpeg")
+	}
+	if err := os.MkdirAll(photosDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(photosDir, filename), content, 0644)
+}
+
+// StartCleanup раз в час удаляет фотографии, так и не попавшие в отчёт за
+// PHOTO_ORPHAN_TTL_DAYS дней (по умолчанию 7), и брошенные загрузки частями
+func StartCleanup() {
+	go func() {
+		for {
+			cleanup()
+			time.Sleep(time.Hour)
+		}
+	}()
+}
+
+func cleanup() {
+	ctx := context.Background()
+	before := time.Now().AddDate(0, 0, -envInt("PHOTO_ORPHAN_TTL_DAYS", defaultOrphanDays)).Format("2006-01-02 15:04:05")
+	var orphans []db.ReportPhoto
+	db.DB.Where("report_id IS NULL AND created_at < ?", before).Find(&orphans)
+	for i := range orphans {
+		Delete(ctx, &orphans[i])
+	}
+
+	staleBefore := time.Now().Add(-uploadSessionHours * time.Hour).Format("2006-01-02 15:04:05")
+	var stale []db.PhotoUpload
+	db.DB.Where("updated_at < ?", staleBefore).Find(&stale)
+	for i := range stale {
+		discardUpload(&stale[i])
+	}
+}
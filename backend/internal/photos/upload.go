@@ -0,0 +1,158 @@
+package photos
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend/internal/db"
+)
+
+// ChunkSize - рекомендуемый размер части при загрузке (клиент может слать меньше)
+const ChunkSize = 1 << 20
+
+// maxChunkSize - наибольшая часть, принимаемая за один запрос
+const maxChunkSize = 8 << 20
+
+var uploadsDir = filepath.Join("uploads", "photo_uploads")
+
+// ErrOffsetMismatch - часть пришла не с того смещения (повтор или пропуск)
+var ErrOffsetMismatch = errors.New("смещение части не совпадает с принятым размером")
+
+// NewUpload начинает загрузку фотографии частями
+func NewUpload(userID uint, filename string, size int64, address string) (*db.PhotoUpload, error) {
+	if size <= 0 {
+		return nil, errors.New("не указан размер файла")
+	}
+	if size > MaxSize() {
+		return nil, fmt.Errorf("размер фотографии превышает %d МБ", MaxSize()>>20)
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	now := time.Now().Format("2006-01-02 15:04:05")
+	upload := db.PhotoUpload{
+		ID:        hex.EncodeToString(token),
+		UserID:    userID,
+		Filename:  filepath.Base(strings.TrimSpace(filename)),
+		Address:   strings.TrimSpace(address),
+		Size:      size,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := db.DB.Create(&upload).Error; err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func partPath(upload *db.PhotoUpload) string {
+	return filepath.Join(uploadsDir, upload.ID+".part")
+}
+
+// WriteChunk дописывает часть с позиции offset. Часть должна начинаться ровно с уже
+// принятого размера: после обрыва клиент узнаёт Received и продолжает с него.
+// Часть сначала принимается во временный файл запроса, а в файл загрузки попадает
+// в одной транзакции с обновлением Received (см. ниже), поэтому одновременные запросы
+// не перемешивают байты. Когда файл получен целиком, фотография обрабатывается,
+// а загрузка удаляется. Если обработка не удалась, загрузка остаётся: её можно
+// повторить пустой частью со смещением, равным размеру файла
+func WriteChunk(ctx context.Context, upload *db.PhotoUpload, offset int64, body io.Reader) (*db.ReportPhoto, error) {
+	if offset != upload.Received {
+		return nil, ErrOffsetMismatch
+	}
+	if err := os.MkdirAll(uploadsDir, 0755); err != nil {
+		return nil, err
+	}
+	chunk, err := os.CreateTemp(uploadsDir, upload.ID+".*.chunk")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(chunk.Name())
+	defer chunk.Close()
+
+	limit := upload.Size - offset
+	if limit > maxChunkSize {
+		limit = maxChunkSize
+	}
+	n, err := io.Copy(chunk, io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > limit {
+		return nil, fmt.Errorf("часть больше допустимого: не более %d байт", limit)
+	}
+
+	// Условное обновление засчитывает часть одному из запросов с этим смещением и блокирует
+	// строку загрузки до конца транзакции: следующая часть ждёт, пока эта не будет
+	// дописана в файл, а при ошибке записи принятый размер откатывается вместе с транзакцией
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&db.PhotoUpload{}).Where("id = ? AND received = ?", upload.ID, offset).
+			Updates(map[string]interface{}{
+				"received":   offset + n,
+				"updated_at": time.Now().Format("2006-01-02 15:04:05"),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrOffsetMismatch
+		}
+		return appendChunk(upload, offset, chunk)
+	})
+	if err != nil {
+		return nil, err
+	}
+	upload.Received = offset + n
+	if upload.Received < upload.Size {
+		return nil, nil
+	}
+
+	raw, err := os.ReadFile(partPath(upload))
+	if err != nil {
+		return nil, err
+	}
+	photo, err := Process(ctx, upload.UserID, upload.Filename, raw, upload.Address)
+	if err != nil {
+		return nil, err
+	}
+	discardUpload(upload)
+	return photo, nil
+}
+
+// appendChunk переносит принятую часть из временного файла в файл загрузки с позиции offset.
+// Хвост от прежней неудачной записи отрезается
+func appendChunk(upload *db.PhotoUpload, offset int64, chunk *os.File) error {
+	if _, err := chunk.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(partPath(upload), os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return err
+	}
+	_, err = io.Copy(io.NewOffsetWriter(f, offset), chunk)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// discardUpload удаляет загрузку и принятые части
+func discardUpload(upload *db.PhotoUpload) {
+	db.DB.Delete(upload)
+	_ = os.Remove(partPath(upload))
+}
@@ -0,0 +1,146 @@
+package photos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/db"
+	"backend/internal/dbtest"
+)
+
+func TestWriteChunkConcurrentSameOffset(t *testing.T) {
+	dbtest.Open(t)
+	t.Chdir(t.TempDir())
+
+	const chunkLen = 256 << 10
+	upload, err := NewUpload(1, "photo.jpg", 2*chunkLen, "ул. Ленина, 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const writers = 8
+	errs := make([]error, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			copyUpload := *upload
+			_, errs[i] = WriteChunk(context.Background(), &copyUpload, 0, bytes.NewReader(bytes.Repeat([]byte{byte('a' + i)}, chunkLen)))
+		}(i)
+	}
+	wg.Wait()
+
+	winner := -1
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if winner >= 0 {
+				t.Fatalf("часть засчитана запросам %d и %d", winner, i)
+			}
+			winner = i
+		case !errors.Is(err, ErrOffsetMismatch):
+			t.Fatalf("запрос %d: %v", i, err)
+		}
+	}
+	if winner < 0 {
+		t.Fatal("часть не засчитана ни одному запросу")
+	}
+
+	var stored db.PhotoUpload
+	if err := db.DB.First(&stored, "id = ?", upload.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Received != chunkLen {
+		t.Fatalf("received = %d, ожидалось %d", stored.Received, chunkLen)
+	}
+	data, err := os.ReadFile(partPath(upload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, bytes.Repeat([]byte{byte('a' + winner)}, chunkLen)) {
+		t.Fatal("в файле загрузки перемешаны байты разных запросов")
+	}
+	chunks, _ := os.ReadDir(uploadsDir)
+	if len(chunks) != 1 {
+		t.Fatalf("в %s остались временные файлы: %d записей", uploadsDir, len(chunks))
+	}
+}
+
+// Следующая часть, отправленная до ответа на предыдущую, дописывается только после неё
+func TestWriteChunkPipelined(t *testing.T) {
+	dbtest.Open(t)
+	t.Chdir(t.TempDir())
+
+	const chunkLen = 256 << 10
+	upload, err := NewUpload(1, "photo.jpg", 3*chunkLen, "ул. Ленина, 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := [][]byte{
+		bytes.Repeat([]byte{'a'}, chunkLen),
+		bytes.Repeat([]byte{'b'}, chunkLen),
+	}
+
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(offset int64, chunk []byte) {
+			defer wg.Done()
+			// Клиент считает, что предыдущие части уже приняты, и повторяет часть после 409
+			for {
+				pipelined := *upload
+				pipelined.Received = offset
+				_, err := WriteChunk(context.Background(), &pipelined, offset, bytes.NewReader(chunk))
+				if !errors.Is(err, ErrOffsetMismatch) {
+					if err != nil {
+						t.Error(err)
+					}
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}(int64(i*chunkLen), chunk)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(partPath(upload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, bytes.Join(chunks, nil)) {
+		t.Fatal("части записаны не по порядку")
+	}
+}
+
+// Если принятый файл не удалось обработать, загрузка не удаляется
+func TestWriteChunkKeepsUploadOnProcessError(t *testing.T) {
+	dbtest.Open(t)
+	t.Chdir(t.TempDir())
+
+	content := []byte("не фотография")
+	upload, err := NewUpload(1, "photo.jpg", int64(len(content)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteChunk(context.Background(), upload, 0, bytes.NewReader(content)); err == nil {
+		t.Fatal("ожидалась ошибка обработки")
+	}
+
+	var stored db.PhotoUpload
+	if err := db.DB.First(&stored, "id = ?", upload.ID).Error; err != nil {
+		t.Fatalf("загрузка удалена: %v", err)
+	}
+	if stored.Received != int64(len(content)) {
+		t.Errorf("received = %d, ожидалось %d", stored.Received, len(content))
+	}
+	// Повтор обработки пустой частью со смещением, равным размеру
+	if _, err := WriteChunk(context.Background(), &stored, stored.Size, bytes.NewReader(nil)); err == nil || errors.Is(err, ErrOffsetMismatch) {
+		t.Fatalf("повтор обработки: %v", err)
+	}
+}
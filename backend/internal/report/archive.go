@@ -0,0 +1,200 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/export"
+	"backend/internal/storage"
+)
+
+const (
+	defaultArchiveWorkers = 4
+	manifestName          = "manifest.csv"
+)
+
+// OpenReportFile открывает PDF отчёта: сначала локальная копия, затем S3
+func OpenReportFile(filename string) (io.ReadCloser, error) {
+	return openReportFile(context.Background(), filename)
+}
+
+func openReportFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join("uploads", "reports", filename))
+	if err == nil {
+		return f, nil
+	}
+	if storage.IsS3Enabled() {
+		obj, _, e := storage.GetReportObject(ctx, "reports/"+filename)
+		if e == nil && obj != nil {
+			return obj, nil
+		}
+		err = e
+	}
+	return nil, err
+}
+
+// ZipEntry - дополнительный файл архива (например, счёт к актам)
+type ZipEntry struct {
+	Name string
+	Data []byte
+}
+
+// fetchedReport - содержимое PDF отчёта, загруженное для архива
+type fetchedReport struct {
+	data []byte
+	err  error
+	slot bool // файл загружен в занятом слоте - его нужно освободить через release
+}
+
+// fetchReports загружает файлы отчётов параллельно, не более workers одновременно.
+// Результат i-го отчёта приходит в i-й канал; слот освобождается только после того,
+// как архив заберёт файл (release), поэтому в памяти не больше workers файлов сразу.
+// После отмены ctx оставшиеся отчёты получают ctx.Err() без слота
+func fetchReports(ctx context.Context, reports []db.Report, workers int) ([]chan fetchedReport, func(fetchedReport)) {
+	slots := make(chan struct{}, workers)
+	results := make([]chan fetchedReport, len(reports))
+	for i := range results {
+		results[i] = make(chan fetchedReport, 1)
+	}
+
+	go func() {
+		for i, report := range reports {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				for _, ch := range results[i:] {
+					ch <- fetchedReport{err: ctx.Err()}
+				}
+				return
+			}
+			go func(ch chan fetchedReport, filename string) {
+				reader, err := openReportFile(ctx, filename)
+				if err != nil || reader == nil {
+					if err == nil {
+						err = os.ErrNotExist
+					}
+					ch <- fetchedReport{err: err, slot: true}
+					return
+				}
+				defer reader.Close()
+				data, err := io.ReadAll(reader)
+				ch <- fetchedReport{data: data, err: err, slot: true}
+			}(results[i], report.Filename)
+		}
+	}()
+	return results, func(fetched fetchedReport) {
+		if fetched.slot {
+			<-slots
+		}
+	}
+}
+
+// uniqueEntryName - имя файла в архиве без совпадений с уже добавленными
+func uniqueEntryName(used map[string]bool, name string) string {
+	name = filepath.Base(name)
+	candidate := name
+	ext := filepath.Ext(name)
+	for i := 1; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// WriteReportsZip пишет ZIP с файлами отчётов, дополнительными файлами extra
+// и manifest.csv (метаданные отчётов и результат добавления каждого файла) в w.
+// Файлы загружаются параллельно (REPORT_ARCHIVE_WORKERS, по умолчанию 4), а в архив
+// попадают по порядку, сразу после загрузки - архив целиком нигде не хранится.
+// Отчёты, файлы которых не найдены, пропускаются и отмечаются в манифесте
+func WriteReportsZip(ctx context.Context, w io.Writer, reports []db.Report, extra ...ZipEntry) error {
+	// Любой досрочный выход останавливает загрузку оставшихся файлов
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	zipWriter := zip.NewWriter(w)
+	used := map[string]bool{manifestName: true}
+
+	for _, entry := range extra {
+		fw, err := zipWriter.Create(uniqueEntryName(used, entry.Name))
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(entry.Data); err != nil {
+			return err
+		}
+	}
+
+	var manifest bytes.Buffer
+	manifest.WriteString("\xEF\xBB\xBF")
+	mw := csv.NewWriter(&manifest)
+	mw.Comma = ';'
+	mw.Write([]string{"ID", "Номер акта", "Дата", "Адрес", "Классификация", "Инженер", "Файл в архиве", "SHA-256", "Результат"})
+	userName := export.UserNames()
+
+	results, release := fetchReports(ctx, reports, envInt("REPORT_ARCHIVE_WORKERS", defaultArchiveWorkers))
+	for i, report := range reports {
+		fetched := <-results[i]
+		entryName, result := "", "включён"
+		var err error
+		switch {
+		case ctx.Err() != nil:
+			err = ctx.Err()
+		case fetched.err != nil:
+			result = "файл не найден"
+		default:
+			entryName = uniqueEntryName(used, report.Filename)
+			// PDF уже сжат - храним без повторного сжатия
+			var fw io.Writer
+			fw, err = zipWriter.CreateHeader(&zip.FileHeader{Name: entryName, Method: zip.Store, Modified: time.Now()})
+			if err == nil {
+				_, err = fw.Write(fetched.data)
+			}
+		}
+		release(fetched)
+		if err != nil {
+			return err
+		}
+		mw.Write([]string{
+			fmt.Sprint(report.ID), report.ActNumber, report.Date, report.Address,
+			classifications.Name(report.Classification), userName(report.UserID),
+			entryName, report.SHA256, result,
+		})
+	}
+
+	mw.Flush()
+	fw, err := zipWriter.Create(manifestName)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(manifest.Bytes()); err != nil {
+		return err
+	}
+	return zipWriter.Close()
+}
+
+// SendReportsZip передаёт клиенту ZIP из файлов отчётов и дополнительных файлов extra
+// потоком, по мере загрузки файлов (см. WriteReportsZip)
+func SendReportsZip(c *gin.Context, reports []db.Report, zipName string, extra ...ZipEntry) {
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(zipName)))
+	c.Status(http.StatusOK)
+	if err := WriteReportsZip(c.Request.Context(), c.Writer, reports, extra...); err != nil {
+		// Заголовки уже отправлены - клиент получит оборванный архив, причина - в логе
+		log.Printf("Ошибка при передаче архива %s: %v", zipName, err)
+	}
+}
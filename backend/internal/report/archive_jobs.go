@@ -0,0 +1,373 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+	"backend/internal/storage"
+)
+
+const (
+	defaultArchiveSyncLimit = 500
+	defaultArchiveTTLDays   = 7
+	archivePrefix           = "archives/"
+)
+
+var archiveQueue = make(chan uint, 64)
+
+// StartReportArchiveWorkers запускает фоновую сборку архивов (REPORT_ARCHIVE_JOBS,
+// по умолчанию 1 архив одновременно) и удаление архивов старше REPORT_ARCHIVE_TTL_DAYS.
+// Архивы, сборка которых прервалась перезапуском, собираются заново
+func StartReportArchiveWorkers() {
+	workers := envInt("REPORT_ARCHIVE_JOBS", 1)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for id := range archiveQueue {
+				processReportArchive(id)
+			}
+		}()
+	}
+
+	var pending []uint
+	db.DB.Model(&db.ReportArchive{}).Where("status IN ?", []string{JobQueued, JobRunning}).
+		Order("id").Pluck("id", &pending)
+	if len(pending) > 0 {
+		db.DB.Model(&db.ReportArchive{}).Where("status = ?", JobRunning).Update("status", JobQueued)
+		go func() {
+			for _, id := range pending {
+				archiveQueue <- id
+			}
+		}()
+	}
+
+	go func() {
+		for {
+			cleanupReportArchives()
+			time.Sleep(time.Hour)
+		}
+	}()
+	log.Printf("Report archive workers started: %d, pending: %d", workers, len(pending))
+}
+
+// SendReportsArchive отдаёт архив отчётов сразу потоком, а при async=true или выборке
+// больше REPORT_ARCHIVE_SYNC_LIMIT отчётов (по умолчанию 500) ставит сборку в очередь
+// и отвечает 202 с задачей: о готовности пользователь узнаёт через SSE и в Telegram.
+// Запросам по API-ключу (без пользователя) архив всегда отдаётся потоком
+func SendReportsArchive(c *gin.Context, reports []db.Report, zipName string) {
+	userID, exists := c.Get("userID")
+	async := c.Query("async") == "true" || len(reports) > envInt("REPORT_ARCHIVE_SYNC_LIMIT", defaultArchiveSyncLimit)
+	if !exists || !async {
+		SendReportsZip(c, reports, zipName)
+		return
+	}
+	id, _ := userID.(uint)
+	archive, err := enqueueReportArchive(id, zipName, reports)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при постановке архива в очередь"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Архив готовится, о готовности придёт уведомление",
+		"archive": archive,
+	})
+}
+
+func enqueueReportArchive(userID uint, name string, reports []db.Report) (*db.ReportArchive, error) {
+	ids := make([]uint, len(reports))
+	for i, report := range reports {
+		ids[i] = report.ID
+	}
+	payload, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+	archive := db.ReportArchive{
+		UserID:    userID,
+		Status:    JobQueued,
+		Name:      name,
+		ReportIDs: string(payload),
+		Count:     len(ids),
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := db.DB.Create(&archive).Error; err != nil {
+		return nil, err
+	}
+	go func() { archiveQueue <- archive.ID }()
+	return &archive, nil
+}
+
+func archiveObjectName(archive *db.ReportArchive) string {
+	return fmt.Sprintf("%d.zip", archive.ID)
+}
+
+func archiveLocalPath(archive *db.ReportArchive) string {
+	return filepath.Join("uploads", "archives", archiveObjectName(archive))
+}
+
+// countingWriter считает записанные байты - размер архива известен только после сборки
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// processReportArchive собирает архив и сохраняет его в хранилище
+func processReportArchive(id uint) {
+	claim := db.DB.Model(&db.ReportArchive{}).Where("id = ? AND status = ?", id, JobQueued).
+		Updates(map[string]interface{}{
+			"status":     JobRunning,
+			"started_at": time.Now().Format("2006-01-02 15:04:05"),
+		})
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return
+	}
+
+	var archive db.ReportArchive
+	if err := db.DB.First(&archive, id).Error; err != nil {
+		return
+	}
+
+	size, err := buildReportArchive(&archive)
+	now := time.Now().Format("2006-01-02 15:04:05")
+	if err != nil {
+		log.Printf("Сборка архива отчётов %d: %v", archive.ID, err)
+		db.DB.Model(&archive).Updates(map[string]interface{}{
+			"status":      JobFailed,
+			"error":       err.Error(),
+			"finished_at": now,
+		})
+		return
+	}
+	db.DB.Model(&archive).Updates(map[string]interface{}{
+		"status":      JobSucceeded,
+		"size":        size,
+		"finished_at": now,
+	})
+	archive.Status, archive.Size = JobSucceeded, size
+	notifyArchiveReady(&archive)
+}
+
+func buildReportArchive(archive *db.ReportArchive) (int64, error) {
+	var ids []uint
+	if err := json.Unmarshal([]byte(archive.ReportIDs), &ids); err != nil {
+		return 0, err
+	}
+	var reports []db.Report
+	if len(ids) > 0 {
+		if err := db.DB.Where("id IN ?", ids).Order("date, id").Find(&reports).Error; err != nil {
+			return 0, err
+		}
+	}
+	ctx := context.Background()
+
+	if storage.IsS3Enabled() {
+		// Архив пишется в S3 по частям через pipe, не занимая ни память, ни диск
+		pr, pw := io.Pipe()
+		counter := &countingWriter{w: pw}
+		go func() {
+			pw.CloseWithError(WriteReportsZip(ctx, counter, reports))
+		}()
+		err := storage.UploadObject(ctx, archivePrefix, archiveObjectName(archive), pr, -1, "application/zip")
+		pr.CloseWithError(err)
+		return counter.n, err
+	}
+
+	if err := os.MkdirAll(filepath.Join("uploads", "archives"), 0755); err != nil {
+		return 0, err
+	}
+	path := archiveLocalPath(archive)
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	counter := &countingWriter{w: f}
+	err = WriteReportsZip(ctx, counter, reports)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return counter.n, err
+}
+
+// notifyArchiveReady сообщает автору в Telegram, что архив готов
+func notifyArchiveReady(archive *db.ReportArchive) {
+	token := os.Getenv("BOT_TOKEN")
+	if token == "" {
+		return
+	}
+	var user db.User
+	if err := db.DB.First(&user, archive.UserID).Error; err != nil || user.TelegramChatID == nil || !user.TelegramNotifyOn {
+		return
+	}
+	body := fmt.Sprintf("📦 Архив <b>%s</b> готов: отчётов - %d, %.1f МБ\n\n<a href=\"https://crmlite-vv.ru/reports\">скачать в разделе отчётов</a>",
+		html.EscapeString(archive.Name), archive.Count, float64(archive.Size)/(1<<20))
+	data := url.Values{}
+	data.Set("chat_id", strconv.FormatInt(*user.TelegramChatID, 10))
+	data.Set("text", body)
+	data.Set("parse_mode", "HTML")
+	resp, err := http.Post(fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", token),
+		"application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+	if err != nil {
+		log.Printf("Уведомление об архиве %d: %v", archive.ID, err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// cleanupReportArchives удаляет архивы старше REPORT_ARCHIVE_TTL_DAYS дней
+func cleanupReportArchives() {
+	before := time.Now().AddDate(0, 0, -envInt("REPORT_ARCHIVE_TTL_DAYS", defaultArchiveTTLDays)).
+		Format("2006-01-02 15:04:05")
+	var expired []db.ReportArchive
+	db.DB.Where("status IN ? AND finished_at < ?", []string{JobSucceeded, JobFailed}, before).Find(&expired)
+	for i := range expired {
+		if expired[i].Status == JobSucceeded {
+			if storage.IsS3Enabled() {
+				_ = storage.DeleteObject(context.Background(), archivePrefix, archiveObjectName(&expired[i]))
+			} else {
+				os.Remove(archiveLocalPath(&expired[i]))
+			}
+		}
+		db.DB.Delete(&expired[i])
+	}
+}
+
+// loadOwnArchive загружает архив, если он принадлежит пользователю (администратор видит все)
+func loadOwnArchive(c *gin.Context) (*db.ReportArchive, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return nil, false
+	}
+
+	var archive db.ReportArchive
+	if err := db.DB.First(&archive, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Архив не найден"})
+		return nil, false
+	}
+
+	if id, _ := userID.(uint); id != archive.UserID {
+		var user db.User
+		if err := db.DB.First(&user, userID).Error; err != nil || user.Department != "Админ" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Архив не найден"})
+			return nil, false
+		}
+	}
+	return &archive, true
+}
+
+// GetReportArchives - последние архивы пользователя
+func GetReportArchives(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return
+	}
+	var archives []db.ReportArchive
+	if err := db.DB.Where("user_id = ?", userID).Order("id DESC").Limit(20).Find(&archives).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении архивов"})
+		return
+	}
+	c.JSON(http.StatusOK, archives)
+}
+
+// GetReportArchive - состояние сборки архива
+func GetReportArchive(c *gin.Context) {
+	archive, ok := loadOwnArchive(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, archive)
+}
+
+// StreamReportArchive - поток событий (SSE) о состоянии сборки архива до её завершения
+func StreamReportArchive(c *gin.Context) {
+	archive, ok := loadOwnArchive(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	last := ""
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		if archive.Status != last {
+			c.SSEvent("status", archive)
+			last = archive.Status
+		}
+		if archive.Status == JobSucceeded || archive.Status == JobFailed {
+			return false
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-ticker.C:
+		}
+
+		var fresh db.ReportArchive
+		if err := db.DB.First(&fresh, archive.ID).Error; err != nil {
+			return false
+		}
+		archive = &fresh
+		return true
+	})
+}
+
+// DownloadReportArchive - скачивание готового архива
+func DownloadReportArchive(c *gin.Context) {
+	archive, ok := loadOwnArchive(c)
+	if !ok {
+		return
+	}
+	if archive.Status != JobSucceeded {
+		c.JSON(http.StatusConflict, gin.H{"error": "Архив ещё не готов"})
+		return
+	}
+
+	disposition := fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(archive.Name))
+	if storage.IsS3Enabled() {
+		obj, info, err := storage.GetObject(c.Request.Context(), archivePrefix, archiveObjectName(archive))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Файл архива не найден"})
+			return
+		}
+		defer obj.Close()
+		c.DataFromReader(http.StatusOK, info.Size, "application/zip", obj, map[string]string{"Content-Disposition": disposition})
+		return
+	}
+
+	path := archiveLocalPath(archive)
+	if _, err := os.Stat(path); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Файл архива не найден"})
+		return
+	}
+	c.Header("Content-Disposition", disposition)
+	c.Header("Content-Type", "application/zip")
+	c.File(path)
+}
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"backend/internal/db"
+	"backend/internal/dbtest"
+)
+
+var errWriteFailed = errors.New("запись не удалась")
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errWriteFailed }
+
+// archiveReports создаёт count отчётов с PDF в uploads/reports текущей директории
+func archiveReports(t *testing.T, count int) []db.Report {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	dir := filepath.Join("uploads", "reports")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	reports := make([]db.Report, count)
+	for i := range reports {
+		reports[i] = db.Report{ID: uint(i + 1), Filename: "act_" + itoa(uint(i+1)) + ".pdf"}
+		data := bytes.Repeat([]byte{byte(i)}, 64<<10)
+		if err := os.WriteFile(filepath.Join(dir, reports[i].Filename), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return reports
+}
+
+// writeZipWithin вызывает WriteReportsZip и падает, если она не завершилась за отведённое время
+func writeZipWithin(t *testing.T, ctx context.Context, w io.Writer, reports []db.Report) error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- WriteReportsZip(ctx, w, reports) }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("WriteReportsZip зависла")
+		return nil
+	}
+}
+
+// waitGoroutines ждёт, пока число горутин вернётся к исходному - загрузка файлов остановлена
+func waitGoroutines(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("остались горутины загрузки: было %d, стало %d", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWriteReportsZipCanceled(t *testing.T) {
+	dbtest.Open(t)
+	t.Setenv("REPORT_ARCHIVE_WORKERS", "1")
+	reports := archiveReports(t, 5)
+
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		before := runtime.NumGoroutine()
+		if err := writeZipWithin(t, ctx, io.Discard, reports); !errors.Is(err, context.Canceled) {
+			t.Fatalf("ошибка %v, ожидалась context.Canceled", err)
+		}
+		waitGoroutines(t, before)
+	}
+}
+
+func TestWriteReportsZipWriterError(t *testing.T) {
+	dbtest.Open(t)
+	t.Setenv("REPORT_ARCHIVE_WORKERS", "1")
+	reports := archiveReports(t, 5)
+
+	before := runtime.NumGoroutine()
+	if err := writeZipWithin(t, context.Background(), failingWriter{}, reports); !errors.Is(err, errWriteFailed) {
+		t.Fatalf("ошибка %v, ожидалась ошибка записи", err)
+	}
+	waitGoroutines(t, before)
+}
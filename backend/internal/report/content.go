@@ -0,0 +1,203 @@
+package report
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/db"
+)
+
+// Версия формата сохраняемого JSON. Увеличивается при несовместимых изменениях ReportData
+const reportDataSchemaVersion = 1
+
+// Поля ReportData, по которым доступен поиск (параметр field -> ключ в JSON)
+var searchableFields = map[string]string{
+	"defects":         "defects",
+	"recommendations": "recommendations",
+	"material":        "material",
+	"additionalWorks": "additionalWorks",
+	"comments":        "comments",
+	"machineName":     "machine_name",
+	"machineNumber":   "machine_number",
+	"inventoryNumber": "inventory_number",
+}
+
+// saveReportContent сохраняет данные отчёта и имя сформированного PDF новой версией
+func saveReportContent(tx *gorm.DB, reportID uint, data ReportData, filename string, createdBy uint) (*db.ReportContent, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxVersion int
+	tx.Model(&db.ReportContent{}).Where("report_id = ?", reportID).
+		Select("COALESCE(MAX(version), 0)").Scan(&maxVersion)
+
+	content := db.ReportContent{
+		ReportID:      reportID,
+		Version:       maxVersion + 1,
+		SchemaVersion: reportDataSchemaVersion,
+		Data:          string(raw),
+		Filename:      filename,
+		CreatedAt:     time.Now().Format("2006-01-02 15:04:05"),
+		CreatedBy:     createdBy,
+	}
+	if err := tx.Create(&content).Error; err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+// LoadReportContent возвращает последнюю сохранённую версию данных отчёта
+func LoadReportContent(reportID uint) (*ReportData, *db.ReportContent, error) {
+	var content db.ReportContent
+	if err := db.DB.Where("report_id = ?", reportID).Order("version DESC").First(&content).Error; err != nil {
+		return nil, nil, err
+	}
+	var data ReportData
+	if err := json.Unmarshal([]byte(content.Data), &data); err != nil {
+		return nil, nil, err
+	}
+	return &data, &content, nil
+}
+
+// afterReportCreated - общие действия после создания отчёта:
+// сохранение исходных данных и привязка к заявкам
+func afterReportCreated(report *db.Report, reportData ReportData, createdBy uint) {
+	if _, err := saveReportContent(db.DB, report.ID, reportData, report.Filename, createdBy); err != nil {
+		log.Printf("Ошибка при сохранении данных отчёта %d: %v", report.ID, err)
+	}
+
+	if reportData.TicketID != nil && *reportData.TicketID > 0 {
+		linkReportToTicket(report.ID, *reportData.TicketID)
+	} else {
+		autoLinkReportToTickets(report.ID, reportData.Address)
+	}
+}
+
+// GetReportData - исходные данные отчёта (оборудование, чек-лист, дефекты, рекомендации и т.д.)
+func GetReportData(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID отчета"})
+		return
+	}
+
+	var report db.Report
+	if err := db.DB.First(&report, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Отчет не найден"})
+		return
+	}
+
+	data, content, err := LoadReportContent(report.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Данные отчёта не сохранены (отчёт загружен файлом или создан до включения хранения)"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"report":  report,
+		"content": content,
+		"data":    data,
+	})
+}
+
+// SearchReportData - поиск по сохранённым данным отчётов (последние версии).
+// field - одно из searchableFields, без него поиск идёт по всему содержимому
+func SearchReportData(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указана строка поиска"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	query := db.DB.Table("report_contents").
+		Joins("JOIN reports ON reports.id = report_contents.report_id").
+		Where("report_contents.version = (SELECT MAX(rc.version) FROM report_contents rc WHERE rc.report_id = report_contents.report_id)")
+
+	if field := c.Query("field"); field != "" {
+		key, ok := searchableFields[field]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Поиск по этому полю не поддерживается"})
+			return
+		}
+		query = query.Where("report_contents.data->>? ILIKE ?", key, "%"+q+"%")
+	} else {
+		query = query.Where("report_contents.data::text ILIKE ?", "%"+q+"%")
+	}
+	if startDate := c.Query("startDate"); startDate != "" {
+		query = query.Where("reports.date >= ?", startDate)
+	}
+	if endDate := c.Query("endDate"); endDate != "" {
+		query = query.Where("reports.date <= ?", endDate)
+	}
+	if classification := c.Query("classification"); classification != "" {
+		query = query.Where("reports.classification = ?", classification)
+	}
+	if address := c.Query("address"); address != "" {
+		query = query.Where("reports.address ILIKE ?", "%"+address+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при поиске"})
+		return
+	}
+
+	var rows []struct {
+		ReportID       uint
+		Filename       string
+		Date           string
+		Address        string
+		Classification string
+		Version        int
+		Data           string
+	}
+	if err := query.Select("reports.id AS report_id, reports.filename, reports.date, reports.address, reports.classification, report_contents.version, report_contents.data").
+		Order("reports.date DESC, reports.id DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при поиске"})
+		return
+	}
+
+	results := make([]gin.H, 0, len(rows))
+	for _, row := range rows {
+		var data ReportData
+		_ = json.Unmarshal([]byte(row.Data), &data)
+		results = append(results, gin.H{
+			"reportId":        row.ReportID,
+			"filename":        row.Filename,
+			"date":            row.Date,
+			"address":         row.Address,
+			"classification":  row.Classification,
+			"version":         row.Version,
+			"defects":         data.Defects,
+			"recommendations": data.Recommendations,
+			"material":        data.Material,
+			"equipmentItems":  data.EquipmentItems,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
@@ -0,0 +1,46 @@
+package report
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/export"
+)
+
+var reportStatusLabels = map[string]string{
+	db.ReportDraft:     "Черновик",
+	db.ReportSubmitted: "На согласовании",
+	db.ReportApproved:  "Согласован",
+	db.ReportRejected:  "Отклонён",
+}
+
+// reportColumns - колонки выгрузки отчётов (имена инженеров кэшируются на время выгрузки)
+func reportColumns() []export.Column[db.Report] {
+	userName := export.UserNames()
+	return []export.Column[db.Report]{
+		{Key: "id", Header: "ID", Kind: export.Number, Width: 8, Value: func(r *db.Report) interface{} { return r.ID }},
+		{Key: "actNumber", Header: "Номер акта", Width: 16, Value: func(r *db.Report) interface{} { return r.ActNumber }},
+		{Key: "date", Header: "Дата", Kind: export.Date, Width: 12, Value: func(r *db.Report) interface{} { return r.Date }},
+		{Key: "address", Header: "Адрес", Width: 40, Value: func(r *db.Report) interface{} { return r.Address }},
+		{Key: "classification", Header: "Классификация", Width: 24, Value: func(r *db.Report) interface{} { return classifications.Name(r.Classification) }},
+		{Key: "engineer", Header: "Инженер", Width: 24, Value: func(r *db.Report) interface{} { return userName(r.UserID) }},
+		{Key: "status", Header: "Статус", Width: 16, Value: func(r *db.Report) interface{} { return reportStatusLabels[r.Status] }},
+		{Key: "reviewedAt", Header: "Дата решения", Kind: export.DateTime, Width: 16, Value: func(r *db.Report) interface{} { return r.ReviewedAt }},
+		{Key: "reviewComment", Header: "Комментарий согласующего", Width: 30, Value: func(r *db.Report) interface{} { return r.ReviewComment }},
+		{Key: "filename", Header: "Файл", Width: 40, Value: func(r *db.Report) interface{} { return r.Filename }},
+	}
+}
+
+// ExportReports - выгрузка отчётов в XLSX/CSV с теми же фильтрами, что и у списка
+// (onlyMine, startDate/endDate, search, actNumber, order) без постраничной разбивки
+func ExportReports(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return
+	}
+	export.Stream(c, reportsQuery(c, userID).Order(reportsOrder(c)), reportColumns(), "reports")
+}
@@ -1,10 +1,8 @@
 package report
 
 import (
-	"archive/zip"
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -12,19 +10,27 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
-	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
+	"backend/internal/analytics"
+	"backend/internal/audit"
+	"backend/internal/checklists"
+	"backend/internal/classifications"
 	"backend/internal/db"
 	"backend/internal/docgen"
+	"backend/internal/integrity"
+	"backend/internal/pdftext"
+	"backend/internal/photos"
+	"backend/internal/signatures"
 	"backend/internal/storage"
+	"backend/internal/templates"
 )
 
 type EquipmentItem struct {
@@ -48,11 +54,14 @@ type ReportData struct {
 	AdditionalWorks  string                   `json:"additionalWorks"`
 	Comments         string                   `json:"comments"`
 	ChecklistItems   []map[string]interface{} `json:"checklistItems"`
-	Photos           []string                 `json:"photos"`
+	Photos           []string                 `json:"photos"`   // устаревшее: фото в base64 прямо в запросе
+	PhotoIDs         []uint                   `json:"photoIds"` // фотографии, загруженные через /api/photos
 	FirstName        string                   `json:"firstName"`
 	LastName         string                   `json:"lastName"`
 	UserId           uint                     `json:"userId"`
 	TicketID         *uint                    `json:"ticketId"` // ID заявки для привязки (опционально)
+	Submit           bool                     `json:"submit"`   // сразу отправить на согласование
+	Signatures       []signatures.Input       `json:"signatures,omitempty"`
 }
 
 func DeleteReport(c *gin.Context) {
@@ -87,6 +96,14 @@ func DeleteReport(c *gin.Context) {
 		return
 	}
 
+	audit.SetEntity(c, "reports", report.ID)
+	audit.SetBefore(c, report)
+	deletePreviousVersionFiles(report)
+	db.DB.Where("report_id = ?", report.ID).Delete(&db.ReportContent{})
+	db.DB.Where("report_id = ?", report.ID).Delete(&db.ReportReview{})
+	db.DB.Where("report_id = ?", report.ID).Delete(&db.ReportText{})
+	signatures.DeleteForReport(context.Background(), report.ID)
+	photos.DeleteForReport(context.Background(), report.ID)
 	if err := db.DB.Delete(&report).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении данных из БД"})
 		return
@@ -154,60 +171,26 @@ func autoLinkReportToTickets(reportID uint, address string) {
 	linkReportToTicket(reportID, ticket.ID)
 }
 
-func CreateReport(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
-		return
-	}
-
-	var reportData ReportData
-	if err := c.ShouldBindJSON(&reportData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
-		return
-	}
-
-	// Если явно передан userId (исполнитель), используем его, иначе текущий userID
-	var execUserId uint
-	if reportData.UserId != 0 {
-		execUserId = reportData.UserId
-	} else if id, ok := userID.(uint); ok {
-		execUserId = id
-	} else if id, ok := userID.(int); ok {
-		execUserId = uint(id)
-	} else {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось определить исполнителя"})
-		return
-	}
-
-	var user db.User
-	if err := db.DB.First(&user, execUserId).Error; err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			gin.H{"error": "Ошибка при получении данных исполнителя"},
-		)
+// ensureAddress добавляет адрес в справочник, если его там ещё нет
+func ensureAddress(address string) {
+	address = strings.TrimSpace(address)
+	if address == "" {
 		return
 	}
-
-	if reportData.Address != "" {
-		var existingAddress db.Address
-		if err := db.DB.Where("address = ?", strings.TrimSpace(reportData.Address)).First(&existingAddress).Error; err != nil {
-			newAddress := db.Address{
-				Address: strings.TrimSpace(reportData.Address),
-			}
-			if err := db.DB.Create(&newAddress).Error; err != nil {
-				log.Printf("Ошибка при добавлении нового адреса: %v", err)
-			}
+	var existingAddress db.Address
+	if err := db.DB.Where("address = ?", address).First(&existingAddress).Error; err != nil {
+		if err := db.DB.Create(&db.Address{Address: address}).Error; err != nil {
+			log.Printf("Ошибка при добавлении нового адреса: %v", err)
 		}
 	}
+}
 
+// rememberEquipment запоминает оборудование адреса для классификации отчёта
+func rememberEquipment(reportData ReportData) {
 	if reportData.Address != "" && reportData.Classification != "" {
 		// Сохраняем каждое оборудование отдельно
 		// Сначала удаляем все старые записи для этого адреса и классификации
-		classification := reportData.Classification
-		if classification == "Аварийный вызов" {
-			classification = "АВ"
-		}
+		classification := classifications.Normalize(reportData.Classification)
 		db.DB.Where("address = ? AND classification = ?", reportData.Address, classification).Delete(&db.EquipmentMemory{})
 
 		// Затем добавляем новые записи
@@ -231,477 +214,248 @@ func CreateReport(c *gin.Context) {
 			}
 		}
 	}
+}
 
-	if reportData.Classification == "Аварийный вызов" {
-		reportData.Classification = "АВ"
+func CreateReport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return
 	}
 
-	reportData.FirstName = user.FirstName
-	reportData.LastName = user.LastName
-
-	pyServiceURL := os.Getenv("PY_SERVICE_URL")
-	if pyServiceURL != "" && storage.IsS3Enabled() {
-		payload, _ := json.Marshal(reportData)
-		req, err := http.NewRequest(http.MethodPost, pyServiceURL, bytes.NewReader(payload))
-		if err == nil {
-			req.Header.Set("Content-Type", "application/json")
-			cli := &http.Client{Timeout: 120 * time.Second}
-			resp, err := cli.Do(req)
-			if err == nil && resp.StatusCode == http.StatusOK {
-				defer resp.Body.Close()
-				var out struct {
-					PDF     string `json:"pdf"`
-					Preview string `json:"preview"`
-				}
-				dec := json.NewDecoder(resp.Body)
-				if err := dec.Decode(&out); err == nil && strings.HasSuffix(out.PDF, ".pdf") {
-					report := db.Report{
-						Filename:       filepath.Base(out.PDF),
-						Date:           reportData.Date,
-						Address:        reportData.Address,
-						UserID:         execUserId,
-						Classification: reportData.Classification,
-					}
-					if result := db.DB.Create(&report); result.Error != nil {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Ошибка при сохранении в БД: %v", result.Error)})
-						return
-					}
-					// Привязка отчёта к заявке
-					if reportData.TicketID != nil && *reportData.TicketID > 0 {
-						linkReportToTicket(report.ID, *reportData.TicketID)
-					} else {
-						autoLinkReportToTickets(report.ID, reportData.Address)
-					}
-					respMap := gin.H{
-						"message":     "Отчет успешно создан",
-						"report":      report,
-						"displayName": report.Filename,
-						"id":          report.ID,
-					}
-					if out.Preview != "" {
-						respMap["previewName"] = filepath.Base(out.Preview)
-					}
-					c.JSON(http.StatusOK, respMap)
-					return
-				}
-			}
-		}
+	var reportData ReportData
+	if err := c.ShouldBindJSON(&reportData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
 	}
 
-	// Попытка использовать gRPC сервис генерации документов
-	grpcClient, err := docgen.GetClientFromEnv()
-	if err == nil && grpcClient != nil {
-		defer grpcClient.Close()
-
-		// Преобразуем checklistItems
-		var checklistItems []*docgen.ChecklistItem
-		for _, item := range reportData.ChecklistItems {
-			if task, ok := item["task"].(string); ok {
-				done, _ := item["done"].(bool)
-				checklistItems = append(checklistItems, &docgen.ChecklistItem{
-					Task: task,
-					Done: done,
-				})
-			}
-		}
-
-		// Создаём gRPC запрос
-		req := &docgen.GenerateDocumentRequest{
-			Date:            reportData.Date,
-			Address:         reportData.Address,
-			MachineName:     reportData.Machine_name,
-			MachineNumber:   reportData.Machine_number,
-			InventoryNumber: reportData.Inventory_number,
-			Classification:  reportData.Classification,
-			CustomClass:     reportData.CustomClass,
-			Material:        reportData.Material,
-			Recommendations: reportData.Recommendations,
-			Defects:         reportData.Defects,
-			AdditionalWorks: reportData.AdditionalWorks,
-			Comments:        reportData.Comments,
-			ChecklistItems:  checklistItems,
-			Photos:          reportData.Photos,
-			FirstName:       reportData.FirstName,
-			LastName:        reportData.LastName,
-		}
-
-		ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
-		defer cancel()
-
-		resp, err := grpcClient.GenerateDocument(ctx, req)
-		if err == nil && resp.Success {
-			// Успешная генерация через gRPC
-			displayName := resp.PdfFilename
-			previewName := resp.PreviewFilename
-
-			// Если есть контент, сохраняем файлы
-			if len(resp.PdfContent) > 0 {
-				uploadsDir := filepath.Join("uploads", "reports")
-				os.MkdirAll(uploadsDir, 0755)
-				filePath := filepath.Join(uploadsDir, displayName)
-				if err := os.WriteFile(filePath, resp.PdfContent, 0644); err != nil {
-					log.Printf("Ошибка при сохранении PDF: %v", err)
-				}
-			}
-
-			if len(resp.PreviewContent) > 0 && previewName != "" {
-				previewsDir := filepath.Join("uploads", "previews")
-				os.MkdirAll(previewsDir, 0755)
-				previewPath := filepath.Join(previewsDir, previewName)
-				if err := os.WriteFile(previewPath, resp.PreviewContent, 0644); err != nil {
-					log.Printf("Ошибка при сохранении превью: %v", err)
-				}
-			}
-
-			filePath := filepath.Clean(filepath.Join("uploads", "reports", displayName))
-
-			if storage.IsS3Enabled() {
-				f, err := os.Open(filePath)
-				if err == nil {
-					defer f.Close()
-					info, _ := f.Stat()
-
-					ext := filepath.Ext(displayName)
-					base := strings.TrimSuffix(displayName, ext)
-					uniqueName := displayName
-					counter := 1
-
-					for {
-						key := "reports/" + uniqueName
-						obj, _, e := storage.GetReportObject(context.Background(), key)
-						if e == nil && obj != nil {
-							obj.Close()
-							uniqueName = fmt.Sprintf("%s(%d)%s", base, counter, ext)
-							counter++
-							continue
-						}
-						break
-					}
-
-					_, _ = f.Seek(0, 0)
-					_ = storage.UploadReportObject(context.Background(), "reports/"+uniqueName, f, info.Size(), "application/pdf")
-					_ = os.Remove(filePath)
-					displayName = uniqueName
-
-					if previewName != "" {
-						p := filepath.Clean(filepath.Join("uploads", "previews", previewName))
-						pf, err := os.Open(p)
-						if err == nil {
-							defer pf.Close()
-							pinfo, _ := pf.Stat()
-
-							pext := filepath.Ext(previewName)
-							pbase := strings.TrimSuffix(previewName, pext)
-							pUnique := previewName
-							pCounter := 1
-
-							for {
-								pKey := "previews/" + pUnique
-								pObj, _, pe := storage.GetReportObject(context.Background(), pKey)
-								if pe == nil && pObj != nil {
-									pObj.Close()
-									pUnique = fmt.Sprintf("%s(%d)%s", pbase, pCounter, pext)
-									pCounter++
-									continue
-								}
-								break
-							}
-
-							_, _ = pf.Seek(0, 0)
-							_ = storage.UploadReportObject(context.Background(), "previews/"+pUnique, pf, pinfo.Size(), "image/png")
-							_ = os.Remove(p)
-							previewName = pUnique
-
-							// Загружаем все страницы превью (_page_1.png, _page_2.png, etc.)
-							previewsDir := filepath.Join("uploads", "previews")
-							pageNum := 1
-							for {
-								pageFileName := fmt.Sprintf("%s_page_%d.png", pbase, pageNum)
-								pagePath := filepath.Join(previewsDir, pageFileName)
-								pageFile, err := os.Open(pagePath)
-								if err != nil {
-									break // Больше нет страниц
-								}
-								pageInfo, _ := pageFile.Stat()
-
-								// Формируем уникальное имя для страницы в S3
-								pageUniqueName := fmt.Sprintf("%s_page_%d.png", strings.TrimSuffix(pUnique, pext), pageNum)
-
-								_, _ = pageFile.Seek(0, 0)
-								_ = storage.UploadReportObject(context.Background(), "previews/"+pageUniqueName, pageFile, pageInfo.Size(), "image/png")
-								pageFile.Close()
-								_ = os.Remove(pagePath)
-								pageNum++
-							}
-						}
-					}
-				} else {
-					_ = os.Remove(filePath)
-				}
-			}
-
-			report := db.Report{
-				Filename:       displayName,
-				Date:           reportData.Date,
-				Address:        reportData.Address,
-				UserID:         execUserId,
-				Classification: reportData.Classification,
-			}
-
-			if result := db.DB.Create(&report); result.Error != nil {
-				c.JSON(
-					http.StatusInternalServerError,
-					gin.H{"error": fmt.Sprintf("Ошибка при сохранении в БД: %v", result.Error)},
-				)
-				return
-			}
-
-			// Привязка отчёта к заявке
-			if reportData.TicketID != nil && *reportData.TicketID > 0 {
-				linkReportToTicket(report.ID, *reportData.TicketID)
-			} else {
-				autoLinkReportToTickets(report.ID, reportData.Address)
-			}
-
-			respMap := gin.H{
-				"message":     "Отчет успешно создан",
-				"report":      report,
-				"displayName": displayName,
-				"id":          report.ID,
-			}
-			if previewName != "" {
-				respMap["previewName"] = previewName
-			}
-			c.JSON(http.StatusOK, respMap)
-			return
-		}
+	// Если явно передан userId (исполнитель), используем его, иначе текущий userID
+	var execUserId uint
+	if reportData.UserId != 0 {
+		execUserId = reportData.UserId
+	} else if id, ok := userID.(uint); ok {
+		execUserId = id
+	} else if id, ok := userID.(int); ok {
+		execUserId = uint(id)
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось определить исполнителя"})
+		return
 	}
 
-	// Fallback: использование прямого вызова Python скрипта через exec
-	log.Println("Используется fallback метод генерации документов через exec")
+	authorID, _ := userID.(uint)
 
-	jsonData, err := json.MarshalIndent(reportData, "", "  ")
-	if err != nil {
+	var user db.User
+	if err := db.DB.First(&user, execUserId).Error; err != nil {
 		c.JSON(
 			http.StatusInternalServerError,
-			gin.H{"error": fmt.Sprintf("Ошибка при обработке данных: %v", err)},
+			gin.H{"error": "Ошибка при получении данных исполнителя"},
 		)
 		return
 	}
 
-	tempFile, err := os.CreateTemp("", "report_data_*.json")
-	if err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			gin.H{"error": fmt.Sprintf("Ошибка при создании временного файла: %v", err)},
-		)
-		return
-	}
-	defer os.Remove(tempFile.Name())
+	reportData.UserId = execUserId
 
-	if _, err := tempFile.Write(jsonData); err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			gin.H{"error": fmt.Sprintf("Ошибка при записи данных: %v", err)},
-		)
+	if !validateReportData(c, &reportData, 0, authorID, execUserId) {
 		return
 	}
 
-	tempFile.Close()
-
-	scriptPath := filepath.Join("scripts", "document_generator_core.py")
-	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-		c.JSON(
-			http.StatusInternalServerError,
-			gin.H{"error": fmt.Sprintf("Скрипт Python не найден: %v", err)},
-		)
+	// Генерация выполняется в фоне, статус - GET /api/report-jobs/:id
+	job, created, err := enqueueReportJob(authorID, idempotencyKeyFrom(c), reportData, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Ошибка при постановке задачи: %v", err)})
 		return
 	}
 
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		pythonPath := filepath.Join("scripts", "venv", "Scripts", "python.exe")
-		cmd = exec.Command(pythonPath, scriptPath, tempFile.Name())
-	} else {
-		pythonPaths := []string{
-			filepath.Join("scripts", "venv", "bin", "python3"),
-			filepath.Join("scripts", "venv", "bin", "python"),
-			"python3",
-			"python",
-		}
-
-		var pythonPath string
-		for _, path := range pythonPaths {
-			if _, err := exec.LookPath(path); err == nil {
-				pythonPath = path
-				break
-			}
-		}
-
-		if pythonPath == "" {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "Python интерпретатор не найден"},
-			)
-			return
-		}
-
-		cmd = exec.Command(pythonPath, scriptPath, tempFile.Name())
+	status := http.StatusAccepted
+	if !created {
+		status = http.StatusOK
 	}
+	c.JSON(status, gin.H{
+		"message": "Отчет поставлен в очередь на формирование",
+		"jobId":   job.ID,
+		"status":  job.Status,
+		"job":     job,
+	})
+}
 
-	output, err := cmd.CombinedOutput()
+// validateReportData - общие для создания и редактирования проверки до постановки в очередь:
+// обязательные пункты чек-листа, подписи (им проставляется время получения) и фотографии.
+// reportID = 0 - новый отчёт, owners - кто мог загрузить фотографии. При ошибке ответ уже отправлен
+func validateReportData(c *gin.Context, reportData *ReportData, reportID uint, owners ...uint) bool {
+	missing, err := checklists.MissingRequired(reportData.Classification, reportEquipment(*reportData), doneTasks(*reportData))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf(
-				"Ошибка при генерации документа: %v\nOutput: %s",
-				err,
-				string(output),
-			),
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при проверке чек-листа"})
+		return false
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":        "Не отмечены обязательные пункты чек-листа: " + strings.Join(missing, "; "),
+			"missingTasks": missing,
 		})
-		return
+		return false
 	}
 
-	lines := strings.Split(string(output), "\n")
-	var displayName string
-	var previewName string
-	log.Printf("Python script output: %s", string(output))
-	for _, lineRaw := range lines {
-		line := strings.TrimSpace(strings.ReplaceAll(strings.TrimPrefix(lineRaw, "\ufeff"), "\r", ""))
-		if line == "" || strings.Contains(line, "%") {
-			continue
-		}
-		log.Printf("Parsed line: '%s'", line)
-		if strings.HasSuffix(line, ".pdf") {
-			displayName = line
-		}
-		if strings.HasSuffix(line, ".png") {
-			previewName = line
-		}
+	signatures.Stamp(reportData.Signatures)
+	if _, err := signatures.Prepare(reportData.Signatures); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
 	}
-	log.Printf("displayName: '%s', previewName: '%s'", displayName, previewName)
-
-	if displayName == "" {
-		c.JSON(
-			http.StatusInternalServerError,
-			gin.H{"error": "Не удалось получить имя сгенерированного файла"},
-		)
-		return
+	if err := photos.Check(reportData.PhotoIDs, reportID, owners...); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
 	}
+	return true
+}
 
-	filePath := filepath.Clean(filepath.Join("uploads", "reports", displayName))
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		c.JSON(
-			http.StatusInternalServerError,
-			gin.H{"error": fmt.Sprintf("Сгенерированный файл не найден: %v", err)},
-		)
-		return
+// reportEquipment - оборудование отчёта; если в форме его нет, берётся запомненное для адреса
+func reportEquipment(reportData ReportData) []string {
+	var names []string
+	for _, item := range reportData.EquipmentItems {
+		if name := strings.TrimSpace(item.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		names = checklists.MemoryEquipment(reportData.Address, reportData.Classification)
 	}
+	return names
+}
 
-	if storage.IsS3Enabled() {
-		f, err := os.Open(filePath)
-		if err == nil {
-			defer f.Close()
-			info, _ := f.Stat()
-
-			ext := filepath.Ext(displayName)
-			base := strings.TrimSuffix(displayName, ext)
-			uniqueName := displayName
-			counter := 1
-
-			for {
-				key := "reports/" + uniqueName
-				obj, _, e := storage.GetReportObject(context.Background(), key)
-				if e == nil && obj != nil {
-					obj.Close()
-					uniqueName = fmt.Sprintf("%s(%d)%s", base, counter, ext)
-					counter++
-					continue
-				}
-				break
-			}
+// doneTasks - отмеченные пункты чек-листа отчёта
+func doneTasks(reportData ReportData) []string {
+	var tasks []string
+	for _, item := range reportData.ChecklistItems {
+		task, _ := item["task"].(string)
+		if done, _ := item["done"].(bool); done && task != "" {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
 
-			_, _ = f.Seek(0, 0)
-			_ = storage.UploadReportObject(context.Background(), "reports/"+uniqueName, f, info.Size(), "application/pdf")
-			_ = os.Remove(filePath)
-			displayName = uniqueName
-
-			if previewName != "" {
-				p := filepath.Clean(filepath.Join("uploads", "previews", previewName))
-				log.Printf("Trying to open preview file: %s", p)
-				pf, err := os.Open(p)
-				if err == nil {
-					defer pf.Close()
-					pinfo, _ := pf.Stat()
-					log.Printf("Preview file size: %d bytes", pinfo.Size())
-
-					pext := filepath.Ext(previewName)
-					pbase := strings.TrimSuffix(previewName, pext)
-					pUnique := previewName
-					pCounter := 1
-
-					for {
-						pKey := "previews/" + pUnique
-						pObj, _, pe := storage.GetReportObject(context.Background(), pKey)
-						if pe == nil && pObj != nil {
-							pObj.Close()
-							pUnique = fmt.Sprintf("%s(%d)%s", pbase, pCounter, pext)
-							pCounter++
-							continue
-						}
-						break
-					}
-
-					_, _ = pf.Seek(0, 0)
-					err := storage.UploadReportObject(context.Background(), "previews/"+pUnique, pf, pinfo.Size(), "image/png")
-					if err != nil {
-						log.Printf("Error uploading preview to S3: %v", err)
-					} else {
-						log.Printf("Preview uploaded to S3: previews/%s", pUnique)
-					}
-					_ = os.Remove(p)
-					previewName = pUnique
-				} else {
-					log.Printf("Error opening preview file: %v", err)
-				}
-			}
+// generateDocument формирует PDF и превью по данным отчёта цепочкой генераторов docgen
+// и выгружает их в хранилище. Возвращает имена файлов PDF и превью.
+// verifyToken - токен публичной проверки, ссылка на неё встраивается в акт QR-кодом
+func generateDocument(reportData ReportData, signed []docgen.DocumentSignature, verifyToken, actNumber string) (string, string, error) {
+	ctx := context.Background()
+	doc := toDocument(reportData)
+	doc.Signatures = signed
+	doc.ActNumber = actNumber
+	if verifyToken != "" {
+		doc.VerifyURL = integrity.VerifyURL(verifyToken)
+		if qr, err := integrity.QRCode(doc.VerifyURL); err == nil {
+			doc.VerifyQR = qr
 		} else {
-			_ = os.Remove(filePath)
+			log.Printf("Ошибка при формировании QR-кода проверки: %v", err)
 		}
 	}
-
-	report := db.Report{
-		Filename:       displayName,
-		Date:           reportData.Date,
-		Address:        reportData.Address,
-		UserID:         execUserId,
-		Classification: reportData.Classification,
+	tpl, err := templates.ForDocument(ctx, reportData.Classification, reportData.Address)
+	if err != nil {
+		log.Printf("Шаблон акта: %v, используется стандартный", err)
+	}
+	doc.Template = tpl
+	uploaded, err := photos.ForDocument(ctx, reportData.PhotoIDs)
+	if err != nil {
+		return "", "", err
 	}
+	doc.Photos = append(doc.Photos, uploaded...)
+	return docgen.DefaultChain().GenerateAndStore(ctx, doc)
+}
 
-	if result := db.DB.Create(&report); result.Error != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			gin.H{"error": fmt.Sprintf("Ошибка при сохранении в БД: %v", result.Error)},
-		)
-		return
+// toDocument преобразует данные формы в документ для генератора
+func toDocument(reportData ReportData) *docgen.Document {
+	doc := &docgen.Document{
+		Date:            reportData.Date,
+		Address:         reportData.Address,
+		MachineName:     reportData.Machine_name,
+		MachineNumber:   reportData.Machine_number,
+		InventoryNumber: reportData.Inventory_number,
+		Classification:  reportData.Classification,
+		CustomClass:     reportData.CustomClass,
+		Material:        reportData.Material,
+		Recommendations: reportData.Recommendations,
+		Defects:         reportData.Defects,
+		AdditionalWorks: reportData.AdditionalWorks,
+		Comments:        reportData.Comments,
+		Photos:          reportData.Photos,
+		FirstName:       reportData.FirstName,
+		LastName:        reportData.LastName,
+	}
+	doc.ClassificationName = classifications.Name(reportData.Classification)
+	for _, item := range reportData.EquipmentItems {
+		doc.EquipmentItems = append(doc.EquipmentItems, docgen.EquipmentEntry{
+			Name:     item.Name,
+			Number:   item.Number,
+			Quantity: item.Quantity,
+		})
 	}
+	for _, item := range reportData.ChecklistItems {
+		if task, ok := item["task"].(string); ok {
+			done, _ := item["done"].(bool)
+			doc.ChecklistItems = append(doc.ChecklistItems, docgen.ChecklistEntry{Task: task, Done: done})
+		}
+	}
+	return doc
+}
 
-	// Привязка отчёта к заявке
-	if reportData.TicketID != nil && *reportData.TicketID > 0 {
-		linkReportToTicket(report.ID, *reportData.TicketID)
-	} else {
-		autoLinkReportToTickets(report.ID, reportData.Address)
+// legacyKeys - отдельные ключи ответов reportscount и trends для встроенных классификаций
+// в прежнем написании. Остальные классификации попадают в other и в общий список byClassification
+var legacyKeys = []struct{ spelling, key string }{
+	{"ТО Китчен", "toKitchen"},
+	{"ТО Пекарня", "toBakery"},
+	{"ТО Китчен/Пекарня", "toKitchenBakery"},
+	{"ТО", "to"},
+	{"АВ", "av"},
+	{"ПНР", "pnr"},
+}
+
+// legacyClassifications - код из справочника -> ключ ответа. Классификация ищется в справочнике
+// по прежнему написанию (код, название или синоним), поэтому переименование кода ключ не теряет
+func legacyClassifications() map[string]string {
+	codes := make(map[string]string, len(legacyKeys))
+	for _, l := range legacyKeys {
+		if c, ok := classifications.Find(l.spelling); ok {
+			codes[c.Code] = l.key
+		}
 	}
+	return codes
+}
+
+// countByClassification - число согласованных отчётов пользователя по классификациям
+// одним запросом через общий движок аналитики
+func countByClassification(userID uint, startDate, endDate string, dims ...string) (*analytics.Result, error) {
+	return analytics.Run(analytics.Query{
+		Dimensions: append(dims, analytics.DimClassification),
+		Measures:   []string{analytics.MeasureReports},
+		StartDate:  startDate,
+		EndDate:    endDate,
+		EngineerID: &userID,
+	})
+}
 
-	resp := gin.H{
-		"message":     "Отчет успешно создан",
-		"report":      report,
-		"displayName": displayName,
-		"id":          report.ID,
+// classificationCounts раскладывает строки аналитики по ключам старого ответа
+func classificationCounts(rows []map[string]interface{}, prefix string, out gin.H) map[string]int64 {
+	all := make(map[string]int64)
+	for _, l := range legacyKeys {
+		out[legacyKey(prefix, l.key)] = int64(0)
+	}
+	codes := legacyClassifications()
+	for _, row := range rows {
+		cls, _ := row[analytics.DimClassification].(string)
+		count := int64(row[analytics.MeasureReports].(float64))
+		all[cls] += count
+		if key, ok := codes[cls]; ok {
+			out[legacyKey(prefix, key)] = out[legacyKey(prefix, key)].(int64) + count
+		}
 	}
-	if previewName != "" {
-		resp["previewName"] = previewName
+	return all
+}
+
+func legacyKey(prefix, key string) string {
+	if prefix == "" {
+		return key
 	}
-	c.JSON(http.StatusOK, resp)
+	return prefix + strings.ToUpper(key[:1]) + key[1:]
 }
 
 func GetReportsCount(c *gin.Context) {
@@ -718,127 +472,41 @@ func GetReportsCount(c *gin.Context) {
 	startDate := c.Query("startDate")
 	endDate := c.Query("endDate")
 
-	var totalCount, monthCount int64
-	var toKitchenCount, toBakeryCount, toKitchenBakeryCount, toCount, avCount, pnrCount int64
-	var filteredTotal, filteredToKitchen, filteredToBakery, filteredToKitchenBakery, filteredTo, filteredAv, filteredPnr int64
-
-	if err := db.DB.Model(&db.Report{}).Where("user_id = ?", userID).Count(&totalCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении общего кол-ва отчетов"})
+	overall, err := countByClassification(userID.(uint), "", "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отчетов"})
 		return
 	}
-
-	if err := db.DB.Model(&db.Report{}).
-		Where("user_id = ? AND date BETWEEN ? AND ?", userID, startOfMonth.Format("2006-01-02"), endOfMonth.Format("2006-01-02")).
-		Count(&monthCount).Error; err != nil {
+	month, err := countByClassification(userID.(uint), startOfMonth.Format("2006-01-02"), endOfMonth.Format("2006-01-02"))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отчетов за месяц"})
 		return
 	}
 
-	if err := db.DB.Model(&db.Report{}).
-		Where("user_id = ? AND classification = ?", userID, "ТО Китчен").
-		Count(&toKitchenCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отчетов с классификацией ТО Китчен"})
-		return
-	}
-	if err := db.DB.Model(&db.Report{}).
-		Where("user_id = ? AND classification = ?", userID, "ТО Пекарня").
-		Count(&toBakeryCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отчетов с классификацией ТО Пекарня"})
-		return
-	}
-	if err := db.DB.Model(&db.Report{}).
-		Where("user_id = ? AND classification = ?", userID, "ТО Китчен/Пекарня").
-		Count(&toKitchenBakeryCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отчетов с классификацией ТО Китчен/Пекарня"})
-		return
-	}
-	if err := db.DB.Model(&db.Report{}).
-		Where("user_id = ? AND classification = ?", userID, "ТО").
-		Count(&toCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отчетов с классификацией ТО"})
-		return
-	}
-	if err := db.DB.Model(&db.Report{}).
-		Where("user_id = ? AND classification = ?", userID, "АВ").
-		Count(&avCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отчетов с классификацией АВ"})
-		return
-	}
-	if err := db.DB.Model(&db.Report{}).
-		Where("user_id = ? AND classification = ?", userID, "ПНР").
-		Count(&pnrCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отчетов с классификацией ПНР"})
-		return
+	response := gin.H{
+		"total": int64(overall.Totals[analytics.MeasureReports]),
+		"month": int64(month.Totals[analytics.MeasureReports]),
 	}
+	response["byClassification"] = classificationCounts(overall.Rows, "", response)
 
+	filtered := &analytics.Result{Totals: map[string]float64{}}
 	if startDate != "" && endDate != "" {
-		if err := db.DB.Model(&db.Report{}).
-			Where("user_id = ? AND date BETWEEN ? AND ?", userID, startDate, endDate).
-			Count(&filteredTotal).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении общего кол-ва отфильтрованных отчетов"})
-			return
-		}
-		if err := db.DB.Model(&db.Report{}).
-			Where("user_id = ? AND classification = ? AND date BETWEEN ? AND ?", userID, "ТО Китчен", startDate, endDate).
-			Count(&filteredToKitchen).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отфильтрованных отчетов с классификацией ТО Китчен"})
-			return
-		}
-		if err := db.DB.Model(&db.Report{}).
-			Where("user_id = ? AND classification = ? AND date BETWEEN ? AND ?", userID, "ТО Пекарня", startDate, endDate).
-			Count(&filteredToBakery).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отфильтрованных отчетов с классификацией ТО Пекарня"})
-			return
-		}
-		if err := db.DB.Model(&db.Report{}).
-			Where("user_id = ? AND classification = ? AND date BETWEEN ? AND ?", userID, "ТО Китчен/Пекарня", startDate, endDate).
-			Count(&filteredToKitchenBakery).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отфильтрованных отчетов с классификацией ТО Китчен/Пекарня"})
-			return
-		}
-		if err := db.DB.Model(&db.Report{}).
-			Where("user_id = ? AND classification = ? AND date BETWEEN ? AND ?", userID, "ТО", startDate, endDate).
-			Count(&filteredTo).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отфильтрованных отчетов с классификацией ТО"})
-			return
-		}
-		if err := db.DB.Model(&db.Report{}).
-			Where("user_id = ? AND classification = ? AND date BETWEEN ? AND ?", userID, "АВ", startDate, endDate).
-			Count(&filteredAv).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отфильтрованных отчетов с классификацией АВ"})
-			return
-		}
-		if err := db.DB.Model(&db.Report{}).
-			Where("user_id = ? AND classification = ? AND date BETWEEN ? AND ?", userID, "ПНР", startDate, endDate).
-			Count(&filteredPnr).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отфильтрованных отчетов с классификацией ПНР"})
+		if filtered, err = countByClassification(userID.(uint), startDate, endDate); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении кол-ва отфильтрованных отчетов"})
 			return
 		}
 	}
+	filteredTotal := int64(filtered.Totals[analytics.MeasureReports])
+	response["filteredTotal"] = filteredTotal
+	response["filteredByClassification"] = classificationCounts(filtered.Rows, "filtered", response)
 
-	filteredOther := filteredTotal - filteredToKitchen - filteredToBakery - filteredToKitchenBakery - filteredTo - filteredAv - filteredPnr
-	if filteredOther < 0 {
-		filteredOther = 0
+	filteredOther := filteredTotal
+	for _, l := range legacyKeys {
+		filteredOther -= response[legacyKey("filtered", l.key)].(int64)
 	}
+	response["filteredOther"] = filteredOther
 
-	c.JSON(http.StatusOK, gin.H{
-		"total":                   totalCount,
-		"month":                   monthCount,
-		"toKitchen":               toKitchenCount,
-		"toBakery":                toBakeryCount,
-		"toKitchenBakery":         toKitchenBakeryCount,
-		"to":                      toCount,
-		"av":                      avCount,
-		"pnr":                     pnrCount,
-		"filteredTotal":           filteredTotal,
-		"filteredToKitchen":       filteredToKitchen,
-		"filteredToBakery":        filteredToBakery,
-		"filteredToKitchenBakery": filteredToKitchenBakery,
-		"filteredTo":              filteredTo,
-		"filteredAv":              filteredAv,
-		"filteredPnr":             filteredPnr,
-		"filteredOther":           filteredOther,
-	})
+	c.JSON(http.StatusOK, response)
 }
 
 func GetReportsTrends(c *gin.Context) {
@@ -854,68 +522,77 @@ func GetReportsTrends(c *gin.Context) {
 		months = 6
 	}
 
-	classifications := []string{"АВ", "ТО Китчен", "ТО Пекарня", "ТО Китчен/Пекарня", "ТО", "ПНР"}
+	now := time.Now()
+	firstMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -(months - 1), 0)
+	endOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, 1, 0).Add(-time.Second)
 
-	type MonthData struct {
-		Month              string `json:"month"`
-		Total              int64  `json:"total"`
-		Av                 int64  `json:"av"`
-		ToKitchen          int64  `json:"toKitchen"`
-		ToBakery           int64  `json:"toBakery"`
-		ToKitchenBakery    int64  `json:"toKitchenBakery"`
-		To                 int64  `json:"to"`
-		Pnr                int64  `json:"pnr"`
-		Other              int64  `json:"other"`
+	found, err := countByClassification(userID.(uint), firstMonth.Format("2006-01-02"), endOfMonth.Format("2006-01-02"), analytics.DimMonth)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении динамики отчетов"})
+		return
+	}
+	rowsByMonth := make(map[string][]map[string]interface{})
+	for _, row := range found.Rows {
+		month, _ := row[analytics.DimMonth].(string)
+		rowsByMonth[month] = append(rowsByMonth[month], row)
 	}
 
-	now := time.Now()
-	result := make([]MonthData, 0, months)
-
-	for i := months - 1; i >= 0; i-- {
-		targetDate := now.AddDate(0, -i, 0)
-		startOfMonth := time.Date(targetDate.Year(), targetDate.Month(), 1, 0, 0, 0, 0, targetDate.Location())
-		endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-time.Second)
-		start := startOfMonth.Format("2006-01-02")
-		end := endOfMonth.Format("2006-01-02")
-		monthLabel := startOfMonth.Format("2006-01")
-
-		var totalCount int64
-		db.DB.Model(&db.Report{}).
-			Where("user_id = ? AND date BETWEEN ? AND ?", userID, start, end).
-			Count(&totalCount)
-
-		counts := make(map[string]int64)
-		var classifiedSum int64
-		for _, cls := range classifications {
-			var cnt int64
-			db.DB.Model(&db.Report{}).
-				Where("user_id = ? AND classification = ? AND date BETWEEN ? AND ?", userID, cls, start, end).
-				Count(&cnt)
-			counts[cls] = cnt
-			classifiedSum += cnt
-		}
+	codes := legacyClassifications()
+	result := make([]gin.H, 0, months)
+	for i := 0; i < months; i++ {
+		monthLabel := firstMonth.AddDate(0, i, 0).Format("2006-01")
+		data := gin.H{"month": monthLabel}
+		all := classificationCounts(rowsByMonth[monthLabel], "", data)
 
-		otherCount := totalCount - classifiedSum
-		if otherCount < 0 {
-			otherCount = 0
+		var total, other int64
+		for cls, count := range all {
+			total += count
+			if _, ok := codes[cls]; !ok {
+				other += count
+			}
 		}
-
-		result = append(result, MonthData{
-			Month:           monthLabel,
-			Total:           totalCount,
-			Av:              counts["АВ"],
-			ToKitchen:       counts["ТО Китчен"],
-			ToBakery:        counts["ТО Пекарня"],
-			ToKitchenBakery: counts["ТО Китчен/Пекарня"],
-			To:              counts["ТО"],
-			Pnr:             counts["ПНР"],
-			Other:           otherCount,
-		})
+		data["total"] = total
+		data["other"] = other
+		data["byClassification"] = all
+		result = append(result, data)
 	}
 
 	c.JSON(http.StatusOK, result)
 }
 
+// reportsQuery - отчёты с фильтрами списка: onlyMine, startDate/endDate, search, actNumber
+func reportsQuery(c *gin.Context, userID interface{}) *gorm.DB {
+	query := db.DB.Model(&db.Report{})
+
+	if c.DefaultQuery("onlyMine", "true") == "true" {
+		query = query.Where("user_id = ?", userID)
+	}
+	startDate := c.Query("startDate")
+	endDate := c.Query("endDate")
+	if startDate != "" && endDate != "" {
+		query = query.Where("date BETWEEN ? AND ?", startDate, endDate)
+	}
+	if search := c.Query("search"); search != "" {
+		searchPattern := "%" + search + "%"
+		query = query.Where(
+			"(address ILIKE ? OR date ILIKE ? OR classification ILIKE ? OR filename ILIKE ? OR act_number ILIKE ?)",
+			searchPattern, searchPattern, searchPattern, searchPattern, searchPattern,
+		)
+	}
+	if actNumber := c.Query("actNumber"); actNumber != "" {
+		query = query.Where("act_number = ?", actNumber)
+	}
+	return query
+}
+
+// reportsOrder - сортировка списка по дате (order=asc|desc)
+func reportsOrder(c *gin.Context) string {
+	if c.DefaultQuery("order", "desc") == "asc" {
+		return "date ASC"
+	}
+	return "date DESC"
+}
+
 func GetReportsHandler(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -923,11 +600,6 @@ func GetReportsHandler(c *gin.Context) {
 		return
 	}
 
-	showOnlyMine := c.DefaultQuery("onlyMine", "true")
-	startDate := c.Query("startDate")
-	endDate := c.Query("endDate")
-	search := c.Query("search")
-	order := c.DefaultQuery("order", "desc") // добавлено
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "12"))
 	if page < 1 {
@@ -938,21 +610,7 @@ func GetReportsHandler(c *gin.Context) {
 	}
 
 	var reports []db.Report
-	query := db.DB.Model(&db.Report{})
-
-	if showOnlyMine == "true" {
-		query = query.Where("user_id = ?", userID)
-	}
-	if startDate != "" && endDate != "" {
-		query = query.Where("date BETWEEN ? AND ?", startDate, endDate)
-	}
-	if search != "" {
-		searchPattern := "%" + search + "%"
-		query = query.Where(
-			"(address ILIKE ? OR date ILIKE ? OR classification ILIKE ? OR filename ILIKE ?)",
-			searchPattern, searchPattern, searchPattern, searchPattern,
-		)
-	}
+	query := reportsQuery(c, userID)
 
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -960,13 +618,7 @@ func GetReportsHandler(c *gin.Context) {
 		return
 	}
 
-	// Применяем сортировку
-	orderClause := "date DESC"
-	if order == "asc" {
-		orderClause = "date ASC"
-	}
-
-	if err := query.Order(orderClause).Offset((page - 1) * pageSize).Limit(pageSize).Find(&reports).Error; err != nil {
+	if err := query.Order(reportsOrder(c)).Offset((page - 1) * pageSize).Limit(pageSize).Find(&reports).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении отчетов"})
 		return
 	}
@@ -981,7 +633,7 @@ func GetReportsHandler(c *gin.Context) {
 }
 
 func UploadReport(c *gin.Context) {
-	_, exists := c.Get("userID")
+	uploaderID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
 		return
@@ -1051,12 +703,31 @@ func UploadReport(c *gin.Context) {
 		Address:        address,
 		UserID:         reportUser.ID,
 		Classification: classification,
+		// Загруженный акт, как и сформированный, попадает к клиенту только после согласования
+		Status: db.ReportSubmitted,
 	}
 
-	if err := db.DB.Create(&report).Error; err != nil {
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		number, err := nextActNumber(tx, date, address, classification)
+		if err != nil {
+			return err
+		}
+		number.assign(&report)
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+		return recordReview(tx, report.ID, db.ReportDraft, db.ReportSubmitted, "Загружен вручную", uploaderID.(uint))
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при сохранении данных в БД"})
 		return
 	}
+	if err := integrity.Seal(context.Background(), &report); err != nil {
+		log.Printf("Ошибка при расчёте хеша отчёта %d: %v", report.ID, err)
+	}
+	pdftext.Enqueue(report.ID)
+	audit.SetEntity(c, "reports", report.ID)
+	audit.SetAfter(c, report)
 
 	// Автоматическая привязка отчёта к заявкам по адресу
 	autoLinkReportToTickets(report.ID, address)
@@ -1075,48 +746,7 @@ func DownloadMonthlyReports(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении отчетов"})
 		return
 	}
-	tempDir, err := os.MkdirTemp("", "monthly_reports_*")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании временной директории"})
-		return
-	}
-	defer os.RemoveAll(tempDir)
-	zipFilePath := filepath.Join(tempDir, "monthly_reports.zip")
-	zipFile, err := os.Create(zipFilePath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании ZIP файла"})
-		return
-	}
-	defer zipFile.Close()
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
-	for _, report := range reports {
-		reportPath := filepath.Join("uploads", "reports", report.Filename)
-		var reader io.ReadCloser
-		f, err := os.Open(reportPath)
-		if err == nil {
-			reader = f
-		} else if storage.IsS3Enabled() {
-			obj, _, e := storage.GetReportObject(context.Background(), "reports/"+report.Filename)
-			if e == nil {
-				reader = obj
-			}
-		}
-		if reader == nil {
-			continue
-		}
-		w, err := zipWriter.Create(report.Filename)
-		if err != nil {
-			reader.Close()
-			continue
-		}
-		io.Copy(w, reader)
-		reader.Close()
-	}
-	zipWriter.Close()
-	c.Header("Content-Type", "application/zip")
-	c.Header("Content-Disposition", "attachment; filename=monthly_reports.zip")
-	c.File(zipFilePath)
+	SendReportsArchive(c, reports, "monthly_reports.zip")
 }
 
 func DownloadReportsByPeriod(c *gin.Context) {
@@ -1131,48 +761,7 @@ func DownloadReportsByPeriod(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении отчетов"})
 		return
 	}
-	tempDir, err := os.MkdirTemp("", "reports_by_period_*")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании временной директории"})
-		return
-	}
-	defer os.RemoveAll(tempDir)
-	zipFilePath := filepath.Join(tempDir, "reports_by_period.zip")
-	zipFile, err := os.Create(zipFilePath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании ZIP файла"})
-		return
-	}
-	defer zipFile.Close()
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
-	for _, report := range reports {
-		reportPath := filepath.Join("uploads", "reports", report.Filename)
-		var reader io.ReadCloser
-		f, err := os.Open(reportPath)
-		if err == nil {
-			reader = f
-		} else if storage.IsS3Enabled() {
-			obj, _, e := storage.GetReportObject(context.Background(), "reports/"+report.Filename)
-			if e == nil {
-				reader = obj
-			}
-		}
-		if reader == nil {
-			continue
-		}
-		w, err := zipWriter.Create(report.Filename)
-		if err != nil {
-			reader.Close()
-			continue
-		}
-		io.Copy(w, reader)
-		reader.Close()
-	}
-	zipWriter.Close()
-	c.Header("Content-Type", "application/zip")
-	c.Header("Content-Disposition", "attachment; filename=reports_by_period.zip")
-	c.File(zipFilePath)
+	SendReportsArchive(c, reports, "reports_by_period.zip")
 }
 
 type ReportUploadInfo struct {
@@ -1185,7 +774,7 @@ type ReportUploadInfo struct {
 
 func UploadMultipleReports(c *gin.Context) {
 	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 2<<30)
-	_, exists := c.Get("userID")
+	uploaderID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
 		return
@@ -1264,6 +853,7 @@ func UploadMultipleReports(c *gin.Context) {
 				Address:        address,
 				UserID:         reportUser.ID,
 				Classification: classification,
+				Status:         db.ReportSubmitted,
 			})
 		} else {
 			if err := os.MkdirAll("uploads/reports", 0755); err != nil {
@@ -1290,6 +880,7 @@ func UploadMultipleReports(c *gin.Context) {
 				Address:        address,
 				UserID:         reportUser.ID,
 				Classification: classification,
+				Status:         db.ReportSubmitted,
 			})
 			dst.Close()
 			if err != nil {
@@ -1301,7 +892,25 @@ func UploadMultipleReports(c *gin.Context) {
 	}
 
 	if len(reports) > 0 {
-		if err := db.DB.CreateInBatches(reports, 100).Error; err != nil {
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			for i := range reports {
+				number, err := nextActNumber(tx, reports[i].Date, reports[i].Address, reports[i].Classification)
+				if err != nil {
+					return err
+				}
+				number.assign(&reports[i])
+			}
+			if err := tx.CreateInBatches(reports, 100).Error; err != nil {
+				return err
+			}
+			for _, r := range reports {
+				if err := recordReview(tx, r.ID, db.ReportDraft, db.ReportSubmitted, "Загружен вручную", uploaderID.(uint)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
 			for _, key := range uploadedKeys {
 				_ = storage.DeleteReportObject(context.Background(), key)
 			}
@@ -1311,6 +920,15 @@ func UploadMultipleReports(c *gin.Context) {
 			})
 			return
 		}
+		ids := make([]uint, 0, len(reports))
+		for i := range reports {
+			if err := integrity.Seal(context.Background(), &reports[i]); err != nil {
+				log.Printf("Ошибка при расчёте хеша отчёта %d: %v", reports[i].ID, err)
+			}
+			ids = append(ids, reports[i].ID)
+		}
+		// Текст загруженных PDF извлекается в фоне для поиска
+		pdftext.Enqueue(ids...)
 	}
 
 	response := gin.H{
@@ -1349,57 +967,7 @@ func DownloadSelectedReports(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No reports found for the selected IDs"})
 		return
 	}
-	tempDir, err := os.MkdirTemp("", "selected_reports_*")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temporary directory"})
-		return
-	}
-	defer os.RemoveAll(tempDir)
-	zipFilePath := filepath.Join(tempDir, "selected_reports.zip")
-	zipFile, err := os.Create(zipFilePath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ZIP file"})
-		return
-	}
-	defer zipFile.Close()
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
-
-	for _, report := range reports {
-		reportPath := filepath.Join("uploads", "reports", report.Filename)
-		var reader io.ReadCloser
-		var openErr error
-		f, err := os.Open(reportPath)
-		if err == nil {
-			reader = f
-		} else if storage.IsS3Enabled() {
-			obj, _, e := storage.GetReportObject(context.Background(), "reports/"+report.Filename)
-			openErr = e
-			reader = obj
-		} else {
-			openErr = err
-		}
-		if reader == nil || openErr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open report file"})
-			return
-		}
-		zipEntry, err := zipWriter.Create(report.Filename)
-		if err != nil {
-			reader.Close()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add file to ZIP archive"})
-			return
-		}
-		if _, err := io.Copy(zipEntry, reader); err != nil {
-			reader.Close()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write file to ZIP archive"})
-			return
-		}
-		reader.Close()
-	}
-	zipWriter.Close()
-	c.Header("Content-Type", "application/zip")
-	c.Header("Content-Disposition", "attachment; filename=selected_reports.zip")
-	c.File(zipFilePath)
+	SendReportsArchive(c, reports, "selected_reports.zip")
 }
 
 func PreviewReport(c *gin.Context) {
@@ -0,0 +1,342 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/integrity"
+	"backend/internal/photos"
+	"backend/internal/signatures"
+)
+
+// Статусы задач генерации отчётов
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Виды задач: создание отчёта и повторная генерация при редактировании
+const (
+	JobKindCreate = "create"
+	JobKindUpdate = "update"
+)
+
+const (
+	defaultJobWorkers     = 2
+	defaultJobMaxAttempts = 3
+	jobRetryDelay         = 15 * time.Second
+)
+
+var (
+	jobQueue       = make(chan uint, 256)
+	jobMaxAttempts = defaultJobMaxAttempts
+)
+
+// StartReportJobWorkers запускает обработчики очереди генерации отчётов.
+// Число одновременных генераций - REPORT_JOB_WORKERS, попыток - REPORT_JOB_MAX_ATTEMPTS.
+// Незавершённые задачи (в т.ч. прерванные перезапуском) возвращаются в очередь
+func StartReportJobWorkers() {
+	workers := envInt("REPORT_JOB_WORKERS", defaultJobWorkers)
+	jobMaxAttempts = envInt("REPORT_JOB_MAX_ATTEMPTS", defaultJobMaxAttempts)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for id := range jobQueue {
+				processReportJob(id)
+			}
+		}()
+	}
+
+	var pending []uint
+	db.DB.Model(&db.ReportJob{}).Where("status IN ?", []string{JobQueued, JobRunning}).
+		Order("id").Pluck("id", &pending)
+	if len(pending) > 0 {
+		db.DB.Model(&db.ReportJob{}).Where("status = ?", JobRunning).Update("status", JobQueued)
+		go func() {
+			for _, id := range pending {
+				jobQueue <- id
+			}
+		}()
+	}
+	log.Printf("Report job workers started: %d, pending: %d", workers, len(pending))
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// enqueueReportJob создаёт задачу генерации; reportID задаётся для редактирования
+// существующего отчёта. При совпадении ключа идемпотентности возвращает ранее
+// созданную задачу (created = false)
+func enqueueReportJob(userID uint, idempotencyKey string, reportData ReportData, reportID *uint) (*db.ReportJob, bool, error) {
+	if idempotencyKey != "" {
+		if job, err := findJobByKey(userID, idempotencyKey); err == nil {
+			return job, false, nil
+		}
+	}
+
+	payload, err := json.Marshal(reportData)
+	if err != nil {
+		return nil, false, err
+	}
+	kind := JobKindCreate
+	if reportID != nil {
+		kind = JobKindUpdate
+	}
+	job := db.ReportJob{
+		UserID:         userID,
+		IdempotencyKey: idempotencyKey,
+		Kind:           kind,
+		ReportID:       reportID,
+		Status:         JobQueued,
+		Payload:        string(payload),
+		CreatedAt:      time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := db.DB.Create(&job).Error; err != nil {
+		// Параллельный запрос с тем же ключом успел создать задачу раньше
+		if idempotencyKey != "" {
+			if existing, e := findJobByKey(userID, idempotencyKey); e == nil {
+				return existing, false, nil
+			}
+		}
+		return nil, false, err
+	}
+
+	go func() { jobQueue <- job.ID }()
+	return &job, true, nil
+}
+
+func findJobByKey(userID uint, key string) (*db.ReportJob, error) {
+	var job db.ReportJob
+	if err := db.DB.Where("user_id = ? AND idempotency_key = ?", userID, key).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// processReportJob выполняет одну попытку задачи; при ошибке планирует повтор
+func processReportJob(id uint) {
+	// Захватываем задачу атомарно, чтобы один ID, попавший в очередь дважды, не выполнился повторно
+	claim := db.DB.Model(&db.ReportJob{}).Where("id = ? AND status = ?", id, JobQueued).
+		Updates(map[string]interface{}{
+			"status":     JobRunning,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"started_at": time.Now().Format("2006-01-02 15:04:05"),
+		})
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return
+	}
+
+	var job db.ReportJob
+	if err := db.DB.First(&job, id).Error; err != nil {
+		return
+	}
+
+	report, previewName, err := runReportJob(&job)
+	now := time.Now().Format("2006-01-02 15:04:05")
+	if err == nil {
+		db.DB.Model(&job).Updates(map[string]interface{}{
+			"status":       JobSucceeded,
+			"report_id":    report.ID,
+			"filename":     report.Filename,
+			"preview_name": previewName,
+			"error":        nil,
+			"finished_at":  now,
+		})
+		return
+	}
+
+	log.Printf("Задача генерации отчёта %d, попытка %d: %v", job.ID, job.Attempts, err)
+	if job.Attempts < jobMaxAttempts {
+		db.DB.Model(&job).Updates(map[string]interface{}{"status": JobQueued, "error": err.Error()})
+		time.AfterFunc(jobRetryDelay*time.Duration(job.Attempts), func() { jobQueue <- job.ID })
+		return
+	}
+	db.DB.Model(&job).Updates(map[string]interface{}{
+		"status":      JobFailed,
+		"error":       err.Error(),
+		"finished_at": now,
+	})
+	releaseJobActNumber(&job)
+}
+
+// runReportJob формирует документ и сохраняет отчёт (то, что раньше делал CreateReport синхронно)
+func runReportJob(job *db.ReportJob) (*db.Report, string, error) {
+	if job.Kind == JobKindUpdate {
+		return runReportUpdateJob(job)
+	}
+	var reportData ReportData
+	if err := json.Unmarshal([]byte(job.Payload), &reportData); err != nil {
+		return nil, "", fmt.Errorf("Ошибка при обработке данных: %v", err)
+	}
+
+	var user db.User
+	if err := db.DB.First(&user, reportData.UserId).Error; err != nil {
+		return nil, "", errors.New("Ошибка при получении данных исполнителя")
+	}
+
+	ensureAddress(reportData.Address)
+	rememberEquipment(reportData)
+
+	reportData.Classification = classifications.Normalize(reportData.Classification)
+	reportData.FirstName = user.FirstName
+	reportData.LastName = user.LastName
+
+	signed, err := signatures.Prepare(reportData.Signatures)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Новый акт попадает к клиенту только после согласования
+	status := db.ReportDraft
+	if reportData.Submit {
+		status = db.ReportSubmitted
+	}
+	report := db.Report{
+		Date:           reportData.Date,
+		Address:        reportData.Address,
+		UserID:         reportData.UserId,
+		Classification: reportData.Classification,
+		Status:         status,
+		VerifyToken:    integrity.NewToken(),
+	}
+
+	number, err := reserveJobActNumber(job, reportData.Date, reportData.Address, reportData.Classification)
+	if err != nil {
+		return nil, "", fmt.Errorf("Ошибка при выдаче номера акта: %v", err)
+	}
+	number.assign(&report)
+
+	displayName, previewName, err := generateDocument(reportData, signed, report.VerifyToken, report.ActNumber)
+	if err != nil {
+		return nil, "", err
+	}
+	report.Filename = displayName
+	if err := db.DB.Create(&report).Error; err != nil {
+		deleteDocumentFiles(displayName)
+		return nil, "", fmt.Errorf("Ошибка при сохранении в БД: %v", err)
+	}
+	if status == db.ReportSubmitted {
+		recordReview(db.DB, report.ID, db.ReportDraft, status, "", job.UserID)
+	}
+
+	// Сохранение исходных данных и привязка отчёта к заявке.
+	// Подписи хранятся отдельно (ReportSignature), в данные отчёта не попадают
+	reportData.Signatures = nil
+	afterReportCreated(&report, reportData, job.UserID)
+	photos.Attach(report.ID, report.Address, reportData.PhotoIDs)
+	if len(signed) > 0 {
+		if err := signatures.Save(context.Background(), report.ID, signed, job.UserID); err != nil {
+			log.Printf("Ошибка при сохранении подписей отчёта %d: %v", report.ID, err)
+		}
+	}
+	if err := integrity.Seal(context.Background(), &report); err != nil {
+		log.Printf("Ошибка при расчёте хеша отчёта %d: %v", report.ID, err)
+	}
+
+	return &report, previewName, nil
+}
+
+// loadOwnJob загружает задачу, если она принадлежит пользователю (администратор видит все)
+func loadOwnJob(c *gin.Context) (*db.ReportJob, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return nil, false
+	}
+
+	var job db.ReportJob
+	if err := db.DB.First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Задача не найдена"})
+		return nil, false
+	}
+
+	if id, _ := userID.(uint); id != job.UserID {
+		var user db.User
+		if err := db.DB.First(&user, userID).Error; err != nil || user.Department != "Админ" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Задача не найдена"})
+			return nil, false
+		}
+	}
+	return &job, true
+}
+
+func jobFinished(job *db.ReportJob) bool {
+	return job.Status == JobSucceeded || job.Status == JobFailed
+}
+
+// GetReportJob - состояние задачи генерации отчёта
+func GetReportJob(c *gin.Context) {
+	job, ok := loadOwnJob(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// StreamReportJob - поток событий (SSE) об изменении состояния задачи до её завершения
+func StreamReportJob(c *gin.Context) {
+	job, ok := loadOwnJob(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	last := ""
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		state := job.Status + strconv.Itoa(job.Attempts)
+		if state != last {
+			c.SSEvent("status", job)
+			last = state
+		}
+		if jobFinished(job) {
+			return false
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-ticker.C:
+		}
+
+		var fresh db.ReportJob
+		if err := db.DB.First(&fresh, job.ID).Error; err != nil {
+			return false
+		}
+		job = &fresh
+		return true
+	})
+}
+
+// idempotencyKeyFrom - ключ идемпотентности из заголовка Idempotency-Key
+func idempotencyKeyFrom(c *gin.Context) string {
+	key := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	if len(key) > 128 {
+		key = key[:128]
+	}
+	return key
+}
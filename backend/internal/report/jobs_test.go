@@ -0,0 +1,69 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+	"backend/internal/dbtest"
+)
+
+// Путь создания отчёта целиком: запрос -> задача в очереди -> генерация FakeGenerator -> строка отчёта
+func TestCreateReportJob(t *testing.T) {
+	t.Setenv("DOCGEN_BACKENDS", "fake")
+	t.Chdir(t.TempDir())
+	dbtest.Open(t)
+	gin.SetMode(gin.TestMode)
+
+	user := db.User{FirstName: "Иван", LastName: "Петров", Department: "Инженер", IsActive: true}
+	if err := db.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(ReportData{
+		Date:           "2025-03-14",
+		Address:        "ул. Ленина, 1",
+		Classification: "ТО",
+		Machine_name:   "Печь",
+	})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/reports", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", user.ID)
+	CreateReport(c)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("CreateReport: статус %d, ответ %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		JobID uint `json:"jobId"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.JobID == 0 {
+		t.Fatalf("нет ID задачи в ответе: %s", w.Body.String())
+	}
+
+	processReportJob(resp.JobID)
+
+	var job db.ReportJob
+	if err := db.DB.First(&job, resp.JobID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != JobSucceeded || job.ReportID == nil {
+		t.Fatalf("задача: статус %q, ошибка %v", job.Status, job.Error)
+	}
+	var report db.Report
+	if err := db.DB.First(&report, *job.ReportID).Error; err != nil {
+		t.Fatalf("отчёт не сохранён: %v", err)
+	}
+	if report.UserID != user.ID || report.Address != "ул. Ленина, 1" || report.Status != db.ReportDraft {
+		t.Errorf("неожиданный отчёт: %+v", report)
+	}
+	if report.Filename == "" || report.ActNumber == "" {
+		t.Errorf("у отчёта нет файла или номера: %q, %q", report.Filename, report.ActNumber)
+	}
+}
@@ -0,0 +1,183 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"backend/internal/classifications"
+	"backend/internal/db"
+)
+
+// Области нумерации актов (ACT_NUMBER_SCOPE)
+const (
+	actScopeYear           = "year"           // единая нумерация в пределах года
+	actScopeClient         = "client"         // отдельная нумерация для каждого клиента
+	actScopeClassification = "classification" // отдельная нумерация для каждой классификации
+)
+
+// actNumber - выданный номер акта
+type actNumber struct {
+	Scope  string
+	Year   int
+	Seq    int
+	Number string
+}
+
+// actNumberScope - ключ счётчика и префикс номера для адреса и классификации.
+// Объекты без клиента нумеруются в общей последовательности года
+func actNumberScope(address, classification string) (string, string) {
+	switch os.Getenv("ACT_NUMBER_SCOPE") {
+	case actScopeClient:
+		var addr db.Address
+		if db.DB.Where("address = ?", address).First(&addr).Error == nil && addr.OrganizationID != nil {
+			id := strconv.FormatUint(uint64(*addr.OrganizationID), 10)
+			return "org:" + id, "К" + id + "-"
+		}
+	case actScopeClassification:
+		if c, ok := classifications.Find(classification); ok {
+			return "cls:" + c.Code, actPrefix(c) + "-"
+		}
+	}
+	return "", ""
+}
+
+// actPrefix - короткий префикс номера для классификации: из справочника, без него - по ID
+func actPrefix(c db.Classification) string {
+	if c.ActPrefix != "" {
+		return c.ActPrefix
+	}
+	return "КЛ" + strconv.FormatUint(uint64(c.ID), 10)
+}
+
+// actYear - год акта по дате ТО (YYYY-MM-DD), при неразборчивой дате - текущий
+func actYear(date string) int {
+	if len(date) >= 4 {
+		if year, err := strconv.Atoi(date[:4]); err == nil && year > 0 {
+			return year
+		}
+	}
+	return time.Now().Year()
+}
+
+// nextActNumber выдаёт следующий номер акта внутри транзакции tx.
+// Строка счётчика блокируется до конца транзакции, поэтому параллельные выдачи
+// в одной области ждут друг друга, а при откате транзакции номер не расходуется.
+// Транзакция должна быть короткой: долгая работа в ней останавливает всю нумерацию области
+func nextActNumber(tx *gorm.DB, date, address, classification string) (actNumber, error) {
+	scope, prefix := actNumberScope(address, classification)
+	year := actYear(date)
+
+	counter := db.ActNumberCounter{Year: year, Scope: scope}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&counter).Error; err != nil {
+		return actNumber{}, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("year = ? AND scope = ?", year, scope).First(&counter).Error; err != nil {
+		return actNumber{}, err
+	}
+	counter.LastNumber++
+	if err := tx.Model(&counter).Update("last_number", counter.LastNumber).Error; err != nil {
+		return actNumber{}, err
+	}
+
+	return actNumber{
+		Scope:  scope,
+		Year:   year,
+		Seq:    counter.LastNumber,
+		Number: fmt.Sprintf("%s%d-%05d", prefix, year, counter.LastNumber),
+	}, nil
+}
+
+// reserveJobActNumber выдаёт задаче номер акта короткой отдельной транзакцией (счётчик
+// не блокируется на время генерации) и сохраняет его в задаче в той же транзакции.
+// Повторная попытка задачи получает уже выданный номер. Новой задаче сначала достаётся
+// номер задачи области, завершившейся ошибкой, и только затем - следующий из счётчика
+func reserveJobActNumber(job *db.ReportJob, date, address, classification string) (actNumber, error) {
+	if job.ActNumber != "" {
+		return actNumber{Scope: job.ActScope, Year: job.ActYear, Seq: job.ActSeq, Number: job.ActNumber}, nil
+	}
+	var number actNumber
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		number, err = takeFailedJobActNumber(tx, date, address, classification)
+		if err != nil {
+			return err
+		}
+		if number.Number == "" {
+			if number, err = nextActNumber(tx, date, address, classification); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&db.ReportJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"act_number": number.Number,
+			"act_scope":  number.Scope,
+			"act_year":   number.Year,
+			"act_seq":    number.Seq,
+		}).Error
+	})
+	if err != nil {
+		return actNumber{}, err
+	}
+	job.ActNumber, job.ActScope, job.ActYear, job.ActSeq = number.Number, number.Scope, number.Year, number.Seq
+	return number, nil
+}
+
+// takeFailedJobActNumber забирает наименьший номер, оставшийся за задачей области,
+// которая завершилась ошибкой. Пустой номер - таких задач нет или номер забрали раньше
+func takeFailedJobActNumber(tx *gorm.DB, date, address, classification string) (actNumber, error) {
+	scope, _ := actNumberScope(address, classification)
+	year := actYear(date)
+
+	var failed db.ReportJob
+	err := tx.Where("status = ? AND act_scope = ? AND act_year = ? AND act_seq > 0", JobFailed, scope, year).
+		Order("act_seq").First(&failed).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return actNumber{}, nil
+	}
+	if err != nil {
+		return actNumber{}, err
+	}
+	res := tx.Model(&db.ReportJob{}).Where("id = ? AND act_seq = ?", failed.ID, failed.ActSeq).Updates(clearedActNumber)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return actNumber{}, res.Error
+	}
+	return actNumber{Scope: scope, Year: year, Seq: failed.ActSeq, Number: failed.ActNumber}, nil
+}
+
+// clearedActNumber - поля задачи без выданного номера
+var clearedActNumber = map[string]interface{}{"act_number": nil, "act_scope": "", "act_year": 0, "act_seq": 0}
+
+// releaseJobActNumber возвращает в счётчик номер задачи, завершившейся ошибкой, если он
+// последний выданный в области. Иначе номер остаётся за задачей, и его заберёт
+// следующая задача той же области (takeFailedJobActNumber) - пропусков в нумерации нет
+func releaseJobActNumber(job *db.ReportJob) {
+	if job.ActNumber == "" {
+		return
+	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&db.ActNumberCounter{}).
+			Where("year = ? AND scope = ? AND last_number = ?", job.ActYear, job.ActScope, job.ActSeq).
+			Update("last_number", job.ActSeq-1)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return tx.Model(&db.ReportJob{}).Where("id = ?", job.ID).Updates(clearedActNumber).Error
+	})
+	if err != nil {
+		log.Printf("Ошибка при возврате номера акта %s задачи %d: %v", job.ActNumber, job.ID, err)
+	}
+}
+
+// assign записывает номер в отчёт
+func (n actNumber) assign(report *db.Report) {
+	report.ActNumber = n.Number
+	report.ActYear = n.Year
+	report.ActSeq = n.Seq
+}
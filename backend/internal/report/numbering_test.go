@@ -0,0 +1,124 @@
+package report
+
+import (
+	"strconv"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/dbtest"
+)
+
+func issueActNumber(t *testing.T, date, address, classification string) string {
+	t.Helper()
+	var number actNumber
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		number, err = nextActNumber(tx, date, address, classification)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("выдача номера: %v", err)
+	}
+	return number.Number
+}
+
+func TestNextActNumberScopes(t *testing.T) {
+	dbtest.Open(t)
+	org := db.ClientOrganization{Name: "Кафе"}
+	if err := db.DB.Create(&org).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.DB.Create(&db.Address{Address: "ул. Ленина, 1", OrganizationID: &org.ID})
+	db.DB.Create(&db.Address{Address: "ул. Мира, 5"})
+	db.DB.Create(&db.Classification{Code: "ТО Китчен/Пекарня", Name: "ТО Китчен/Пекарня", ActPrefix: "ТОКП", CreatedAt: "2025-01-01 00:00:00"})
+	noPrefix := db.Classification{Code: "Монтаж", Name: "Монтаж", CreatedAt: "2025-01-01 00:00:00"}
+	db.DB.Create(&noPrefix)
+	classifications.Invalidate()
+	t.Cleanup(classifications.Invalidate)
+
+	tests := []struct {
+		scope, date, address, classification, want string
+	}{
+		{"", "2025-03-14", "ул. Ленина, 1", "ТО", "2025-00001"},
+		{"", "2025-04-01", "ул. Мира, 5", "АВ", "2025-00002"},
+		{"", "2026-01-10", "ул. Мира, 5", "АВ", "2026-00001"},
+		{actScopeClient, "2025-03-14", "ул. Ленина, 1", "ТО", "К" + itoa(org.ID) + "-2025-00001"},
+		{actScopeClient, "2025-03-15", "ул. Ленина, 1", "ТО", "К" + itoa(org.ID) + "-2025-00002"},
+		// Объект без клиента - общая нумерация года
+		{actScopeClient, "2025-03-15", "ул. Мира, 5", "ТО", "2025-00003"},
+		{actScopeClassification, "2025-03-14", "ул. Мира, 5", "ТО Китчен/Пекарня", "ТОКП-2025-00001"},
+		{actScopeClassification, "2025-03-14", "ул. Мира, 5", "ТО Китчен/Пекарня", "ТОКП-2025-00002"},
+		{actScopeClassification, "2025-03-14", "ул. Мира, 5", "Монтаж", "КЛ" + itoa(noPrefix.ID) + "-2025-00001"},
+		// Классификации нет в справочнике - общая нумерация года
+		{actScopeClassification, "2025-03-14", "ул. Мира, 5", "Прочее", "2025-00004"},
+	}
+	for _, tt := range tests {
+		t.Setenv("ACT_NUMBER_SCOPE", tt.scope)
+		if got := issueActNumber(t, tt.date, tt.address, tt.classification); got != tt.want {
+			t.Errorf("%q %s %s %s: номер %q, ожидался %q", tt.scope, tt.date, tt.address, tt.classification, got, tt.want)
+		}
+	}
+}
+
+func itoa(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// Повтор задачи получает тот же номер, а номер окончательно не выполненной задачи
+// возвращается в счётчик или достаётся следующей задаче - нумерация без пропусков
+func TestJobActNumberWithoutGaps(t *testing.T) {
+	dbtest.Open(t)
+	t.Setenv("ACT_NUMBER_SCOPE", "")
+
+	newJob := func() *db.ReportJob {
+		job := db.ReportJob{UserID: 1, Status: JobRunning, Payload: "{}", CreatedAt: "2025-03-14 10:00:00"}
+		if err := db.DB.Create(&job).Error; err != nil {
+			t.Fatal(err)
+		}
+		return &job
+	}
+	reserve := func(job *db.ReportJob) string {
+		t.Helper()
+		number, err := reserveJobActNumber(job, "2025-03-14", "ул. Ленина, 1", "ТО")
+		if err != nil {
+			t.Fatalf("выдача номера задаче %d: %v", job.ID, err)
+		}
+		return number.Number
+	}
+	fail := func(job *db.ReportJob) {
+		db.DB.Model(job).Update("status", JobFailed)
+		releaseJobActNumber(job)
+	}
+
+	first := newJob()
+	if got := reserve(first); got != "2025-00001" {
+		t.Fatalf("первый номер %q", got)
+	}
+	// Повторная попытка: задача перечитывается из БД, как в processReportJob
+	var retried db.ReportJob
+	db.DB.First(&retried, first.ID)
+	if got := reserve(&retried); got != "2025-00001" {
+		t.Fatalf("повтор получил номер %q, ожидался прежний", got)
+	}
+
+	second := newJob()
+	if got := reserve(second); got != "2025-00002" {
+		t.Fatalf("второй номер %q", got)
+	}
+	// Номер 00001 уже не последний - он остаётся за задачей и достаётся следующей
+	fail(&retried)
+	if got := reserve(newJob()); got != "2025-00001" {
+		t.Fatalf("номер упавшей задачи не использован повторно: %q", got)
+	}
+	// Номер 00002 последний - он возвращается в счётчик
+	fail(second)
+	if got := reserve(newJob()); got != "2025-00002" {
+		t.Fatalf("возвращённый номер не выдан повторно: %q", got)
+	}
+	if got := reserve(newJob()); got != "2025-00003" {
+		t.Fatalf("следующий номер %q", got)
+	}
+}
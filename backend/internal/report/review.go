@@ -0,0 +1,248 @@
+package report
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/audit"
+	"backend/internal/db"
+	"backend/internal/users"
+)
+
+// recordReview записывает переход статуса в историю согласования
+func recordReview(tx *gorm.DB, reportID uint, from, to, comment string, userID uint) error {
+	return tx.Create(&db.ReportReview{
+		ReportID:   reportID,
+		FromStatus: from,
+		ToStatus:   to,
+		Comment:    comment,
+		UserID:     userID,
+		CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
+	}).Error
+}
+
+// ErrStatusChanged - статус отчёта успел измениться другим запросом (согласование, правка)
+var ErrStatusChanged = errors.New("статус отчёта изменился, обновите данные")
+
+// transitionReport меняет статус отчёта и пишет историю в одной транзакции.
+// Для решений согласующего (approved/rejected) запоминает комментарий и автора решения.
+// Обновление идёт только из статуса, с которым отчёт был прочитан, иначе - ErrStatusChanged
+func transitionReport(report *db.Report, to, comment string, userID uint) error {
+	from := report.Status
+	now := time.Now().Format("2006-01-02 15:04:05")
+	updates := map[string]interface{}{"status": to}
+	if to == db.ReportApproved || to == db.ReportRejected {
+		updates["review_comment"] = comment
+		updates["reviewed_by"] = userID
+		updates["reviewed_at"] = now
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(report).Where("status = ?", from).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+		return recordReview(tx, report.ID, from, to, comment, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	report.Status = to
+	if to == db.ReportApproved || to == db.ReportRejected {
+		report.ReviewComment = comment
+		report.ReviewedBy = &userID
+		report.ReviewedAt = now
+	}
+	return nil
+}
+
+// loadReviewReport загружает отчёт и текущего пользователя для операций согласования
+func loadReviewReport(c *gin.Context) (*db.Report, *db.User, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return nil, nil, false
+	}
+	var user db.User
+	if err := db.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не найден"})
+		return nil, nil, false
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID отчета"})
+		return nil, nil, false
+	}
+	var report db.Report
+	if err := db.DB.First(&report, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Отчет не найден"})
+		return nil, nil, false
+	}
+	return &report, &user, true
+}
+
+// SubmitReport - отправка черновика или возвращённого отчёта на согласование.
+// Доступно исполнителю отчёта и согласующим
+func SubmitReport(c *gin.Context) {
+	report, user, ok := loadReviewReport(c)
+	if !ok {
+		return
+	}
+	if report.UserID != user.ID && !users.IsReviewer(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Отправить на согласование может только исполнитель отчёта"})
+		return
+	}
+	if report.Status != db.ReportDraft && report.Status != db.ReportRejected {
+		c.JSON(http.StatusConflict, gin.H{"error": "Отчёт уже отправлен на согласование"})
+		return
+	}
+
+	var input struct {
+		Comment string `json:"comment"`
+	}
+	_ = c.ShouldBindJSON(&input)
+
+	audit.SetEntity(c, "reports", report.ID)
+	audit.SetBefore(c, *report)
+	if err := transitionReport(report, db.ReportSubmitted, strings.TrimSpace(input.Comment), user.ID); err != nil {
+		if errors.Is(err, ErrStatusChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Статус отчёта изменился, обновите страницу"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при отправке отчёта на согласование"})
+		return
+	}
+	audit.SetAfter(c, *report)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Отчёт отправлен на согласование", "report": report})
+}
+
+// ApproveReport - согласование отчёта. Если указан ticketId, отчёт перепривязывается
+// к этой заявке (исправление ошибочной автопривязки)
+func ApproveReport(c *gin.Context) {
+	report, user, ok := loadReviewReport(c)
+	if !ok {
+		return
+	}
+	if report.Status != db.ReportSubmitted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Согласовать можно только отчёт, отправленный на согласование"})
+		return
+	}
+
+	var input struct {
+		Comment  string `json:"comment"`
+		TicketID *uint  `json:"ticketId"`
+	}
+	_ = c.ShouldBindJSON(&input)
+
+	if input.TicketID != nil {
+		var ticket db.ClientTicket
+		if err := db.DB.First(&ticket, *input.TicketID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Заявка не найдена"})
+			return
+		}
+	}
+
+	audit.SetEntity(c, "reports", report.ID)
+	audit.SetBefore(c, *report)
+	if err := transitionReport(report, db.ReportApproved, strings.TrimSpace(input.Comment), user.ID); err != nil {
+		if errors.Is(err, ErrStatusChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Статус отчёта изменился, обновите страницу"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при согласовании отчёта"})
+		return
+	}
+	if input.TicketID != nil {
+		db.DB.Where("report_id = ?", report.ID).Delete(&db.TicketReport{})
+		linkReportToTicket(report.ID, *input.TicketID)
+	}
+	audit.SetAfter(c, *report)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Отчёт согласован", "report": report})
+}
+
+// RejectReport - возврат отчёта исполнителю на доработку с обязательным комментарием.
+// Согласованный отчёт тоже можно вернуть - он перестанет быть виден клиенту
+func RejectReport(c *gin.Context) {
+	report, user, ok := loadReviewReport(c)
+	if !ok {
+		return
+	}
+	if report.Status != db.ReportSubmitted && report.Status != db.ReportApproved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Вернуть можно только отчёт на согласовании или согласованный"})
+		return
+	}
+
+	var input struct {
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Comment) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Укажите причину возврата"})
+		return
+	}
+
+	audit.SetEntity(c, "reports", report.ID)
+	audit.SetBefore(c, *report)
+	if err := transitionReport(report, db.ReportRejected, strings.TrimSpace(input.Comment), user.ID); err != nil {
+		if errors.Is(err, ErrStatusChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Статус отчёта изменился, обновите страницу"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при возврате отчёта"})
+		return
+	}
+	audit.SetAfter(c, *report)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Отчёт возвращён на доработку", "report": report})
+}
+
+// GetReviewQueue - отчёты, ожидающие решения. Параметр status (по умолчанию submitted)
+func GetReviewQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", db.ReportSubmitted)
+	switch status {
+	case db.ReportDraft, db.ReportSubmitted, db.ReportApproved, db.ReportRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный статус"})
+		return
+	}
+
+	type queueItem struct {
+		db.Report
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+	}
+	var items []queueItem
+	err := db.DB.Table("reports").
+		Select("reports.*, users.first_name, users.last_name").
+		Joins("LEFT JOIN users ON users.id = reports.user_id").
+		Where("reports.status = ?", status).
+		Order("reports.id ASC").
+		Scan(&items).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении очереди согласования"})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// GetReportReviews - история согласования отчёта
+func GetReportReviews(c *gin.Context) {
+	var reviews []db.ReportReview
+	if err := db.DB.Where("report_id = ?", c.Param("id")).Order("id").Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении истории согласования"})
+		return
+	}
+	c.JSON(http.StatusOK, reviews)
+}
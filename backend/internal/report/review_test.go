@@ -0,0 +1,86 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+	"backend/internal/dbtest"
+)
+
+// Решение по отчёту, прочитанному до чужого перехода, отклоняется и не пишет историю
+func TestTransitionReportStaleStatus(t *testing.T) {
+	dbtest.Open(t)
+	report := db.Report{Date: "2025-03-14", Address: "ул. Ленина, 1", UserID: 1, Status: db.ReportSubmitted}
+	if err := db.DB.Create(&report).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	stale := report
+	if err := transitionReport(&report, db.ReportApproved, "", 2); err != nil {
+		t.Fatalf("согласование: %v", err)
+	}
+	if err := transitionReport(&stale, db.ReportRejected, "поздно", 3); !errors.Is(err, ErrStatusChanged) {
+		t.Fatalf("ожидалась ErrStatusChanged, получено %v", err)
+	}
+
+	var saved db.Report
+	db.DB.First(&saved, report.ID)
+	if saved.Status != db.ReportApproved {
+		t.Errorf("статус %q, ожидался %q", saved.Status, db.ReportApproved)
+	}
+	var reviews int64
+	db.DB.Model(&db.ReportReview{}).Where("report_id = ?", report.ID).Count(&reviews)
+	if reviews != 1 {
+		t.Errorf("записей истории %d, ожидалась 1", reviews)
+	}
+}
+
+// Загруженный вручную акт ждёт согласования и не виден клиенту сразу
+func TestUploadReportNeedsReview(t *testing.T) {
+	dbtest.Open(t)
+	t.Chdir(t.TempDir())
+	gin.SetMode(gin.TestMode)
+
+	engineer := db.User{FirstName: "Иван", LastName: "Петров", Department: "Инженер", Phone: "79000000001", IsActive: true}
+	db.DB.Create(&engineer)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "act.pdf")
+	part.Write([]byte("%PDF-1.4"))
+	form.WriteField("date", "2025-03-14")
+	form.WriteField("address", "ул. Ленина, 1")
+	form.WriteField("userId", itoa(engineer.ID))
+	form.WriteField("classification", "ТО")
+	form.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/reports/upload", &body)
+	c.Request.Header.Set("Content-Type", form.FormDataContentType())
+	c.Set("userID", engineer.ID)
+	UploadReport(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("UploadReport: статус %d, ответ %s", w.Code, w.Body.String())
+	}
+
+	var report db.Report
+	if err := db.DB.First(&report, "filename = ?", "act.pdf").Error; err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != db.ReportSubmitted {
+		t.Errorf("статус %q, ожидался %q", report.Status, db.ReportSubmitted)
+	}
+	var reviews int64
+	db.DB.Model(&db.ReportReview{}).Where("report_id = ? AND to_status = ?", report.ID, db.ReportSubmitted).Count(&reviews)
+	if reviews != 1 {
+		t.Errorf("записей истории %d, ожидалась 1", reviews)
+	}
+}
@@ -0,0 +1,310 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/audit"
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/integrity"
+	"backend/internal/photos"
+	"backend/internal/signatures"
+	"backend/internal/storage"
+	"backend/internal/users"
+)
+
+// canEditReport - отчёт правит согласующий или его исполнитель/автор, согласованный акт - только согласующий
+func canEditReport(editor *db.User, report *db.Report) bool {
+	if users.IsReviewer(editor) {
+		return true
+	}
+	if report.Status == db.ReportApproved {
+		return false
+	}
+	if editor.ID == report.UserID {
+		return true
+	}
+	var creator uint
+	db.DB.Model(&db.ReportContent{}).Where("report_id = ?", report.ID).
+		Order("version").Limit(1).Pluck("created_by", &creator)
+	return creator != 0 && editor.ID == creator
+}
+
+// UpdateReport - редактирование отчёта: те же проверки, что и при создании, затем повторная
+// генерация PDF и новая версия в фоне (статус - GET /api/report-jobs/:id).
+// ID отчёта и его привязки к заявкам не меняются, PDF прежних версий остаются в хранилище
+func UpdateReport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return
+	}
+	authorID, _ := userID.(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID отчета"})
+		return
+	}
+
+	var report db.Report
+	if err := db.DB.First(&report, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Отчет не найден"})
+		return
+	}
+
+	var editor db.User
+	if err := db.DB.First(&editor, authorID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не найден"})
+		return
+	}
+	if !canEditReport(&editor, &report) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Недостаточно прав для редактирования этого отчёта"})
+		return
+	}
+
+	if _, _, err := LoadReportContent(report.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Для этого отчёта нет сохранённых данных, редактирование невозможно"})
+		return
+	}
+
+	var reportData ReportData
+	if err := c.ShouldBindJSON(&reportData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	if reportData.UserId == 0 {
+		reportData.UserId = report.UserID
+	}
+	if reportData.UserId != report.UserID && !users.IsReviewer(&editor) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Сменить исполнителя может только согласующий"})
+		return
+	}
+	if err := db.DB.First(&db.User{}, reportData.UserId).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "указанный исполнитель не найден"})
+		return
+	}
+
+	if !validateReportData(c, &reportData, report.ID, authorID, reportData.UserId) {
+		return
+	}
+
+	job, created, err := enqueueReportJob(authorID, idempotencyKeyFrom(c), reportData, &report.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Ошибка при постановке задачи: %v", err)})
+		return
+	}
+	audit.SetEntity(c, "reports", report.ID)
+	audit.SetBefore(c, report)
+
+	status := http.StatusAccepted
+	if !created {
+		status = http.StatusOK
+	}
+	c.JSON(status, gin.H{
+		"message": "Изменения поставлены в очередь на формирование",
+		"jobId":   job.ID,
+		"status":  job.Status,
+		"job":     job,
+	})
+}
+
+// runReportUpdateJob заново формирует акт по изменённым данным и сохраняет новую версию
+func runReportUpdateJob(job *db.ReportJob) (*db.Report, string, error) {
+	ctx := context.Background()
+	var reportData ReportData
+	if err := json.Unmarshal([]byte(job.Payload), &reportData); err != nil {
+		return nil, "", fmt.Errorf("Ошибка при обработке данных: %v", err)
+	}
+	if job.ReportID == nil {
+		return nil, "", errors.New("не указан редактируемый отчёт")
+	}
+
+	var report db.Report
+	if err := db.DB.First(&report, *job.ReportID).Error; err != nil {
+		return nil, "", errors.New("Отчет не найден")
+	}
+	current, _, err := LoadReportContent(report.ID)
+	if err != nil {
+		return nil, "", errors.New("Для этого отчёта нет сохранённых данных")
+	}
+	// Пока задача ждала очереди, отчёт мог быть согласован
+	var editor db.User
+	if err := db.DB.First(&editor, job.UserID).Error; err != nil || !canEditReport(&editor, &report) {
+		return nil, "", errors.New("Недостаточно прав для редактирования этого отчёта")
+	}
+	var user db.User
+	if err := db.DB.First(&user, reportData.UserId).Error; err != nil {
+		return nil, "", errors.New("Ошибка при получении данных исполнителя")
+	}
+	reportData.Classification = classifications.Normalize(reportData.Classification)
+	reportData.FirstName = user.FirstName
+	reportData.LastName = user.LastName
+	// Привязки к заявкам при редактировании не меняются
+	reportData.TicketID = current.TicketID
+
+	ensureAddress(reportData.Address)
+	rememberEquipment(reportData)
+
+	// Новые подписи заменяют прежние, без них в акт встраиваются сохранённые
+	signed, err := signatures.Prepare(reportData.Signatures)
+	if err != nil {
+		return nil, "", err
+	}
+	resign := len(signed) > 0
+	if !resign {
+		if signed, err = signatures.ForReport(ctx, report.ID); err != nil {
+			return nil, "", fmt.Errorf("Ошибка при загрузке подписей: %v", err)
+		}
+	}
+	reportData.Signatures = nil
+
+	if report.VerifyToken == "" {
+		report.VerifyToken = integrity.NewToken()
+	}
+	displayName, previewName, err := generateDocument(reportData, signed, report.VerifyToken, report.ActNumber)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Изменённый акт заново уходит на согласование, правки согласующего его не сбрасывают
+	newStatus := report.Status
+	if !users.IsReviewer(&editor) && report.Status != db.ReportDraft {
+		newStatus = db.ReportSubmitted
+	}
+
+	var version int
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		content, err := saveReportContent(tx, report.ID, reportData, displayName, job.UserID)
+		if err != nil {
+			return err
+		}
+		version = content.Version
+		if newStatus != report.Status {
+			if err := recordReview(tx, report.ID, report.Status, newStatus, "Отчёт изменён", job.UserID); err != nil {
+				return err
+			}
+		}
+		// Согласование, пришедшее во время генерации, не перетирается правкой
+		res := tx.Model(&report).Where("status = ?", report.Status).Updates(map[string]interface{}{
+			"status":         newStatus,
+			"filename":       displayName,
+			"date":           reportData.Date,
+			"address":        reportData.Address,
+			"user_id":        reportData.UserId,
+			"classification": reportData.Classification,
+			"version":        version,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+		return nil
+	})
+	if err != nil {
+		deleteDocumentFiles(displayName)
+		return nil, "", fmt.Errorf("Ошибка при сохранении в БД: %v", err)
+	}
+	report.Status = newStatus
+	report.Filename = displayName
+	report.Date = reportData.Date
+	report.Address = reportData.Address
+	report.UserID = reportData.UserId
+	report.Classification = reportData.Classification
+	report.Version = version
+	if err := integrity.Seal(ctx, &report); err != nil {
+		log.Printf("Ошибка при расчёте хеша отчёта %d: %v", report.ID, err)
+	}
+	if resign {
+		if err := signatures.Save(ctx, report.ID, signed, job.UserID); err != nil {
+			log.Printf("Ошибка при сохранении подписей отчёта %d: %v", report.ID, err)
+		}
+	}
+	photos.Attach(report.ID, report.Address, reportData.PhotoIDs)
+	return &report, previewName, nil
+}
+
+// GetReportVersions - список версий отчёта (новые первыми)
+func GetReportVersions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID отчета"})
+		return
+	}
+
+	var report db.Report
+	if err := db.DB.First(&report, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Отчет не найден"})
+		return
+	}
+
+	var contents []db.ReportContent
+	if err := db.DB.Where("report_id = ?", report.ID).Order("version DESC").Find(&contents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении версий"})
+		return
+	}
+
+	authors := map[uint]string{}
+	versions := make([]gin.H, 0, len(contents))
+	for _, content := range contents {
+		name, ok := authors[content.CreatedBy]
+		if !ok && content.CreatedBy != 0 {
+			var user db.User
+			if err := db.DB.Select("id", "first_name", "last_name").First(&user, content.CreatedBy).Error; err == nil {
+				name = strings.TrimSpace(user.LastName + " " + user.FirstName)
+			}
+			authors[content.CreatedBy] = name
+		}
+		filename := content.Filename
+		if filename == "" && content.Version == report.Version {
+			filename = report.Filename
+		}
+		versions = append(versions, gin.H{
+			"version":       content.Version,
+			"filename":      filename,
+			"createdAt":     content.CreatedAt,
+			"createdBy":     content.CreatedBy,
+			"createdByName": name,
+			"current":       content.Version == report.Version,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report": report, "versions": versions})
+}
+
+// deletePreviousVersionFiles удаляет PDF и превью прежних версий отчёта
+func deletePreviousVersionFiles(report db.Report) {
+	var filenames []string
+	db.DB.Model(&db.ReportContent{}).
+		Where("report_id = ? AND filename IS NOT NULL AND filename <> '' AND filename <> ?", report.ID, report.Filename).
+		Pluck("filename", &filenames)
+
+	for _, filename := range filenames {
+		deleteDocumentFiles(filename)
+	}
+}
+
+// deleteDocumentFiles удаляет PDF акта и его превью из хранилища и uploads/
+func deleteDocumentFiles(filename string) {
+	preview := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".png"
+	if storage.IsS3Enabled() {
+		_ = storage.DeleteReportObject(context.Background(), "reports/"+filename)
+		_ = storage.DeleteReportObject(context.Background(), "previews/"+preview)
+	}
+	_ = os.Remove(filepath.Join("uploads", "reports", filename))
+	_ = os.Remove(filepath.Join("uploads", "previews", preview))
+}
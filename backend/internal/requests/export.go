@@ -0,0 +1,29 @@
+package requests
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/classifications"
+	"backend/internal/db"
+	"backend/internal/export"
+)
+
+// requestColumns - колонки выгрузки графика (имена инженеров кэшируются на время выгрузки)
+func requestColumns() []export.Column[db.Request] {
+	userName := export.UserNames()
+	return []export.Column[db.Request]{
+		{Key: "id", Header: "№", Kind: export.Number, Width: 8, Value: func(r *db.Request) interface{} { return r.ID }},
+		{Key: "date", Header: "Дата", Kind: export.Date, Width: 12, Value: func(r *db.Request) interface{} { return r.Date }},
+		{Key: "departTime", Header: "Время выезда", Width: 12, Value: func(r *db.Request) interface{} { return r.DepartTime }},
+		{Key: "address", Header: "Адрес", Width: 40, Value: func(r *db.Request) interface{} { return r.Address }},
+		{Key: "type", Header: "Вид работ", Width: 24, Value: func(r *db.Request) interface{} { return classifications.Name(r.Type) }},
+		{Key: "engineer", Header: "Инженер", Width: 24, Value: func(r *db.Request) interface{} { return userName(r.EngineerID) }},
+		{Key: "status", Header: "Статус", Width: 16, Value: func(r *db.Request) interface{} { return r.Status }},
+		{Key: "description", Header: "Описание", Width: 50, Value: func(r *db.Request) interface{} { return r.Description }},
+	}
+}
+
+// ExportRequests - выгрузка графика в XLSX/CSV с фильтрами списка (startDate/endDate, engineerId)
+func ExportRequests(c *gin.Context) {
+	export.Stream(c, requestsQuery(c).Order("date, depart_time, id"), requestColumns(), "schedule")
+}
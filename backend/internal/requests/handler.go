@@ -1,15 +1,29 @@
 package requests
 
 import (
+	"backend/internal/classifications"
 	"backend/internal/db"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // TODO: Изменение, удаление заявок + фронтенд
 
+// requestsQuery - заявки графика с необязательными фильтрами startDate/endDate и engineerId
+func requestsQuery(c *gin.Context) *gorm.DB {
+	query := db.DB.Model(&db.Request{})
+	if startDate, endDate := c.Query("startDate"), c.Query("endDate"); startDate != "" && endDate != "" {
+		query = query.Where("date BETWEEN ? AND ?", startDate, endDate)
+	}
+	if engineerID := c.Query("engineerId"); engineerID != "" {
+		query = query.Where("engineer_id = ?", engineerID)
+	}
+	return query
+}
+
 func GetRequests(c *gin.Context) {
 	_, exists := c.Get("userID")
 	if !exists {
@@ -18,7 +32,7 @@ func GetRequests(c *gin.Context) {
 	}
 
 	var requests []db.Request
-	if err := db.DB.Preload("Engineer").Preload("Report").Find(&requests).Error; err != nil {
+	if err := requestsQuery(c).Preload("Engineer").Preload("Report").Find(&requests).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении заявок"})
 		return
 	}
@@ -58,6 +72,7 @@ func CreateRequest(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Неверный формат запроса: %v", err)})
 		return
 	}
+	newRequest.Type = classifications.Normalize(newRequest.Type)
 
 	if err := db.DB.Create(&newRequest).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании заявки"})
@@ -88,6 +103,7 @@ func UpdateRequest(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат запроса"})
 		return
 	}
+	updatedData.Type = classifications.Normalize(updatedData.Type)
 
 	if err := db.DB.Model(&request).Updates(updatedData).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении заявки"})
@@ -0,0 +1,194 @@
+package search
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+)
+
+// Типы найденных сущностей
+const (
+	TypeReport      = "report"
+	TypeTicket      = "ticket"
+	TypeCertificate = "certificate"
+)
+
+// hitsSQL - совпадения по всем сущностям с рангом и текстом для подсветки.
+// Отчёты ищутся по полям отчёта, последней версии его данных (ReportContent)
+// и тексту, извлечённому из загруженного PDF (ReportText)
+const hitsSQL = `WITH q AS (SELECT websearch_to_tsquery('russian', ?) AS query),
+hits AS (
+	SELECT 'report' AS type, r.id AS id, r.filename AS title, r.date AS date, r.classification AS classification, r.address AS address,
+		ts_rank_cd(r.search_vector || coalesce(rc.search_vector, ''::tsvector) || coalesce(rt.search_vector, ''::tsvector), q.query) AS rank,
+		coalesce(r.act_number, '') || ' ' || r.address || '. ' || coalesce(rc.data->>'defects', '') || ' ' || coalesce(rc.data->>'recommendations', '') || ' ' ||
+			coalesce(rc.data->>'machine_name', '') || ' ' || coalesce(rc.data->>'machine_number', '') || ' ' || coalesce(rc.data->>'inventory_number', '') || ' ' ||
+			coalesce(rc.data->>'material', '') || ' ' || coalesce(rc.data->>'additionalWorks', '') || ' ' || coalesce(rc.data->>'comments', '') || ' ' ||
+			coalesce(rt.machine_name, '') || ' ' || coalesce(rt.machine_number, '') || ' ' || left(coalesce(rt."text", ''), 5000) AS body
+	FROM reports r
+	LEFT JOIN report_contents rc ON rc.report_id = r.id
+		AND rc.version = (SELECT MAX(v.version) FROM report_contents v WHERE v.report_id = r.id)
+	LEFT JOIN report_texts rt ON rt.report_id = r.id
+	CROSS JOIN q
+	WHERE r.id IN (
+		SELECT id FROM reports WHERE search_vector @@ (SELECT query FROM q)
+		UNION SELECT report_id FROM report_contents WHERE search_vector @@ (SELECT query FROM q)
+		UNION SELECT report_id FROM report_texts WHERE search_vector @@ (SELECT query FROM q)
+	) AND (r.search_vector || coalesce(rc.search_vector, ''::tsvector) || coalesce(rt.search_vector, ''::tsvector)) @@ q.query
+	UNION ALL
+	SELECT 'ticket', t.id, 'Заявка №' || t.id, t.date, NULL, t.address,
+		ts_rank_cd(t.search_vector, q.query),
+		t.description || '. ' || t.address || ' ' || t.full_name
+	FROM client_tickets t CROSS JOIN q
+	WHERE t.search_vector @@ q.query
+	UNION ALL
+	SELECT 'certificate', f.id, f.filename, NULL, NULL, NULL,
+		ts_rank_cd(f.search_vector, q.query),
+		f.filename || ' ' || coalesce(f.tags, '')
+	FROM files f CROSS JOIN q
+	WHERE f.search_vector @@ q.query
+)
+`
+
+// Параметры подсветки: фрагменты вокруг совпадений, совпадения в <mark>
+const headlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=25, MinWords=8, FragmentDelimiter=" … "`
+
+// Hit - найденная сущность
+type Hit struct {
+	Type           string  `json:"type"`
+	ID             uint    `json:"id"`
+	Title          string  `json:"title"`
+	Date           *string `json:"date"`
+	Classification *string `json:"classification"`
+	Address        *string `json:"address"`
+	Rank           float64 `json:"rank"`
+	Highlight      string  `json:"highlight"`
+}
+
+// FacetValue - значение фасета и число совпадений
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Search - полнотекстовый поиск (русская морфология) по отчётам, заявкам и сертификатам.
+// Параметры: q, type (через запятую), classification, startDate, endDate, page, pageSize.
+// Фасеты (тип, классификация, месяц) считаются по всем совпадениям без учёта фильтров
+func Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указана строка поиска"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var filters []string
+	args := []interface{}{q}
+	if raw := c.Query("type"); raw != "" {
+		var types []string
+		for _, t := range strings.Split(raw, ",") {
+			switch t = strings.TrimSpace(t); t {
+			case TypeReport, TypeTicket, TypeCertificate:
+				types = append(types, t)
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестный тип: " + t})
+				return
+			}
+		}
+		filters = append(filters, "type IN ?")
+		args = append(args, types)
+	}
+	if classification := c.Query("classification"); classification != "" {
+		filters = append(filters, "classification = ?")
+		args = append(args, classification)
+	}
+	if startDate := c.Query("startDate"); startDate != "" {
+		filters = append(filters, "date >= ?")
+		args = append(args, startDate)
+	}
+	if endDate := c.Query("endDate"); endDate != "" {
+		filters = append(filters, "date <= ?")
+		args = append(args, endDate)
+	}
+	where := ""
+	if len(filters) > 0 {
+		where = " WHERE " + strings.Join(filters, " AND ")
+	}
+
+	var total int64
+	if err := db.DB.Raw(hitsSQL+"SELECT COUNT(*) FROM hits"+where, args...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при поиске"})
+		return
+	}
+
+	hits := make([]Hit, 0, pageSize)
+	pageArgs := append(append([]interface{}{}, args...), pageSize, (page-1)*pageSize)
+	if err := db.DB.Raw(hitsSQL+`SELECT h.type, h.id, h.title, h.date, h.classification, h.address, h.rank,
+		ts_headline('russian', h.body, q.query, '`+headlineOptions+`') AS highlight
+	FROM (SELECT * FROM hits`+where+` ORDER BY rank DESC, date DESC NULLS LAST, id DESC LIMIT ? OFFSET ?) h
+	CROSS JOIN q
+	ORDER BY h.rank DESC, h.date DESC NULLS LAST, h.id DESC`, pageArgs...).Scan(&hits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при поиске"})
+		return
+	}
+
+	facets, err := loadFacets(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при подсчёте фасетов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":    hits,
+		"total":      total,
+		"page":       page,
+		"totalPages": int((total + int64(pageSize) - 1) / int64(pageSize)),
+		"facets":     facets,
+	})
+}
+
+// loadFacets считает совпадения по типу, классификации и месяцу одним запросом
+func loadFacets(q string) (gin.H, error) {
+	var rows []struct {
+		GroupType      int
+		GroupCls       int
+		Type           *string
+		Classification *string
+		Month          *string
+		Count          int64
+	}
+	err := db.DB.Raw(hitsSQL+`SELECT GROUPING(type) AS group_type, GROUPING(classification) AS group_cls,
+		type, classification, month, COUNT(*) AS count
+	FROM (SELECT type, classification, substr(date, 1, 7) AS month FROM hits) h
+	GROUP BY GROUPING SETS ((type), (classification), (month))
+	ORDER BY count DESC`, q).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]FacetValue, 0)
+	classifications := make([]FacetValue, 0)
+	months := make([]FacetValue, 0)
+	for _, row := range rows {
+		switch {
+		case row.GroupType == 0 && row.Type != nil:
+			types = append(types, FacetValue{*row.Type, row.Count})
+		case row.GroupCls == 0 && row.Classification != nil:
+			classifications = append(classifications, FacetValue{*row.Classification, row.Count})
+		case row.GroupType == 1 && row.GroupCls == 1 && row.Month != nil && *row.Month != "":
+			months = append(months, FacetValue{*row.Month, row.Count})
+		}
+	}
+	return gin.H{"types": types, "classifications": classifications, "months": months}, nil
+}
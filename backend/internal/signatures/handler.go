@@ -0,0 +1,45 @@
+package signatures
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+)
+
+// GetReportSignatures - данные для страницы проверки подписей акта:
+// отчёт и метаданные подписей (подписант, должность, время, SHA-256 изображения)
+func GetReportSignatures(c *gin.Context) {
+	var report db.Report
+	if err := db.DB.First(&report, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Отчет не найден"})
+		return
+	}
+
+	var rows []db.ReportSignature
+	if err := db.DB.Where("report_id = ?", report.ID).Order("id").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении подписей"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"report":     report,
+		"signatures": rows,
+	})
+}
+
+// GetSignatureImage - изображение подписи (PNG)
+func GetSignatureImage(c *gin.Context) {
+	var sig db.ReportSignature
+	if err := db.DB.Where("report_id = ?", c.Param("id")).First(&sig, c.Param("signatureId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Подпись не найдена"})
+		return
+	}
+	data, err := Load(c.Request.Context(), &sig)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Изображение подписи не найдено"})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", data)
+}
@@ -0,0 +1,96 @@
+package signatures
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// Размер растра подписи, нарисованной штрихами, и толщина линии в пикселях
+const (
+	strokeCanvasW = 600
+	strokeCanvasH = 240
+	strokePadding = 12
+	strokeWidth   = 3.0
+	maxStrokePts  = 20000
+)
+
+var inkColor = color.RGBA{R: 0x1a, G: 0x23, B: 0x7e, A: 0xff}
+
+// renderStrokes рисует векторную подпись в PNG с прозрачным фоном.
+// Подпись масштабируется по своему охвату, пропорции сохраняются
+func renderStrokes(strokes [][]Point) ([]byte, error) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	total := 0
+	for _, stroke := range strokes {
+		for _, p := range stroke {
+			if math.IsNaN(p.X) || math.IsNaN(p.Y) || math.IsInf(p.X, 0) || math.IsInf(p.Y, 0) {
+				return nil, errors.New("некорректные координаты штриха")
+			}
+			minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+			maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+			total++
+		}
+	}
+	if total == 0 {
+		return nil, errors.New("подпись пуста")
+	}
+	if total > maxStrokePts {
+		return nil, errors.New("слишком много точек в подписи")
+	}
+
+	spanX, spanY := math.Max(maxX-minX, 1), math.Max(maxY-minY, 1)
+	scale := math.Min(float64(strokeCanvasW-2*strokePadding)/spanX, float64(strokeCanvasH-2*strokePadding)/spanY)
+	offX := (float64(strokeCanvasW) - spanX*scale) / 2
+	offY := (float64(strokeCanvasH) - spanY*scale) / 2
+	project := func(p Point) (float64, float64) {
+		return offX + (p.X-minX)*scale, offY + (p.Y-minY)*scale
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, strokeCanvasW, strokeCanvasH))
+	for _, stroke := range strokes {
+		if len(stroke) == 1 {
+			x, y := project(stroke[0])
+			dot(img, x, y)
+			continue
+		}
+		for i := 1; i < len(stroke); i++ {
+			x0, y0 := project(stroke[i-1])
+			x1, y1 := project(stroke[i])
+			line(img, x0, y0, x1, y1)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// line рисует отрезок, ставя круглую кисть с шагом в полпикселя
+func line(img *image.RGBA, x0, y0, x1, y1 float64) {
+	steps := int(math.Ceil(math.Hypot(x1-x0, y1-y0)*2)) + 1
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		dot(img, x0+(x1-x0)*t, y0+(y1-y0)*t)
+	}
+}
+
+// dot - круглая кисть диаметром strokeWidth
+func dot(img *image.RGBA, cx, cy float64) {
+	r := strokeWidth / 2
+	for y := int(cy - r); y <= int(cy+r); y++ {
+		for x := int(cx - r); x <= int(cx+r); x++ {
+			if (float64(x)+0.5-cx)*(float64(x)+0.5-cx)+(float64(y)+0.5-cy)*(float64(y)+0.5-cy) <= r*r {
+				if (image.Point{X: x, Y: y}).In(img.Rect) {
+					img.SetRGBA(x, y, inkColor)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package signatures
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend/internal/db"
+	"backend/internal/docgen"
+	"backend/internal/storage"
+)
+
+// Роли подписантов акта
+const (
+	RoleEngineer = "engineer"
+	RoleClient   = "client"
+)
+
+// Ограничения на присылаемое изображение подписи
+const (
+	maxImageSize   = 1 << 20
+	maxImagePixels = 2000
+)
+
+var signaturesDir = filepath.Join("uploads", "signatures")
+
+// Point - точка штриха подписи в координатах холста
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Input - подпись из формы отчёта: изображение (data URL PNG/JPEG) или векторные штрихи
+type Input struct {
+	Role           string    `json:"role"`
+	SignerName     string    `json:"signerName"`
+	SignerPosition string    `json:"signerPosition"`
+	Image          string    `json:"image,omitempty"`
+	Strokes        [][]Point `json:"strokes,omitempty"`
+	SignedAt       string    `json:"signedAt,omitempty"`
+}
+
+// Stamp проставляет подписям время получения сервером. Время с клиента не используется
+func Stamp(inputs []Input) {
+	now := time.Now().Format("2006-01-02 15:04:05")
+	for i := range inputs {
+		inputs[i].SignedAt = now
+	}
+}
+
+// Prepare проверяет подписи и приводит их к PNG для встраивания в акт.
+// Каждая роль - не более одной подписи, ФИО подписанта обязательно
+func Prepare(inputs []Input) ([]docgen.DocumentSignature, error) {
+	var result []docgen.DocumentSignature
+	seen := map[string]bool{}
+	for _, in := range inputs {
+		if in.Role != RoleEngineer && in.Role != RoleClient {
+			return nil, fmt.Errorf("неизвестная роль подписанта: %q", in.Role)
+		}
+		if seen[in.Role] {
+			return nil, fmt.Errorf("подпись с ролью %q передана несколько раз", in.Role)
+		}
+		seen[in.Role] = true
+
+		name := strings.TrimSpace(in.SignerName)
+		if name == "" {
+			return nil, errors.New("не указано ФИО подписанта")
+		}
+
+		var data []byte
+		var err error
+		switch {
+		case in.Image != "":
+			data, err = normalizeImage(in.Image)
+		case len(in.Strokes) > 0:
+			data, err = renderStrokes(in.Strokes)
+		default:
+			err = errors.New("подпись пуста")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("подпись %s: %v", name, err)
+		}
+
+		signedAt := in.SignedAt
+		if signedAt == "" {
+			signedAt = time.Now().Format("2006-01-02 15:04:05")
+		}
+		result = append(result, docgen.DocumentSignature{
+			Role:           in.Role,
+			SignerName:     name,
+			SignerPosition: strings.TrimSpace(in.SignerPosition),
+			SignedAt:       signedAt,
+			Image:          data,
+		})
+	}
+	return result, nil
+}
+
+// normalizeImage декодирует data URL или base64 и перекодирует изображение в PNG
+func normalizeImage(s string) ([]byte, error) {
+	if i := strings.Index(s, ","); i >= 0 && strings.HasPrefix(s, "data:") {
+		s = s[i+1:]
+	}
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("изображение должно быть в base64")
+	}
+	if len(raw) > maxImageSize {
+		return nil, fmt.Errorf("размер изображения превышает %d МБ", maxImageSize>>20)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, errors.New("поддерживаются изображения PNG и JPEG")
+	}
+	if cfg.Width > maxImagePixels || cfg.Height > maxImagePixels {
+		return nil, fmt.Errorf("изображение больше %dx%d", maxImagePixels, maxImagePixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, errors.New("не удалось прочитать изображение")
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Save сохраняет подписи отчёта, заменяя прежние. Изображения хранятся по SHA-256
+func Save(ctx context.Context, reportID uint, signatures []docgen.DocumentSignature, createdBy uint) error {
+	var previous []db.ReportSignature
+	db.DB.Where("report_id = ?", reportID).Find(&previous)
+
+	var rows []db.ReportSignature
+	for _, sig := range signatures {
+		sum := sha256.Sum256(sig.Image)
+		hash := hex.EncodeToString(sum[:])
+		filename := hash + ".png"
+		if err := save(ctx, filename, sig.Image); err != nil {
+			return fmt.Errorf("не удалось сохранить подпись: %v", err)
+		}
+		rows = append(rows, db.ReportSignature{
+			ReportID:       reportID,
+			Role:           sig.Role,
+			SignerName:     sig.SignerName,
+			SignerPosition: sig.SignerPosition,
+			SignedAt:       sig.SignedAt,
+			Filename:       filename,
+			SHA256:         hash,
+			CreatedBy:      createdBy,
+			CreatedAt:      time.Now().Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("report_id = ?", reportID).Delete(&db.ReportSignature{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return err
+	}
+	for i := range previous {
+		remove(ctx, &previous[i])
+	}
+	return nil
+}
+
+// ForReport возвращает сохранённые подписи отчёта для повторной генерации акта
+func ForReport(ctx context.Context, reportID uint) ([]docgen.DocumentSignature, error) {
+	var rows []db.ReportSignature
+	if err := db.DB.Where("report_id = ?", reportID).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	var result []docgen.DocumentSignature
+	for i := range rows {
+		data, err := Load(ctx, &rows[i])
+		if err != nil {
+			return nil, fmt.Errorf("изображение подписи %d недоступно: %v", rows[i].ID, err)
+		}
+		result = append(result, docgen.DocumentSignature{
+			Role:           rows[i].Role,
+			SignerName:     rows[i].SignerName,
+			SignerPosition: rows[i].SignerPosition,
+			SignedAt:       rows[i].SignedAt,
+			Image:          data,
+		})
+	}
+	return result, nil
+}
+
+// DeleteForReport удаляет подписи отчёта вместе с изображениями
+func DeleteForReport(ctx context.Context, reportID uint) {
+	var rows []db.ReportSignature
+	db.DB.Where("report_id = ?", reportID).Find(&rows)
+	db.DB.Where("report_id = ?", reportID).Delete(&db.ReportSignature{})
+	for i := range rows {
+		remove(ctx, &rows[i])
+	}
+}
+
+// Load читает изображение подписи из S3 или локального uploads/signatures
+func Load(ctx context.Context, sig *db.ReportSignature) ([]byte, error) {
+	if storage.IsS3Enabled() {
+		obj, _, err := storage.GetObject(ctx, "signatures/", sig.Filename)
+		if err == nil {
+			defer obj.Close()
+			return io.ReadAll(obj)
+		}
+	}
+	return os.ReadFile(filepath.Join(signaturesDir, sig.Filename))
+}
+
+func save(ctx context.Context, filename string, content []byte) error {
+	if storage.IsS3Enabled() {
+		return storage.UploadObject(ctx, "signatures/", filename, bytes.NewReader(content), int64(len(content)), "image/png")
+	}
+	if err := os.MkdirAll(signaturesDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(signaturesDir, filename), content, 0644)
+}
+
+// remove удаляет изображение подписи, если на него не ссылаются другие записи
+func remove(ctx context.Context, sig *db.ReportSignature) {
+	var count int64
+	db.DB.Model(&db.ReportSignature{}).Where("filename = ?", sig.Filename).Count(&count)
+	if count > 0 {
+		return
+	}
+	if storage.IsS3Enabled() {
+		_ = storage.DeleteObject(ctx, "signatures/", sig.Filename)
+	}
+	_ = os.Remove(filepath.Join(signaturesDir, sig.Filename))
+}
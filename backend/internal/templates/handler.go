@@ -0,0 +1,223 @@
+package templates
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"backend/internal/audit"
+	"backend/internal/classifications"
+	"backend/internal/db"
+)
+
+// GetPlaceholders - справочник плейсхолдеров, которые понимает генератор
+func GetPlaceholders(c *gin.Context) {
+	c.JSON(http.StatusOK, Placeholders)
+}
+
+// GetTemplates - список шаблонов со всеми версиями. Фильтры: classification, organizationId
+func GetTemplates(c *gin.Context) {
+	query := db.DB.Order("classification, organization_id NULLS FIRST, version DESC")
+	if classification, ok := c.GetQuery("classification"); ok {
+		query = query.Where("classification = ?", classifications.Normalize(classification))
+	}
+	if orgID := c.Query("organizationId"); orgID != "" {
+		query = query.Where("organization_id = ?", orgID)
+	}
+
+	var list []db.ReportTemplate
+	if err := query.Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении шаблонов"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// UploadTemplate - загрузка новой версии шаблона (multipart: file, name,
+// classification, organizationId, placeholders через запятую)
+func UploadTemplate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не удалось получить файл"})
+		return
+	}
+	defer file.Close()
+
+	if !strings.EqualFold(filepath.Ext(header.Filename), ".docx") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Шаблон должен быть в формате .docx"})
+		return
+	}
+	content, err := io.ReadAll(io.LimitReader(file, maxTemplateSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка при чтении файла"})
+		return
+	}
+
+	placeholders, err := Validate(content, ParsePlaceholders(c.PostForm("placeholders")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var orgID *uint
+	if raw := c.PostForm("organizationId"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID организации"})
+			return
+		}
+		var org db.ClientOrganization
+		if err := db.DB.First(&org, id).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Организация не найдена"})
+			return
+		}
+		orgID = &org.ID
+	}
+
+	// Resolve ищет шаблон по коду из справочника, поэтому название или синоним приводятся к коду
+	classification := classifications.Normalize(c.PostForm("classification"))
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+	}
+
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	filename := hash + ".docx"
+	if err := save(context.Background(), filename, content); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при сохранении шаблона"})
+		return
+	}
+
+	tpl := db.ReportTemplate{
+		Name:           name,
+		Classification: classification,
+		OrganizationID: orgID,
+		Filename:       filename,
+		Placeholders:   strings.Join(placeholders, ","),
+		SHA256:         hash,
+		Size:           int64(len(content)),
+		IsActive:       true,
+		CreatedAt:      time.Now().Format("2006-01-02 15:04:05"),
+		CreatedBy:      userID.(uint),
+	}
+	// Номер версии защищён уникальным индексом: при одновременной загрузке
+	// в ту же область проигравший запрос берёт следующий номер
+	for attempt := 1; ; attempt++ {
+		var maxVersion int
+		scopeQuery(db.DB.Model(&db.ReportTemplate{}), classification, orgID).
+			Select("COALESCE(MAX(version), 0)").Scan(&maxVersion)
+		tpl.ID = 0
+		tpl.Version = maxVersion + 1
+		err = db.DB.Create(&tpl).Error
+		if err == nil || !isUniqueViolation(err) || attempt == maxVersionAttempts {
+			break
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при сохранении шаблона"})
+		return
+	}
+	audit.SetEntity(c, "report-templates", tpl.ID)
+	audit.SetAfter(c, tpl)
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Шаблон загружен (версия %d)", tpl.Version), "template": tpl})
+}
+
+// Сколько раз пробовать выдать номер версии при одновременных загрузках
+const maxVersionAttempts = 5
+
+// isUniqueViolation - нарушение уникального индекса PostgreSQL (23505)
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// scopeQuery ограничивает запрос областью шаблона: классификация + организация
+func scopeQuery(query *gorm.DB, classification string, orgID *uint) *gorm.DB {
+	query = query.Where("classification = ?", classification)
+	if orgID == nil {
+		return query.Where("organization_id IS NULL")
+	}
+	return query.Where("organization_id = ?", *orgID)
+}
+
+// DownloadTemplate - скачивание DOCX версии шаблона
+func DownloadTemplate(c *gin.Context) {
+	var tpl db.ReportTemplate
+	if err := db.DB.First(&tpl, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Шаблон не найден"})
+		return
+	}
+	content, err := Load(c.Request.Context(), &tpl)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Файл шаблона не найден"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s v%d.docx\"", tpl.Name, tpl.Version))
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.wordprocessingml.document", content)
+}
+
+// SetTemplateActive - включение/отключение версии шаблона (откат к предыдущей версии)
+func SetTemplateActive(c *gin.Context) {
+	var tpl db.ReportTemplate
+	if err := db.DB.First(&tpl, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Шаблон не найден"})
+		return
+	}
+	var input struct {
+		IsActive bool `json:"isActive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат данных"})
+		return
+	}
+	audit.SetBefore(c, tpl)
+
+	if err := db.DB.Model(&tpl).Update("is_active", input.IsActive).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении шаблона"})
+		return
+	}
+	tpl.IsActive = input.IsActive
+	audit.SetEntity(c, "report-templates", tpl.ID)
+	audit.SetAfter(c, tpl)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Шаблон обновлён", "template": tpl})
+}
+
+// DeleteTemplate - удаление версии шаблона
+func DeleteTemplate(c *gin.Context) {
+	var tpl db.ReportTemplate
+	if err := db.DB.First(&tpl, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Шаблон не найден"})
+		return
+	}
+	audit.SetEntity(c, "report-templates", tpl.ID)
+	audit.SetBefore(c, tpl)
+
+	if err := db.DB.Delete(&tpl).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении шаблона"})
+		return
+	}
+	remove(c.Request.Context(), &tpl)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Шаблон удалён"})
+}
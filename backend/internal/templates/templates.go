@@ -0,0 +1,272 @@
+package templates
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"backend/internal/db"
+	"backend/internal/docgen"
+	"backend/internal/storage"
+)
+
+// Максимальный размер загружаемого шаблона
+const maxTemplateSize = 10 << 20
+
+var templatesDir = filepath.Join("uploads", "templates")
+
+// Placeholder - плейсхолдер шаблона, который заполняет генератор
+type Placeholder struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+// Placeholders - плейсхолдеры, поддерживаемые document_generator_core.py
+var Placeholders = []Placeholder{
+	{"[дата]", "Дата ТО"},
+	{"[адрес]", "Адрес объекта"},
+	{"[назв_обор]", "Название оборудования"},
+	{"[номер_обор]", "Номер оборудования"},
+	{"[инв_номер]", "Инвентаризационный номер"},
+	{"[классификация]", "Классификация работ"},
+	{"[работы]", "Выполненные пункты чек-листа"},
+	{"[вставка]", "Фото фиксация"},
+	{"[материалы]", "Материалы"},
+	{"[доп_работы]", "Дополнительные работы"},
+	{"[рекомендации]", "Рекомендации"},
+	{"[комментарии]", "Комментарии"},
+	{"[дефекты]", "Выявленные дефекты"},
+	{"[фио]", "Фамилия и имя инженера"},
+	{"[номер_акта]", "Номер акта"},
+}
+
+func isKnownPlaceholder(key string) bool {
+	for _, p := range Placeholders {
+		if p.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	xmlTagPattern      = regexp.MustCompile(`<[^>]*>`)
+	placeholderPattern = regexp.MustCompile(`\[[^\[\]\s]{1,40}\]`)
+)
+
+// FindPlaceholders открывает DOCX и возвращает найденные в нём плейсхолдеры.
+// Word может разбить текст плейсхолдера на несколько фрагментов разметки,
+// поэтому поиск идёт по тексту без XML-тегов
+func FindPlaceholders(content []byte) ([]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, errors.New("файл не является документом DOCX")
+	}
+
+	hasDocument := false
+	found := map[string]bool{}
+	for _, f := range zr.File {
+		isPart := f.Name == "word/document.xml" ||
+			strings.HasPrefix(f.Name, "word/header") ||
+			strings.HasPrefix(f.Name, "word/footer")
+		if !isPart || !strings.HasSuffix(f.Name, ".xml") {
+			continue
+		}
+		if f.Name == "word/document.xml" {
+			hasDocument = true
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, maxTemplateSize*4))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать %s: %v", f.Name, err)
+		}
+		text := xmlTagPattern.ReplaceAllString(string(data), "")
+		for _, key := range placeholderPattern.FindAllString(text, -1) {
+			found[key] = true
+		}
+	}
+	if !hasDocument {
+		return nil, errors.New("в файле нет word/document.xml")
+	}
+
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// ParsePlaceholders разбирает список плейсхолдеров через запятую; скобки необязательны
+func ParsePlaceholders(s string) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ",") {
+		key := strings.TrimSpace(part)
+		if key == "" {
+			continue
+		}
+		if !strings.HasPrefix(key, "[") {
+			key = "[" + key + "]"
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate проверяет шаблон: это DOCX, все плейсхолдеры в нём известны генератору
+// и совпадают с объявленными. Пустой список объявленных означает «как в файле».
+// Возвращает итоговый список плейсхолдеров
+func Validate(content []byte, declared []string) ([]string, error) {
+	if len(content) == 0 {
+		return nil, errors.New("файл шаблона пуст")
+	}
+	if len(content) > maxTemplateSize {
+		return nil, fmt.Errorf("размер шаблона превышает %d МБ", maxTemplateSize>>20)
+	}
+
+	found, err := FindPlaceholders(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	for _, key := range append(append([]string{}, found...), declared...) {
+		if !isKnownPlaceholder(key) && !contains(unknown, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("неизвестные плейсхолдеры: %s", strings.Join(unknown, ", "))
+	}
+	if len(found) == 0 {
+		return nil, errors.New("в шаблоне нет ни одного плейсхолдера")
+	}
+	if len(declared) == 0 {
+		return found, nil
+	}
+
+	var missing, undeclared []string
+	for _, key := range declared {
+		if !contains(found, key) {
+			missing = append(missing, key)
+		}
+	}
+	for _, key := range found {
+		if !contains(declared, key) {
+			undeclared = append(undeclared, key)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("объявленные плейсхолдеры отсутствуют в шаблоне: %s", strings.Join(missing, ", "))
+	}
+	if len(undeclared) > 0 {
+		return nil, fmt.Errorf("в шаблоне есть необъявленные плейсхолдеры: %s", strings.Join(undeclared, ", "))
+	}
+	return declared, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve выбирает шаблон для акта: сначала для организации адреса и классификации,
+// затем для организации, затем для классификации, затем общий. nil - стандартный шаблон
+func Resolve(classification, address string) *db.ReportTemplate {
+	var orgID *uint
+	var addr db.Address
+	if err := db.DB.Where("address = ?", address).First(&addr).Error; err == nil {
+		orgID = addr.OrganizationID
+	}
+
+	query := db.DB.Where("is_active = ?", true).
+		Where("(classification = ? OR classification = '')", classification)
+	if orgID != nil {
+		query = query.Where("(organization_id = ? OR organization_id IS NULL)", *orgID)
+	} else {
+		query = query.Where("organization_id IS NULL")
+	}
+
+	var tpl db.ReportTemplate
+	if err := query.Order("organization_id IS NULL, classification = '', version DESC").First(&tpl).Error; err != nil {
+		return nil
+	}
+	return &tpl
+}
+
+// ForDocument подбирает шаблон и загружает его содержимое для генератора.
+// nil без ошибки - подходящего шаблона нет, используется стандартный
+func ForDocument(ctx context.Context, classification, address string) (*docgen.DocumentTemplate, error) {
+	tpl := Resolve(classification, address)
+	if tpl == nil {
+		return nil, nil
+	}
+	content, err := Load(ctx, tpl)
+	if err != nil {
+		return nil, fmt.Errorf("шаблон %q v%d недоступен: %v", tpl.Name, tpl.Version, err)
+	}
+	return &docgen.DocumentTemplate{
+		ID:       tpl.ID,
+		Version:  tpl.Version,
+		Filename: tpl.Filename,
+		Content:  content,
+	}, nil
+}
+
+// Load читает содержимое шаблона из S3 или локального uploads/templates
+func Load(ctx context.Context, tpl *db.ReportTemplate) ([]byte, error) {
+	if storage.IsS3Enabled() {
+		obj, _, err := storage.GetObject(ctx, "templates/", tpl.Filename)
+		if err == nil {
+			defer obj.Close()
+			return io.ReadAll(obj)
+		}
+	}
+	return os.ReadFile(filepath.Join(templatesDir, tpl.Filename))
+}
+
+// save сохраняет содержимое шаблона в S3 или локально
+func save(ctx context.Context, filename string, content []byte) error {
+	if storage.IsS3Enabled() {
+		return storage.UploadObject(ctx, "templates/", filename, bytes.NewReader(content), int64(len(content)),
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+	}
+	if err := os.MkdirAll(templatesDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(templatesDir, filename), content, 0644)
+}
+
+// remove удаляет файл шаблона, если на него не ссылаются другие версии
+func remove(ctx context.Context, tpl *db.ReportTemplate) {
+	var count int64
+	db.DB.Model(&db.ReportTemplate{}).Where("filename = ? AND id <> ?", tpl.Filename, tpl.ID).Count(&count)
+	if count > 0 {
+		return
+	}
+	if storage.IsS3Enabled() {
+		_ = storage.DeleteObject(ctx, "templates/", tpl.Filename)
+	}
+	_ = os.Remove(filepath.Join(templatesDir, tpl.Filename))
+}
@@ -0,0 +1,26 @@
+package tickets
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+	"backend/internal/export"
+)
+
+var ticketColumns = []export.Column[db.ClientTicket]{
+	{Key: "id", Header: "№", Kind: export.Number, Width: 8, Value: func(t *db.ClientTicket) interface{} { return t.ID }},
+	{Key: "date", Header: "Дата", Kind: export.Date, Width: 12, Value: func(t *db.ClientTicket) interface{} { return t.Date }},
+	{Key: "fullName", Header: "Заявитель", Width: 24, Value: func(t *db.ClientTicket) interface{} { return t.FullName }},
+	{Key: "position", Header: "Должность", Width: 20, Value: func(t *db.ClientTicket) interface{} { return t.Position }},
+	{Key: "contact", Header: "Контакт", Width: 20, Value: func(t *db.ClientTicket) interface{} { return t.Contact }},
+	{Key: "address", Header: "Адрес", Width: 40, Value: func(t *db.ClientTicket) interface{} { return t.Address }},
+	{Key: "description", Header: "Описание", Width: 50, Value: func(t *db.ClientTicket) interface{} { return t.Description }},
+	{Key: "status", Header: "Статус", Width: 16, Value: func(t *db.ClientTicket) interface{} { return t.Status }},
+	{Key: "engineer", Header: "Инженер", Width: 24, Value: func(t *db.ClientTicket) interface{} { return t.EngineerName }},
+	{Key: "completedAt", Header: "Выполнена", Kind: export.DateTime, Width: 16, Value: func(t *db.ClientTicket) interface{} { return t.CompletedAt }},
+}
+
+// ExportClientTickets - выгрузка заявок в XLSX/CSV с фильтрами списка (status, date, search, sort)
+func ExportClientTickets(c *gin.Context) {
+	export.Stream(c, clientTicketsQuery(c), ticketColumns, "tickets")
+}
@@ -2,6 +2,7 @@ package tickets
 
 import (
 	"backend/internal/db"
+	"backend/internal/organizations"
 	"backend/internal/storage"
 	"context"
 	"encoding/json"
@@ -19,6 +20,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/streadway/amqp"
+	"gorm.io/gorm"
 )
 
 // RabbitMQ connection (инициализируй в main)
@@ -67,7 +69,7 @@ func notifyUnassignedIfNeeded() {
 		return
 	}
 	var users []db.User
-	db.DB.Where("department != ? AND telegram_notify_on = ? AND telegram_chat_id IS NOT NULL", "Клиент", true).Find(&users)
+	db.DB.Where("department != ? AND telegram_notify_on = ? AND is_active = ? AND telegram_chat_id IS NOT NULL", "Клиент", true, true).Find(&users)
 	var chatIDs []int64
 	for _, u := range users {
 		if u.TelegramChatID != nil {
@@ -156,6 +158,14 @@ func CreateTicket(c *gin.Context) {
 		}
 	}
 
+	// Наблюдатели организации не могут оставлять заявки
+	if clientID != nil {
+		if client, err := organizations.LoadClient(*clientID); err == nil && !organizations.CanCreateTickets(client) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Ваша роль в организации не позволяет создавать заявки"})
+			return
+		}
+	}
+
 	if strings.HasPrefix(c.GetHeader("Content-Type"), "multipart/form-data") {
 		ticket.FullName = c.PostForm("fullName")
 		ticket.Position = c.PostForm("position")
@@ -232,37 +242,38 @@ func CreateTicket(c *gin.Context) {
 	go notifyUnassignedIfNeeded()
 }
 
-func GetClientTickets(c *gin.Context) {
-	var tickets []db.ClientTicket
-	var total int64
-
-	// Фильтры
-	status := c.Query("status")
-	date := c.Query("date")
-	search := c.Query("search")
-	sort := c.DefaultQuery("sort", "desc")
-	page := c.DefaultQuery("page", "1")
-	limit := c.DefaultQuery("limit", "20")
-
+// clientTicketsQuery - заявки с фильтрами и сортировкой списка: status, date, search, sort
+func clientTicketsQuery(c *gin.Context) *gorm.DB {
 	dbQuery := db.DB.Model(&db.ClientTicket{})
 
-	if status != "" {
+	if status := c.Query("status"); status != "" {
 		dbQuery = dbQuery.Where("status = ?", status)
 	}
-	if date != "" {
+	if date := c.Query("date"); date != "" {
 		dbQuery = dbQuery.Where("date = ?", date)
 	}
-	if search != "" {
+	if search := c.Query("search"); search != "" {
 		like := "%" + search + "%"
 		dbQuery = dbQuery.Where("full_name ILIKE ? OR position ILIKE ? OR contact ILIKE ? OR address ILIKE ? OR description ILIKE ?", like, like, like, like, like)
 	}
 
 	// Сортировка
-	if sort == "asc" {
+	if c.DefaultQuery("sort", "desc") == "asc" {
 		dbQuery = dbQuery.Order("date asc")
 	} else {
 		dbQuery = dbQuery.Order("date desc")
 	}
+	return dbQuery
+}
+
+func GetClientTickets(c *gin.Context) {
+	var tickets []db.ClientTicket
+	var total int64
+
+	page := c.DefaultQuery("page", "1")
+	limit := c.DefaultQuery("limit", "20")
+
+	dbQuery := clientTicketsQuery(c)
 
 	// Пагинация
 	var pageInt, limitInt int
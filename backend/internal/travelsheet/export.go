@@ -0,0 +1,37 @@
+package travelsheet
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/internal/db"
+	"backend/internal/export"
+)
+
+// travelRecordsQuery - записи путевого листа пользователя, необязательно за период startDate/endDate
+func travelRecordsQuery(c *gin.Context, userID uint) *gorm.DB {
+	query := db.DB.Model(&db.TravelRecord{}).Where("user_id = ?", userID)
+	if startDate, endDate := c.Query("startDate"), c.Query("endDate"); startDate != "" && endDate != "" {
+		query = query.Where("date BETWEEN ? AND ?", startDate, endDate)
+	}
+	return query
+}
+
+var travelColumns = []export.Column[db.TravelRecord]{
+	{Key: "date", Header: "Дата", Kind: export.Date, Width: 12, Value: func(r *db.TravelRecord) interface{} { return r.Date }},
+	{Key: "startPoint", Header: "Откуда", Width: 40, Value: func(r *db.TravelRecord) interface{} { return r.StartPoint }},
+	{Key: "endPoint", Header: "Куда", Width: 40, Value: func(r *db.TravelRecord) interface{} { return r.EndPoint }},
+	{Key: "distance", Header: "Пробег, км", Kind: export.Number, Width: 12, Value: func(r *db.TravelRecord) interface{} { return r.Distance }},
+}
+
+// ExportTravelRecords - выгрузка путевого листа в XLSX/CSV (startDate/endDate - необязательный период)
+func ExportTravelRecords(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return
+	}
+	export.Stream(c, travelRecordsQuery(c, userID.(uint)).Order("date, id"), travelColumns, "travel_sheet")
+}
@@ -70,7 +70,7 @@ func GetTravelRecords(c *gin.Context) {
 	}
 
 	var records []db.TravelRecord
-	if err := db.DB.Where("user_id = ?", userID.(uint)).Order("date DESC, id DESC").Find(&records).Error; err != nil {
+	if err := travelRecordsQuery(c, userID.(uint)).Order("date DESC, id DESC").Find(&records).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении записей"})
 		return
 	}
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"backend/internal/audit"
 	"backend/internal/db"
 	"fmt"
 	"net/http"
@@ -13,20 +14,15 @@ import (
 	"github.com/joho/godotenv"
 	_ "github.com/mattn/go-sqlite3"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-var jwtkey string
-var secretKey = []byte(jwtkey)
+var secretKey []byte
 
+// Ключ подписи берётся из окружения; .env необязателен (наличие JWTKEY проверяет cmd/main.go)
 func init() {
-	if err := godotenv.Load(); err != nil {
-		panic("No .env file found")
-	}
-	var exists bool
-	jwtkey, exists = os.LookupEnv("JWTKEY")
-	if !exists {
-		panic("JWTKEY not found in .env file")
-	}
+	_ = godotenv.Load()
+	secretKey = []byte(os.Getenv("JWTKEY"))
 }
 
 func checkIfPhoneAllowed(inputPhone string) bool {
@@ -37,8 +33,19 @@ func checkIfPhoneAllowed(inputPhone string) bool {
 	return true
 }
 
+// registerInput - данные регистрации; пароль приходит только здесь и в Login,
+// у db.User он скрыт из JSON
+type registerInput struct {
+	FirstName   string `json:"firstName"`
+	LastName    string `json:"lastName"`
+	Department  string `json:"department"`
+	HomeAddress string `json:"homeAddress"`
+	Phone       string `json:"phone"`
+	Password    string `json:"password"`
+}
+
 func Register(c *gin.Context) {
-	var input db.User
+	var input registerInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
@@ -55,15 +62,27 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	if input.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указан пароль"})
+		return
+	}
 	hashedPass, _ := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-	input.Password = string(hashedPass)
+	user := db.User{
+		FirstName:   input.FirstName,
+		LastName:    input.LastName,
+		Department:  input.Department,
+		HomeAddress: input.HomeAddress,
+		Phone:       input.Phone,
+		Password:    string(hashedPass),
+		IsActive:    true,
+	}
 
-	if err := db.DB.Create(&input).Error; err != nil {
+	if err := db.DB.Create(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while registrating"})
 		return
 	}
 
-	token, err := generateToken(input.ID)
+	token, err := generateToken(user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
 		return
@@ -104,6 +123,11 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	if !user.IsActive {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Учетная запись деактивирована"})
+		return
+	}
+
 	token, err := generateToken(user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при генерации токена"})
@@ -231,6 +255,11 @@ func CheckAuth(c *gin.Context) {
 		return
 	}
 
+	if !user.IsActive {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "учетная запись деактивирована"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"user": gin.H{
 			"id":          user.ID,
@@ -243,9 +272,29 @@ func CheckAuth(c *gin.Context) {
 	})
 }
 
+// userView - пользователь в списке: без хеша пароля и служебных полей Telegram,
+// список доступен и интеграциям по API-ключу
+type userView struct {
+	ID            uint   `json:"id"`
+	FirstName     string `json:"firstName"`
+	LastName      string `json:"lastName"`
+	Department    string `json:"department"`
+	HomeAddress   string `json:"homeAddress"`
+	Phone         string `json:"phone"`
+	IsActive      bool   `json:"isActive"`
+	DeactivatedAt string `json:"deactivatedAt"`
+	AnonymizedAt  string `json:"anonymizedAt"`
+}
+
+// GetUsers возвращает активных пользователей (для выбора исполнителя).
+// С параметром includeInactive=true возвращаются и деактивированные
 func GetUsers(c *gin.Context) {
-	var users []db.User
-	if err := db.DB.Find(&users).Error; err != nil {
+	var users []userView
+	query := db.DB.Model(&db.User{}).Order("id")
+	if c.Query("includeInactive") != "true" {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.Find(&users).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении пользователей"})
 		return
 	}
@@ -359,6 +408,8 @@ func AddAllowedPhone(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при добавлении телефона"})
 		return
 	}
+	audit.SetEntity(c, "allowed-phones", allowedPhone.Phone)
+	audit.SetAfter(c, allowedPhone)
 
 	var allowedPhones []db.AllowedPhone
 	db.DB.Find(&allowedPhones)
@@ -384,6 +435,7 @@ func RemoveAllowedPhone(c *gin.Context) {
 		return
 	}
 
+	audit.SetBefore(c, allowedPhone)
 	if err := db.DB.Delete(&allowedPhone).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении телефона"})
 		return
@@ -436,14 +488,26 @@ func UpdateUser(c *gin.Context) {
 		updates["password"] = string(hashedPassword)
 	}
 
+	var before db.User
+	if err := db.DB.First(&before, targetUserID).Error; err == nil {
+		audit.SetBefore(c, before)
+	}
+
 	if err := db.DB.Model(&db.User{}).Where("id = ?", targetUserID).Updates(updates).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении пользователя"})
 		return
 	}
 
+	var after db.User
+	if err := db.DB.First(&after, targetUserID).Error; err == nil {
+		audit.SetAfter(c, after)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "пользователь успешно обновлен"})
 }
 
+// DeleteUser деактивирует пользователя вместо физического удаления:
+// вход блокируется, а отчёты, путевые листы и график сохраняются
 func DeleteUser(c *gin.Context) {
 	targetUserID := c.Param("id")
 	if targetUserID == "" {
@@ -453,16 +517,116 @@ func DeleteUser(c *gin.Context) {
 
 	userID, exists := c.Get("userID")
 	if exists && fmt.Sprintf("%v", userID) == targetUserID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "нельзя удалить самого себя"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "нельзя деактивировать самого себя"})
+		return
+	}
+
+	var user db.User
+	if err := db.DB.First(&user, targetUserID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "пользователь не найден"})
+		return
+	}
+
+	audit.SetBefore(c, user)
+	updates := map[string]interface{}{
+		"is_active":      false,
+		"deactivated_at": time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := db.DB.Model(&user).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при деактивации пользователя"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "пользователь деактивирован"})
+}
+
+// ActivateUser - повторная активация ранее деактивированного пользователя
+func ActivateUser(c *gin.Context) {
+	targetUserID := c.Param("id")
+
+	var user db.User
+	if err := db.DB.First(&user, targetUserID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "пользователь не найден"})
+		return
+	}
+
+	if user.AnonymizedAt != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "нельзя активировать обезличенного пользователя"})
+		return
+	}
+
+	audit.SetBefore(c, user)
+	updates := map[string]interface{}{
+		"is_active":      true,
+		"deactivated_at": nil,
+	}
+	if err := db.DB.Model(&user).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при активации пользователя"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "пользователь активирован"})
+}
+
+// AnonymizeUser - безвозвратное удаление персональных данных пользователя.
+// Запись остаётся, чтобы отчёты и путевые листы не теряли владельца
+func AnonymizeUser(c *gin.Context) {
+	targetUserID := c.Param("id")
+
+	userID, exists := c.Get("userID")
+	if exists && fmt.Sprintf("%v", userID) == targetUserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "нельзя обезличить самого себя"})
+		return
+	}
+
+	var user db.User
+	if err := db.DB.First(&user, targetUserID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "пользователь не найден"})
 		return
 	}
 
-	if err := db.DB.Delete(&db.User{}, targetUserID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении пользователя"})
+	audit.SetBefore(c, gin.H{"id": user.ID, "isActive": user.IsActive})
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	deactivatedAt := user.DeactivatedAt
+	if deactivatedAt == "" {
+		deactivatedAt = now
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"first_name":         "Удаленный",
+			"last_name":          "пользователь",
+			"home_address":       nil,
+			"phone":              fmt.Sprintf("deleted-%d", user.ID),
+			"password":           "",
+			"telegram_chat_id":   nil,
+			"telegram_notify_on": false,
+			"is_active":          false,
+			"deactivated_at":     deactivatedAt,
+			"anonymized_at":      now,
+		}
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&db.ClientTicket{}).
+			Where("engineer_id = ?", user.ID).
+			Update("engineer_name", "Удаленный пользователь").Error; err != nil {
+			return err
+		}
+		if user.Phone != "" {
+			if err := tx.Where("phone = ?", user.Phone).Delete(&db.AllowedPhone{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обезличивании пользователя"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "пользователь успешно удален"})
+	c.JSON(http.StatusOK, gin.H{"message": "персональные данные пользователя удалены"})
 }
 
 func AuthMiddleware() gin.HandlerFunc {
@@ -509,11 +673,59 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		userID := uint(claims["user_id"].(float64))
+
+		var user db.User
+		if err := db.DB.Select("id", "is_active").First(&user, userID).Error; err != nil || !user.IsActive {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "учетная запись деактивирована"})
+			c.Abort()
+			return
+		}
+
 		c.Set("userID", userID)
 		c.Next()
 	}
 }
 
+// Отделы, сотрудники которых согласуют отчёты
+var reviewerDepartments = []string{"Админ", "Руководитель"}
+
+// IsReviewer - может ли пользователь согласовывать и возвращать отчёты
+func IsReviewer(user *db.User) bool {
+	for _, department := range reviewerDepartments {
+		if user.Department == department {
+			return true
+		}
+	}
+	return false
+}
+
+// ReviewerMiddleware пропускает только согласующих (администраторы и руководители)
+func ReviewerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+			c.Abort()
+			return
+		}
+
+		var user db.User
+		if err := db.DB.First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не найден"})
+			c.Abort()
+			return
+		}
+
+		if !IsReviewer(&user) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "недостаточно прав"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, exists := c.Get("userID")
@@ -0,0 +1,66 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/internal/db"
+	"backend/internal/dbtest"
+)
+
+func postJSON(handler gin.HandlerFunc, body gin.H) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler(c)
+	return w
+}
+
+func TestRegisterThenLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbtest.Open(t)
+	db.DB.Create(&db.AllowedPhone{Phone: "79001234567"})
+
+	w := postJSON(Register, gin.H{"phone": "79001234567", "password": "s3cret", "firstName": "Иван", "lastName": "Петров", "department": "Сервис"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("регистрация: %d %s", w.Code, w.Body)
+	}
+	var user db.User
+	if err := db.DB.Where("phone = ?", "79001234567").First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.FirstName != "Иван" || !user.IsActive {
+		t.Fatalf("сохранён пользователь %+v", user)
+	}
+
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", http.StatusUnauthorized},
+		{"wrong", http.StatusUnauthorized},
+		{"s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := postJSON(Login, gin.H{"phone": "79001234567", "password": tt.password}); w.Code != tt.want {
+			t.Errorf("вход с паролем %q: %d, ожидалось %d", tt.password, w.Code, tt.want)
+		}
+	}
+}
+
+func TestRegisterRequiresPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbtest.Open(t)
+	db.DB.Create(&db.AllowedPhone{Phone: "79001234567"})
+
+	if w := postJSON(Register, gin.H{"phone": "79001234567", "firstName": "Иван"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("регистрация без пароля: %d, ожидалось %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -78,9 +78,7 @@ def generate_document(json_file):
                 if "[инв_номер]" in cell.text:
                     cell.text = cell.text.replace("[инв_номер]", user_info["inventory_number"])
                 if "[классификация]" in cell.text:
-                    classification = user_info["classification"]
-                    if classification == "АВ":
-                        classification = "Аварийный вызов"
+                    classification = user_info.get("classificationName") or user_info["classification"]
                     cell.text = cell.text.replace("[классификация]", classification)
                 if "[материалы]" in cell.text:
                     cell.text = cell.text.replace(
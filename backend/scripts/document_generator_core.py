@@ -126,6 +126,115 @@ def add_stamp_to_pdf(pdf_path):
     return final_pdf
 
 
+SIGNATURE_ROLES = {
+    "engineer": "Работы произвел",
+    "client": "Представитель заказчика",
+}
+
+
+def _free_space_on_last_page(doc, block_height, margin=36):
+    """Место под текстом последней страницы; при нехватке добавляется новая страница.
+    Возвращает страницу и верхнюю координату свободного места"""
+    page = doc[-1]
+    rect = page.rect
+    content_bottom = margin
+    for block in page.get_text("blocks"):
+        content_bottom = max(content_bottom, block[3])
+    for image in page.get_image_info():
+        content_bottom = max(content_bottom, image["bbox"][3])
+
+    top = content_bottom + 12
+    if top + block_height > rect.height - margin:
+        page = doc.new_page(width=rect.width, height=rect.height)
+        top = margin
+    return page, top
+
+
+def add_verification_qr(pdf_path, qr_image, verify_url):
+    """Добавление QR-кода со ссылкой на публичную проверку акта"""
+    if not verify_url:
+        return True
+    if isinstance(qr_image, str):
+        qr_image = base64.b64decode(qr_image.split(",")[-1])
+
+    doc = fitz.open(pdf_path)
+    page, top = _free_space_on_last_page(doc, 80)
+    x = 36
+    if qr_image:
+        page.insert_image(fitz.Rect(x, top, x + 72, top + 72), stream=qr_image, overlay=True)
+        x += 80
+    page.insert_text((x, top + 30), "Проверка подлинности акта:", fontname="helv", fontsize=9,
+                     encoding=fitz.TEXT_ENCODING_CYRILLIC)
+    page.insert_text((x, top + 44), verify_url, fontname="helv", fontsize=8)
+
+    output_pdf = pdf_path.replace(".pdf", "_qr.pdf")
+    doc.save(output_pdf)
+    doc.close()
+    os.replace(output_pdf, pdf_path)
+    return True
+
+
+def add_act_number_to_pdf(pdf_path, act_number):
+    """Номер акта в правом верхнем углу первой страницы"""
+    if not act_number:
+        return True
+    doc = fitz.open(pdf_path)
+    page = doc[0]
+    text = f"Акт № {act_number}"
+    width = fitz.get_text_length(text, fontname="helv", fontsize=10)
+    page.insert_text((page.rect.width - 36 - width, 28), text, fontname="helv", fontsize=10,
+                     encoding=fitz.TEXT_ENCODING_CYRILLIC)
+
+    output_pdf = pdf_path.replace(".pdf", "_num.pdf")
+    doc.save(output_pdf)
+    doc.close()
+    os.replace(output_pdf, pdf_path)
+    return True
+
+
+def add_signatures_to_pdf(pdf_path, signatures):
+    """Добавление подписей инженера и представителя заказчика под текстом последней страницы.
+    Если места не хватает, подписи переносятся на новую страницу"""
+    if not signatures:
+        return True
+
+    doc = fitz.open(pdf_path)
+    page, top = _free_space_on_last_page(doc, 110)
+    rect = page.rect
+    margin = 36
+
+    column_width = (rect.width - 2 * margin) / 2
+    for index, signature in enumerate(signatures[:2]):
+        x = margin + index * column_width
+        image = signature.get("image")
+        if isinstance(image, str):
+            image = base64.b64decode(image.split(",")[-1])
+
+        title = SIGNATURE_ROLES.get(signature.get("role"), "Подпись")
+        page.insert_text((x, top + 10), title + ":", fontname="helv", fontsize=9,
+                         encoding=fitz.TEXT_ENCODING_CYRILLIC)
+        if image:
+            page.insert_image(fitz.Rect(x, top + 16, x + 150, top + 66), stream=image,
+                              keep_proportion=True, overlay=True)
+        lines = [
+            signature.get("signerName", ""),
+            signature.get("signerPosition", ""),
+            "Подписано: " + signature.get("signedAt", ""),
+        ]
+        y = top + 78
+        for line in lines:
+            if line:
+                page.insert_text((x, y), line, fontname="helv", fontsize=8,
+                                 encoding=fitz.TEXT_ENCODING_CYRILLIC)
+                y += 10
+
+    output_pdf = pdf_path.replace(".pdf", "_signed.pdf")
+    doc.save(output_pdf)
+    doc.close()
+    os.replace(output_pdf, pdf_path)
+    return True
+
+
 def generate_preview_png(pdf_path, preview_png_path):
     """Генерация PNG превью всех страниц PDF
     
@@ -229,6 +338,10 @@ def generate_document_from_data(user_info: dict) -> dict:
         os.makedirs(uploads_dir, exist_ok=True)
         os.makedirs(previews_dir, exist_ok=True)
         template_path = os.path.join(script_dir, "template.docx")
+        # Шаблон, выбранный бэкендом для классификации/клиента
+        custom_template = (user_info.get("template") or {}).get("path")
+        if custom_template:
+            template_path = custom_template
 
         if not os.path.exists(template_path):
             return {"success": False, "error": f"Шаблон не найден: {template_path}"}
@@ -240,6 +353,11 @@ def generate_document_from_data(user_info: dict) -> dict:
             user_info["works"] = "\n• " + "\n• ".join(user_info["works"])
 
         # Заполняем шаблон
+        act_number_placed = False
+        for paragraph in doc.paragraphs:
+            if "[номер_акта]" in paragraph.text:
+                act_number_placed = True
+                paragraph.text = paragraph.text.replace("[номер_акта]", user_info.get("actNumber", ""))
         for table in doc.tables:
             for row in table.rows:
                 for cell in row.cells:
@@ -254,9 +372,7 @@ def generate_document_from_data(user_info: dict) -> dict:
                     if "[инв_номер]" in cell.text:
                         cell.text = cell.text.replace("[инв_номер]", user_info.get("inventory_number", ""))
                     if "[классификация]" in cell.text:
-                        classification = user_info.get("classification", "")
-                        if classification == "АВ":
-                            classification = "Аварийный вызов"
+                        classification = user_info.get("classificationName") or user_info.get("classification", "")
                         cell.text = cell.text.replace("[классификация]", classification)
                     if "[материалы]" in cell.text:
                         cell.text = cell.text.replace("[материалы]", user_info.get("material", ""))
@@ -275,6 +391,9 @@ def generate_document_from_data(user_info: dict) -> dict:
                             if item.get("done")
                         )
                         cell.text = cell.text.replace("[работы]", checklist_text if checklist_text else "")
+                    if "[номер_акта]" in cell.text:
+                        act_number_placed = True
+                        cell.text = cell.text.replace("[номер_акта]", user_info.get("actNumber", ""))
                     if "[фио]" in cell.text:
                         full_name = f"{user_info.get('lastName', '')} {user_info.get('firstName', '')}"
                         cell.text = cell.text.replace("[фио]", full_name)
@@ -312,6 +431,14 @@ def generate_document_from_data(user_info: dict) -> dict:
         if not final_pdf:
             return {"success": False, "error": "Ошибка при добавлении печати"}
 
+        # Добавляем подписи
+        add_signatures_to_pdf(final_pdf, user_info.get("signatures") or [])
+        # Номер акта, если в шаблоне нет плейсхолдера
+        if not act_number_placed:
+            add_act_number_to_pdf(final_pdf, user_info.get("actNumber"))
+        # QR-код проверки подлинности
+        add_verification_qr(final_pdf, user_info.get("verifyQr"), user_info.get("verifyUrl"))
+
         # Генерируем превью (всех страниц)
         preview_png, page_count = generate_preview_png(final_pdf, preview_png_path)
 
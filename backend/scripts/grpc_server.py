@@ -8,6 +8,7 @@ import os
 import sys
 import signal
 import argparse
+import tempfile
 
 # Добавляем путь к сгенерированным proto файлам
 sys.path.insert(0, os.path.dirname(os.path.abspath(__file__)))
@@ -52,9 +53,39 @@ class DocumentGeneratorServicer(document_generator_pb2_grpc.DocumentGeneratorSer
                 "photos": list(request.photos),
                 "firstName": request.first_name,
                 "lastName": request.last_name,
+                "signatures": [
+                    {
+                        "role": sig.role,
+                        "signerName": sig.signer_name,
+                        "signerPosition": sig.signer_position,
+                        "signedAt": sig.signed_at,
+                        "image": sig.image,
+                    }
+                    for sig in request.signatures
+                ],
+                "verifyUrl": request.verify_url,
+                "verifyQr": request.verify_qr,
+                "actNumber": request.act_number,
+                "classificationName": request.classification_name,
             }
-            
-            result = generate_document_from_data(report_data)
+
+            # Шаблон приходит содержимым DOCX - сохраняем во временный файл
+            template_file = None
+            if request.HasField("template") and request.template.content:
+                template_file = tempfile.NamedTemporaryFile(suffix=".docx", delete=False)
+                template_file.write(request.template.content)
+                template_file.close()
+                report_data["template"] = {
+                    "id": request.template.id,
+                    "version": request.template.version,
+                    "path": template_file.name,
+                }
+
+            try:
+                result = generate_document_from_data(report_data)
+            finally:
+                if template_file:
+                    os.remove(template_file.name)
             
             if result["success"]:
                 response = document_generator_pb2.GenerateDocumentResponse(
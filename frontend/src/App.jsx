@@ -6,13 +6,18 @@ import Navbar from './components/Navbar';
 import Dashboard from './pages/Dashboard';
 import NewReport from './pages/NewReport';
 import Reports from './pages/Reports';
+import ReportSignatures from './pages/ReportSignatures';
+import VerifyReport from './pages/VerifyReport';
 import Files from './pages/Files';
+import Search from './pages/Search';
 import Profile from './pages/Profile';
 import Auth from './pages/Auth';
 import Admin from './pages/Admin';
 import MaintenancePage from './pages/MaintenancePage';
 import Statistics from './pages/Statistics';
 import TravelSheet from './pages/TravelSheet';
+import Payroll from './pages/Payroll';
+import Billing from './pages/Billing';
 import Tickets from './pages/clients/Tickets';
 import ClientAuth from './pages/clients/ClientAuth';
 import ClientTickets from './pages/clients/ClientTickets';
@@ -90,18 +95,23 @@ function App() {
         <Routes>
           <Route path="/auth" element={isAuthenticated ? <Navigate to="/" replace /> : <Auth />} />
           <Route path="/tickets" element={<Tickets />} />
+          <Route path="/verify/:token" element={<VerifyReport />} />
           {/* Клиентский портал */}
           <Route path="/client/auth" element={<ClientAuth />} />
           <Route path="/client/tickets" element={<ClientTickets />} />
           <Route path="/" element={isAuthenticated ? (isViewOnly ? <Navigate to="/reports" replace /> : <Dashboard />) : <Navigate to="/tickets" replace />} />
           <Route path="/new-report" element={isAuthenticated ? (isViewOnly ? <Navigate to="/reports" replace /> : <NewReport />) : <Navigate to="/auth" replace />} />
           <Route path="/reports" element={isAuthenticated ? <Reports /> : <Navigate to="/auth" replace />} />
+          <Route path="/reports/:id/signatures" element={isAuthenticated ? <ReportSignatures /> : <Navigate to="/auth" replace />} />
           <Route path="/inner-tickets" element={isAuthenticated ? (isViewOnly ? <Navigate to="/reports" replace /> : <InnerTickets />) : <Navigate to="/auth" replace />} />
+          <Route path="/search" element={isAuthenticated ? <Search /> : <Navigate to="/auth" replace />} />
           <Route path="/files" element={isAuthenticated ? (isViewOnly ? <Navigate to="/reports" replace /> : <Files />) : <Navigate to="/auth" replace />} />
           <Route path="/travel-sheet" element={isAuthenticated ? (isViewOnly ? <Navigate to="/reports" replace /> : <TravelSheet />) : <Navigate to="/auth" replace />} />
           <Route path="/profile" element={isAuthenticated ? <Profile /> : <Navigate to="/auth" replace />} />
           <Route path="/statistics" element={isAuthenticated ? (isViewOnly ? <Navigate to="/reports" replace /> : <Statistics />) : <Navigate to="/auth" replace />} />
+          <Route path="/payroll" element={isAuthenticated ? (isViewOnly ? <Navigate to="/reports" replace /> : <Payroll />) : <Navigate to="/auth" replace />} />
           <Route path="/admin" element={isAuthenticated && isAdmin ? <Admin /> : <Navigate to="/" replace />} />
+          <Route path="/billing" element={isAuthenticated && isAdmin ? <Billing /> : <Navigate to="/" replace />} />
           <Route path="*" element={<Navigate to="/tickets" replace />} />
         </Routes>
       </div>
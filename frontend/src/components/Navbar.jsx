@@ -1,6 +1,6 @@
 import React, { useState, useEffect, useRef } from 'react';
 import { Link, useLocation } from 'react-router-dom';
-import { FaBars, FaTimes, FaSun, FaMoon, FaHome, FaFileAlt, FaClipboardList, FaTicketAlt, FaChartBar, FaFolder, FaRoute, FaUser, FaCog } from 'react-icons/fa';
+import { FaBars, FaTimes, FaSun, FaMoon, FaHome, FaFileAlt, FaClipboardList, FaTicketAlt, FaChartBar, FaFolder, FaRoute, FaUser, FaCog, FaSearch, FaMoneyBillWave, FaFileInvoiceDollar } from 'react-icons/fa';
 import '../styles/Navbar.css';
 import { useAuth } from '../context/AuthContext';
 import { useNewTickets } from '../context/NewTicketsContext';
@@ -142,6 +142,16 @@ function Navbar() {
             <span>Отчеты</span>
           </Link>
         </li>
+        <li className="nav-item">
+          <Link
+            className={`nav-link ${location.pathname === '/search' ? 'active' : ''}`}
+            to="/search"
+            onClick={mobile ? toggleMenu : undefined}
+          >
+            {mobile && <FaSearch className="nav-icon" />}
+            <span>Поиск</span>
+          </Link>
+        </li>
         <li className="nav-item">
           <Link
             className={`nav-link ${location.pathname === '/inner-tickets' ? 'active' : ''}`}
@@ -163,6 +173,16 @@ function Navbar() {
             <span>Статистика</span>
           </Link>
         </li>
+        <li className="nav-item">
+          <Link
+            className={`nav-link ${location.pathname === '/payroll' ? 'active' : ''}`}
+            to="/payroll"
+            onClick={mobile ? toggleMenu : undefined}
+          >
+            {mobile && <FaMoneyBillWave className="nav-icon" />}
+            <span>Зарплата</span>
+          </Link>
+        </li>
         <li className="nav-item">
           <Link
             className={`nav-link ${location.pathname === '/files' ? 'active' : ''}`}
@@ -193,6 +213,18 @@ function Navbar() {
             <span>Профиль</span>
           </Link>
         </li>
+        {isAdmin && (
+          <li className="nav-item">
+            <Link
+              className={`nav-link ${location.pathname === '/billing' ? 'active' : ''}`}
+              to="/billing"
+              onClick={mobile ? toggleMenu : undefined}
+            >
+              {mobile && <FaFileInvoiceDollar className="nav-icon" />}
+              <span>Счета</span>
+            </Link>
+          </li>
+        )}
         {isAdmin && (
           <li className="nav-item">
             <Link
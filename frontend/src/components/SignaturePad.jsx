@@ -0,0 +1,82 @@
+import React, { useRef, useEffect, useCallback } from 'react';
+
+// Поле для рукописной подписи. Отдаёт векторные штрихи [[{x, y}, ...], ...]
+// в координатах холста; растеризация выполняется на сервере
+function SignaturePad({ strokes, onChange, height = 160 }) {
+  const canvasRef = useRef(null);
+  const drawingRef = useRef(false);
+  const currentRef = useRef([]);
+
+  const redraw = useCallback((all) => {
+    const canvas = canvasRef.current;
+    if (!canvas) return;
+    const ctx = canvas.getContext('2d');
+    ctx.clearRect(0, 0, canvas.width, canvas.height);
+    ctx.strokeStyle = '#1a237e';
+    ctx.lineWidth = 2;
+    ctx.lineCap = 'round';
+    ctx.lineJoin = 'round';
+    all.forEach(stroke => {
+      if (stroke.length === 0) return;
+      ctx.beginPath();
+      ctx.moveTo(stroke[0].x, stroke[0].y);
+      stroke.slice(1).forEach(p => ctx.lineTo(p.x, p.y));
+      if (stroke.length === 1) ctx.lineTo(stroke[0].x + 0.1, stroke[0].y);
+      ctx.stroke();
+    });
+  }, []);
+
+  useEffect(() => {
+    const canvas = canvasRef.current;
+    if (canvas) {
+      canvas.width = canvas.offsetWidth;
+      canvas.height = height;
+    }
+    redraw(strokes || []);
+  }, [strokes, height, redraw]);
+
+  const pointFromEvent = (e) => {
+    const rect = canvasRef.current.getBoundingClientRect();
+    return { x: Math.round(e.clientX - rect.left), y: Math.round(e.clientY - rect.top) };
+  };
+
+  const handlePointerDown = (e) => {
+    e.preventDefault();
+    canvasRef.current.setPointerCapture(e.pointerId);
+    drawingRef.current = true;
+    currentRef.current = [pointFromEvent(e)];
+    redraw([...(strokes || []), currentRef.current]);
+  };
+
+  const handlePointerMove = (e) => {
+    if (!drawingRef.current) return;
+    e.preventDefault();
+    currentRef.current.push(pointFromEvent(e));
+    redraw([...(strokes || []), currentRef.current]);
+  };
+
+  const handlePointerUp = () => {
+    if (!drawingRef.current) return;
+    drawingRef.current = false;
+    onChange([...(strokes || []), currentRef.current]);
+    currentRef.current = [];
+  };
+
+  return (
+    <div>
+      <canvas
+        ref={canvasRef}
+        style={{ width: '100%', height, border: '1px solid #ced4da', borderRadius: 4, touchAction: 'none', background: '#fff' }}
+        onPointerDown={handlePointerDown}
+        onPointerMove={handlePointerMove}
+        onPointerUp={handlePointerUp}
+        onPointerLeave={handlePointerUp}
+      />
+      <button type="button" className="btn btn-outline-secondary btn-sm mt-1" onClick={() => onChange([])}>
+        Очистить
+      </button>
+    </div>
+  );
+}
+
+export default SignaturePad;
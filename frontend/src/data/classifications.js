@@ -0,0 +1,31 @@
+import { useEffect, useState } from 'react';
+import axios from 'axios';
+
+// Справочник классификаций работ (/api/classifications): код хранится в отчётах,
+// название показывается пользователю, псевдонимы - другие написания («Аварийный вызов»)
+export const useClassifications = (activeOnly = true) => {
+  const [classifications, setClassifications] = useState([]);
+
+  useEffect(() => {
+    let cancelled = false;
+    axios.get('/api/classifications', { params: activeOnly ? { active: true } : {} })
+      .then((response) => {
+        if (!cancelled) setClassifications(response.data || []);
+      })
+      .catch((err) => console.error('Ошибка при загрузке классификаций:', err));
+    return () => {
+      cancelled = true;
+    };
+  }, [activeOnly]);
+
+  return classifications;
+};
+
+// Классификация по коду, названию или псевдониму
+export const findClassification = (classifications, value) => {
+  if (!value) return undefined;
+  const lower = value.trim().toLowerCase();
+  return classifications.find((c) => c.code === value)
+    || classifications.find((c) => [c.code, c.name, ...(c.aliases || '').split(',')]
+      .some((name) => name.trim().toLowerCase() === lower));
+};
@@ -0,0 +1,45 @@
+import axios from 'axios';
+
+// Выгрузка списка в XLSX или CSV (/api/.../export): params - те же фильтры, что и у списка,
+// columns - ключи колонок через запятую (по умолчанию все)
+export const downloadExport = async (path, params = {}, format = 'xlsx', name = 'export') => {
+  const response = await axios.get(path, { params: { ...params, format }, responseType: 'blob' });
+  const url = window.URL.createObjectURL(new Blob([response.data], { type: response.headers['content-type'] }));
+  const link = document.createElement('a');
+  link.href = url;
+  link.setAttribute('download', `${name}_${new Date().toISOString().slice(0, 10)}.${format}`);
+  document.body.appendChild(link);
+  link.click();
+  link.remove();
+  window.URL.revokeObjectURL(url);
+};
+
+const saveBlob = (data, filename) => {
+  const url = window.URL.createObjectURL(new Blob([data]));
+  const link = document.createElement('a');
+  link.href = url;
+  link.setAttribute('download', filename);
+  document.body.appendChild(link);
+  link.click();
+  link.remove();
+  window.URL.revokeObjectURL(url);
+};
+
+// Архив отчётов: request - axios-запрос с responseType 'blob'. Небольшой архив сразу
+// сохраняется как файл; для большой выборки (или async=true) сервер отвечает 202 и собирает
+// архив в фоне - тогда возвращается задача архива для отслеживания
+export const downloadReportsZip = async (request, filename) => {
+  const response = await request;
+  if (response.status === 202) {
+    const payload = JSON.parse(await response.data.text());
+    return payload.archive;
+  }
+  saveBlob(response.data, filename);
+  return null;
+};
+
+// Скачивание архива, собранного в фоне
+export const downloadReportArchive = async (archive) => {
+  const response = await axios.get(`/api/report-archives/${archive.id}/download`, { responseType: 'blob' });
+  saveBlob(response.data, archive.name);
+};
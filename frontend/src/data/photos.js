@@ -0,0 +1,53 @@
+import axios from 'axios';
+
+const MAX_RETRIES = 3;
+
+const wait = (ms) => new Promise((resolve) => setTimeout(resolve, ms));
+
+// Загрузка фотографии частями (/api/photos/uploads) с продолжением после обрыва:
+// при ошибке сети часть повторяется, при 409 загрузка продолжается с принятого сервером смещения.
+// Если файл принят целиком, а ответ потерян, обработка повторяется пустой частью со смещением = размеру.
+// address - адрес объекта для проверки геометки. Возвращает сохранённую фотографию
+export const uploadPhoto = async (file, address = '', onProgress) => {
+  const { data } = await axios.post('/api/photos/uploads', {
+    filename: file.name,
+    size: file.size,
+    address,
+  });
+  const { upload, chunkSize } = data;
+  let offset = upload.received || 0;
+  let retries = 0;
+
+  while (offset <= file.size) {
+    const chunk = file.slice(offset, offset + chunkSize);
+    try {
+      const response = await axios.put(`/api/photos/uploads/${upload.id}?offset=${offset}`, chunk, {
+        headers: { 'Content-Type': 'application/octet-stream' },
+      });
+      retries = 0;
+      offset = response.data.upload.received;
+      if (onProgress) onProgress(Math.round((offset / file.size) * 100));
+      if (response.data.photo) return response.data.photo;
+    } catch (error) {
+      if (error.response?.status === 409) {
+        offset = error.response.data.received;
+        continue;
+      }
+      if (error.response || retries >= MAX_RETRIES) throw error;
+      retries += 1;
+      await wait(1000 * retries);
+      // После обрыва сверяемся с сервером: часть могла быть принята
+      const state = await axios.get(`/api/photos/uploads/${upload.id}`);
+      offset = state.data.received;
+    }
+  }
+  throw new Error('Загрузка фотографии не завершена');
+};
+
+// Подписи к результату проверки геометки
+export const geoStatusLabels = {
+  ok: 'снято на объекте',
+  far: 'снято далеко от объекта',
+  no_gps: 'нет геометки',
+  unknown: 'координаты объекта не заданы',
+};
@@ -5,6 +5,8 @@ import axios from 'axios';
 import { toast } from 'react-toastify';
 import '../styles/Admin.css';
 
+const EMPTY_CLASSIFICATION = { code: '', name: '', aliases: '', actPrefix: '', color: '#636e72', planned: false, emergency: false, billable: true, position: 0 };
+
 const Admin = () => {
   const { user } = useAuth();
   const navigate = useNavigate();
@@ -26,8 +28,11 @@ const Admin = () => {
   const [newEquipment, setNewEquipment] = useState('');
   const [equipmentSearchQuery, setEquipmentSearchQuery] = useState('');
 
-  // Доступные классификации отчетов
-  const CLASSIFICATIONS = ['ТО Китчен', 'ТО Пекарня', 'ТО', 'АВ', 'ПНР'];
+  // Справочник классификаций: в выпадающих списках - только действующие
+  const [classifications, setClassifications] = useState([]);
+  const [classificationForm, setClassificationForm] = useState(EMPTY_CLASSIFICATION);
+  const [editingClassificationId, setEditingClassificationId] = useState(null);
+  const activeClassifications = classifications.filter(c => c.isActive);
 
   // Состояния для раздела загрузки отчетов
   const [reportFiles, setReportFiles] = useState([]);
@@ -36,6 +41,254 @@ const Admin = () => {
   const [reportClassification, setReportClassification] = useState('');
   const [uploadProgress, setUploadProgress] = useState(0);
   const [uploadStatus, setUploadStatus] = useState('');
+
+  // Состояния для раздела шаблонов чек-листов
+  const [checklistTasks, setChecklistTasks] = useState([]);
+  const [newChecklistTask, setNewChecklistTask] = useState({
+    task: '', equipmentType: '', classification: '', required: false, position: 0
+  });
+
+  // Состояния для раздела шаблонов актов
+  const [templates, setTemplates] = useState([]);
+  const [integrity, setIntegrity] = useState(null);
+  const [textExtraction, setTextExtraction] = useState(null);
+  const [placeholderCatalog, setPlaceholderCatalog] = useState([]);
+  const [organizations, setOrganizations] = useState([]);
+  const [templateFile, setTemplateFile] = useState(null);
+  const [templateName, setTemplateName] = useState('');
+  const [templateClassification, setTemplateClassification] = useState('');
+  const [templateOrganization, setTemplateOrganization] = useState('');
+  const [templatePlaceholders, setTemplatePlaceholders] = useState([]);
+  // Функции для работы со справочником классификаций
+  const fetchClassifications = useCallback(async () => {
+    try {
+      const response = await axios.get('/api/classifications');
+      setClassifications(response.data);
+    } catch (error) {
+      toast.error('Ошибка при загрузке классификаций');
+    }
+  }, []);
+
+  const saveClassification = async () => {
+    if (!classificationForm.code.trim()) {
+      toast.warning('Введите код классификации');
+      return;
+    }
+    const payload = { ...classificationForm, position: parseInt(classificationForm.position, 10) || 0 };
+    try {
+      if (editingClassificationId) {
+        await axios.put(`/api/classifications/${editingClassificationId}`, payload);
+        toast.success('Классификация обновлена');
+      } else {
+        await axios.post('/api/classifications', payload);
+        toast.success('Классификация добавлена');
+      }
+      setClassificationForm(EMPTY_CLASSIFICATION);
+      setEditingClassificationId(null);
+      fetchClassifications();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при сохранении классификации');
+    }
+  };
+
+  const editClassification = (classification) => {
+    setEditingClassificationId(classification.id);
+    setClassificationForm({ ...EMPTY_CLASSIFICATION, ...classification });
+  };
+
+  const toggleClassification = async (classification) => {
+    try {
+      await axios.put(`/api/classifications/${classification.id}`, { ...classification, isActive: !classification.isActive });
+      fetchClassifications();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при обновлении классификации');
+    }
+  };
+
+  const deleteClassification = async (id) => {
+    try {
+      await axios.delete(`/api/classifications/${id}`);
+      toast.success('Классификация удалена');
+      fetchClassifications();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при удалении классификации');
+    }
+  };
+
+  // Функции для работы с шаблонами чек-листов
+  const fetchChecklistTasks = useCallback(async () => {
+    try {
+      const response = await axios.get('/api/checklist-tasks');
+      setChecklistTasks(response.data);
+    } catch (error) {
+      toast.error('Ошибка при загрузке пунктов чек-листа');
+    }
+  }, []);
+
+  const addChecklistTask = async () => {
+    if (!newChecklistTask.task.trim()) {
+      toast.warning('Введите текст пункта');
+      return;
+    }
+    try {
+      await axios.post('/api/checklist-tasks', {
+        ...newChecklistTask,
+        position: parseInt(newChecklistTask.position, 10) || 0
+      });
+      toast.success('Пункт чек-листа добавлен');
+      setNewChecklistTask(prev => ({ ...prev, task: '', required: false }));
+      fetchChecklistTasks();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при добавлении пункта');
+    }
+  };
+
+  const updateChecklistTask = async (task, changes) => {
+    try {
+      await axios.put(`/api/checklist-tasks/${task.id}`, { ...task, ...changes });
+      fetchChecklistTasks();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при обновлении пункта');
+    }
+  };
+
+  const deleteChecklistTask = async (id) => {
+    try {
+      await axios.delete(`/api/checklist-tasks/${id}`);
+      toast.success('Пункт чек-листа удалён');
+      fetchChecklistTasks();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при удалении пункта');
+    }
+  };
+
+  // Функции для работы с шаблонами актов
+  const fetchTemplates = useCallback(async () => {
+    try {
+      const [templatesRes, placeholdersRes, orgsRes] = await Promise.all([
+        axios.get('/api/report-templates'),
+        axios.get('/api/report-templates/placeholders'),
+        axios.get('/api/client-organizations'),
+      ]);
+      setTemplates(templatesRes.data);
+      setPlaceholderCatalog(placeholdersRes.data);
+      setOrganizations(orgsRes.data);
+    } catch (error) {
+      toast.error('Ошибка при загрузке шаблонов');
+    }
+  }, []);
+
+  const togglePlaceholder = (key) => {
+    setTemplatePlaceholders(prev =>
+      prev.includes(key) ? prev.filter(p => p !== key) : [...prev, key]
+    );
+  };
+
+  const uploadTemplate = async (e) => {
+    e.preventDefault();
+    if (!templateFile) {
+      toast.warning('Выберите файл шаблона');
+      return;
+    }
+    const formData = new FormData();
+    formData.append('file', templateFile);
+    formData.append('name', templateName);
+    formData.append('classification', templateClassification);
+    formData.append('organizationId', templateOrganization);
+    formData.append('placeholders', templatePlaceholders.join(','));
+    try {
+      const response = await axios.post('/api/report-templates', formData, {
+        headers: { 'Content-Type': 'multipart/form-data' }
+      });
+      toast.success(response.data.message);
+      setTemplateFile(null);
+      setTemplateName('');
+      setTemplatePlaceholders([]);
+      document.getElementById('template-file').value = '';
+      fetchTemplates();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при загрузке шаблона');
+    }
+  };
+
+  const setTemplateActive = async (id, isActive) => {
+    try {
+      await axios.put(`/api/report-templates/${id}/active`, { isActive });
+      fetchTemplates();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при обновлении шаблона');
+    }
+  };
+
+  const downloadTemplate = async (tpl) => {
+    try {
+      const response = await axios.get(`/api/report-templates/${tpl.id}/download`, { responseType: 'blob' });
+      const url = window.URL.createObjectURL(new Blob([response.data]));
+      const link = document.createElement('a');
+      link.href = url;
+      link.setAttribute('download', `${tpl.name} v${tpl.version}.docx`);
+      document.body.appendChild(link);
+      link.click();
+      link.remove();
+      window.URL.revokeObjectURL(url);
+    } catch (error) {
+      toast.error('Ошибка при скачивании шаблона');
+    }
+  };
+
+  const deleteTemplate = async (id) => {
+    if (window.confirm('Удалить эту версию шаблона?')) {
+      try {
+        await axios.delete(`/api/report-templates/${id}`);
+        toast.success('Шаблон удалён');
+        fetchTemplates();
+      } catch (error) {
+        toast.error(error.response?.data?.error || 'Ошибка при удалении шаблона');
+      }
+    }
+  };
+
+  // Целостность файлов отчётов
+  const fetchIntegrity = useCallback(async () => {
+    try {
+      const response = await axios.get('/api/report-integrity');
+      setIntegrity(response.data);
+      const textResponse = await axios.get('/api/report-texts');
+      setTextExtraction(textResponse.data);
+    } catch (error) {
+      console.error('Ошибка при загрузке состояния целостности:', error);
+    }
+  }, []);
+
+  const startIntegrityCheck = async () => {
+    try {
+      await axios.post('/api/report-integrity/verify');
+      toast.success('Перепроверка запущена');
+      setTimeout(fetchIntegrity, 2000);
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при запуске перепроверки');
+    }
+  };
+
+  const reindexTexts = async (scope) => {
+    try {
+      const response = await axios.post('/api/report-texts/reindex', null, { params: { scope } });
+      toast.success(`В очередь поставлено отчетов: ${response.data.count}`);
+      setTimeout(fetchIntegrity, 2000);
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при постановке в очередь');
+    }
+  };
+
+  const TEXT_STATUS_LABELS = { pending: 'В очереди', running: 'Обрабатывается', done: 'Извлечен', empty: 'Нет текста', failed: 'Ошибка' };
+
+  const INTEGRITY_LABELS = { ok: 'Совпадает', mismatch: 'Изменён', missing: 'Нет файла', '': 'Не проверен' };
+
+  const organizationName = (id) => {
+    if (!id) return 'Все клиенты';
+    return organizations.find(org => org.id === id)?.name || `#${id}`;
+  };
+
   // Функции для работы с оборудованием
   const fetchEquipment = useCallback(async () => {
     try {
@@ -88,7 +341,7 @@ const Admin = () => {
   // Функции для работы с пользователями
   const fetchUsers = useCallback(async () => {
     try {
-      const response = await axios.get('/api/users');
+      const response = await axios.get('/api/users', { params: { includeInactive: true } });
       setUsers(response.data);
       console.log('Пользователи загружены:', response.data);
     } catch (error) {
@@ -118,7 +371,11 @@ const Admin = () => {
     fetchUsers();
     fetchAllowedPhones();
     fetchEquipment();
-  }, [user, navigate, fetchAddresses, fetchUsers, fetchAllowedPhones, fetchEquipment]);
+    fetchTemplates();
+    fetchChecklistTasks();
+    fetchIntegrity();
+    fetchClassifications();
+  }, [user, navigate, fetchAddresses, fetchUsers, fetchAllowedPhones, fetchEquipment, fetchTemplates, fetchChecklistTasks, fetchIntegrity, fetchClassifications]);
   
   const addAddress = async () => {
     if (!newAddress.trim()) {
@@ -158,13 +415,35 @@ const Admin = () => {
   };
   
   const deleteUser = async (userId) => {
-    if (window.confirm('Вы уверены, что хотите удалить этого пользователя?')) {
+    if (window.confirm('Деактивировать пользователя? Он не сможет войти, но его отчеты и путевые листы сохранятся.')) {
       try {
         await axios.delete(`/api/users/${userId}`);
-        toast.success('Пользователь успешно удален');
+        toast.success('Пользователь деактивирован');
         fetchUsers();
       } catch (error) {
-        toast.error('Ошибка при удалении пользователя');
+        toast.error(error.response?.data?.error || 'Ошибка при деактивации пользователя');
+      }
+    }
+  };
+
+  const activateUser = async (userId) => {
+    try {
+      await axios.post(`/api/users/${userId}/activate`);
+      toast.success('Пользователь активирован');
+      fetchUsers();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при активации пользователя');
+    }
+  };
+
+  const anonymizeUser = async (userId) => {
+    if (window.confirm('Безвозвратно удалить персональные данные пользователя? Отменить это действие нельзя.')) {
+      try {
+        await axios.post(`/api/users/${userId}/anonymize`);
+        toast.success('Персональные данные пользователя удалены');
+        fetchUsers();
+      } catch (error) {
+        toast.error(error.response?.data?.error || 'Ошибка при удалении персональных данных');
       }
     }
   };
@@ -312,6 +591,30 @@ const Admin = () => {
         >
           Загрузка отчетов
         </button>
+        <button
+          className={activeTab === 'classifications' ? 'active' : ''}
+          onClick={() => setActiveTab('classifications')}
+        >
+          Классификации
+        </button>
+        <button 
+          className={activeTab === 'checklists' ? 'active' : ''} 
+          onClick={() => setActiveTab('checklists')}
+        >
+          Чек-листы
+        </button>
+        <button 
+          className={activeTab === 'templates' ? 'active' : ''} 
+          onClick={() => setActiveTab('templates')}
+        >
+          Шаблоны актов
+        </button>
+        <button 
+          className={activeTab === 'integrity' ? 'active' : ''} 
+          onClick={() => setActiveTab('integrity')}
+        >
+          Целостность
+        </button>
       </div>
         {/* Раздел управления оборудованием */}
         {activeTab === 'equipment' && (
@@ -475,7 +778,7 @@ const Admin = () => {
                 </thead>
                 <tbody>
                   {users.map(user => (
-                    <tr key={user.id}>
+                    <tr key={user.id} style={user.isActive ? undefined : { opacity: 0.6 }}>
                       <td>{user.id}</td>
                       <td>
                         {editingUser === user.id ? (
@@ -549,12 +852,31 @@ const Admin = () => {
                             >
                               Редактировать
                             </button>
-                            <button 
-                              className="delete-btn" 
-                              onClick={() => deleteUser(user.id)}
-                            >
-                              Удалить
-                            </button>
+                            {user.isActive ? (
+                              <button 
+                                className="delete-btn" 
+                                onClick={() => deleteUser(user.id)}
+                              >
+                                Деактивировать
+                              </button>
+                            ) : (
+                              !user.anonymizedAt && (
+                                <button 
+                                  className="edit-btn" 
+                                  onClick={() => activateUser(user.id)}
+                                >
+                                  Активировать
+                                </button>
+                              )
+                            )}
+                            {!user.anonymizedAt && (
+                              <button 
+                                className="delete-btn" 
+                                onClick={() => anonymizeUser(user.id)}
+                              >
+                                Удалить данные
+                              </button>
+                            )}
                           </>
                         )}
                       </td>
@@ -627,9 +949,9 @@ const Admin = () => {
                   required
                 >
                   <option value="">Выберите классификацию</option>
-                  {CLASSIFICATIONS.map(classification => (
-                    <option key={classification} value={classification}>
-                      {classification}
+                  {activeClassifications.map(classification => (
+                    <option key={classification.code} value={classification.code}>
+                      {classification.name}
                     </option>
                   ))}
                 </select>
@@ -659,6 +981,433 @@ const Admin = () => {
             </form>
           </div>
         )}
+
+        {/* Раздел справочника классификаций */}
+        {activeTab === 'classifications' && (
+          <div className="checklists-section">
+            <h2>Классификации работ</h2>
+            <div className="equipment-controls">
+              <div className="equipment-add">
+                <input
+                  type="text"
+                  value={classificationForm.code}
+                  onChange={e => setClassificationForm(prev => ({ ...prev, code: e.target.value }))}
+                  placeholder="Код (АВ)"
+                  style={{ width: '120px' }}
+                />
+                <input
+                  type="text"
+                  value={classificationForm.name}
+                  onChange={e => setClassificationForm(prev => ({ ...prev, name: e.target.value }))}
+                  placeholder="Название"
+                />
+                <input
+                  type="text"
+                  value={classificationForm.aliases}
+                  onChange={e => setClassificationForm(prev => ({ ...prev, aliases: e.target.value }))}
+                  placeholder="Псевдонимы через запятую"
+                />
+                <input
+                  type="text"
+                  value={classificationForm.actPrefix}
+                  onChange={e => setClassificationForm(prev => ({ ...prev, actPrefix: e.target.value }))}
+                  placeholder="Префикс акта (ТОК)"
+                  style={{ width: '130px' }}
+                />
+                <input
+                  type="color"
+                  value={classificationForm.color || '#636e72'}
+                  onChange={e => setClassificationForm(prev => ({ ...prev, color: e.target.value }))}
+                />
+                <input
+                  type="number"
+                  value={classificationForm.position}
+                  onChange={e => setClassificationForm(prev => ({ ...prev, position: e.target.value }))}
+                  placeholder="Порядок"
+                  style={{ width: '80px' }}
+                />
+                {[['planned', 'Плановая'], ['emergency', 'Аварийная'], ['billable', 'Оплачиваемая']].map(([key, label]) => (
+                  <label key={key}>
+                    <input
+                      type="checkbox"
+                      checked={!!classificationForm[key]}
+                      onChange={e => setClassificationForm(prev => ({ ...prev, [key]: e.target.checked }))}
+                    />
+                    {' '}{label}
+                  </label>
+                ))}
+                <button onClick={saveClassification}>{editingClassificationId ? 'Сохранить' : 'Добавить'}</button>
+                {editingClassificationId && (
+                  <button
+                    className="cancel-btn"
+                    onClick={() => {
+                      setEditingClassificationId(null);
+                      setClassificationForm(EMPTY_CLASSIFICATION);
+                    }}
+                  >
+                    Отмена
+                  </button>
+                )}
+              </div>
+            </div>
+            <table>
+              <thead>
+                <tr>
+                  <th>Порядок</th>
+                  <th>Код</th>
+                  <th>Название</th>
+                  <th>Псевдонимы</th>
+                  <th>Префикс акта</th>
+                  <th>Признаки</th>
+                  <th>Действия</th>
+                </tr>
+              </thead>
+              <tbody>
+                {classifications.map(classification => (
+                  <tr key={classification.id} style={classification.isActive ? {} : { opacity: 0.5 }}>
+                    <td>{classification.position}</td>
+                    <td>
+                      <span style={{ display: 'inline-block', width: 12, height: 12, borderRadius: 2, marginRight: 6, background: classification.color || '#636e72' }} />
+                      {classification.code}
+                    </td>
+                    <td>{classification.name}</td>
+                    <td>{classification.aliases}</td>
+                    <td>{classification.actPrefix}</td>
+                    <td>
+                      {[
+                        classification.planned && 'плановая',
+                        classification.emergency && 'аварийная',
+                        classification.billable && 'оплачиваемая',
+                      ].filter(Boolean).join(', ')}
+                    </td>
+                    <td>
+                      <button className="save-btn" onClick={() => editClassification(classification)}>
+                        Изменить
+                      </button>
+                      <button
+                        className={classification.isActive ? 'cancel-btn' : 'save-btn'}
+                        onClick={() => toggleClassification(classification)}
+                      >
+                        {classification.isActive ? 'Отключить' : 'Включить'}
+                      </button>
+                      <button className="delete-btn" onClick={() => deleteClassification(classification.id)}>
+                        Удалить
+                      </button>
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          </div>
+        )}
+
+        {/* Раздел шаблонов чек-листов */}
+        {activeTab === 'checklists' && (
+          <div className="checklists-section">
+            <h2>Шаблоны чек-листов</h2>
+            <div className="equipment-controls">
+              <div className="equipment-add">
+                <input
+                  type="text"
+                  value={newChecklistTask.task}
+                  onChange={e => setNewChecklistTask(prev => ({ ...prev, task: e.target.value }))}
+                  placeholder="Пункт чек-листа"
+                />
+                <select
+                  value={newChecklistTask.equipmentType}
+                  onChange={e => setNewChecklistTask(prev => ({ ...prev, equipmentType: e.target.value }))}
+                >
+                  <option value="">Любое оборудование</option>
+                  {equipment.map(eq => (
+                    <option key={eq.id} value={eq.equipment}>{eq.equipment}</option>
+                  ))}
+                </select>
+                <select
+                  value={newChecklistTask.classification}
+                  onChange={e => setNewChecklistTask(prev => ({ ...prev, classification: e.target.value }))}
+                >
+                  <option value="">Любая классификация</option>
+                  {activeClassifications.map(classification => (
+                    <option key={classification.code} value={classification.code}>{classification.name}</option>
+                  ))}
+                </select>
+                <input
+                  type="number"
+                  value={newChecklistTask.position}
+                  onChange={e => setNewChecklistTask(prev => ({ ...prev, position: e.target.value }))}
+                  placeholder="Порядок"
+                  style={{ width: '80px' }}
+                />
+                <label>
+                  <input
+                    type="checkbox"
+                    checked={newChecklistTask.required}
+                    onChange={e => setNewChecklistTask(prev => ({ ...prev, required: e.target.checked }))}
+                  />
+                  {' '}Обязательный
+                </label>
+                <button onClick={addChecklistTask}>Добавить</button>
+              </div>
+            </div>
+            <table>
+              <thead>
+                <tr>
+                  <th>Порядок</th>
+                  <th>Пункт</th>
+                  <th>Оборудование</th>
+                  <th>Классификация</th>
+                  <th>Обязательный</th>
+                  <th>Действия</th>
+                </tr>
+              </thead>
+              <tbody>
+                {checklistTasks.map(task => (
+                  <tr key={task.id} style={task.isActive ? {} : { opacity: 0.5 }}>
+                    <td>{task.position}</td>
+                    <td>{task.task}</td>
+                    <td>{task.equipmentType || 'Любое'}</td>
+                    <td>{task.classification || 'Любая'}</td>
+                    <td>
+                      <input
+                        type="checkbox"
+                        checked={task.required}
+                        onChange={() => updateChecklistTask(task, { required: !task.required })}
+                      />
+                    </td>
+                    <td>
+                      <button
+                        className={task.isActive ? 'cancel-btn' : 'save-btn'}
+                        onClick={() => updateChecklistTask(task, { isActive: !task.isActive })}
+                      >
+                        {task.isActive ? 'Отключить' : 'Включить'}
+                      </button>
+                      <button className="delete-btn" onClick={() => deleteChecklistTask(task.id)}>
+                        Удалить
+                      </button>
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          </div>
+        )}
+
+        {/* Раздел шаблонов актов */}
+        {activeTab === 'templates' && (
+          <div className="templates-section">
+            <h2>Шаблоны актов</h2>
+
+            <form onSubmit={uploadTemplate} className="report-upload-form">
+              <div className="form-group">
+                <label htmlFor="template-file">Файл шаблона (.docx):</label>
+                <input
+                  type="file"
+                  id="template-file"
+                  accept=".docx"
+                  onChange={(e) => setTemplateFile(e.target.files[0] || null)}
+                  required
+                />
+              </div>
+
+              <div className="form-group">
+                <label htmlFor="template-name">Название:</label>
+                <input
+                  type="text"
+                  id="template-name"
+                  value={templateName}
+                  onChange={(e) => setTemplateName(e.target.value)}
+                  placeholder="По имени файла"
+                />
+              </div>
+
+              <div className="form-group">
+                <label htmlFor="template-classification">Классификация:</label>
+                <select
+                  id="template-classification"
+                  value={templateClassification}
+                  onChange={(e) => setTemplateClassification(e.target.value)}
+                >
+                  <option value="">Любая</option>
+                  {activeClassifications.map(classification => (
+                    <option key={classification.code} value={classification.code}>
+                      {classification.name}
+                    </option>
+                  ))}
+                </select>
+              </div>
+
+              <div className="form-group">
+                <label htmlFor="template-organization">Клиент:</label>
+                <select
+                  id="template-organization"
+                  value={templateOrganization}
+                  onChange={(e) => setTemplateOrganization(e.target.value)}
+                >
+                  <option value="">Все клиенты</option>
+                  {organizations.map(org => (
+                    <option key={org.id} value={org.id}>
+                      {org.name}
+                    </option>
+                  ))}
+                </select>
+              </div>
+
+              <div className="form-group">
+                <label>Плейсхолдеры шаблона:</label>
+                {placeholderCatalog.map(p => (
+                  <label key={p.key} style={{ display: 'block', fontWeight: 'normal' }}>
+                    <input
+                      type="checkbox"
+                      checked={templatePlaceholders.includes(p.key)}
+                      onChange={() => togglePlaceholder(p.key)}
+                    />
+                    {' '}{p.key} - {p.description}
+                  </label>
+                ))}
+                <small className="form-text text-muted">
+                  Если ничего не отмечено, будут использованы плейсхолдеры из файла.
+                  Отмеченные плейсхолдеры должны совпадать с найденными в шаблоне.
+                </small>
+              </div>
+
+              <button type="submit" className="upload-btn">Загрузить шаблон</button>
+            </form>
+
+            <table>
+              <thead>
+                <tr>
+                  <th>Название</th>
+                  <th>Классификация</th>
+                  <th>Клиент</th>
+                  <th>Версия</th>
+                  <th>Плейсхолдеры</th>
+                  <th>Загружен</th>
+                  <th>Действия</th>
+                </tr>
+              </thead>
+              <tbody>
+                {templates.map(tpl => (
+                  <tr key={tpl.id}>
+                    <td>{tpl.name}</td>
+                    <td>{tpl.classification || 'Любая'}</td>
+                    <td>{organizationName(tpl.organizationId)}</td>
+                    <td>v{tpl.version}{tpl.isActive ? '' : ' (отключен)'}</td>
+                    <td>{tpl.placeholders.split(',').join(', ')}</td>
+                    <td>{tpl.createdAt}</td>
+                    <td>
+                      <button className="edit-btn" onClick={() => downloadTemplate(tpl)}>
+                        Скачать
+                      </button>
+                      <button
+                        className={tpl.isActive ? 'cancel-btn' : 'save-btn'}
+                        onClick={() => setTemplateActive(tpl.id, !tpl.isActive)}
+                      >
+                        {tpl.isActive ? 'Отключить' : 'Включить'}
+                      </button>
+                      <button className="delete-btn" onClick={() => deleteTemplate(tpl.id)}>
+                        Удалить
+                      </button>
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          </div>
+        )}
+
+        {/* Раздел целостности файлов отчётов */}
+        {activeTab === 'integrity' && integrity && (
+          <div className="integrity-section">
+            <h2>Целостность файлов отчётов</h2>
+            <p>
+              Подпись хешей: {integrity.signing ? 'включена (Ed25519)' : 'отключена (не задан REPORT_SIGNING_KEY)'}
+            </p>
+            <p>
+              {integrity.counts.map(item => (
+                <span key={item.status} className="me-3">
+                  {INTEGRITY_LABELS[item.status] || item.status}: {item.count}
+                </span>
+              ))}
+            </p>
+            {integrity.last && (
+              <p>
+                Последняя перепроверка: {integrity.last.startedAt} — {integrity.last.finishedAt};
+                проверено {integrity.last.checked}, изменено {integrity.last.mismatch},
+                нет файла {integrity.last.missing}, запечатано {integrity.last.sealed}
+              </p>
+            )}
+            <button className="save-btn" onClick={startIntegrityCheck} disabled={integrity.running}>
+              {integrity.running ? 'Перепроверка выполняется...' : 'Перепроверить все файлы'}
+            </button>
+            <button className="edit-btn ms-2" onClick={fetchIntegrity}>
+              Обновить
+            </button>
+
+            <table>
+              <thead>
+                <tr>
+                  <th>ID</th>
+                  <th>Файл</th>
+                  <th>Дата</th>
+                  <th>Адрес</th>
+                  <th>Состояние</th>
+                  <th>Проверен</th>
+                </tr>
+              </thead>
+              <tbody>
+                {integrity.problems.map(report => (
+                  <tr key={report.id}>
+                    <td>{report.id}</td>
+                    <td>{report.filename}</td>
+                    <td>{report.date}</td>
+                    <td>{report.address}</td>
+                    <td>{INTEGRITY_LABELS[report.integrityStatus] || report.integrityStatus}</td>
+                    <td>{report.integrityCheckedAt}</td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+
+            {textExtraction && (
+              <>
+                <h3 className="mt-4">Текст загруженных PDF</h3>
+                <p>
+                  {textExtraction.counts.map(item => (
+                    <span key={item.status} className="me-3">
+                      {TEXT_STATUS_LABELS[item.status] || item.status}: {item.count}
+                    </span>
+                  ))}
+                </p>
+                <button className="save-btn" onClick={() => reindexTexts('failed')}>
+                  Повторить неудачные
+                </button>
+                <button className="edit-btn ms-2" onClick={() => reindexTexts('all')}>
+                  Извлечь заново все
+                </button>
+                {textExtraction.failed.length > 0 && (
+                  <table>
+                    <thead>
+                      <tr>
+                        <th>Отчет</th>
+                        <th>Попыток</th>
+                        <th>Ошибка</th>
+                      </tr>
+                    </thead>
+                    <tbody>
+                      {textExtraction.failed.map(row => (
+                        <tr key={row.id}>
+                          <td>{row.reportId}</td>
+                          <td>{row.attempts}</td>
+                          <td>{row.error}</td>
+                        </tr>
+                      ))}
+                    </tbody>
+                  </table>
+                )}
+              </>
+            )}
+          </div>
+        )}
       </div>
     </div>
   );
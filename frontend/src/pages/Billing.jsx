@@ -0,0 +1,437 @@
+import React, { useState, useEffect, useCallback } from 'react';
+import axios from 'axios';
+import { toast } from 'react-toastify';
+import { useClassifications } from '../data/classifications';
+import '../styles/Admin.css';
+
+const STATUS_LABELS = { draft: 'Черновик', issued: 'Выставлен', paid: 'Оплачен' };
+
+const EMPTY_PRICE = { organizationId: '', kind: 'work', classification: '', name: '', unit: 'шт', price: '' };
+
+const currentPeriod = () => new Date().toISOString().slice(0, 7);
+
+const formatMoney = (value) => Number(value || 0).toLocaleString('ru-RU', { minimumFractionDigits: 2, maximumFractionDigits: 2 });
+
+const Billing = () => {
+  const classifications = useClassifications(false);
+
+  const [activeTab, setActiveTab] = useState('invoices');
+  const [period, setPeriod] = useState(currentPeriod());
+  const [organizations, setOrganizations] = useState([]);
+
+  // Счета за выбранный месяц
+  const [invoices, setInvoices] = useState([]);
+  const [selected, setSelected] = useState(null);
+  const [generating, setGenerating] = useState(false);
+  const [unpriced, setUnpriced] = useState([]);
+
+  // Прайс-лист
+  const [prices, setPrices] = useState([]);
+  const [priceForm, setPriceForm] = useState(EMPTY_PRICE);
+  const [editingPriceId, setEditingPriceId] = useState(null);
+
+  const organizationName = (id) => organizations.find(o => o.id === id)?.name || (id ? `#${id}` : 'Все клиенты');
+  const classificationName = (code) => classifications.find(c => c.code === code)?.name || code;
+
+  const fetchInvoices = useCallback(async () => {
+    try {
+      const response = await axios.get('/api/billing/invoices', { params: { period } });
+      setInvoices(response.data || []);
+    } catch (error) {
+      toast.error('Ошибка при загрузке счетов');
+    }
+  }, [period]);
+
+  const fetchPrices = useCallback(async () => {
+    try {
+      const response = await axios.get('/api/billing/prices');
+      setPrices(response.data || []);
+    } catch (error) {
+      toast.error('Ошибка при загрузке прайс-листа');
+    }
+  }, []);
+
+  useEffect(() => {
+    fetchInvoices();
+    setSelected(null);
+    setUnpriced([]);
+  }, [fetchInvoices]);
+
+  useEffect(() => {
+    axios.get('/api/client-organizations').then(r => setOrganizations(r.data || [])).catch(() => setOrganizations([]));
+    fetchPrices();
+  }, [fetchPrices]);
+
+  const openInvoice = async (id) => {
+    try {
+      const response = await axios.get(`/api/billing/invoices/${id}`);
+      setSelected(response.data);
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при загрузке счёта');
+    }
+  };
+
+  const generate = async (organizationId) => {
+    setGenerating(true);
+    try {
+      const response = await axios.post('/api/billing/invoices/generate', { period, organizationId: organizationId || undefined });
+      const { invoices: calculated, locked, unpriced: missing } = response.data;
+      toast.success(`Рассчитано счетов: ${calculated.length}`);
+      if (locked.length > 0) {
+        toast.info(`Пропущено выставленных счетов: ${locked.length}`);
+      }
+      setUnpriced(missing);
+      await fetchInvoices();
+      if (selected) openInvoice(selected.id);
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при расчёте счетов');
+    } finally {
+      setGenerating(false);
+    }
+  };
+
+  const setStatus = async (invoice, status) => {
+    const question = status === 'issued'
+      ? `Выставить счёт ${invoice.organizationName} за ${invoice.period}? Счёт получит номер и больше не будет пересчитываться.`
+      : `Отметить счёт ${invoice.number} как оплаченный?`;
+    if (!window.confirm(question)) return;
+    try {
+      await axios.post(`/api/billing/invoices/${invoice.id}/status`, { status });
+      toast.success('Статус счёта обновлён');
+      fetchInvoices();
+      openInvoice(invoice.id);
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при изменении статуса');
+    }
+  };
+
+  const deleteInvoice = async (invoice) => {
+    if (!window.confirm(`Удалить черновик счёта ${invoice.organizationName}?`)) return;
+    try {
+      await axios.delete(`/api/billing/invoices/${invoice.id}`);
+      toast.success('Счёт удалён');
+      if (selected?.id === invoice.id) setSelected(null);
+      fetchInvoices();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при удалении счёта');
+    }
+  };
+
+  const download = async (invoice, format) => {
+    try {
+      const response = await axios.get(`/api/billing/invoices/${invoice.id}/${format}`, { responseType: 'blob' });
+      const url = window.URL.createObjectURL(new Blob([response.data], { type: response.headers['content-type'] }));
+      const link = document.createElement('a');
+      link.href = url;
+      link.setAttribute('download', `invoice_${invoice.period}_${invoice.number || 'draft'}.${format}`);
+      document.body.appendChild(link);
+      link.click();
+      link.remove();
+      window.URL.revokeObjectURL(url);
+    } catch (error) {
+      toast.error('Ошибка при скачивании счёта');
+    }
+  };
+
+  const savePrice = async (e) => {
+    e.preventDefault();
+    if (priceForm.price === '' || Number(priceForm.price) < 0 || (priceForm.kind === 'material' && !priceForm.name.trim())) {
+      toast.warning('Укажите цену и наименование материала');
+      return;
+    }
+    const payload = {
+      ...priceForm,
+      organizationId: priceForm.organizationId ? Number(priceForm.organizationId) : null,
+      price: Number(priceForm.price),
+    };
+    try {
+      if (editingPriceId) {
+        await axios.put(`/api/billing/prices/${editingPriceId}`, payload);
+        toast.success('Цена обновлена');
+      } else {
+        await axios.post('/api/billing/prices', payload);
+        toast.success('Цена добавлена');
+      }
+      setPriceForm(EMPTY_PRICE);
+      setEditingPriceId(null);
+      fetchPrices();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при сохранении цены');
+    }
+  };
+
+  const editPrice = (item) => {
+    setEditingPriceId(item.id);
+    setPriceForm({
+      organizationId: item.organizationId || '',
+      kind: item.kind,
+      classification: item.classification,
+      name: item.name,
+      unit: item.unit,
+      price: item.price,
+    });
+  };
+
+  const deletePrice = async (id) => {
+    if (!window.confirm('Удалить позицию прайс-листа?')) return;
+    try {
+      await axios.delete(`/api/billing/prices/${id}`);
+      toast.success('Цена удалена');
+      fetchPrices();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при удалении цены');
+    }
+  };
+
+  return (
+    <div className="admin-container">
+      <h1>Счета клиентам</h1>
+
+      <div className="admin-tabs">
+        <button className={activeTab === 'invoices' ? 'active' : ''} onClick={() => setActiveTab('invoices')}>
+          Счета
+        </button>
+        <button className={activeTab === 'prices' ? 'active' : ''} onClick={() => setActiveTab('prices')}>
+          Прайс-лист
+        </button>
+      </div>
+
+      <div className="admin-content">
+        {activeTab === 'invoices' && (
+          <div>
+            <div className="form-group">
+              <label htmlFor="billing-period">Месяц:</label>
+              <input id="billing-period" type="month" value={period} onChange={(e) => setPeriod(e.target.value)} />
+            </div>
+            <button className="upload-btn" onClick={() => generate()} disabled={generating}>
+              {generating ? 'Расчёт...' : 'Сформировать счета'}
+            </button>
+
+            {unpriced.length > 0 && (
+              <div>
+                <h3>Нет в прайс-листе</h3>
+                <ul>
+                  {unpriced.map((item, i) => (
+                    <li key={i}>{item}</li>
+                  ))}
+                </ul>
+              </div>
+            )}
+
+            <table>
+              <thead>
+                <tr>
+                  <th>Клиент</th>
+                  <th>Номер</th>
+                  <th>Актов</th>
+                  <th>Сумма</th>
+                  <th>Статус</th>
+                  <th>Действия</th>
+                </tr>
+              </thead>
+              <tbody>
+                {invoices.length === 0 && (
+                  <tr>
+                    <td colSpan="6">Счетов за этот месяц нет</td>
+                  </tr>
+                )}
+                {invoices.map(invoice => (
+                  <tr key={invoice.id}>
+                    <td>{invoice.organizationName}</td>
+                    <td>{invoice.number || '—'}</td>
+                    <td>{invoice.reports}</td>
+                    <td>{formatMoney(invoice.total)}</td>
+                    <td>{STATUS_LABELS[invoice.status] || invoice.status}</td>
+                    <td>
+                      <button className="edit-btn" onClick={() => openInvoice(invoice.id)}>Открыть</button>
+                      {invoice.status === 'draft' && (
+                        <>
+                          <button className="edit-btn" onClick={() => generate(invoice.organizationId)} disabled={generating}>
+                            Пересчитать
+                          </button>
+                          <button className="save-btn" onClick={() => setStatus(invoice, 'issued')}>Выставить</button>
+                          <button className="delete-btn" onClick={() => deleteInvoice(invoice)}>Удалить</button>
+                        </>
+                      )}
+                      {invoice.status === 'issued' && (
+                        <button className="save-btn" onClick={() => setStatus(invoice, 'paid')}>Оплачен</button>
+                      )}
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+
+            {selected && (
+              <div>
+                <h2>{selected.organizationName}: {selected.number ? `счёт № ${selected.number}` : 'черновик'} за {selected.period}</h2>
+                <p>
+                  {STATUS_LABELS[selected.status] || selected.status}
+                  {selected.issuedAt && `, выставлен ${selected.issuedAt}`}
+                  {selected.paidAt && `, оплачен ${selected.paidAt}`}
+                </p>
+                <button className="edit-btn" onClick={() => download(selected, 'pdf')}>Скачать PDF</button>
+                <button className="edit-btn" onClick={() => download(selected, 'zip')}>Счёт с актами (ZIP)</button>
+                <table>
+                  <thead>
+                    <tr>
+                      <th>№</th>
+                      <th>Наименование</th>
+                      <th>Ед.</th>
+                      <th>Кол-во</th>
+                      <th>Цена</th>
+                      <th>Сумма</th>
+                    </tr>
+                  </thead>
+                  <tbody>
+                    {(selected.lines || []).map(line => (
+                      <tr key={line.id}>
+                        <td>{line.position}</td>
+                        <td>{line.description}</td>
+                        <td>{line.unit}</td>
+                        <td>{line.quantity}</td>
+                        <td>{formatMoney(line.price)}</td>
+                        <td>{formatMoney(line.amount)}</td>
+                      </tr>
+                    ))}
+                    <tr>
+                      <td colSpan="5"><strong>Итого</strong></td>
+                      <td><strong>{formatMoney(selected.total)}</strong></td>
+                    </tr>
+                  </tbody>
+                </table>
+              </div>
+            )}
+          </div>
+        )}
+
+        {activeTab === 'prices' && (
+          <div>
+            <h2>Прайс-лист</h2>
+            <p>
+              Работа оценивается по классификации акта: сначала цена клиента для классификации, затем общая
+              для классификации, затем цена клиента и общая для любой классификации. Материалы из актов и
+              установленный ЗИП ищутся по наименованию. Изменение цен не затрагивает выставленные счета.
+            </p>
+            <form onSubmit={savePrice}>
+              <div className="form-group">
+                <label htmlFor="price-kind">Вид:</label>
+                <select
+                  id="price-kind"
+                  value={priceForm.kind}
+                  onChange={(e) => setPriceForm({ ...priceForm, kind: e.target.value })}
+                >
+                  <option value="work">Работа по акту</option>
+                  <option value="material">Материал</option>
+                </select>
+              </div>
+              <div className="form-group">
+                <label htmlFor="price-organization">Клиент:</label>
+                <select
+                  id="price-organization"
+                  value={priceForm.organizationId}
+                  onChange={(e) => setPriceForm({ ...priceForm, organizationId: e.target.value })}
+                >
+                  <option value="">Все клиенты</option>
+                  {organizations.map(org => (
+                    <option key={org.id} value={org.id}>{org.name}</option>
+                  ))}
+                </select>
+              </div>
+              {priceForm.kind === 'work' ? (
+                <div className="form-group">
+                  <label htmlFor="price-classification">Классификация:</label>
+                  <select
+                    id="price-classification"
+                    value={priceForm.classification}
+                    onChange={(e) => setPriceForm({ ...priceForm, classification: e.target.value })}
+                  >
+                    <option value="">Любая</option>
+                    {classifications.filter(c => c.isActive).map(c => (
+                      <option key={c.id} value={c.code}>{c.name}</option>
+                    ))}
+                  </select>
+                </div>
+              ) : (
+                <>
+                  <div className="form-group">
+                    <label htmlFor="price-name">Наименование:</label>
+                    <input
+                      id="price-name"
+                      type="text"
+                      value={priceForm.name}
+                      onChange={(e) => setPriceForm({ ...priceForm, name: e.target.value })}
+                    />
+                  </div>
+                  <div className="form-group">
+                    <label htmlFor="price-unit">Единица:</label>
+                    <input
+                      id="price-unit"
+                      type="text"
+                      value={priceForm.unit}
+                      onChange={(e) => setPriceForm({ ...priceForm, unit: e.target.value })}
+                    />
+                  </div>
+                </>
+              )}
+              <div className="form-group">
+                <label htmlFor="price-value">Цена:</label>
+                <input
+                  id="price-value"
+                  type="number"
+                  min="0"
+                  step="0.01"
+                  value={priceForm.price}
+                  onChange={(e) => setPriceForm({ ...priceForm, price: e.target.value })}
+                />
+              </div>
+              <button type="submit" className="upload-btn">{editingPriceId ? 'Сохранить' : 'Добавить'}</button>
+              {editingPriceId && (
+                <button
+                  type="button"
+                  className="cancel-btn"
+                  onClick={() => {
+                    setEditingPriceId(null);
+                    setPriceForm(EMPTY_PRICE);
+                  }}
+                >
+                  Отмена
+                </button>
+              )}
+            </form>
+
+            <table>
+              <thead>
+                <tr>
+                  <th>Вид</th>
+                  <th>Наименование</th>
+                  <th>Клиент</th>
+                  <th>Ед.</th>
+                  <th>Цена</th>
+                  <th>Действия</th>
+                </tr>
+              </thead>
+              <tbody>
+                {prices.map(item => (
+                  <tr key={item.id}>
+                    <td>{item.kind === 'work' ? 'Работа' : 'Материал'}</td>
+                    <td>{item.kind === 'work' ? (item.classification ? classificationName(item.classification) : 'Любая классификация') : item.name}</td>
+                    <td>{organizationName(item.organizationId)}</td>
+                    <td>{item.unit}</td>
+                    <td>{formatMoney(item.price)}</td>
+                    <td>
+                      <button className="edit-btn" onClick={() => editPrice(item)}>Изменить</button>
+                      <button className="delete-btn" onClick={() => deletePrice(item.id)}>Удалить</button>
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          </div>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default Billing;
@@ -8,6 +8,7 @@ function Files() {
   const [files, setFiles] = useState([]);
   const [certificates, setCertificates] = useState([]);
   const [newNames, setNewNames] = useState({});
+  const [tags, setTags] = useState({});
   const [searchQuery, setSearchQuery] = useState('');
   const [showOnlyMine, setShowOnlyMine] = useState(false);
   const [user, setUser] = useState(null);
@@ -33,6 +34,8 @@ function Files() {
     try {
       const response = await axios.get('/api/files');
       setCertificates(response.data || []);
+      const tagsResponse = await axios.get('/api/files/tags');
+      setTags(tagsResponse.data || {});
     } catch (error) {
       console.error('Ошибка при загрузке сертификатов', error);
       setCertificates([]);
@@ -93,6 +96,18 @@ function Files() {
     }
   };
 
+  const handleEditTags = async (filename) => {
+    const value = window.prompt('Теги через запятую', tags[filename] || '');
+    if (value === null) return;
+
+    try {
+      const response = await axios.put('/api/files/tags', { filename, tags: value.split(',') });
+      setTags((prev) => ({ ...prev, [filename]: response.data.tags.join(', ') }));
+    } catch (error) {
+      console.error('Ошибка при сохранении тегов', error);
+    }
+  };
+
   const handleDelete = async (filename) => {
     try {
       await axios.delete(`/api/files/delete/${filename}`);
@@ -189,7 +204,10 @@ function Files() {
                       title="Нажмите для увеличения"
                     />
                   </td>
-                  <td>{certificate}</td>
+                  <td>
+                    {certificate}
+                    {tags[certificate] && <div className="text-muted small">Теги: {tags[certificate]}</div>}
+                  </td>
                   <td>
                     <input
                       type="text"
@@ -201,6 +219,9 @@ function Files() {
                     <button className="btn btn-warning btn-sm mx-2" onClick={() => handleRename(certificate)}>
                       Переименовать
                     </button>
+                    <button className="btn btn-secondary btn-sm me-2" onClick={() => handleEditTags(certificate)}>
+                      Теги
+                    </button>
                     <button className="btn btn-success btn-sm" onClick={() => handleDownload(certificate)}>
                       Скачать
                     </button>
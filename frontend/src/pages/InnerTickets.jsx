@@ -3,6 +3,7 @@ import axios from 'axios';
 import { useNavigate } from 'react-router-dom';
 import { Table, Button, Modal, Tabs, Tab, Fade, Form } from 'react-bootstrap';
 import TicketsMap from '../components/TicketsMap';
+import { downloadExport } from '../data/exports';
 import '../styles/Schedule.css';
 import '../styles/InnerTickets.css';
 
@@ -80,6 +81,15 @@ function InnerTickets() {
   };
 
   // Привязка отчёта к заявке
+  // Выгрузка всех заявок в Excel/CSV
+  const handleExport = async (format) => {
+    try {
+      await downloadExport('/api/client-tickets/export', {}, format, 'tickets');
+    } catch (error) {
+      console.error('Ошибка при выгрузке заявок:', error);
+    }
+  };
+
   const handleLinkReport = async () => {
     if (!selectedReportId || !selectedTicket) return;
     try {
@@ -388,6 +398,12 @@ function InnerTickets() {
             >
               {showCompletedTickets ? "Показать активные" : "Показать завершенные"}
             </Button>
+            <Button variant="outline-secondary" className="ms-2" onClick={() => handleExport('xlsx')}>
+              Excel
+            </Button>
+            <Button variant="outline-secondary" className="ms-2" onClick={() => handleExport('csv')}>
+              CSV
+            </Button>
           </div>
           <div className="scroll-indicator">
             ← Прокрутите таблицу для просмотра всех столбцов и действий →
@@ -3,6 +3,9 @@ import { useAuth } from '../context/AuthContext';
 import axios from 'axios';
 import { useNavigate } from 'react-router-dom';
 import { FaChevronDown } from 'react-icons/fa';
+import SignaturePad from '../components/SignaturePad';
+import { useClassifications, findClassification } from '../data/classifications';
+import { uploadPhoto, geoStatusLabels } from '../data/photos';
 
 // Стили для скрытия стрелок у input[type=number]
 const quantityInputStyle = {
@@ -36,7 +39,6 @@ function NewReport() {
       defects: '',
       additionalWorks: '',
       comments: '',
-      photos: [],
       checklistItems: [
         { task: 'Ежемесячный технический осмотр оборудования на предмет его работоспособности', done: false },
         { task: 'Технический осмотр оборудования на предмет его работоспособности', done: false },
@@ -63,6 +65,17 @@ function NewReport() {
   };
 
   const [formData, setFormData] = useState(getInitialFormData());
+  const classifications = useClassifications();
+
+  // Классификация из ссылки может прийти названием или псевдонимом - приводим к коду
+  useEffect(() => {
+    const found = findClassification(classifications, formData.classification);
+    if (found && found.code !== formData.classification) {
+      setFormData(prev => ({ ...prev, classification: found.code }));
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [classifications]);
+
   // Список пользователей и выбранный исполнитель
   const [users, setUsers] = useState([]);
   const [selectedUserId, setSelectedUserId] = useState(null);
@@ -85,6 +98,11 @@ function NewReport() {
     fetchUsers();
   }, [user]);
 
+  // Подписи инженера и представителя заказчика (векторные штрихи)
+  const [engineerSignature, setEngineerSignature] = useState({ position: 'Инженер', strokes: [] });
+  const [clientSignature, setClientSignature] = useState({ name: '', position: '', strokes: [] });
+
+  // Фотографии: локальное превью и состояние загрузки на сервер (/api/photos)
   const [previewImages, setPreviewImages] = useState([]);
   const [error, setError] = useState('');
   const [success, setSuccess] = useState('');
@@ -107,6 +125,8 @@ function NewReport() {
   const fileInputRef = useRef(null);
   const addressInputRef = useRef(null);
   const equipmentInputRef = useRef(null);
+  // Ключ идемпотентности: повторная отправка той же формы не создаст второй акт
+  const idempotencyKeyRef = useRef(null);
   const [uploadedFiles, setUploadedFiles] = useState([]);
   // Загружаем список оборудования при монтировании компонента
   // Получаем список оборудования с правильным полем (equipment)
@@ -350,16 +370,18 @@ function NewReport() {
 
     setUploadedFiles((prev) => [...prev, ...newFiles]);
 
+    const updatePreview = (key, patch) =>
+      setPreviewImages((prev) => prev.map((p) => (p.key === key ? { ...p, ...patch } : p)));
+
     newFiles.forEach((file) => {
-      const reader = new FileReader();
-      reader.onloadend = () => {
-        setPreviewImages((prev) => [...prev, reader.result]);
-        setFormData((prev) => ({
-          ...prev,
-          photos: [...prev.photos, reader.result],
-        }));
-      };
-      reader.readAsDataURL(file);
+      const key = `${file.name}-${file.size}-${file.lastModified}`;
+      setPreviewImages((prev) => [...prev, { key, url: URL.createObjectURL(file), progress: 0 }]);
+      uploadPhoto(file, formData.address, (progress) => updatePreview(key, { progress }))
+        .then((photo) => updatePreview(key, { photo, progress: 100 }))
+        .catch((err) => {
+          console.error('Ошибка при загрузке фотографии:', err);
+          updatePreview(key, { error: err.response?.data?.error || 'Ошибка загрузки' });
+        });
     });
 
     if (fileInputRef.current) {
@@ -367,6 +389,38 @@ function NewReport() {
     }
   };
 
+  // Чек-лист из шаблонов для классификации и оборудования объекта.
+  // Если шаблоны не настроены, остаётся стандартный список
+  const checklistEquipmentKey = formData.equipmentItems
+    .map(item => item.name.trim())
+    .filter(Boolean)
+    .join('|');
+  useEffect(() => {
+    const classification = formData.classification === 'Другое' ? formData.customClass : formData.classification;
+    if (!classification || classification === 'не выбрано') return;
+    const timer = setTimeout(async () => {
+      try {
+        const params = new URLSearchParams({ classification });
+        if (formData.address) params.append('address', formData.address);
+        checklistEquipmentKey.split('|').filter(Boolean).forEach(name => params.append('equipment', name));
+        const response = await axios.get(`/api/checklists/expected?${params.toString()}`);
+        const items = response.data.items || [];
+        if (items.length === 0) return;
+        setFormData(prev => ({
+          ...prev,
+          checklistItems: items.map(item => ({
+            task: item.task,
+            required: item.required,
+            done: prev.checklistItems.some(p => p.task === item.task && p.done),
+          })),
+        }));
+      } catch (error) {
+        console.error('Ошибка при загрузке чек-листа:', error);
+      }
+    }, 300);
+    return () => clearTimeout(timer);
+  }, [formData.address, formData.classification, formData.customClass, checklistEquipmentKey]);
+
   const handleChecklistChange = (index) => {
     setFormData((prev) => ({
       ...prev,
@@ -375,10 +429,13 @@ function NewReport() {
   };
 
   const handleRemovePhoto = (index) => {
-    setFormData((prev) => ({
-      ...prev,
-      photos: prev.photos.filter((_, i) => i !== index),
-    }));
+    const preview = previewImages[index];
+    if (preview) {
+      URL.revokeObjectURL(preview.url);
+      if (preview.photo) {
+        axios.delete(`/api/photos/${preview.photo.id}`).catch((err) => console.error('Ошибка при удалении фотографии:', err));
+      }
+    }
     setPreviewImages((prev) => prev.filter((_, i) => i !== index));
     setUploadedFiles((prev) => prev.filter((_, i) => i !== index));
   };
@@ -406,8 +463,21 @@ function NewReport() {
       return;
     }
 
+    const missingTasks = formData.checklistItems.filter(item => item.required && !item.done);
+    if (missingTasks.length > 0) {
+      setError(`Отметьте обязательные пункты чек-листа: ${missingTasks.map(item => item.task).join('; ')}`);
+      return;
+    }
+
+    if (previewImages.some((p) => !p.photo && !p.error)) {
+      setError('Дождитесь окончания загрузки фотографий');
+      return;
+    }
+
     // Если выбрана классификация "Другое", заменяем значение
     let dataToSend = { ...formData };
+    // Фотографии уже загружены, в отчёт передаются только их ID
+    dataToSend.photoIds = previewImages.filter((p) => p.photo).map((p) => p.photo.id);
     // Формируем machine_name и machine_number для отображения в отчёте (совместимость с docgen)
     const equipmentNames = formData.equipmentItems
       .filter(item => item.name.trim())
@@ -429,24 +499,68 @@ function NewReport() {
       }));
     // Добавляем выбранного исполнителя
     dataToSend.userId = parseInt(selectedUserId, 10);
+    // Готовый отчёт сразу уходит на согласование руководителю
+    dataToSend.submit = true;
+    const signatures = [];
+    if (engineerSignature.strokes.length > 0) {
+      const engineer = users.find(u => u.id === parseInt(selectedUserId, 10));
+      signatures.push({
+        role: 'engineer',
+        signerName: engineer ? `${engineer.lastName} ${engineer.firstName}` : '',
+        signerPosition: engineerSignature.position,
+        strokes: engineerSignature.strokes,
+      });
+    }
+    if (clientSignature.strokes.length > 0) {
+      if (!clientSignature.name.trim()) {
+        setError('Укажите ФИО представителя заказчика');
+        return;
+      }
+      signatures.push({
+        role: 'client',
+        signerName: clientSignature.name,
+        signerPosition: clientSignature.position,
+        strokes: clientSignature.strokes,
+      });
+    }
+    if (signatures.length > 0) {
+      dataToSend.signatures = signatures;
+    }
     // Добавляем выбранную заявку для привязки (если есть)
     if (selectedTicketId) {
       dataToSend.ticketId = selectedTicketId;
     }
     if (dataToSend.classification === 'Другое') {
       dataToSend.classification = dataToSend.customClass;
-    } else if (dataToSend.classification === 'Аварийный вызов') {
-      dataToSend.classification = 'АВ';
     }
 
     // Показываем индикатор загрузки и скрываем форму
     setIsLoading(true);
 
+    if (!idempotencyKeyRef.current) {
+      idempotencyKeyRef.current = `${Date.now()}-${Math.random().toString(36).slice(2)}`;
+    }
+
     try {
       const response = await axios.post('/api/report', dataToSend, {
-        headers: { 'Content-Type': 'application/json' },
+        headers: {
+          'Content-Type': 'application/json',
+          'Idempotency-Key': idempotencyKeyRef.current,
+        },
       });
-      const newReportId = response.data.id;
+      // Отчёт формируется в фоне - ждём завершения задачи
+      let job = response.data.job;
+      while (job.status === 'queued' || job.status === 'running') {
+        await new Promise(resolve => setTimeout(resolve, 2000));
+        const jobResponse = await axios.get(`/api/report-jobs/${job.id}`);
+        job = jobResponse.data;
+      }
+      if (job.status === 'failed') {
+        idempotencyKeyRef.current = null;
+        setError(job.error || 'Ошибка при создании отчета');
+        setIsLoading(false);
+        return;
+      }
       setSuccess('Отчет успешно создан');
       fetchAddresses();
 
@@ -616,11 +730,9 @@ function NewReport() {
           <label className="form-label fw-bold">Классификация</label>
           <select className="form-select" name="classification" value={formData.classification} onChange={handleChange}>
             <option value="не выбрано">Не выбрано</option>
-            <option value="ТО Китчен">ТО Китчен</option>
-            <option value="ТО Пекарня">ТО Пекарня</option>
-            <option value="ТО Китчен/Пекарня">ТО Китчен/Пекарня</option>
-            <option value="ПНР">ПНР</option>
-            <option value="Аварийный вызов">Аварийный вызов</option>
+            {classifications.map(c => (
+              <option key={c.code} value={c.code}>{c.name}</option>
+            ))}
             <option value="Другое">Другое</option>
           </select>
 
@@ -881,10 +993,19 @@ function NewReport() {
             {previewImages.map((preview, index) => (
               <div key={index} className="position-relative">
                 <img
-                  src={preview}
+                  src={preview.url}
                   alt={`Preview ${index + 1}`}
-                  style={{ width: '100px', height: '100px', objectFit: 'cover' }}
+                  style={{ width: '100px', height: '100px', objectFit: 'cover', opacity: preview.photo ? 1 : 0.5 }}
                 />
+                <div className="small text-center" style={{ width: '100px' }}>
+                  {preview.error && <span className="text-danger">{preview.error}</span>}
+                  {!preview.error && !preview.photo && <span>{preview.progress}%</span>}
+                  {preview.photo && (
+                    <span className={preview.photo.geoStatus === 'far' ? 'text-danger' : 'text-muted'}>
+                      {geoStatusLabels[preview.photo.geoStatus]}
+                    </span>
+                  )}
+                </div>
                 <button
                   type="button"
                   className="btn btn-danger btn-sm position-absolute top-0 end-0"
@@ -937,6 +1058,7 @@ function NewReport() {
               />
               <label className="form-check-label" htmlFor={`checklist-${index}`}>
                 {item.task}
+                {item.required && <span className="text-danger"> *</span>}
               </label>
             </div>
           ))}
@@ -964,6 +1086,49 @@ function NewReport() {
           />
         </div>
 
+        {/* Подписи */}
+        <div className="mb-3">
+          <label className="form-label fw-bold">Подписи</label>
+          <div className="row">
+            <div className="col-md-6 mb-3">
+              <div className="mb-1">Исполнитель</div>
+              <input
+                type="text"
+                className="form-control form-control-sm mb-2"
+                placeholder="Должность"
+                value={engineerSignature.position}
+                onChange={(e) => setEngineerSignature(prev => ({ ...prev, position: e.target.value }))}
+              />
+              <SignaturePad
+                strokes={engineerSignature.strokes}
+                onChange={(strokes) => setEngineerSignature(prev => ({ ...prev, strokes }))}
+              />
+            </div>
+            <div className="col-md-6 mb-3">
+              <div className="mb-1">Представитель заказчика</div>
+              <input
+                type="text"
+                className="form-control form-control-sm mb-2"
+                placeholder="ФИО"
+                value={clientSignature.name}
+                onChange={(e) => setClientSignature(prev => ({ ...prev, name: e.target.value }))}
+              />
+              <input
+                type="text"
+                className="form-control form-control-sm mb-2"
+                placeholder="Должность"
+                value={clientSignature.position}
+                onChange={(e) => setClientSignature(prev => ({ ...prev, position: e.target.value }))}
+              />
+              <SignaturePad
+                strokes={clientSignature.strokes}
+                onChange={(strokes) => setClientSignature(prev => ({ ...prev, strokes }))}
+              />
+            </div>
+          </div>
+          <small className="text-muted">Подписи встраиваются в акт вместе с ФИО, должностью и временем подписания</small>
+        </div>
+
         {/* Привязка к заявке */}
         <div className="mb-3">
           <label className="form-label fw-bold">Привязать к заявке</label>
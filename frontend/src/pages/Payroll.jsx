@@ -0,0 +1,533 @@
+import React, { useState, useEffect, useCallback } from 'react';
+import axios from 'axios';
+import { toast } from 'react-toastify';
+import { useAuth } from '../context/AuthContext';
+import { useClassifications } from '../data/classifications';
+import '../styles/Admin.css';
+
+const RATE_KINDS = [
+  { value: 'act', label: 'За акт' },
+  { value: 'km', label: 'За километр' },
+  { value: 'sla_breach', label: 'Удержание за нарушение SLA' },
+];
+
+const STATUS_LABELS = { draft: 'Черновик', approved: 'Утверждена' };
+
+const EMPTY_RATE = { kind: 'act', classification: '', userId: '', amount: '' };
+const EMPTY_ADJUSTMENT = { userId: '', kind: 'bonus', amount: '', reason: '' };
+
+const currentPeriod = () => new Date().toISOString().slice(0, 7);
+
+const formatMoney = (value) => Number(value || 0).toLocaleString('ru-RU', { minimumFractionDigits: 2, maximumFractionDigits: 2 });
+
+const Payroll = () => {
+  const { user } = useAuth();
+  const isReviewer = user && ['Админ', 'Руководитель'].includes(user.department);
+  const classifications = useClassifications(false);
+
+  const [activeTab, setActiveTab] = useState('statements');
+  const [period, setPeriod] = useState(currentPeriod());
+  const [users, setUsers] = useState([]);
+
+  // Ведомости за выбранный месяц
+  const [statements, setStatements] = useState([]);
+  const [selected, setSelected] = useState(null);
+  const [calculating, setCalculating] = useState(false);
+
+  // Ставки и корректировки (только для руководителей)
+  const [rates, setRates] = useState([]);
+  const [rateForm, setRateForm] = useState(EMPTY_RATE);
+  const [editingRateId, setEditingRateId] = useState(null);
+  const [adjustments, setAdjustments] = useState([]);
+  const [adjustmentForm, setAdjustmentForm] = useState(EMPTY_ADJUSTMENT);
+
+  const userName = (id) => {
+    const u = users.find(item => item.id === id);
+    return u ? `${u.lastName} ${u.firstName}` : `#${id}`;
+  };
+
+  const classificationName = (code) => classifications.find(c => c.code === code)?.name || code;
+
+  const fetchStatements = useCallback(async () => {
+    try {
+      const response = await axios.get('/api/payroll/statements', { params: { period } });
+      setStatements(response.data || []);
+    } catch (error) {
+      toast.error('Ошибка при загрузке ведомостей');
+    }
+  }, [period]);
+
+  const fetchRates = useCallback(async () => {
+    try {
+      const response = await axios.get('/api/payroll/rates');
+      setRates(response.data || []);
+    } catch (error) {
+      toast.error('Ошибка при загрузке ставок');
+    }
+  }, []);
+
+  const fetchAdjustments = useCallback(async () => {
+    try {
+      const response = await axios.get('/api/payroll/adjustments', { params: { period } });
+      setAdjustments(response.data || []);
+    } catch (error) {
+      toast.error('Ошибка при загрузке корректировок');
+    }
+  }, [period]);
+
+  useEffect(() => {
+    fetchStatements();
+    setSelected(null);
+  }, [fetchStatements]);
+
+  useEffect(() => {
+    if (!isReviewer) return;
+    axios.get('/api/users').then(r => setUsers(r.data || [])).catch(() => setUsers([]));
+    fetchRates();
+  }, [isReviewer, fetchRates]);
+
+  useEffect(() => {
+    if (isReviewer) fetchAdjustments();
+  }, [isReviewer, fetchAdjustments]);
+
+  const openStatement = async (id) => {
+    try {
+      const response = await axios.get(`/api/payroll/statements/${id}`);
+      setSelected(response.data);
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при загрузке ведомости');
+    }
+  };
+
+  const calculate = async (userId) => {
+    setCalculating(true);
+    try {
+      const response = await axios.post('/api/payroll/statements/calculate', { period, userId: userId || undefined });
+      const { statements: calculated, locked } = response.data;
+      toast.success(`Рассчитано ведомостей: ${calculated.length}`);
+      if (locked.length > 0) {
+        toast.info(`Пропущено утверждённых ведомостей: ${locked.length}`);
+      }
+      await fetchStatements();
+      if (selected) openStatement(selected.id);
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при расчёте ведомостей');
+    } finally {
+      setCalculating(false);
+    }
+  };
+
+  const approve = async (statement) => {
+    if (!window.confirm(`Утвердить ведомость ${statement.engineerName} за ${statement.period}? После утверждения она не пересчитывается.`)) return;
+    try {
+      await axios.post(`/api/payroll/statements/${statement.id}/approve`);
+      toast.success('Ведомость утверждена');
+      fetchStatements();
+      openStatement(statement.id);
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при утверждении ведомости');
+    }
+  };
+
+  const exportStatement = async (statement, format) => {
+    try {
+      const response = await axios.get(`/api/payroll/statements/${statement.id}/export`, {
+        params: { format },
+        responseType: 'blob',
+      });
+      const url = window.URL.createObjectURL(new Blob([response.data], { type: response.headers['content-type'] }));
+      const link = document.createElement('a');
+      link.href = url;
+      link.setAttribute('download', `Ведомость_${statement.period}_${statement.engineerName.replace(/ /g, '_')}.${format}`);
+      document.body.appendChild(link);
+      link.click();
+      link.remove();
+      window.URL.revokeObjectURL(url);
+    } catch (error) {
+      toast.error('Ошибка при выгрузке ведомости');
+    }
+  };
+
+  const saveRate = async (e) => {
+    e.preventDefault();
+    if (rateForm.amount === '' || Number(rateForm.amount) < 0) {
+      toast.warning('Введите ставку');
+      return;
+    }
+    const payload = {
+      kind: rateForm.kind,
+      classification: rateForm.kind === 'act' ? rateForm.classification : '',
+      userId: rateForm.userId ? Number(rateForm.userId) : null,
+      amount: Number(rateForm.amount),
+    };
+    try {
+      if (editingRateId) {
+        await axios.put(`/api/payroll/rates/${editingRateId}`, payload);
+        toast.success('Ставка обновлена');
+      } else {
+        await axios.post('/api/payroll/rates', payload);
+        toast.success('Ставка добавлена');
+      }
+      setRateForm(EMPTY_RATE);
+      setEditingRateId(null);
+      fetchRates();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при сохранении ставки');
+    }
+  };
+
+  const editRate = (rate) => {
+    setEditingRateId(rate.id);
+    setRateForm({
+      kind: rate.kind,
+      classification: rate.classification,
+      userId: rate.userId || '',
+      amount: rate.amount,
+    });
+  };
+
+  const deleteRate = async (id) => {
+    if (!window.confirm('Удалить ставку?')) return;
+    try {
+      await axios.delete(`/api/payroll/rates/${id}`);
+      toast.success('Ставка удалена');
+      fetchRates();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при удалении ставки');
+    }
+  };
+
+  const addAdjustment = async (e) => {
+    e.preventDefault();
+    if (!adjustmentForm.userId || !(Number(adjustmentForm.amount) > 0) || !adjustmentForm.reason.trim()) {
+      toast.warning('Укажите инженера, сумму и причину');
+      return;
+    }
+    try {
+      await axios.post('/api/payroll/adjustments', {
+        ...adjustmentForm,
+        userId: Number(adjustmentForm.userId),
+        amount: Number(adjustmentForm.amount),
+        period,
+      });
+      toast.success('Корректировка добавлена, пересчитайте ведомость');
+      setAdjustmentForm(EMPTY_ADJUSTMENT);
+      fetchAdjustments();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при добавлении корректировки');
+    }
+  };
+
+  const deleteAdjustment = async (id) => {
+    if (!window.confirm('Удалить корректировку?')) return;
+    try {
+      await axios.delete(`/api/payroll/adjustments/${id}`);
+      toast.success('Корректировка удалена');
+      fetchAdjustments();
+    } catch (error) {
+      toast.error(error.response?.data?.error || 'Ошибка при удалении корректировки');
+    }
+  };
+
+  return (
+    <div className="admin-container">
+      <h1>Зарплата</h1>
+
+      <div className="form-group">
+        <label htmlFor="payroll-period">Месяц:</label>
+        <input id="payroll-period" type="month" value={period} onChange={(e) => setPeriod(e.target.value)} />
+      </div>
+
+      {isReviewer && (
+        <div className="admin-tabs">
+          <button className={activeTab === 'statements' ? 'active' : ''} onClick={() => setActiveTab('statements')}>
+            Ведомости
+          </button>
+          <button className={activeTab === 'adjustments' ? 'active' : ''} onClick={() => setActiveTab('adjustments')}>
+            Премии и удержания
+          </button>
+          <button className={activeTab === 'rates' ? 'active' : ''} onClick={() => setActiveTab('rates')}>
+            Ставки
+          </button>
+        </div>
+      )}
+
+      <div className="admin-content">
+        {activeTab === 'statements' && (
+          <div>
+            <h2>Ведомости за {period}</h2>
+            {isReviewer && (
+              <button className="upload-btn" onClick={() => calculate()} disabled={calculating}>
+                {calculating ? 'Расчёт...' : 'Рассчитать всех'}
+              </button>
+            )}
+
+            <table>
+              <thead>
+                <tr>
+                  <th>Инженер</th>
+                  <th>Акты</th>
+                  <th>Заявки (в SLA / нарушения)</th>
+                  <th>Пробег, км</th>
+                  <th>Итого</th>
+                  <th>Статус</th>
+                  <th>Действия</th>
+                </tr>
+              </thead>
+              <tbody>
+                {statements.length === 0 && (
+                  <tr>
+                    <td colSpan="7">Ведомостей за этот месяц нет</td>
+                  </tr>
+                )}
+                {statements.map(s => (
+                  <tr key={s.id}>
+                    <td>{s.engineerName}</td>
+                    <td>{s.reports}</td>
+                    <td>{s.tickets} ({s.slaHits} / {s.slaBreaches})</td>
+                    <td>{s.km}</td>
+                    <td>{formatMoney(s.total)}</td>
+                    <td>{STATUS_LABELS[s.status] || s.status}</td>
+                    <td>
+                      <button className="edit-btn" onClick={() => openStatement(s.id)}>Открыть</button>
+                      {isReviewer && s.status === 'draft' && (
+                        <>
+                          <button className="edit-btn" onClick={() => calculate(s.userId)} disabled={calculating}>
+                            Пересчитать
+                          </button>
+                          <button className="save-btn" onClick={() => approve(s)}>Утвердить</button>
+                        </>
+                      )}
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+
+            {selected && (
+              <div>
+                <h2>{selected.engineerName}, {selected.period}</h2>
+                <p>
+                  {STATUS_LABELS[selected.status] || selected.status}
+                  {selected.approvedAt ? `, утверждена ${selected.approvedAt}` : `, рассчитана ${selected.calculatedAt}`}
+                </p>
+                <button className="edit-btn" onClick={() => exportStatement(selected, 'xlsx')}>Скачать XLSX</button>
+                <button className="edit-btn" onClick={() => exportStatement(selected, 'pdf')}>Скачать PDF</button>
+                <table>
+                  <thead>
+                    <tr>
+                      <th>№</th>
+                      <th>Начисление</th>
+                      <th>Количество</th>
+                      <th>Ставка</th>
+                      <th>Сумма</th>
+                    </tr>
+                  </thead>
+                  <tbody>
+                    {(selected.lines || []).map(line => (
+                      <tr key={line.id}>
+                        <td>{line.position}</td>
+                        <td>{line.description}</td>
+                        <td>{line.quantity}</td>
+                        <td>{formatMoney(line.rate)}</td>
+                        <td>{formatMoney(line.amount)}</td>
+                      </tr>
+                    ))}
+                    <tr>
+                      <td colSpan="4"><strong>Итого</strong></td>
+                      <td><strong>{formatMoney(selected.total)}</strong></td>
+                    </tr>
+                  </tbody>
+                </table>
+              </div>
+            )}
+          </div>
+        )}
+
+        {isReviewer && activeTab === 'adjustments' && (
+          <div>
+            <h2>Премии и удержания за {period}</h2>
+            <form onSubmit={addAdjustment}>
+              <div className="form-group">
+                <label htmlFor="adjustment-user">Инженер:</label>
+                <select
+                  id="adjustment-user"
+                  value={adjustmentForm.userId}
+                  onChange={(e) => setAdjustmentForm({ ...adjustmentForm, userId: e.target.value })}
+                >
+                  <option value="">Выберите инженера</option>
+                  {users.map(u => (
+                    <option key={u.id} value={u.id}>{u.lastName} {u.firstName}</option>
+                  ))}
+                </select>
+              </div>
+              <div className="form-group">
+                <label htmlFor="adjustment-kind">Вид:</label>
+                <select
+                  id="adjustment-kind"
+                  value={adjustmentForm.kind}
+                  onChange={(e) => setAdjustmentForm({ ...adjustmentForm, kind: e.target.value })}
+                >
+                  <option value="bonus">Премия</option>
+                  <option value="penalty">Удержание</option>
+                </select>
+              </div>
+              <div className="form-group">
+                <label htmlFor="adjustment-amount">Сумма:</label>
+                <input
+                  id="adjustment-amount"
+                  type="number"
+                  min="0"
+                  step="0.01"
+                  value={adjustmentForm.amount}
+                  onChange={(e) => setAdjustmentForm({ ...adjustmentForm, amount: e.target.value })}
+                />
+              </div>
+              <div className="form-group">
+                <label htmlFor="adjustment-reason">Причина:</label>
+                <input
+                  id="adjustment-reason"
+                  type="text"
+                  value={adjustmentForm.reason}
+                  onChange={(e) => setAdjustmentForm({ ...adjustmentForm, reason: e.target.value })}
+                />
+              </div>
+              <button type="submit" className="upload-btn">Добавить</button>
+            </form>
+
+            <table>
+              <thead>
+                <tr>
+                  <th>Инженер</th>
+                  <th>Вид</th>
+                  <th>Сумма</th>
+                  <th>Причина</th>
+                  <th>Добавлена</th>
+                  <th>Действия</th>
+                </tr>
+              </thead>
+              <tbody>
+                {adjustments.map(a => (
+                  <tr key={a.id}>
+                    <td>{userName(a.userId)}</td>
+                    <td>{a.kind === 'bonus' ? 'Премия' : 'Удержание'}</td>
+                    <td>{formatMoney(a.amount)}</td>
+                    <td>{a.reason}</td>
+                    <td>{a.createdAt}</td>
+                    <td>
+                      <button className="delete-btn" onClick={() => deleteAdjustment(a.id)}>Удалить</button>
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          </div>
+        )}
+
+        {isReviewer && activeTab === 'rates' && (
+          <div>
+            <h2>Ставки</h2>
+            <p>
+              Применяется самая точная ставка: для инженера и классификации, затем для классификации,
+              затем для инженера, затем общая. Изменение ставок не затрагивает утверждённые ведомости.
+            </p>
+            <form onSubmit={saveRate}>
+              <div className="form-group">
+                <label htmlFor="rate-kind">Вид:</label>
+                <select
+                  id="rate-kind"
+                  value={rateForm.kind}
+                  onChange={(e) => setRateForm({ ...rateForm, kind: e.target.value })}
+                >
+                  {RATE_KINDS.map(k => (
+                    <option key={k.value} value={k.value}>{k.label}</option>
+                  ))}
+                </select>
+              </div>
+              {rateForm.kind === 'act' && (
+                <div className="form-group">
+                  <label htmlFor="rate-classification">Классификация:</label>
+                  <select
+                    id="rate-classification"
+                    value={rateForm.classification}
+                    onChange={(e) => setRateForm({ ...rateForm, classification: e.target.value })}
+                  >
+                    <option value="">Любая</option>
+                    {classifications.filter(c => c.isActive).map(c => (
+                      <option key={c.id} value={c.code}>{c.name}</option>
+                    ))}
+                  </select>
+                </div>
+              )}
+              <div className="form-group">
+                <label htmlFor="rate-user">Инженер:</label>
+                <select
+                  id="rate-user"
+                  value={rateForm.userId}
+                  onChange={(e) => setRateForm({ ...rateForm, userId: e.target.value })}
+                >
+                  <option value="">Все инженеры</option>
+                  {users.map(u => (
+                    <option key={u.id} value={u.id}>{u.lastName} {u.firstName}</option>
+                  ))}
+                </select>
+              </div>
+              <div className="form-group">
+                <label htmlFor="rate-amount">Ставка:</label>
+                <input
+                  id="rate-amount"
+                  type="number"
+                  min="0"
+                  step="0.01"
+                  value={rateForm.amount}
+                  onChange={(e) => setRateForm({ ...rateForm, amount: e.target.value })}
+                />
+              </div>
+              <button type="submit" className="upload-btn">{editingRateId ? 'Сохранить' : 'Добавить'}</button>
+              {editingRateId && (
+                <button
+                  type="button"
+                  className="cancel-btn"
+                  onClick={() => {
+                    setEditingRateId(null);
+                    setRateForm(EMPTY_RATE);
+                  }}
+                >
+                  Отмена
+                </button>
+              )}
+            </form>
+
+            <table>
+              <thead>
+                <tr>
+                  <th>Вид</th>
+                  <th>Классификация</th>
+                  <th>Инженер</th>
+                  <th>Ставка</th>
+                  <th>Действия</th>
+                </tr>
+              </thead>
+              <tbody>
+                {rates.map(rate => (
+                  <tr key={rate.id}>
+                    <td>{RATE_KINDS.find(k => k.value === rate.kind)?.label || rate.kind}</td>
+                    <td>{rate.kind === 'act' ? (rate.classification ? classificationName(rate.classification) : 'Любая') : '—'}</td>
+                    <td>{rate.userId ? userName(rate.userId) : 'Все'}</td>
+                    <td>{formatMoney(rate.amount)}</td>
+                    <td>
+                      <button className="edit-btn" onClick={() => editRate(rate)}>Изменить</button>
+                      <button className="delete-btn" onClick={() => deleteRate(rate.id)}>Удалить</button>
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          </div>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default Payroll;
@@ -0,0 +1,96 @@
+import React, { useState, useEffect } from 'react';
+import axios from 'axios';
+import { Link, useParams } from 'react-router-dom';
+
+const ROLE_LABELS = {
+  engineer: 'Исполнитель',
+  client: 'Представитель заказчика',
+};
+
+// Страница проверки подписей акта: подписант, должность, время подписания и изображение
+function ReportSignatures() {
+  const { id } = useParams();
+  const [report, setReport] = useState(null);
+  const [signatures, setSignatures] = useState([]);
+  const [images, setImages] = useState({});
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(true);
+
+  useEffect(() => {
+    let urls = [];
+    const fetchSignatures = async () => {
+      try {
+        const response = await axios.get(`/api/reports/${id}/signatures`);
+        setReport(response.data.report);
+        setSignatures(response.data.signatures || []);
+
+        const loaded = {};
+        for (const sig of response.data.signatures || []) {
+          try {
+            const imageResponse = await axios.get(`/api/reports/${id}/signatures/${sig.id}/image`, { responseType: 'blob' });
+            loaded[sig.id] = URL.createObjectURL(imageResponse.data);
+            urls.push(loaded[sig.id]);
+          } catch (err) {
+            console.error('Ошибка при загрузке изображения подписи', err);
+          }
+        }
+        setImages(loaded);
+      } catch (err) {
+        setError(err.response?.data?.error || 'Ошибка при загрузке подписей');
+      } finally {
+        setLoading(false);
+      }
+    };
+    fetchSignatures();
+    return () => urls.forEach(url => URL.revokeObjectURL(url));
+  }, [id]);
+
+  return (
+    <div className="container mt-5">
+      <h1>Проверка подписей акта</h1>
+      <Link to={`/reports?highlight=${id}`}>← К отчетам</Link>
+
+      {loading && <p className="mt-3 text-muted">Загрузка...</p>}
+      {error && <p className="mt-3 text-danger">{error}</p>}
+
+      {report && (
+        <div className="card mt-3">
+          <div className="card-body">
+            <h5 className="card-title">{report.filename}</h5>
+            <p className="card-text mb-1">Объект: {report.address}</p>
+            <p className="card-text mb-1">Дата: {report.date}</p>
+            <p className="card-text mb-1">Классификация: {report.classification}</p>
+            <p className="card-text">Версия: {report.version}</p>
+          </div>
+        </div>
+      )}
+
+      {!loading && report && signatures.length === 0 && (
+        <p className="mt-3 text-muted">Акт не подписан</p>
+      )}
+
+      <div className="row mt-3">
+        {signatures.map(sig => (
+          <div key={sig.id} className="col-md-6 mb-3">
+            <div className="card h-100">
+              <div className="card-body">
+                <h6 className="card-subtitle mb-2 text-muted">{ROLE_LABELS[sig.role] || sig.role}</h6>
+                {images[sig.id] && (
+                  <img src={images[sig.id]} alt="Подпись" style={{ maxWidth: '100%', maxHeight: 120 }} className="mb-2" />
+                )}
+                <p className="card-text mb-1"><strong>{sig.signerName}</strong></p>
+                {sig.signerPosition && <p className="card-text mb-1">{sig.signerPosition}</p>}
+                <p className="card-text mb-1">Подписано: {sig.signedAt}</p>
+                <p className="card-text text-muted" style={{ fontSize: '0.8em', wordBreak: 'break-all' }}>
+                  SHA-256: {sig.sha256}
+                </p>
+              </div>
+            </div>
+          </div>
+        ))}
+      </div>
+    </div>
+  );
+}
+
+export default ReportSignatures;
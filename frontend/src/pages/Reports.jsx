@@ -1,12 +1,16 @@
 import React, { useState, useEffect, useRef, useCallback } from 'react';
 import axios from 'axios';
 import { Modal } from 'react-bootstrap';
+import { Link } from 'react-router-dom';
 import '../styles/Reports.css';
 import { useAuth } from '../context/AuthContext';
+import { useClassifications } from '../data/classifications';
+import { downloadExport, downloadReportsZip, downloadReportArchive } from '../data/exports';
 
 function Reports() {
   const { user } = useAuth();
   const isViewOnly = user?.phone === 'viewonlyuser';
+  const isReviewer = user && ['Админ', 'Руководитель'].includes(user.department);
 
   const [reports, setReports] = useState([]);
   const [users, setUsers] = useState({});
@@ -22,7 +26,8 @@ function Reports() {
   const [showDatePicker, setShowDatePicker] = useState(false);
   const [dateRange, setDateRange] = useState({ startDate: '', endDate: '' });
   const [isDateFiltered, setIsDateFiltered] = useState(false);
-  const [classificationStats, setClassificationStats] = useState({ toKitchen: 0, toBakery: 0, toKitchenBakery: 0, to: 0, av: 0, pnr: 0 });
+  const [classificationStats, setClassificationStats] = useState({ all: {}, filtered: {} });
+  const classifications = useClassifications();
   const [selectedReports, setSelectedReports] = useState([]);
   const [pageSize] = useState(20); // Размер страницы
   const [isLoading, setIsLoading] = useState(false); // UI индикатор
@@ -35,6 +40,16 @@ function Reports() {
   const pagesCacheRef = useRef(new Map()); // Кэш списка страниц для избежания повторных запросов
   const [previewLoading, setPreviewLoading] = useState(false); // Индикатор загрузки превью
   const [previewGenerating, setPreviewGenerating] = useState(false); // Индикатор генерации превью
+  const [showReviewQueue, setShowReviewQueue] = useState(false);
+  const [reviewQueue, setReviewQueue] = useState([]);
+  const [archives, setArchives] = useState([]); // Архивы, собираемые в фоне
+
+  const STATUS_LABELS = {
+    draft: { text: 'Черновик', className: 'bg-secondary' },
+    submitted: { text: 'На согласовании', className: 'bg-warning text-dark' },
+    approved: { text: 'Согласован', className: 'bg-success' },
+    rejected: { text: 'Возвращён', className: 'bg-danger' },
+  };
 
   const formatDate = (dateString) => {
     return new Date(dateString).toLocaleDateString('ru-RU');
@@ -42,7 +57,7 @@ function Reports() {
 
   const fetchUsers = useCallback(async () => {
     try {
-      const response = await axios.get('/api/users');
+      const response = await axios.get('/api/users', { params: { includeInactive: true } });
       const usersData = {};
       response.data.forEach(user => {
         usersData[user.id] = user;
@@ -67,18 +82,8 @@ function Reports() {
         filteredMonth: response.data.filteredMonth || 0,
       });
       setClassificationStats({
-        toKitchen: response.data.toKitchen,
-        toBakery: response.data.toBakery,
-        toKitchenBakery: response.data.toKitchenBakery,
-        to: response.data.to,
-        av: response.data.av,
-        pnr: response.data.pnr,
-        filteredToKitchen: response.data.filteredToKitchen || 0,
-        filteredToBakery: response.data.filteredToBakery || 0,
-        filteredToKitchenBakery: response.data.filteredToKitchenBakery || 0,
-        filteredTo: response.data.filteredTo || 0,
-        filteredAv: response.data.filteredAv || 0,
-        filteredPnr: response.data.filteredPnr || 0,
+        all: response.data.byClassification || {},
+        filtered: response.data.filteredByClassification || {},
       });
     } catch (error) {
       console.error('Ошибка при загрузке статистики отчетов', error);
@@ -336,47 +341,152 @@ function Reports() {
 
   const handleDownloadMonthlyReports = async () => {
     try {
-      const response = await axios.get('/api/reports/monthly-zip', {
-        responseType: 'blob',
-      });
-
-      const url = window.URL.createObjectURL(new Blob([response.data]));
-      const link = document.createElement('a');
-      link.href = url;
-      link.setAttribute('download', 'monthly_reports.zip');
-      document.body.appendChild(link);
-      link.click();
-      link.remove();
-      window.URL.revokeObjectURL(url);
+      const archive = await downloadReportsZip(
+        axios.get('/api/reports/monthly-zip', { responseType: 'blob' }),
+        'monthly_reports.zip'
+      );
+      if (archive) setArchives((prev) => [archive, ...prev]);
     } catch (error) {
       setError('Ошибка при скачивании архива отчетов');
       console.error('Ошибка при скачивании архива отчетов', error);
     }
   };
 
+  // Выгрузка списка отчётов с текущими фильтрами
+  const handleExport = async (format) => {
+    const params = { onlyMine: showOnlyMine, order: sortOrder || 'desc' };
+    if (isDateFiltered && dateRange.startDate && dateRange.endDate) {
+      params.startDate = dateRange.startDate;
+      params.endDate = dateRange.endDate;
+    }
+    if (searchTerm) params.search = searchTerm;
+    try {
+      await downloadExport('/api/reports/export', params, format, 'reports');
+    } catch (error) {
+      setError('Ошибка при выгрузке списка отчетов');
+      console.error('Ошибка при выгрузке списка отчетов:', error);
+    }
+  };
+
   const handleDownloadReportsByPeriod = async () => {
     try {
-      const response = await axios.get(
-        `/api/reports/period-zip?startDate=${dateRange.startDate}&endDate=${dateRange.endDate}`,
-        {
-          responseType: 'blob',
-        }
+      const archive = await downloadReportsZip(
+        axios.get(
+          `/api/reports/period-zip?startDate=${dateRange.startDate}&endDate=${dateRange.endDate}`,
+          { responseType: 'blob' }
+        ),
+        'reports_by_period.zip'
       );
-
-      const url = window.URL.createObjectURL(new Blob([response.data]));
-      const link = document.createElement('a');
-      link.href = url;
-      link.setAttribute('download', 'reports_by_period.zip');
-      document.body.appendChild(link);
-      link.click();
-      link.remove();
-      window.URL.revokeObjectURL(url);
+      if (archive) setArchives((prev) => [archive, ...prev]);
     } catch (error) {
       setError('Ошибка при скачивании архива отчетов за период');
       console.error('Ошибка при скачивании архива отчетов за период:', error);
     }
   };
 
+  const fetchArchives = useCallback(async () => {
+    try {
+      const response = await axios.get('/api/report-archives');
+      setArchives(response.data || []);
+    } catch (error) {
+      console.error('Ошибка при загрузке архивов', error);
+    }
+  }, []);
+
+  useEffect(() => {
+    fetchArchives();
+  }, [fetchArchives]);
+
+  // Пока архивы собираются - обновляем их состояние
+  const archivesPending = archives.some((a) => a.status === 'queued' || a.status === 'running');
+  useEffect(() => {
+    if (!archivesPending) return undefined;
+    const timer = setInterval(fetchArchives, 5000);
+    return () => clearInterval(timer);
+  }, [archivesPending, fetchArchives]);
+
+  const handleDownloadArchive = async (archive) => {
+    try {
+      await downloadReportArchive(archive);
+    } catch (error) {
+      setError('Ошибка при скачивании архива');
+      console.error('Ошибка при скачивании архива', error);
+    }
+  };
+
+  const fetchReviewQueue = useCallback(async () => {
+    if (!isReviewer) return;
+    try {
+      const response = await axios.get('/api/reports/review-queue');
+      setReviewQueue(response.data || []);
+    } catch (error) {
+      console.error('Ошибка при загрузке очереди согласования', error);
+    }
+  }, [isReviewer]);
+
+  // Обновляет отчёт в списке после смены статуса
+  const applyReportUpdate = (updated) => {
+    setReports(prev => prev.map(r => (r.id === updated.id ? { ...r, ...updated } : r)));
+    setReviewQueue(prev => prev.filter(r => r.id !== updated.id || updated.status === 'submitted'));
+  };
+
+  const handleSubmitForReview = async (report) => {
+    try {
+      const response = await axios.post(`/api/reports/${report.id}/submit`, {});
+      applyReportUpdate(response.data.report);
+    } catch (error) {
+      setError(error.response?.data?.error || 'Ошибка при отправке на согласование');
+    }
+  };
+
+  const handleApprove = async (report) => {
+    const comment = window.prompt('Комментарий к согласованию (необязательно):', '');
+    if (comment === null) return;
+    try {
+      const response = await axios.post(`/api/reports/${report.id}/approve`, { comment });
+      applyReportUpdate(response.data.report);
+      fetchReportsCount();
+    } catch (error) {
+      setError(error.response?.data?.error || 'Ошибка при согласовании отчета');
+    }
+  };
+
+  const handleReject = async (report) => {
+    const comment = window.prompt('Причина возврата на доработку:', '');
+    if (comment === null) return;
+    if (!comment.trim()) {
+      alert('Укажите причину возврата');
+      return;
+    }
+    try {
+      const response = await axios.post(`/api/reports/${report.id}/reject`, { comment });
+      applyReportUpdate(response.data.report);
+      fetchReportsCount();
+    } catch (error) {
+      setError(error.response?.data?.error || 'Ошибка при возврате отчета');
+    }
+  };
+
+  const renderReviewButtons = (report) => (
+    <>
+      {['draft', 'rejected'].includes(report.status) && !isViewOnly && (
+        <button className="btn btn-outline-primary btn-sm me-2" onClick={() => handleSubmitForReview(report)}>
+          На согласование
+        </button>
+      )}
+      {isReviewer && report.status === 'submitted' && (
+        <button className="btn btn-outline-success btn-sm me-2" onClick={() => handleApprove(report)}>
+          Согласовать
+        </button>
+      )}
+      {isReviewer && ['submitted', 'approved'].includes(report.status) && (
+        <button className="btn btn-outline-danger btn-sm" onClick={() => handleReject(report)}>
+          Вернуть
+        </button>
+      )}
+    </>
+  );
+
   const toggleReportSelection = (reportId) => {
     setSelectedReports((prev) =>
       prev.includes(reportId)
@@ -392,20 +502,11 @@ function Reports() {
     }
 
     try {
-      const response = await axios.post('/api/reports/download-selected', {
-        reportIds: selectedReports,
-      }, {
-        responseType: 'blob',
-      });
-
-      const url = window.URL.createObjectURL(new Blob([response.data]));
-      const link = document.createElement('a');
-      link.href = url;
-      link.setAttribute('download', 'selected_reports.zip');
-      document.body.appendChild(link);
-      link.click();
-      link.remove();
-      window.URL.revokeObjectURL(url);
+      const archive = await downloadReportsZip(
+        axios.post('/api/reports/download-selected', { reportIds: selectedReports }, { responseType: 'blob' }),
+        'selected_reports.zip'
+      );
+      if (archive) setArchives((prev) => [archive, ...prev]);
     } catch (error) {
       console.error('Ошибка при скачивании выбранных отчетов:', error);
     }
@@ -415,8 +516,9 @@ function Reports() {
     fetchReports(1, true);
     fetchUsers();
     fetchReportsCount();
+    fetchReviewQueue();
     // fetchReports стабилен по зависимостям выше
-  }, [fetchUsers, fetchReportsCount, fetchReports]);
+  }, [fetchUsers, fetchReportsCount, fetchReports, fetchReviewQueue]);
 
   useEffect(() => {
     const searchParams = new URLSearchParams(window.location.search);
@@ -479,24 +581,53 @@ function Reports() {
             Скачать за месяц
           </button>
         )}
+        <button className="btn btn-outline-secondary" onClick={() => handleExport('xlsx')}>
+          Excel
+        </button>
+        <button className="btn btn-outline-secondary" onClick={() => handleExport('csv')}>
+          CSV
+        </button>
       </div>
       <div style={{ fontSize: '0.9em', marginBottom: '1rem' }}>
-        <span>ТО Китчен: {isDateFiltered ? classificationStats.filteredToKitchen || 0 : classificationStats.toKitchen || 0} | </span>
-        <span>ТО Пекарня: {isDateFiltered ? classificationStats.filteredToBakery || 0 : classificationStats.toBakery || 0} | </span>
-        <span>ТО Китчен/Пекарня: {isDateFiltered ? classificationStats.filteredToKitchenBakery || 0 : classificationStats.toKitchenBakery || 0} | </span>
-        <span>АВ: {isDateFiltered ? classificationStats.filteredAv || 0 : classificationStats.av || 0} | </span>
-        <span>ПНР: {isDateFiltered ? classificationStats.filteredPnr || 0 : classificationStats.pnr || 0} | </span>
-        <span>Прочие ТО: {isDateFiltered ? classificationStats.filteredTo || 0 : classificationStats.to || 0}</span>
+        {classifications.map((c, index) => (
+          <span key={c.code}>
+            {index > 0 && ' | '}
+            {c.name}: {(isDateFiltered ? classificationStats.filtered : classificationStats.all)?.[c.code] || 0}
+          </span>
+        ))}
       </div>
 
       {error && <p className="text-danger">{error}</p>}
 
+      {archives.length > 0 && (
+        <ul className="list-unstyled mb-3" style={{ fontSize: '0.9em' }}>
+          {archives.slice(0, 5).map((archive) => (
+            <li key={archive.id} className="d-flex gap-2 align-items-center mb-1">
+              <span>
+                Архив {archive.name} ({archive.count} шт., {archive.createdAt})
+              </span>
+              {archive.status === 'succeeded' && (
+                <button className="btn btn-link btn-sm p-0" onClick={() => handleDownloadArchive(archive)}>
+                  Скачать
+                </button>
+              )}
+              {(archive.status === 'queued' || archive.status === 'running') && (
+                <span className="text-muted">готовится, о готовности придёт уведомление</span>
+              )}
+              {archive.status === 'failed' && (
+                <span className="text-danger">ошибка: {archive.error}</span>
+              )}
+            </li>
+          ))}
+        </ul>
+      )}
+
       <div className="row">
         <div className="col-md-6">
           <input
             type="text"
             className="form-control"
-            placeholder="Поиск по адресу или дате..."
+            placeholder="Поиск по адресу, дате или номеру акта..."
             value={searchTerm}
             onChange={(e) => setSearchTerm(e.target.value)}
           />
@@ -546,6 +677,17 @@ function Reports() {
           >
             Скачать выбранное
           </button>
+          {isReviewer && (
+            <button
+              className="btn btn-warning ms-3"
+              onClick={() => {
+                fetchReviewQueue();
+                setShowReviewQueue(true);
+              }}
+            >
+              На согласовании: {reviewQueue.length}
+            </button>
+          )}
         </div>
       </div>
 
@@ -575,6 +717,9 @@ function Reports() {
                   onChange={() => toggleReportSelection(report.id)}
                 />
                 <h5 className="card-title">Объект: {report.address}</h5>
+                {report.actNumber && (
+                  <p className="card-text mb-1 text-muted" style={{ fontSize: '0.9em' }}>Акт № {report.actNumber}</p>
+                )}
                 <div className="card-text d-flex justify-content-between align-items-center">
                   <span>Дата: {formatDate(report.date)}</span>
                   <span className="text-secondary" style={{ fontSize: '0.95em', textAlign: 'right', minWidth: '90px' }}>{report.classification}</span>
@@ -582,7 +727,23 @@ function Reports() {
 
                 <p className="card-text text-muted" style={{ fontSize: '0.9em' }}>
                   {getUserFullName(report.userId || report.user_id)}
+                  {STATUS_LABELS[report.status] && (
+                    <span className={`badge ms-2 ${STATUS_LABELS[report.status].className}`}>
+                      {STATUS_LABELS[report.status].text}
+                    </span>
+                  )}
                 </p>
+                {report.status === 'rejected' && report.reviewComment && (
+                  <p className="card-text text-danger" style={{ fontSize: '0.9em' }}>
+                    Замечания: {report.reviewComment}
+                  </p>
+                )}
+                <div className="mt-2">
+                  {renderReviewButtons(report)}
+                  <Link className="btn btn-link btn-sm" to={`/reports/${report.id}/signatures`}>
+                    Подписи
+                  </Link>
+                </div>
                 <div className="d-flex justify-content-between mt-3">
                   <button className="btn btn-primary me-2" onClick={() => handlePreviewClick(report)}>
                     Предпросмотр
@@ -666,6 +827,45 @@ function Reports() {
         </Modal.Body>
       </Modal>
 
+      <Modal show={showReviewQueue} onHide={() => setShowReviewQueue(false)} size="lg" centered>
+        <Modal.Header closeButton>
+          <Modal.Title>Отчеты на согласовании</Modal.Title>
+        </Modal.Header>
+        <Modal.Body>
+          {reviewQueue.length === 0 ? (
+            <p className="text-muted text-center">Нет отчетов, ожидающих согласования</p>
+          ) : (
+            <table className="table table-sm align-middle">
+              <thead>
+                <tr>
+                  <th>Дата</th>
+                  <th>Объект</th>
+                  <th>Классификация</th>
+                  <th>Исполнитель</th>
+                  <th></th>
+                </tr>
+              </thead>
+              <tbody>
+                {reviewQueue.map((report) => (
+                  <tr key={report.id}>
+                    <td>{formatDate(report.date)}</td>
+                    <td>{report.address}</td>
+                    <td>{report.classification}</td>
+                    <td>{`${report.lastName || ''} ${report.firstName || ''}`.trim()}</td>
+                    <td className="text-nowrap">
+                      <button className="btn btn-primary btn-sm me-2" onClick={() => handlePreviewClick(report)}>
+                        Просмотр
+                      </button>
+                      {renderReviewButtons(report)}
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          )}
+        </Modal.Body>
+      </Modal>
+
       <Modal show={showDatePicker} onHide={() => setShowDatePicker(false)} centered>
         <Modal.Header closeButton>
           <Modal.Title>Выберите интервал дат</Modal.Title>
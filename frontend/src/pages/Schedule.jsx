@@ -4,6 +4,8 @@ import { useAuth } from '../context/AuthContext';
 import axios from 'axios';
 import { useNavigate } from 'react-router-dom';
 import { FaChevronDown } from 'react-icons/fa';
+import { useClassifications, findClassification } from '../data/classifications';
+import { downloadExport } from '../data/exports';
 
 function Schedule() {
   const { user } = useAuth();
@@ -37,6 +39,15 @@ function Schedule() {
     userId: '',
   });
   const [validation, setValidation] = useState({});
+  const classifications = useClassifications();
+
+  // Тип выезда в поля формы: классификация из справочника или «Другое» со своим вариантом
+  const toFormClass = (type) => {
+    const found = findClassification(classifications, type);
+    return found
+      ? { classification: found.code, customClass: '' }
+      : { classification: 'Другое', customClass: type || '' };
+  };
 
   // Удалить выезд
   const handleDelete = async (row) => {
@@ -127,7 +138,7 @@ function Schedule() {
       date: form.date,
       departTime: form.departTime, // добавить это поле
       address: form.address,
-      type: form.classification === 'Другое' ? form.customClass : (form.classification === 'Аварийный вызов' ? 'АВ' : form.classification),
+      type: form.classification === 'Другое' ? form.customClass : form.classification,
       classification: form.classification === 'Другое' ? form.customClass : form.classification,
       description: '-', // минимальное не пустое значение
       engineerId: Number(form.userId),
@@ -244,8 +255,7 @@ function Schedule() {
       const params = new URLSearchParams({
         date: updatedRow.date || '',
         address: updatedRow.address || '',
-        classification: updatedRow.type || '',
-        customClass: !['ТО Китчен', 'ТО Пекарня', 'ПНР', 'Аварийный вызов'].includes(updatedRow.type) ? (updatedRow.type || '') : ''
+        ...toFormClass(updatedRow.type),
       });
       navigate(`/new-report?${params.toString()}`);
     } catch (e) {
@@ -263,6 +273,15 @@ function Schedule() {
     }
   };
 
+  // Выгрузка графика: с фильтром «Мои вызовы» - только свои выезды
+  const handleExport = async (format) => {
+    try {
+      await downloadExport('/api/requests/export', onlyMine && user ? { engineerId: user.id } : {}, format, 'schedule');
+    } catch (err) {
+      alert('Ошибка при выгрузке графика');
+    }
+  };
+
   // Render
 
   return (
@@ -273,6 +292,12 @@ function Schedule() {
         <button className="btn btn-primary me-3" onClick={openModal} style={{ fontWeight: 'bold' }}>
           добавить выезд
         </button>
+        <button className="btn btn-outline-secondary me-2" onClick={() => handleExport('xlsx')}>
+          Excel
+        </button>
+        <button className="btn btn-outline-secondary me-3" onClick={() => handleExport('csv')}>
+          CSV
+        </button>
         <div className="form-check me-3">
           <input
             className="form-check-input"
@@ -400,10 +425,9 @@ function Schedule() {
                     <label className="form-label">Классификация *</label>
                     <select className="form-select" value={form.classification} onChange={handleClassChange} style={validation.classification ? { borderColor: '#dc3545', borderWidth: 2 } : {}} required>
                       <option value="" disabled>Не выбрано</option>
-                      <option value="ТО Китчен">ТО Китчен</option>
-                      <option value="ТО Пекарня">ТО Пекарня</option>
-                      <option value="ПНР">ПНР</option>
-                      <option value="Аварийный вызов">Аварийный вызов</option>
+                      {classifications.map(c => (
+                        <option key={c.code} value={c.code}>{c.name}</option>
+                      ))}
                       <option value="Другое">Другое</option>
                     </select>
                     {form.classification === 'Другое' && (
@@ -457,8 +481,7 @@ function Schedule() {
                         date: row.date,
                         departTime: row.departTime || '',
                         address: row.address,
-                        classification: row.type === 'АВ' ? 'Аварийный вызов' : (['ТО Китчен', 'ТО Пекарня', 'ПНР', 'Аварийный вызов'].includes(row.type) ? row.type : 'Другое'),
-                        customClass: !['ТО Китчен', 'ТО Пекарня', 'ПНР', 'Аварийный вызов'].includes(row.type) ? row.type : '',
+                        ...toFormClass(row.type),
                         userId: row.engineerId ? String(row.engineerId) : (users.find(u => u.lastName === row.user?.lastName && u.firstName[0] === row.user?.firstName[0])?.id || ''),
                       });
                       setShowModal(true);
@@ -505,8 +528,7 @@ function Schedule() {
                     date: row.date,
                     departTime: row.departTime || '',
                     address: row.address,
-                    classification: row.type === 'АВ' ? 'Аварийный вызов' : (['ТО Китчен', 'ТО Пекарня', 'ПНР', 'Аварийный вызов'].includes(row.type) ? row.type : 'Другое'),
-                    customClass: !['ТО Китчен', 'ТО Пекарня', 'ПНР', 'Аварийный вызов'].includes(row.type) ? row.type : '',
+                    ...toFormClass(row.type),
                     userId: row.engineerId ? String(row.engineerId) : (users.find(u => u.lastName === row.user?.lastName && u.firstName[0] === row.user?.firstName[0])?.id || ''),
                   });
                   setShowModal(true);
@@ -0,0 +1,151 @@
+import React, { useState } from 'react';
+import axios from 'axios';
+import { Link } from 'react-router-dom';
+
+const TYPE_LABELS = {
+  report: 'Отчеты',
+  ticket: 'Заявки',
+  certificate: 'Сертификаты',
+};
+
+// Подсветка из ts_headline: совпадения приходят в <mark>, остальной текст выводится как есть
+function Highlight({ text }) {
+  const parts = (text || '').split(/<mark>(.*?)<\/mark>/g);
+  return (
+    <span>
+      {parts.map((part, index) => (index % 2 === 1 ? <mark key={index}>{part}</mark> : part))}
+    </span>
+  );
+}
+
+function resultLink(hit) {
+  switch (hit.type) {
+    case 'report':
+      return `/reports?highlight=${hit.id}`;
+    case 'ticket':
+      return '/inner-tickets';
+    default:
+      return '/files';
+  }
+}
+
+// Полнотекстовый поиск по отчетам, заявкам и сертификатам с фасетами
+function Search() {
+  const [query, setQuery] = useState('');
+  const [filters, setFilters] = useState({ type: '', classification: '', month: '' });
+  const [data, setData] = useState(null);
+  const [page, setPage] = useState(1);
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  const runSearch = async (nextFilters = filters, nextPage = 1) => {
+    if (!query.trim()) return;
+    setLoading(true);
+    setError('');
+    try {
+      const params = { q: query, page: nextPage };
+      if (nextFilters.type) params.type = nextFilters.type;
+      if (nextFilters.classification) params.classification = nextFilters.classification;
+      if (nextFilters.month) {
+        params.startDate = `${nextFilters.month}-01`;
+        params.endDate = `${nextFilters.month}-31`;
+      }
+      const response = await axios.get('/api/search', { params });
+      setData(response.data);
+      setPage(nextPage);
+    } catch (err) {
+      setError(err.response?.data?.error || 'Ошибка при поиске');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const toggleFilter = (key, value) => {
+    const next = { ...filters, [key]: filters[key] === value ? '' : value };
+    setFilters(next);
+    runSearch(next);
+  };
+
+  const renderFacet = (title, key, values, labels = {}) => (
+    values && values.length > 0 && (
+      <div className="mb-3">
+        <h6>{title}</h6>
+        {values.map((facet) => (
+          <button
+            key={facet.value}
+            className={`btn btn-sm me-1 mb-1 ${filters[key] === facet.value ? 'btn-primary' : 'btn-outline-secondary'}`}
+            onClick={() => toggleFilter(key, facet.value)}
+          >
+            {labels[facet.value] || facet.value} <span className="badge bg-light text-dark">{facet.count}</span>
+          </button>
+        ))}
+      </div>
+    )
+  );
+
+  return (
+    <div className="container mt-5">
+      <h1>Поиск</h1>
+      <form
+        className="d-flex mt-3"
+        onSubmit={(e) => {
+          e.preventDefault();
+          runSearch();
+        }}
+      >
+        <input
+          type="text"
+          className="form-control me-2"
+          placeholder="Дефекты, номер оборудования, описание заявки, сертификат..."
+          value={query}
+          onChange={(e) => setQuery(e.target.value)}
+        />
+        <button type="submit" className="btn btn-primary" disabled={loading}>
+          Найти
+        </button>
+      </form>
+
+      {error && <div className="alert alert-danger mt-3">{error}</div>}
+
+      {data && (
+        <div className="row mt-4">
+          <div className="col-md-3">
+            {renderFacet('Тип', 'type', data.facets.types, TYPE_LABELS)}
+            {renderFacet('Классификация', 'classification', data.facets.classifications)}
+            {renderFacet('Месяц', 'month', data.facets.months)}
+          </div>
+          <div className="col-md-9">
+            <p className="text-muted">Найдено: {data.total}</p>
+            {data.results.map((hit) => (
+              <div key={`${hit.type}-${hit.id}`} className="card mb-2">
+                <div className="card-body">
+                  <div className="d-flex justify-content-between">
+                    <Link to={resultLink(hit)} className="card-title h6">{hit.title}</Link>
+                    <span className="badge bg-secondary">{TYPE_LABELS[hit.type]}</span>
+                  </div>
+                  <p className="card-text text-muted mb-1" style={{ fontSize: '0.9em' }}>
+                    {[hit.date, hit.address, hit.classification].filter(Boolean).join(' · ')}
+                  </p>
+                  <p className="card-text mb-0"><Highlight text={hit.highlight} /></p>
+                </div>
+              </div>
+            ))}
+            {data.totalPages > 1 && (
+              <div className="d-flex justify-content-center mt-3">
+                <button className="btn btn-outline-primary btn-sm me-2" disabled={page <= 1} onClick={() => runSearch(filters, page - 1)}>
+                  Назад
+                </button>
+                <span className="align-self-center">{page} / {data.totalPages}</span>
+                <button className="btn btn-outline-primary btn-sm ms-2" disabled={page >= data.totalPages} onClick={() => runSearch(filters, page + 1)}>
+                  Вперед
+                </button>
+              </div>
+            )}
+          </div>
+        </div>
+      )}
+    </div>
+  );
+}
+
+export default Search;
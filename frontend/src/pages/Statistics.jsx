@@ -8,6 +8,7 @@ import {
   ResponsiveContainer, PieChart, Pie, Cell
 } from 'recharts';
 import { FaChevronLeft, FaChevronRight } from 'react-icons/fa';
+import { useClassifications } from '../data/classifications';
 import '../styles/Statistics.css';
 
 pdfjsLib.GlobalWorkerOptions.workerSrc = `//cdnjs.cloudflare.com/ajax/libs/pdf.js/${pdfjsLib.version}/pdf.worker.min.js`;
@@ -22,28 +23,36 @@ const SHORT_MONTH_NAMES = [
   'Июл', 'Авг', 'Сен', 'Окт', 'Ноя', 'Дек'
 ];
 
-const CHART_COLORS = {
-  av: '#ff6b6b',
-  toKitchen: '#4ecdc4',
-  toBakery: '#45b7d1',
-  toKitchenBakery: '#96ceb4',
-  to: '#feca57',
-  pnr: '#a29bfe',
-  other: '#636e72'
-};
-
 const PIE_COLORS = ['#ff6b6b', '#4ecdc4', '#45b7d1', '#96ceb4', '#feca57', '#a29bfe', '#636e72'];
 
-const CLASSIFICATIONS = [
-  { key: 'filteredAv', label: 'АВ', apiClass: 'АВ', trendKey: 'av' },
-  { key: 'filteredToKitchen', label: 'ТО Китчен', apiClass: 'ТО Китчен', trendKey: 'toKitchen' },
-  { key: 'filteredToBakery', label: 'ТО Пекарня', apiClass: 'ТО Пекарня', trendKey: 'toBakery' },
-  { key: 'filteredToKitchenBakery', label: 'ТО К/П', apiClass: 'ТО Китчен/Пекарня', trendKey: 'toKitchenBakery' },
-  { key: 'filteredTo', label: 'ТО', apiClass: 'ТО', trendKey: 'to' },
-  { key: 'filteredPnr', label: 'ПНР', apiClass: 'ПНР', trendKey: 'pnr' },
-  { key: 'filteredOther', label: 'Другие', apiClass: 'Другие', trendKey: 'other' },
+// Отчёты с классификациями не из справочника
+const OTHER_CLASS = 'Другие';
+const OTHER_COLOR = '#636e72';
+
+const PIVOT_DIMENSIONS = [
+  { value: 'classification', label: 'Классификация' },
+  { value: 'engineer', label: 'Инженер' },
+  { value: 'client', label: 'Клиент' },
+  { value: 'address', label: 'Адрес' },
+  { value: 'month', label: 'Месяц' },
+  { value: 'week', label: 'Неделя' },
+];
+
+const PIVOT_MEASURES = [
+  { value: 'reports', label: 'Отчёты' },
+  { value: 'tickets', label: 'Заявки' },
+  { value: 'sla_hits', label: 'Заявки в SLA' },
+  { value: 'sla_breaches', label: 'Нарушения SLA' },
+  { value: 'km', label: 'Пробег, км' },
 ];
 
+// Подпись значения измерения: для инженеров и клиентов сервер присылает имена
+const pivotLabel = (row, dimension) => {
+  if (dimension === 'engineer') return row.engineerName || `#${row.engineer}`;
+  if (dimension === 'client') return row.clientName || (row.client ? `#${row.client}` : 'Без клиента');
+  return row[dimension] ?? '—';
+};
+
 const getMonthRange = (year, month) => {
   const start = new Date(year, month, 1).toISOString().slice(0, 10);
   const end = new Date(year, month + 1, 0).toISOString().slice(0, 10);
@@ -68,6 +77,12 @@ const Statistics = () => {
   const [selectedMonth, setSelectedMonth] = useState(now.getMonth());
   const [customStart, setCustomStart] = useState('');
   const [customEnd, setCustomEnd] = useState('');
+  const [pivotRows, setPivotRows] = useState('classification');
+  const [pivotColumns, setPivotColumns] = useState('');
+  const [pivotMeasure, setPivotMeasure] = useState('reports');
+  const [pivotError, setPivotError] = useState('');
+  const [pivot, setPivot] = useState(null);
+  const catalog = useClassifications(false);
 
   const getDateRange = useCallback(() => {
     if (filterMode === 'month') {
@@ -119,6 +134,56 @@ const Statistics = () => {
     fetchTrends();
   }, [fetchTrends]);
 
+  const fetchPivot = useCallback(async () => {
+    const { start, end } = getDateRange();
+    if (!start || !end) return;
+    const dimensions = [pivotRows, pivotColumns].filter(Boolean).join(',');
+    try {
+      const response = await axios.get('/api/analytics', {
+        params: { dimensions, measures: pivotMeasure, startDate: start, endDate: end },
+      });
+      setPivot(response.data);
+      setPivotError('');
+    } catch (err) {
+      console.error('Ошибка при загрузке сводной таблицы:', err);
+      // Например, пробег нельзя разбить по классификации - сервер объясняет, почему
+      setPivot(null);
+      setPivotError(err.response?.data?.error || 'Ошибка при загрузке сводной таблицы');
+    }
+  }, [getDateRange, pivotRows, pivotColumns, pivotMeasure]);
+
+  useEffect(() => {
+    fetchPivot();
+  }, [fetchPivot]);
+
+  // Строки аналитики раскладываются в таблицу: строки - первое измерение, столбцы - второе
+  const getPivotTable = () => {
+    if (!pivot) return null;
+    const rowKeys = [];
+    const columnKeys = [];
+    const cells = {};
+    const rowLabels = {};
+    const columnLabels = {};
+    pivot.rows.forEach(row => {
+      const rowKey = String(row[pivotRows] ?? '');
+      const columnKey = pivotColumns ? String(row[pivotColumns] ?? '') : '';
+      if (!(rowKey in rowLabels)) {
+        rowKeys.push(rowKey);
+        rowLabels[rowKey] = pivotLabel(row, pivotRows);
+      }
+      if (!(columnKey in columnLabels)) {
+        columnKeys.push(columnKey);
+        columnLabels[columnKey] = pivotColumns ? pivotLabel(row, pivotColumns) : '';
+      }
+      cells[`${rowKey}|${columnKey}`] = row[pivotMeasure];
+    });
+    return { rowKeys, columnKeys, cells, rowLabels, columnLabels };
+  };
+
+  const formatMeasure = (value) => (
+    value === undefined ? '' : Number(value).toLocaleString('ru-RU', { maximumFractionDigits: 1 })
+  );
+
   useEffect(() => {
     setActiveClass('');
     setFilteredReports([]);
@@ -238,15 +303,10 @@ const Statistics = () => {
         }
       }
 
-      let result = allReports;
-      if (["ТО Китчен", "ТО Пекарня", "ТО Китчен/Пекарня", "ТО", "АВ", "ПНР"].includes(classification)) {
-        result = allReports.filter(r => (r.classification || '').toLowerCase() === classification.toLowerCase());
-      } else if (classification === 'Другие') {
-        result = allReports.filter(r => {
-          const c = (r.classification || '').toLowerCase();
-          return c !== 'то китчен' && c !== 'то пекарня' && c !== 'то китчен/пекарня' && c !== 'то' && c !== 'ав' && c !== 'пнр';
-        });
-      }
+      const codes = catalog.map(c => c.code);
+      const result = classification === OTHER_CLASS
+        ? allReports.filter(r => !codes.includes(r.classification))
+        : allReports.filter(r => r.classification === classification);
       setFilteredReports(result);
     } catch (err) {
       setError('Ошибка при загрузке актов');
@@ -255,13 +315,30 @@ const Statistics = () => {
     }
   };
 
-  const getPieData = () => {
+  // Карточки по справочнику: отключённые классификации показываются, только если по ним есть отчёты.
+  // Всё, чего нет в справочнике, попадает в «Другие»
+  const getClassCards = () => {
     if (!stats) return [];
-    return CLASSIFICATIONS
-      .map(c => ({ name: c.label, value: stats[c.key] || 0 }))
-      .filter(d => d.value > 0);
+    const counts = stats.filteredByClassification || {};
+    const codes = catalog.map(c => c.code);
+    const cards = catalog
+      .map((c, index) => ({
+        code: c.code,
+        label: c.name,
+        color: c.color || PIE_COLORS[index % PIE_COLORS.length],
+        value: counts[c.code] || 0,
+        isActive: c.isActive,
+      }))
+      .filter(card => card.isActive || card.value > 0);
+    const other = Object.entries(counts)
+      .filter(([code]) => !codes.includes(code))
+      .reduce((sum, [, count]) => sum + count, 0);
+    cards.push({ code: OTHER_CLASS, label: OTHER_CLASS, color: OTHER_COLOR, value: other });
+    return cards;
   };
 
+  const getPieData = () => getClassCards().filter(d => d.value > 0).map(d => ({ name: d.label, value: d.value, color: d.color }));
+
   const getTrendsChartData = () => {
     return trends.map(item => {
       const [, month] = item.month.split('-');
@@ -380,13 +457,13 @@ const Statistics = () => {
               <div className="stat-card-value">{stats.filteredTotal || 0}</div>
               <div className="stat-card-label">Всего за период</div>
             </div>
-            {CLASSIFICATIONS.map(c => (
+            {getClassCards().map(c => (
               <div
-                key={c.key}
-                className={`stat-card${activeClass === c.apiClass ? ' active' : ''}`}
-                onClick={() => handleClassClick(c.apiClass)}
+                key={c.code}
+                className={`stat-card${activeClass === c.code ? ' active' : ''}`}
+                onClick={() => handleClassClick(c.code)}
               >
-                <div className="stat-card-value">{stats[c.key] || 0}</div>
+                <div className="stat-card-value">{c.value}</div>
                 <div className="stat-card-label">{c.label}</div>
               </div>
             ))}
@@ -414,8 +491,8 @@ const Statistics = () => {
                       stroke="var(--bg-card)"
                       strokeWidth={2}
                     >
-                      {getPieData().map((_, index) => (
-                        <Cell key={`cell-${index}`} fill={PIE_COLORS[index % PIE_COLORS.length]} />
+                      {getPieData().map((entry, index) => (
+                        <Cell key={`cell-${index}`} fill={entry.color} />
                       ))}
                     </Pie>
                     <Tooltip content={<PieTooltip />} />
@@ -434,11 +511,15 @@ const Statistics = () => {
                     <YAxis allowDecimals={false} tick={{ fill: 'var(--text-secondary)', fontSize: 11 }} />
                     <Tooltip content={<CustomTooltip />} />
                     <Legend wrapperStyle={{ fontSize: 12 }} />
-                    <Bar dataKey="av" name="АВ" fill={CHART_COLORS.av} radius={[2, 2, 0, 0]} />
-                    <Bar dataKey="toKitchen" name="ТО Китчен" fill={CHART_COLORS.toKitchen} radius={[2, 2, 0, 0]} />
-                    <Bar dataKey="toBakery" name="ТО Пекарня" fill={CHART_COLORS.toBakery} radius={[2, 2, 0, 0]} />
-                    <Bar dataKey="to" name="ТО" fill={CHART_COLORS.to} radius={[2, 2, 0, 0]} />
-                    <Bar dataKey="pnr" name="ПНР" fill={CHART_COLORS.pnr} radius={[2, 2, 0, 0]} />
+                    {catalog.filter(c => c.isActive).map((c, index) => (
+                      <Bar
+                        key={c.code}
+                        dataKey={item => (item.byClassification || {})[c.code] || 0}
+                        name={c.name}
+                        fill={c.color || PIE_COLORS[index % PIE_COLORS.length]}
+                        radius={[2, 2, 0, 0]}
+                      />
+                    ))}
                   </BarChart>
                 </ResponsiveContainer>
               </div>
@@ -480,6 +561,68 @@ const Statistics = () => {
         </>
       )}
 
+      <div className="reports-list-section">
+        <div className="reports-list-title">Сводная таблица за {getPeriodLabel()}</div>
+        <div className="d-flex flex-wrap gap-2 mb-3">
+          <select className="form-select form-select-sm w-auto" value={pivotRows} onChange={(e) => {
+            if (e.target.value === pivotColumns) setPivotColumns('');
+            setPivotRows(e.target.value);
+          }}>
+            {PIVOT_DIMENSIONS.map(d => <option key={d.value} value={d.value}>Строки: {d.label}</option>)}
+          </select>
+          <select className="form-select form-select-sm w-auto" value={pivotColumns} onChange={(e) => setPivotColumns(e.target.value)}>
+            <option value="">Столбцы: нет</option>
+            {PIVOT_DIMENSIONS.filter(d => d.value !== pivotRows).map(d => (
+              <option key={d.value} value={d.value}>Столбцы: {d.label}</option>
+            ))}
+          </select>
+          <select className="form-select form-select-sm w-auto" value={pivotMeasure} onChange={(e) => setPivotMeasure(e.target.value)}>
+            {PIVOT_MEASURES.map(m => <option key={m.value} value={m.value}>{m.label}</option>)}
+          </select>
+        </div>
+        {(() => {
+          if (pivotError) {
+            return <div className="empty-state">{pivotError}</div>;
+          }
+          const table = getPivotTable();
+          if (!table || table.rowKeys.length === 0) {
+            return <div className="empty-state">Нет данных</div>;
+          }
+          return (
+            <div className="table-responsive">
+              <table className="table table-sm table-striped">
+                <thead>
+                  <tr>
+                    <th>{PIVOT_DIMENSIONS.find(d => d.value === pivotRows)?.label}</th>
+                    {table.columnKeys.map(key => (
+                      <th key={key} className="text-end">
+                        {pivotColumns ? table.columnLabels[key] : PIVOT_MEASURES.find(m => m.value === pivotMeasure)?.label}
+                      </th>
+                    ))}
+                  </tr>
+                </thead>
+                <tbody>
+                  {table.rowKeys.map(rowKey => (
+                    <tr key={rowKey}>
+                      <td>{table.rowLabels[rowKey]}</td>
+                      {table.columnKeys.map(columnKey => (
+                        <td key={columnKey} className="text-end">{formatMeasure(table.cells[`${rowKey}|${columnKey}`])}</td>
+                      ))}
+                    </tr>
+                  ))}
+                </tbody>
+                <tfoot>
+                  <tr>
+                    <th>Итого</th>
+                    <th colSpan={table.columnKeys.length} className="text-end">{formatMeasure(pivot.totals[pivotMeasure])}</th>
+                  </tr>
+                </tfoot>
+              </table>
+            </div>
+          );
+        })()}
+      </div>
+
       <Modal show={showPreview} onHide={() => setShowPreview(false)} size="lg" centered>
         <Modal.Header closeButton>
           <Modal.Title>
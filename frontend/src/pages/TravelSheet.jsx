@@ -7,6 +7,7 @@ import {
 } from 'recharts';
 import '../styles/TravelSheet.css';
 import { useNavigate } from 'react-router-dom';
+import { downloadExport } from '../data/exports';
 
 function TravelSheet() {
   const [travelRecords, setTravelRecords] = useState([]);
@@ -54,6 +55,17 @@ function TravelSheet() {
     }
   };
 
+  // Выгрузка путевого листа за выбранный месяц
+  const handleExport = async (format) => {
+    const [year, month] = selectedMonth.split('-').map(Number);
+    const endDate = `${selectedMonth}-${String(new Date(year, month, 0).getDate()).padStart(2, '0')}`;
+    try {
+      await downloadExport('/api/travel-sheet/export', { startDate: `${selectedMonth}-01`, endDate }, format, 'travel_sheet');
+    } catch (err) {
+      setError('Ошибка при выгрузке путевого листа');
+    }
+  };
+
   const fetchTravelRecords = async () => {
     try {
       const response = await axios.get('/api/travel-sheet');
@@ -327,6 +339,12 @@ function TravelSheet() {
     <div className="travel-sheet-container">
       <div className="travel-sheet-header">
         <h2>Путевой лист</h2>
+        <button className="btn btn-outline-secondary" onClick={() => handleExport('xlsx')}>
+          Excel за месяц
+        </button>
+        <button className="btn btn-outline-secondary" onClick={() => handleExport('csv')}>
+          CSV за месяц
+        </button>
         <button
           className="btn btn-primary add-record-btn"
           onClick={() => setShowForm(!showForm)}
@@ -0,0 +1,117 @@
+import React, { useState, useEffect } from 'react';
+import axios from 'axios';
+import { useParams } from 'react-router-dom';
+
+const ROLE_LABELS = {
+  engineer: 'Исполнитель',
+  client: 'Представитель заказчика',
+};
+
+// Публичная страница проверки подлинности акта (ссылка из QR-кода)
+function VerifyReport() {
+  const { token } = useParams();
+  const [result, setResult] = useState(null);
+  const [error, setError] = useState('');
+  const [fileCheck, setFileCheck] = useState(null);
+  const [checking, setChecking] = useState(false);
+
+  useEffect(() => {
+    const fetchVerification = async () => {
+      try {
+        const response = await axios.get(`/api/verify/${token}`);
+        setResult(response.data);
+      } catch (err) {
+        setError(err.response?.data?.error || 'Ошибка при проверке акта');
+      }
+    };
+    fetchVerification();
+  }, [token]);
+
+  const handleFileCheck = async (e) => {
+    const file = e.target.files[0];
+    if (!file) return;
+    setChecking(true);
+    setFileCheck(null);
+    try {
+      const formData = new FormData();
+      formData.append('file', file);
+      const response = await axios.post(`/api/verify/${token}`, formData);
+      setFileCheck(response.data);
+    } catch (err) {
+      setError(err.response?.data?.error || 'Ошибка при проверке файла');
+    } finally {
+      setChecking(false);
+    }
+  };
+
+  return (
+    <div className="container mt-5" style={{ maxWidth: 720 }}>
+      <h1>Проверка подлинности акта</h1>
+      {error && <div className="alert alert-danger mt-3">{error}</div>}
+
+      {result && (
+        <>
+          <div className={`alert mt-3 ${result.valid ? 'alert-success' : 'alert-warning'}`}>
+            {result.valid
+              ? 'Акт выдан и согласован, файл в хранилище не изменялся'
+              : !result.approved
+                ? 'Акт не согласован или отозван'
+                : 'Не удалось подтвердить целостность акта'}
+          </div>
+
+          <table className="table table-sm">
+            <tbody>
+              <tr><th>Номер акта</th><td>{result.report.actNumber || '—'}</td></tr>
+              <tr><th>Объект</th><td>{result.report.address}</td></tr>
+              <tr><th>Дата</th><td>{result.report.date}</td></tr>
+              <tr><th>Классификация</th><td>{result.report.classification}</td></tr>
+              <tr><th>Версия</th><td>{result.report.version}</td></tr>
+              <tr><th>Файл</th><td>{result.report.filename}</td></tr>
+              <tr><th>SHA-256</th><td style={{ wordBreak: 'break-all' }}>{result.sha256 || '—'}</td></tr>
+              <tr><th>Запечатан</th><td>{result.sealedAt || '—'}</td></tr>
+              <tr>
+                <th>Подпись сервера</th>
+                <td>
+                  {result.signed
+                    ? (result.signatureValid ? `${result.algorithm}: подпись верна` : `${result.algorithm}: подпись неверна`)
+                    : 'не используется'}
+                </td>
+              </tr>
+              <tr><th>Последняя перепроверка</th><td>{result.integrityCheckedAt || '—'}</td></tr>
+            </tbody>
+          </table>
+
+          {result.signatures.length > 0 && (
+            <>
+              <h5>Подписи</h5>
+              <ul>
+                {result.signatures.map((sig, index) => (
+                  <li key={index}>
+                    {ROLE_LABELS[sig.role] || sig.role}: {sig.signerName}
+                    {sig.signerPosition && `, ${sig.signerPosition}`} — {sig.signedAt}
+                  </li>
+                ))}
+              </ul>
+            </>
+          )}
+
+          <h5 className="mt-4">Сверить файл</h5>
+          <p className="text-muted">Выберите PDF акта, чтобы проверить, что он совпадает с выданным</p>
+          <input type="file" className="form-control" accept="application/pdf" onChange={handleFileCheck} disabled={checking} />
+          {checking && <p className="mt-2 text-muted">Проверка...</p>}
+          {fileCheck && (
+            <div className={`alert mt-3 ${fileCheck.current ? 'alert-success' : fileCheck.matches ? 'alert-warning' : 'alert-danger'}`}>
+              {fileCheck.current
+                ? 'Файл совпадает с актуальной версией акта'
+                : fileCheck.matches
+                  ? `Файл совпадает с устаревшей версией акта (${fileCheck.version})`
+                  : 'Файл не совпадает ни с одной версией акта'}
+            </div>
+          )}
+        </>
+      )}
+    </div>
+  );
+}
+
+export default VerifyReport;
@@ -161,6 +161,28 @@ export default function ClientTickets() {
     return url;
   };
 
+  // Скачивание PDF акта
+  const handleDownloadPdf = async (previewName) => {
+    const pdfName = previewName.replace(/\.png$/i, '.pdf');
+    try {
+      const response = await axios.get(`/api/client/reports/download/${encodeURIComponent(pdfName)}`, {
+        responseType: 'blob',
+        withCredentials: true,
+      });
+      const url = URL.createObjectURL(response.data);
+      const link = document.createElement('a');
+      link.href = url;
+      link.download = pdfName;
+      document.body.appendChild(link);
+      link.click();
+      link.remove();
+      URL.revokeObjectURL(url);
+    } catch (err) {
+      console.error('Ошибка скачивания PDF:', err);
+      alert('Не удалось скачать PDF');
+    }
+  };
+
   // Обработчик предпросмотра отчёта (загружаем все страницы PNG превью)
   const handlePreviewPdf = async (filename) => {
     const previewName = getPreviewName(filename);
@@ -902,6 +924,12 @@ export default function ClientTickets() {
             <div className={styles.pdfHeader}>
               <h3>Просмотр отчёта</h3>
               <div className={styles.pdfActions}>
+                <button
+                  onClick={() => handleDownloadPdf(previewPdf)}
+                  className={styles.pdfDownloadBtn}
+                >
+                  Скачать PDF
+                </button>
                 <button
                   onClick={closePdfPreview}
                   className={styles.pdfCloseBtn}